@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sort"
@@ -129,10 +130,15 @@ type AnalyticsService struct {
 	funnels           map[string]FunnelAnalysis
 	eventChannels     map[string]chan Event
 	processingWorkers int
-	metrics           ServiceMetrics
-	isRunning         bool
-	shutdown          chan struct{}
-	workerGroup       errgroup.Group
+	// Longest a cycle waits for an aggregation type before keeping its
+	// previous values; set through Configure
+	aggregationTimeout time.Duration
+	// aggregate computes one aggregation type; nil means calculateAggregation
+	aggregate   func(ctx context.Context, events []Event, aggType AggregationType) (map[string]MetricAggregation, error)
+	metrics     ServiceMetrics
+	isRunning   bool
+	shutdown    chan struct{}
+	workerGroup errgroup.Group
 }
 
 type ServiceMetrics struct {
@@ -146,8 +152,17 @@ type ServiceMetrics struct {
 	GoroutinePool        int              `json:"goroutine_pool"`
 	ChannelBufferSizes   map[string]int   `json:"channel_buffer_sizes"`
 	ConcurrentOperations int64            `json:"concurrent_operations"`
+	SkippedAggregations  int64            `json:"skipped_aggregations"`
 }
 
+// ServiceConfig holds the runtime settings accepted by Configure
+type ServiceConfig struct {
+	AggregationTimeout string `json:"aggregation_timeout"` // Go duration, e.g. "30s"
+}
+
+// Default per-cycle budget for metric aggregations
+const defaultAggregationTimeout = 30 * time.Second
+
 // Global service instance
 var (
 	analyticsService *AnalyticsService
@@ -158,11 +173,12 @@ var (
 func getAnalyticsService() *AnalyticsService {
 	serviceOnce.Do(func() {
 		analyticsService = &AnalyticsService{
-			events:            make([]Event, 0, 10000),
-			aggregations:      make(map[string]MetricAggregation),
-			funnels:           make(map[string]FunnelAnalysis),
-			eventChannels:     make(map[string]chan Event),
-			processingWorkers: 10, // Concurrent goroutines
+			events:             make([]Event, 0, 10000),
+			aggregations:       make(map[string]MetricAggregation),
+			funnels:            make(map[string]FunnelAnalysis),
+			eventChannels:      make(map[string]chan Event),
+			processingWorkers:  10, // Concurrent goroutines
+			aggregationTimeout: defaultAggregationTimeout,
 			metrics: ServiceMetrics{
 				EventTypes:         make(map[string]int64),
 				ChannelBufferSizes: make(map[string]int),
@@ -274,37 +290,94 @@ func (as *AnalyticsService) aggregationWorker() error {
 	}
 }
 
-// Perform metric aggregations using Go's concurrency
+// aggregationResult carries one aggregation type's values back to the cycle
+type aggregationResult struct {
+	aggType AggregationType
+	values  map[string]MetricAggregation
+	err     error
+}
+
+// Perform metric aggregations using Go's concurrency. Each type runs in its
+// own goroutine under a shared deadline; types that miss it are skipped for
+// this cycle and keep their previous values.
 func (as *AnalyticsService) performAggregations() {
 	as.mu.RLock()
 	events := make([]Event, len(as.events))
 	copy(events, as.events)
+	timeout := as.aggregationTimeout
+	aggregate := as.aggregate
 	as.mu.RUnlock()
+	if aggregate == nil {
+		aggregate = as.calculateAggregation
+	}
 
-	// Process aggregations concurrently using goroutines
-	var wg sync.WaitGroup
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Process aggregations concurrently using goroutines. The channel is
+	// buffered so stragglers can finish without blocking once the cycle ends.
 	aggregationTypes := []AggregationType{
 		AggregationCount, AggregationSum, AggregationAverage,
 		AggregationMin, AggregationMax, AggregationUnique,
 	}
+	results := make(chan aggregationResult, len(aggregationTypes))
 
 	for _, aggType := range aggregationTypes {
-		wg.Add(1)
 		go func(aggregationType AggregationType) {
-			defer wg.Done()
-			as.calculateAggregation(events, aggregationType)
+			values, err := aggregate(ctx, events, aggregationType)
+			results <- aggregationResult{aggType: aggregationType, values: values, err: err}
 		}(aggType)
 	}
 
-	wg.Wait()
+	pending := make(map[AggregationType]bool, len(aggregationTypes))
+	for _, aggType := range aggregationTypes {
+		pending[aggType] = true
+	}
+
+	for len(pending) > 0 {
+		select {
+		case result := <-results:
+			delete(pending, result.aggType)
+			if result.err != nil {
+				as.skipAggregation(result.aggType, result.err)
+				continue
+			}
+			as.mu.Lock()
+			for key, aggregation := range result.values {
+				as.aggregations[key] = aggregation
+			}
+			as.mu.Unlock()
+		case <-ctx.Done():
+			for aggType := range pending {
+				as.skipAggregation(aggType, ctx.Err())
+			}
+			return
+		}
+	}
 }
 
-// Calculate specific aggregation type with concurrent processing
-func (as *AnalyticsService) calculateAggregation(events []Event, aggType AggregationType) {
+// skipAggregation records an aggregation type left out of this cycle
+func (as *AnalyticsService) skipAggregation(aggType AggregationType, err error) {
+	fmt.Printf("Skipping %s aggregation this cycle: %v\n", aggType, err)
+
+	as.mu.Lock()
+	as.metrics.SkippedAggregations++
+	as.mu.Unlock()
+}
+
+// Calculate specific aggregation type with concurrent processing. Values are
+// returned rather than stored so a cycle that times out leaves the previous
+// aggregations untouched; ctx is checked between time windows.
+func (as *AnalyticsService) calculateAggregation(ctx context.Context, events []Event, aggType AggregationType) (map[string]MetricAggregation, error) {
 	now := time.Now()
 	timeWindows := []string{"1m", "5m", "1h", "1d"}
+	values := make(map[string]MetricAggregation)
 
 	for _, window := range timeWindows {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		duration := parseDuration(window)
 		startTime := now.Add(-duration)
 
@@ -330,12 +403,11 @@ func (as *AnalyticsService) calculateAggregation(events []Event, aggType Aggrega
 		for dimensionKey, groupEvents := range dimensionGroups {
 			aggregation := as.calculateMetricValue(groupEvents, aggType)
 			aggregationKey := fmt.Sprintf("%s_%s_%s", aggType, window, dimensionKey)
-
-			as.mu.Lock()
-			as.aggregations[aggregationKey] = aggregation
-			as.mu.Unlock()
+			values[aggregationKey] = aggregation
 		}
 	}
+
+	return values, nil
 }
 
 // Calculate metric values using Go's built-in functions
@@ -691,6 +763,29 @@ func GetFunnelResults(funnelId string) []byte {
 	return result
 }
 
+// Configure applies a JSON ServiceConfig. Omitted fields keep their current
+// values; an invalid or non-positive timeout rejects the whole update.
+func Configure(configData []byte) bool {
+	service := getAnalyticsService()
+
+	var config ServiceConfig
+	if err := json.Unmarshal(configData, &config); err != nil {
+		return false
+	}
+
+	if config.AggregationTimeout != "" {
+		timeout, err := time.ParseDuration(config.AggregationTimeout)
+		if err != nil || timeout <= 0 {
+			return false
+		}
+		service.mu.Lock()
+		service.aggregationTimeout = timeout
+		service.mu.Unlock()
+	}
+
+	return true
+}
+
 func HealthCheck() bool {
 	service := getAnalyticsService()
 	return service.isRunning
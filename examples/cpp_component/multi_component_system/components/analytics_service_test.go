@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// newTestService builds a service without starting its background workers
+func newTestService(timeout time.Duration) *AnalyticsService {
+	return &AnalyticsService{
+		aggregations:       make(map[string]MetricAggregation),
+		aggregationTimeout: timeout,
+		metrics: ServiceMetrics{
+			EventTypes:         make(map[string]int64),
+			ChannelBufferSizes: make(map[string]int),
+		},
+	}
+}
+
+// TestPerformAggregationsTimeout injects a sum aggregation that ignores its
+// context and never finishes in time. The cycle must still end at the
+// timeout, commit the other types and keep the previous sum.
+func TestPerformAggregationsTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+
+	service := newTestService(timeout)
+	service.aggregations["sum_1m_all"] = MetricAggregation{Aggregation: AggregationSum, Value: 42}
+
+	release := make(chan struct{})
+	defer close(release)
+	service.aggregate = func(ctx context.Context, events []Event, aggType AggregationType) (map[string]MetricAggregation, error) {
+		if aggType == AggregationSum {
+			<-release
+		}
+		key := string(aggType) + "_1m_all"
+		return map[string]MetricAggregation{key: {Aggregation: aggType, Value: 1}}, nil
+	}
+
+	start := time.Now()
+	service.performAggregations()
+	if elapsed := time.Since(start); elapsed > timeout+time.Second {
+		t.Fatalf("cycle took %v with a %v timeout", elapsed, timeout)
+	}
+
+	service.mu.RLock()
+	defer service.mu.RUnlock()
+
+	if got := service.aggregations["sum_1m_all"].Value; got != 42 {
+		t.Errorf("slow sum aggregation = %v, want the previous value 42", got)
+	}
+	for _, aggType := range []AggregationType{AggregationCount, AggregationAverage, AggregationMin, AggregationMax, AggregationUnique} {
+		if got, ok := service.aggregations[string(aggType)+"_1m_all"]; !ok || got.Value != 1 {
+			t.Errorf("%s aggregation = %+v, %v; want it committed", aggType, got, ok)
+		}
+	}
+	if got := service.metrics.SkippedAggregations; got != 1 {
+		t.Errorf("skipped aggregations = %d, want 1", got)
+	}
+}
+
+// TestCalculateAggregationCancelled checks the real aggregation stops at an
+// expired context and returns no partial values
+func TestCalculateAggregationCancelled(t *testing.T) {
+	service := newTestService(defaultAggregationTimeout)
+	events := []Event{{EventType: "page_view", Timestamp: time.Now().Unix()}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	values, err := service.calculateAggregation(ctx, events, AggregationCount)
+	if err != context.Canceled || values != nil {
+		t.Errorf("calculateAggregation = %v, %v; want nil, %v", values, err, context.Canceled)
+	}
+
+	values, err = service.calculateAggregation(context.Background(), events, AggregationCount)
+	if err != nil || len(values) == 0 {
+		t.Errorf("calculateAggregation = %v, %v; want values", values, err)
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	service := getAnalyticsService()
+
+	tests := []struct {
+		config string
+		ok     bool
+		want   time.Duration
+	}{
+		{config: `{"aggregation_timeout": "45s"}`, ok: true, want: 45 * time.Second},
+		{config: `{}`, ok: true, want: 45 * time.Second},
+		{config: `{"aggregation_timeout": "0s"}`, ok: false, want: 45 * time.Second},
+		{config: `{"aggregation_timeout": "soon"}`, ok: false, want: 45 * time.Second},
+		{config: `not json`, ok: false, want: 45 * time.Second},
+		{config: `{"aggregation_timeout": "250ms"}`, ok: true, want: 250 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if got := Configure([]byte(tt.config)); got != tt.ok {
+			t.Errorf("Configure(%s) = %v, want %v", tt.config, got, tt.ok)
+		}
+		service.mu.RLock()
+		got := service.aggregationTimeout
+		service.mu.RUnlock()
+		if got != tt.want {
+			t.Errorf("after Configure(%s), timeout = %v, want %v", tt.config, got, tt.want)
+		}
+	}
+}
@@ -33,11 +33,13 @@ func (m *MathUtils) Factorial(n int) (int64, error) {
 
 	result := int64(1)
 	for i := 2; i <= n; i++ {
-		result *= int64(i)
-		// Check for overflow
-		if result < 0 {
+		// Check for overflow before multiplying; a wrapped product can land
+		// back on a positive value (23! does), so checking the sign afterwards
+		// is not enough
+		if result > math.MaxInt64/int64(i) {
 			return 0, fmt.Errorf("factorial overflow for input: %d", n)
 		}
+		result *= int64(i)
 	}
 
 	return result, nil
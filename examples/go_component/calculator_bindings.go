@@ -1,3 +1,9 @@
+//go:build tinygo
+
+// The component entry points are built by TinyGo only. Each defines main and
+// needs generated bindings, so leaving them out of native builds lets go test
+// exercise the pure helpers in calculator.go and utils.go.
+
 package main
 
 import (
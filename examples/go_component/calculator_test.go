@@ -0,0 +1,103 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFactorial(t *testing.T) {
+	utils := &MathUtils{}
+	tests := []struct {
+		n       int
+		want    int64
+		wantErr bool
+	}{
+		{n: -1, wantErr: true},
+		{n: 0, want: 1},
+		{n: 1, want: 1},
+		{n: 5, want: 120},
+		{n: 20, want: 2432902008176640000},
+		{n: 21, wantErr: true},
+		// 23! wraps to a positive int64, which a sign check would miss
+		{n: 23, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := utils.Factorial(tt.n)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Factorial(%d) error = %v, wantErr %v", tt.n, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("Factorial(%d) = %d, want %d", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestSquareRoot(t *testing.T) {
+	utils := &MathUtils{}
+	tests := []struct {
+		a       float64
+		want    float64
+		wantErr bool
+	}{
+		{a: 0, want: 0},
+		{a: 16, want: 4},
+		{a: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := utils.SquareRoot(tt.a)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SquareRoot(%v) error = %v, wantErr %v", tt.a, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("SquareRoot(%v) = %v, want %v", tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidNumber(t *testing.T) {
+	utils := &MathUtils{}
+	tests := []struct {
+		n    float64
+		want bool
+	}{
+		{n: 0, want: true},
+		{n: -1.5, want: true},
+		{n: math.MaxFloat64, want: true},
+		{n: math.NaN(), want: false},
+		{n: math.Inf(1), want: false},
+		{n: math.Inf(-1), want: false},
+	}
+
+	for _, tt := range tests {
+		if got := utils.IsValidNumber(tt.n); got != tt.want {
+			t.Errorf("IsValidNumber(%v) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestValidateOperation(t *testing.T) {
+	tests := []struct {
+		op      string
+		a, b    float64
+		wantErr bool
+	}{
+		{op: "add", a: 1, b: 2},
+		{op: "divide", a: 1, b: 2},
+		{op: "divide", a: 1, b: 0, wantErr: true},
+		{op: "power", a: 2, b: -1},
+		{op: "power", a: 0, b: -1, wantErr: true},
+		{op: "add", a: math.NaN(), b: 1, wantErr: true},
+		{op: "multiply", a: 1, b: math.Inf(1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := ValidateOperation(tt.op, tt.a, tt.b)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateOperation(%q, %v, %v) error = %v, wantErr %v", tt.op, tt.a, tt.b, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+// Helpers of the HTTP service component that do not depend on its generated
+// bindings, so they can be tested natively with go test
+
+// cachedResponse is a memoized response and its expiry time
+type cachedResponse[R any] struct {
+	response R
+	expires  time.Time
+}
+
+// responseCache memoizes GET responses by path for a short TTL
+type responseCache[R any] struct {
+	ttl     time.Duration
+	entries map[string]cachedResponse[R]
+}
+
+func newResponseCache[R any](ttl time.Duration) *responseCache[R] {
+	return &responseCache[R]{
+		ttl:     ttl,
+		entries: make(map[string]cachedResponse[R]),
+	}
+}
+
+func (c *responseCache[R]) get(path string, now time.Time) (R, bool) {
+	entry, ok := c.entries[path]
+	if !ok || now.After(entry.expires) {
+		var zero R
+		return zero, false
+	}
+	return entry.response, true
+}
+
+func (c *responseCache[R]) put(path string, response R, now time.Time) {
+	if c.ttl <= 0 {
+		return
+	}
+	c.entries[path] = cachedResponse[R]{
+		response: response,
+		expires:  now.Add(c.ttl),
+	}
+}
+
+func (c *responseCache[R]) invalidate() {
+	c.entries = make(map[string]cachedResponse[R])
+}
+
+// correlationIDHeader carries the ID used to trace a request across composed components
+const correlationIDHeader = "X-Correlation-Id"
+
+// correlationID returns the request's X-Correlation-Id, generating a new one
+// when the caller did not supply it
+func correlationID(headers [][2]string) string {
+	if id := headerValue(headers, correlationIDHeader); id != "" {
+		return id
+	}
+	return newCorrelationID()
+}
+
+// newCorrelationID generates a random 128-bit hex ID
+func newCorrelationID() string {
+	var buf [16]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		// Fall back to a time-based ID if WASI random is unavailable
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf[:])
+}
+
+// headerValue returns the first value of a header, matching names case-insensitively
+func headerValue(headers [][2]string, name string) string {
+	for _, header := range headers {
+		if strings.EqualFold(header[0], name) {
+			return header[1]
+		}
+	}
+	return ""
+}
+
+// acceptsGzip reports whether an Accept-Encoding header value allows gzip
+func acceptsGzip(acceptEncoding string) bool {
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(coding), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+			continue
+		}
+		// "gzip;q=0" explicitly refuses the encoding
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+// encodeBody negotiates the encoding of a response body against the
+// request's Accept-Encoding, returning the body to send and the headers to
+// add. Any body could be sent either way, so every one carries
+// Vary: Accept-Encoding. The WIT http-response body is a string, which must
+// be valid UTF-8 across the component boundary, so gzipped bytes are base64
+// encoded and flagged with X-Body-Encoding: base64 for the host to decode
+// before sending.
+func encodeBody(acceptEncoding, body string) (string, [][2]string) {
+	if body == "" {
+		return body, nil
+	}
+
+	headers := [][2]string{{"Vary", "Accept-Encoding"}}
+	if !acceptsGzip(acceptEncoding) {
+		return body, headers
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(body)); err != nil {
+		log.Printf("gzip compression failed, sending identity body: %v", err)
+		return body, headers
+	}
+	if err := gz.Close(); err != nil {
+		log.Printf("gzip compression failed, sending identity body: %v", err)
+		return body, headers
+	}
+
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), append(headers,
+		[2]string{"Content-Encoding", "gzip"},
+		[2]string{"X-Body-Encoding", "base64"},
+	)
+}
+
+// clientIDHeader optionally identifies the caller for per-client rate limits
+const clientIDHeader = "X-Client-Id"
+
+// tokenBucket holds the tokens available to one rate-limit key
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// minRateLimitSweep is the bucket count at which idle buckets are first swept
+const minRateLimitSweep = 64
+
+// rateLimiter is a token-bucket limiter keyed by path and client. Buckets are
+// refilled lazily from elapsed time on each request rather than by a
+// background goroutine, which may be unavailable under WASI. Clients pick
+// their own X-Client-Id, so buckets that have refilled to full are swept
+// whenever the map doubles: a full bucket behaves exactly like a new one.
+type rateLimiter struct {
+	rate    float64 // tokens added per second
+	burst   float64 // bucket capacity
+	buckets map[string]*tokenBucket
+	sweepAt int // bucket count that triggers the next sweep
+}
+
+func newRateLimiter(rate, burst float64) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*tokenBucket),
+		sweepAt: minRateLimitSweep,
+	}
+}
+
+// sweep drops the buckets that would be full by now
+func (l *rateLimiter) sweep(now time.Time) {
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.lastRefill).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+	l.sweepAt = max(minRateLimitSweep, 2*len(l.buckets))
+}
+
+// allow takes a token for key, returning false and the wait until the next
+// token when the bucket is empty
+func (l *rateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	if l.rate <= 0 {
+		return true, 0
+	}
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= l.sweepAt {
+			l.sweep(now)
+		}
+		bucket = &tokenBucket{tokens: l.burst, lastRefill: now}
+		l.buckets[key] = bucket
+	}
+
+	elapsed := now.Sub(bucket.lastRefill).Seconds()
+	if elapsed > 0 {
+		bucket.tokens = min(l.burst, bucket.tokens+elapsed*l.rate)
+		bucket.lastRefill = now
+	}
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// rateLimitKey is the bucket a request draws from: its path, narrowed to the
+// client when it sends X-Client-Id
+func rateLimitKey(path string, headers [][2]string) string {
+	if clientID := headerValue(headers, clientIDHeader); clientID != "" {
+		return path + "|" + clientID
+	}
+	return path
+}
+
+// retryAfterSeconds rounds a wait up to the whole seconds of a Retry-After header
+func retryAfterSeconds(wait time.Duration) int {
+	return int(math.Ceil(wait.Seconds()))
+}
@@ -1,42 +1,100 @@
+//go:build tinygo
+
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	httpservice "example.com/calculator/example/http-service/http-service"
 	"go.bytecodealliance.org/cm"
 )
 
+// defaultCacheTTL is how long GET responses for cacheable paths are reused.
+// Override with HTTP_SERVICE_CACHE_TTL_MS (0 disables caching).
+const defaultCacheTTL = 2 * time.Second
+
+// cacheablePaths lists the routes whose GET responses may be memoized
+var cacheablePaths = map[string]bool{
+	"/":      true,
+	"/stats": true,
+}
+
+// Default rate limit per path (and client, when X-Client-Id is sent).
+// Override with HTTP_SERVICE_RATE_LIMIT_RPS and HTTP_SERVICE_RATE_LIMIT_BURST
+// (a rate of 0 disables limiting).
+const (
+	defaultRateLimitRPS   = 10.0
+	defaultRateLimitBurst = 20.0
+)
+
 // ServiceImpl implements the HTTP service interface
 type ServiceImpl struct {
-	startTime time.Time
-	requests  uint64
+	startTime           time.Time
+	requests            uint64
+	cache               *responseCache[httpservice.HTTPResponse]
+	cacheHits           uint64
+	cacheMisses         uint64
+	limiter             *rateLimiter
+	rateLimitRejections uint64
+}
+
+// rateLimitFromEnv reads the limiter configuration from the environment
+func rateLimitFromEnv() (float64, float64) {
+	return floatFromEnv("HTTP_SERVICE_RATE_LIMIT_RPS", defaultRateLimitRPS),
+		floatFromEnv("HTTP_SERVICE_RATE_LIMIT_BURST", defaultRateLimitBurst)
+}
+
+func floatFromEnv(name string, fallback float64) float64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil || parsed < 0 {
+		log.Printf("Ignoring invalid %s=%q", name, value)
+		return fallback
+	}
+	return parsed
+}
+
+// cacheTTLFromEnv reads HTTP_SERVICE_CACHE_TTL_MS, falling back to defaultCacheTTL
+func cacheTTLFromEnv() time.Duration {
+	value := os.Getenv("HTTP_SERVICE_CACHE_TTL_MS")
+	if value == "" {
+		return defaultCacheTTL
+	}
+	ms, err := strconv.Atoi(value)
+	if err != nil || ms < 0 {
+		log.Printf("Ignoring invalid HTTP_SERVICE_CACHE_TTL_MS=%q", value)
+		return defaultCacheTTL
+	}
+	return time.Duration(ms) * time.Millisecond
 }
 
 // Initialize the HTTP service component exports with generated bindings
 func init() {
+	rate, burst := rateLimitFromEnv()
 	service := &ServiceImpl{
 		startTime: time.Now(),
 		requests:  0,
+		cache:     newResponseCache[httpservice.HTTPResponse](cacheTTLFromEnv()),
+		limiter:   newRateLimiter(rate, burst),
 	}
 
 	httpservice.Exports.HandleRequest = func(request httpservice.HTTPRequest) httpservice.HTTPResponse {
 		service.requests++
-		log.Printf("Handling %s request to %s", request.Method, request.Path)
-
-		// Route the request
-		switch request.Path {
-		case "/":
-			return service.handleRoot(request)
-		case "/health":
-			return service.handleHealth(request)
-		case "/stats":
-			return service.handleStats(request)
-		default:
-			return service.handleNotFound(request)
-		}
+		correlationID := correlationID(request.Headers.Slice())
+		logRequest(request, correlationID)
+
+		response := service.handleRateLimited(request)
+		response = compressResponse(request, response)
+		return withHeader(response, correlationIDHeader, correlationID)
 	}
 
 	httpservice.Exports.GetServiceInfo = func() httpservice.ServiceInfo {
@@ -45,13 +103,250 @@ func init() {
 			Name:        "Go WebAssembly HTTP Service",
 			Version:     "1.0.0",
 			Description: "A sample HTTP service built as a WebAssembly component using Go",
-			Endpoints:   cm.ToList([]string{"/", "/health", "/stats"}),
+			Endpoints:   cm.ToList(service.endpointPaths()),
 			Uptime:      uint64(uptime.Seconds()),
 			Requests:    service.requests,
 		}
 	}
 }
 
+// logRequest logs a handled request together with its correlation ID
+func logRequest(request httpservice.HTTPRequest, correlationID string) {
+	log.Printf("Handling %s request to %s [correlation_id=%s]", request.Method, request.Path, correlationID)
+}
+
+// compressResponse gzips the response body when the client accepts it. See
+// encodeBody for how the compressed bytes cross the component boundary.
+func compressResponse(request httpservice.HTTPRequest, response httpservice.HTTPResponse) httpservice.HTTPResponse {
+	body, headers := encodeBody(headerValue(request.Headers.Slice(), "Accept-Encoding"), response.Body)
+	response.Body = body
+	for _, header := range headers {
+		response = withHeader(response, header[0], header[1])
+	}
+	return response
+}
+
+// withHeader returns a copy of the response with an extra header appended
+func withHeader(response httpservice.HTTPResponse, name, value string) httpservice.HTTPResponse {
+	existing := response.Headers.Slice()
+	headers := make([][2]string, 0, len(existing)+1)
+	headers = append(headers, existing...)
+	headers = append(headers, [2]string{name, value})
+	response.Headers = cm.ToList(headers)
+	return response
+}
+
+// handleRateLimited rejects requests over the path's (and client's) limit
+// with 429 Too Many Requests before they reach the cache or router
+func (s *ServiceImpl) handleRateLimited(request httpservice.HTTPRequest) httpservice.HTTPResponse {
+	key := rateLimitKey(request.Path, request.Headers.Slice())
+	allowed, wait := s.limiter.allow(key, time.Now())
+	if allowed {
+		return s.handleCached(request)
+	}
+
+	s.rateLimitRejections++
+	retryAfter := retryAfterSeconds(wait)
+
+	return httpservice.HTTPResponse{
+		Status: 429,
+		Headers: cm.ToList([][2]string{
+			{"Content-Type", "application/json"},
+			{"Retry-After", strconv.Itoa(retryAfter)},
+		}),
+		Body: fmt.Sprintf(`{
+		"error": "Too Many Requests",
+		"message": "Rate limit exceeded for '%s'",
+		"retry_after_seconds": %d
+	}`, request.Path, retryAfter),
+	}
+}
+
+// handleCached serves identical GETs from the response cache within the TTL
+// and drops every cached entry on a mutating request
+func (s *ServiceImpl) handleCached(request httpservice.HTTPRequest) httpservice.HTTPResponse {
+	if request.Method != "GET" && request.Method != "HEAD" {
+		s.cache.invalidate()
+		return s.route(request)
+	}
+
+	if !cacheablePaths[request.Path] {
+		return s.route(request)
+	}
+
+	now := time.Now()
+	if cached, ok := s.cache.get(request.Path, now); ok {
+		s.cacheHits++
+		return cached
+	}
+
+	s.cacheMisses++
+	response := s.route(request)
+	if response.Status == 200 {
+		s.cache.put(request.Path, response, now)
+	}
+	return response
+}
+
+// route describes one endpoint of the service. The router, ServiceInfo
+// endpoints and the OpenAPI document are all generated from this table.
+type route struct {
+	Path     string
+	Method   string
+	Summary  string
+	Response map[string]interface{} // JSON schema of the 200 response body
+	Handler  func(request httpservice.HTTPRequest) httpservice.HTTPResponse
+}
+
+// routes returns the service's endpoint table
+func (s *ServiceImpl) routes() []route {
+	return []route{
+		{
+			Path:    "/",
+			Method:  "GET",
+			Summary: "Welcome message with service version",
+			Response: objectSchema(map[string]string{
+				"message":   "string",
+				"version":   "string",
+				"timestamp": "string",
+			}),
+			Handler: s.handleRoot,
+		},
+		{
+			Path:    "/health",
+			Method:  "GET",
+			Summary: "Health status and uptime",
+			Response: objectSchema(map[string]string{
+				"status":          "string",
+				"uptime_seconds":  "number",
+				"requests_served": "integer",
+			}),
+			Handler: s.handleHealth,
+		},
+		{
+			Path:    "/stats",
+			Method:  "GET",
+			Summary: "Request and cache statistics",
+			Response: objectSchema(map[string]string{
+				"service_name": "string",
+				"version":      "string",
+				"uptime":       "object",
+				"requests":     "object",
+				"cache":        "object",
+				"rate_limit":   "object",
+				"started_at":   "string",
+			}),
+			Handler: s.handleStats,
+		},
+		{
+			Path:     "/openapi.json",
+			Method:   "GET",
+			Summary:  "OpenAPI 3 description of this service",
+			Response: map[string]interface{}{"type": "object"},
+			Handler:  s.handleOpenAPI,
+		},
+	}
+}
+
+// endpointPaths lists the paths served by the router
+func (s *ServiceImpl) endpointPaths() []string {
+	var paths []string
+	for _, r := range s.routes() {
+		paths = append(paths, r.Path)
+	}
+	return paths
+}
+
+// route dispatches a request to its handler
+func (s *ServiceImpl) route(request httpservice.HTTPRequest) httpservice.HTTPResponse {
+	for _, r := range s.routes() {
+		if r.Path == request.Path {
+			return r.Handler(request)
+		}
+	}
+	return s.handleNotFound(request)
+}
+
+// openAPIDocument builds a minimal OpenAPI 3 document from the route table
+func (s *ServiceImpl) openAPIDocument() map[string]interface{} {
+	paths := make(map[string]interface{})
+	for _, r := range s.routes() {
+		paths[r.Path] = map[string]interface{}{
+			strings.ToLower(r.Method): map[string]interface{}{
+				"summary": r.Summary,
+				"responses": map[string]interface{}{
+					"200": map[string]interface{}{
+						"description": r.Summary,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{"schema": r.Response},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Go WebAssembly HTTP Service",
+			"version":     "1.0.0",
+			"description": "A sample HTTP service built as a WebAssembly component using Go",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				// Mirrors the service-info record in http-service.wit
+				"ServiceInfo": objectSchema(map[string]string{
+					"name":        "string",
+					"version":     "string",
+					"description": "string",
+					"endpoints":   "array",
+					"uptime":      "integer",
+					"requests":    "integer",
+				}),
+			},
+		},
+	}
+}
+
+// objectSchema builds a JSON schema object from property name to type
+func objectSchema(properties map[string]string) map[string]interface{} {
+	props := make(map[string]interface{})
+	for name, typ := range properties {
+		if typ == "array" {
+			props[name] = map[string]interface{}{"type": typ, "items": map[string]string{"type": "string"}}
+			continue
+		}
+		props[name] = map[string]string{"type": typ}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+	}
+}
+
+func (s *ServiceImpl) handleOpenAPI(request httpservice.HTTPRequest) httpservice.HTTPResponse {
+	body, err := json.MarshalIndent(s.openAPIDocument(), "", "  ")
+	if err != nil {
+		return httpservice.HTTPResponse{
+			Status: 500,
+			Headers: cm.ToList([][2]string{
+				{"Content-Type", "application/json"},
+			}),
+			Body: fmt.Sprintf(`{"error": "Internal Server Error", "message": %q}`, err.Error()),
+		}
+	}
+
+	return httpservice.HTTPResponse{
+		Status: 200,
+		Headers: cm.ToList([][2]string{
+			{"Content-Type", "application/json"},
+		}),
+		Body: string(body),
+	}
+}
+
 func (s *ServiceImpl) handleRoot(request httpservice.HTTPRequest) httpservice.HTTPResponse {
 	body := fmt.Sprintf(`{
 		"message": "Welcome to Go WebAssembly HTTP Service",
@@ -101,12 +396,28 @@ func (s *ServiceImpl) handleStats(request httpservice.HTTPRequest) httpservice.H
 			"total": %d,
 			"rate_per_minute": %.2f
 		},
+		"cache": {
+			"hits": %d,
+			"misses": %d,
+			"ttl_ms": %d
+		},
+		"rate_limit": {
+			"rejected": %d,
+			"requests_per_second": %.2f,
+			"burst": %.0f
+		},
 		"started_at": "%s"
 	}`,
 		uptime.Seconds(),
 		uptime.String(),
 		s.requests,
 		float64(s.requests)/uptime.Minutes(),
+		s.cacheHits,
+		s.cacheMisses,
+		s.cache.ttl.Milliseconds(),
+		s.rateLimitRejections,
+		s.limiter.rate,
+		s.limiter.burst,
 		s.startTime.Format(time.RFC3339),
 	)
 
@@ -120,11 +431,13 @@ func (s *ServiceImpl) handleStats(request httpservice.HTTPRequest) httpservice.H
 }
 
 func (s *ServiceImpl) handleNotFound(request httpservice.HTTPRequest) httpservice.HTTPResponse {
+	availablePaths, _ := json.Marshal(s.endpointPaths())
+
 	body := fmt.Sprintf(`{
 		"error": "Not Found",
 		"message": "Path '%s' not found",
-		"available_paths": ["/", "/health", "/stats"]
-	}`, request.Path)
+		"available_paths": %s
+	}`, request.Path, availablePaths)
 
 	return httpservice.HTTPResponse{
 		Status: 404,
@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResponseCache(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	ttl := 2 * time.Second
+
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		put    string
+		get    string
+		after  time.Duration
+		want   string
+		wantOK bool
+	}{
+		{name: "hit within TTL", ttl: ttl, put: "/", get: "/", after: time.Second, want: "root", wantOK: true},
+		{name: "hit at TTL boundary", ttl: ttl, put: "/", get: "/", after: ttl, want: "root", wantOK: true},
+		{name: "expired after TTL", ttl: ttl, put: "/", get: "/", after: ttl + time.Millisecond},
+		{name: "other path misses", ttl: ttl, put: "/", get: "/stats"},
+		{name: "path prefix misses", ttl: ttl, put: "/stats", get: "/stats/"},
+		{name: "zero TTL stores nothing", ttl: 0, put: "/", get: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newResponseCache[string](tt.ttl)
+			cache.put(tt.put, "root", start)
+
+			got, ok := cache.get(tt.get, start.Add(tt.after))
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("get(%q) after %s = %q, %v; want %q, %v", tt.get, tt.after, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestResponseCacheKeysAndInvalidate(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache := newResponseCache[string](time.Minute)
+	cache.put("/", "root", now)
+	cache.put("/stats", "stats", now)
+
+	for path, want := range map[string]string{"/": "root", "/stats": "stats"} {
+		if got, ok := cache.get(path, now); !ok || got != want {
+			t.Errorf("get(%q) = %q, %v; want %q", path, got, ok, want)
+		}
+	}
+
+	// A later put replaces only its own path and restarts its TTL
+	cache.put("/", "root v2", now.Add(30*time.Second))
+	if got, ok := cache.get("/", now.Add(80*time.Second)); !ok || got != "root v2" {
+		t.Errorf("get(/) after refresh = %q, %v; want the refreshed entry", got, ok)
+	}
+	if _, ok := cache.get("/stats", now.Add(80*time.Second)); ok {
+		t.Error("get(/stats) hit after its own TTL expired")
+	}
+
+	cache.invalidate()
+	if _, ok := cache.get("/", now.Add(40*time.Second)); ok {
+		t.Error("get(/) hit after invalidate")
+	}
+}
+
+func TestCorrelationID(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers [][2]string
+		want    string
+	}{
+		{name: "echoes incoming ID", headers: [][2]string{{"X-Correlation-Id", "req-42"}}, want: "req-42"},
+		{name: "matches header name case-insensitively", headers: [][2]string{{"x-correlation-id", "req-43"}}, want: "req-43"},
+		{name: "first of repeated headers wins", headers: [][2]string{{"X-Correlation-Id", "first"}, {"X-Correlation-Id", "second"}}, want: "first"},
+		{name: "ignores other headers", headers: [][2]string{{"X-Request-Id", "other"}, {"X-Correlation-Id", "req-44"}}, want: "req-44"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := correlationID(tt.headers); got != tt.want {
+				t.Errorf("correlationID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorrelationIDGenerated(t *testing.T) {
+	for name, headers := range map[string][][2]string{
+		"no headers":   nil,
+		"empty header": {{"X-Correlation-Id", ""}},
+		"other header": {{"Accept", "application/json"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			first, second := correlationID(headers), correlationID(headers)
+			for _, id := range []string{first, second} {
+				if len(id) != 32 || strings.Trim(id, "0123456789abcdef") != "" {
+					t.Errorf("generated ID %q, want 32 lowercase hex characters", id)
+				}
+			}
+			if first == second {
+				t.Errorf("two requests got the same generated ID %q", first)
+			}
+		})
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           bool
+	}{
+		{"", false},
+		{"identity", false},
+		{"gzip", true},
+		{"GZIP", true},
+		{"deflate, gzip", true},
+		{"br;q=1.0, gzip;q=0.8, *;q=0.1", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0", false},
+		{"gzip;q=0.5", true},
+		{"x-gzip", false},
+		{"deflate, br", false},
+	}
+
+	for _, tt := range tests {
+		if got := acceptsGzip(tt.acceptEncoding); got != tt.want {
+			t.Errorf("acceptsGzip(%q) = %v, want %v", tt.acceptEncoding, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeBody(t *testing.T) {
+	const body = `{"message": "Hello from Go WebAssembly HTTP Service!"}`
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		body           string
+		wantGzip       bool
+		wantHeaders    [][2]string
+	}{
+		{
+			name:        "identity still varies",
+			body:        body,
+			wantHeaders: [][2]string{{"Vary", "Accept-Encoding"}},
+		},
+		{
+			name:           "refused gzip still varies",
+			acceptEncoding: "gzip;q=0",
+			body:           body,
+			wantHeaders:    [][2]string{{"Vary", "Accept-Encoding"}},
+		},
+		{
+			name:           "gzip",
+			acceptEncoding: "gzip, deflate",
+			body:           body,
+			wantGzip:       true,
+			wantHeaders: [][2]string{
+				{"Vary", "Accept-Encoding"},
+				{"Content-Encoding", "gzip"},
+				{"X-Body-Encoding", "base64"},
+			},
+		},
+		{
+			name:           "empty body is left alone",
+			acceptEncoding: "gzip",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, headers := encodeBody(tt.acceptEncoding, tt.body)
+			if !reflect.DeepEqual(headers, tt.wantHeaders) {
+				t.Errorf("headers = %q, want %q", headers, tt.wantHeaders)
+			}
+
+			if !tt.wantGzip {
+				if got != tt.body {
+					t.Errorf("body = %q, want it unchanged", got)
+				}
+				return
+			}
+
+			// Round trip: base64, then gzip, back to the original body
+			compressed, err := base64.StdEncoding.DecodeString(got)
+			if err != nil {
+				t.Fatalf("body is not base64: %v", err)
+			}
+			reader, err := gzip.NewReader(bytes.NewReader(compressed))
+			if err != nil {
+				t.Fatalf("body is not gzip: %v", err)
+			}
+			decoded, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("reading gzip body: %v", err)
+			}
+			if string(decoded) != tt.body {
+				t.Errorf("round trip = %q, want %q", decoded, tt.body)
+			}
+		})
+	}
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type call struct {
+		at          time.Duration // since start
+		key         string
+		wantAllowed bool
+		wantRetry   int // Retry-After seconds of a rejection
+	}
+	tests := []struct {
+		name  string
+		rate  float64
+		burst float64
+		calls []call
+	}{
+		{
+			name: "burst then 429 with Retry-After",
+			rate: 1, burst: 3,
+			calls: []call{
+				{key: "/", wantAllowed: true},
+				{key: "/", wantAllowed: true},
+				{key: "/", wantAllowed: true},
+				{key: "/", wantRetry: 1},
+			},
+		},
+		{
+			name: "Retry-After rounds the wait up",
+			rate: 0.4, burst: 1,
+			calls: []call{
+				{key: "/", wantAllowed: true},
+				{at: 500 * time.Millisecond, key: "/", wantRetry: 2},
+			},
+		},
+		{
+			name: "refills from elapsed time",
+			rate: 2, burst: 1,
+			calls: []call{
+				{key: "/", wantAllowed: true},
+				{at: 250 * time.Millisecond, key: "/", wantRetry: 1},
+				{at: 500 * time.Millisecond, key: "/", wantAllowed: true},
+				{at: 500 * time.Millisecond, key: "/", wantRetry: 1},
+			},
+		},
+		{
+			name: "refill is capped at the burst",
+			rate: 10, burst: 2,
+			calls: []call{
+				{key: "/", wantAllowed: true},
+				{at: time.Hour, key: "/", wantAllowed: true},
+				{at: time.Hour, key: "/", wantAllowed: true},
+				{at: time.Hour, key: "/", wantRetry: 1},
+			},
+		},
+		{
+			name: "paths are limited independently",
+			rate: 1, burst: 1,
+			calls: []call{
+				{key: "/", wantAllowed: true},
+				{key: "/", wantRetry: 1},
+				{key: "/stats", wantAllowed: true},
+				{key: "/stats", wantRetry: 1},
+				{key: "/health", wantAllowed: true},
+			},
+		},
+		{
+			name: "zero rate disables limiting",
+			rate: 0, burst: 0,
+			calls: []call{
+				{key: "/", wantAllowed: true},
+				{key: "/", wantAllowed: true},
+				{key: "/", wantAllowed: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter := newRateLimiter(tt.rate, tt.burst)
+			for i, c := range tt.calls {
+				allowed, wait := limiter.allow(c.key, start.Add(c.at))
+				if allowed != c.wantAllowed {
+					t.Fatalf("call %d (%s at %s): allowed = %v, want %v", i, c.key, c.at, allowed, c.wantAllowed)
+				}
+				if !allowed && retryAfterSeconds(wait) != c.wantRetry {
+					t.Errorf("call %d (%s at %s): Retry-After %d (wait %s), want %d", i, c.key, c.at, retryAfterSeconds(wait), wait, c.wantRetry)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitKey(t *testing.T) {
+	tests := []struct {
+		path    string
+		headers [][2]string
+		want    string
+	}{
+		{path: "/", want: "/"},
+		{path: "/stats", headers: [][2]string{{"X-Client-Id", "ci"}}, want: "/stats|ci"},
+		{path: "/stats", headers: [][2]string{{"x-client-id", "dev"}}, want: "/stats|dev"},
+		{path: "/stats", headers: [][2]string{{"X-Client-Id", ""}}, want: "/stats"},
+	}
+
+	for _, tt := range tests {
+		if got := rateLimitKey(tt.path, tt.headers); got != tt.want {
+			t.Errorf("rateLimitKey(%q, %q) = %q, want %q", tt.path, tt.headers, got, tt.want)
+		}
+	}
+}
+
+func TestRateLimiterSweepsIdleBuckets(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := start.Add(10 * time.Second)
+	limiter := newRateLimiter(1, 1)
+
+	// Fill the map up to the sweep threshold: every bucket but one drains at
+	// start and has refilled by later; "busy" drains at later
+	for i := 0; i < minRateLimitSweep-1; i++ {
+		limiter.allow(fmt.Sprintf("/|client-%d", i), start)
+	}
+	limiter.allow("/|busy", later)
+	if len(limiter.buckets) != minRateLimitSweep {
+		t.Fatalf("%d buckets before the sweep, want %d", len(limiter.buckets), minRateLimitSweep)
+	}
+
+	// The next new key sweeps the refilled buckets
+	limiter.allow("/|new", later)
+	if len(limiter.buckets) != 2 {
+		t.Errorf("%d buckets after the sweep, want 2 (busy and new)", len(limiter.buckets))
+	}
+	if limiter.sweepAt != minRateLimitSweep {
+		t.Errorf("sweepAt = %d, want %d", limiter.sweepAt, minRateLimitSweep)
+	}
+
+	// Sweeping changes no decision: busy is still empty, a swept client is full
+	if allowed, _ := limiter.allow("/|busy", later); allowed {
+		t.Error("busy client allowed after the sweep, want it still limited")
+	}
+	if allowed, _ := limiter.allow("/|client-0", later); !allowed {
+		t.Error("swept client rejected, want a full bucket")
+	}
+}
+
+func TestRateLimiterSweepThresholdGrows(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(1, 1)
+
+	// Buckets that are all still draining survive the sweep, which then
+	// waits for the map to double before scanning again
+	for i := 0; i <= minRateLimitSweep; i++ {
+		limiter.allow(fmt.Sprintf("/|client-%d", i), start)
+	}
+	if len(limiter.buckets) != minRateLimitSweep+1 {
+		t.Errorf("%d buckets, want all %d kept", len(limiter.buckets), minRateLimitSweep+1)
+	}
+	if want := 2 * minRateLimitSweep; limiter.sweepAt != want {
+		t.Errorf("sweepAt = %d, want %d", limiter.sweepAt, want)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+)
+
+// TestUploadComponentAtomicKeepsPriorVersion pushes a good signed version,
+// then a series of bad ones, and checks each is rejected without touching
+// what is stored under name:tag
+func TestUploadComponentAtomicKeepsPriorVersion(t *testing.T) {
+	newTestRegistry(t)
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, msg := setComponentPublicKey("calc", "v1", publicKey); status != 1 {
+		t.Fatalf("setComponentPublicKey: %s", msg)
+	}
+
+	manifest := []byte(`{"schemaVersion":2,"annotations":{"org.opencontainers.image.version":"1.0.0"}}`)
+	good := []byte("\x00asm\x0d\x00\x01\x00 first version")
+	if status, msg, swapped := uploadComponentAtomic("calc", "v1", good, ed25519.Sign(privateKey, good)); status != 1 || !swapped {
+		t.Fatalf("good upload: status %d, swapped %v: %s", status, swapped, msg)
+	}
+	if status, msg := uploadManifest("calc", "v1", manifest); status != 1 {
+		t.Fatalf("uploadManifest: %s", msg)
+	}
+
+	replacement := []byte("\x00asm\x0d\x00\x01\x00 second version")
+	notWasm := []byte("#!/bin/sh\necho not a component\n")
+
+	tests := []struct {
+		name      string
+		data      []byte
+		signature []byte
+	}{
+		{name: "not wasm", data: notWasm, signature: ed25519.Sign(privateKey, notWasm)},
+		{name: "truncated header", data: good[:6], signature: ed25519.Sign(privateKey, good[:6])},
+		{name: "wrong key", data: replacement, signature: ed25519.Sign(otherKey, replacement)},
+		{name: "signature of other bytes", data: replacement, signature: ed25519.Sign(privateKey, good)},
+		{name: "malformed signature", data: replacement, signature: []byte("short")},
+		{name: "unsigned", data: replacement},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, msg, swapped := uploadComponentAtomic("calc", "v1", tt.data, tt.signature)
+			if status != 0 || swapped {
+				t.Fatalf("bad upload accepted: status %d, swapped %v: %s", status, swapped, msg)
+			}
+
+			if status, msg, data := downloadComponent("calc", "v1"); status != 1 || !bytes.Equal(data, good) {
+				t.Errorf("after rejected upload, download = %d %q (%s), want the first version", status, data, msg)
+			}
+			storeMu.RLock()
+			defer storeMu.RUnlock()
+			if _, staged := components[componentKey("calc", "v1")+"#staging"]; staged {
+				t.Error("staging entry left behind")
+			}
+			if got := components[componentKey("calc", "v1")].Manifest; !bytes.Equal(got, manifest) {
+				t.Errorf("manifest = %q, want it preserved", got)
+			}
+		})
+	}
+
+	t.Run("no public key", func(t *testing.T) {
+		status, msg, swapped := uploadComponentAtomic("other", "v1", replacement, ed25519.Sign(privateKey, replacement))
+		if status != 0 || swapped {
+			t.Fatalf("upload without a registered key accepted: %s", msg)
+		}
+		if status, _, _ := downloadComponent("other", "v1"); status != 0 {
+			t.Error("component stored despite the missing key")
+		}
+	})
+
+	t.Run("good replacement swaps", func(t *testing.T) {
+		status, msg, swapped := uploadComponentAtomic("calc", "v1", replacement, ed25519.Sign(privateKey, replacement))
+		if status != 1 || !swapped {
+			t.Fatalf("good replacement rejected: %s", msg)
+		}
+		if _, _, data := downloadComponent("calc", "v1"); !bytes.Equal(data, replacement) {
+			t.Errorf("download = %q, want the replacement", data)
+		}
+		if status, msg, annotations := getComponentAnnotations("calc", "v1"); status != 1 || len(annotations) != 1 ||
+			annotations[0] != [2]string{"org.opencontainers.image.version", "1.0.0"} {
+			t.Errorf("annotations = %d %q (%s), want them carried over with the manifest", status, annotations, msg)
+		}
+		storeMu.RLock()
+		defer storeMu.RUnlock()
+		if got := components[componentKey("calc", "v1")].Manifest; !bytes.Equal(got, manifest) {
+			t.Errorf("manifest = %q, want it carried over", got)
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+)
+
+// TestDebugRoutesRequireAuth checks that in bearer mode the debug endpoints
+// that change state, and the snapshot dump, want a token with the right scope
+func TestDebugRoutesRequireAuth(t *testing.T) {
+	server := newTestServer(t)
+	if status, msg := uploadComponent("calc", "v1", []byte("\x00asm\x0d\x00\x01\x00")); status != 1 {
+		t.Fatalf("uploadComponent: %s", msg)
+	}
+	authMode = "bearer"
+	registerToken("debug-reader", []string{scopePull})
+	registerToken("debug-pusher", []string{scopePull, scopePush})
+	registerToken("debug-admin", []string{scopePull, scopePush, scopeDelete})
+
+	snapshot, err := encodeSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path string
+		body         []byte
+		granted      string // Least privileged token that is allowed
+		denied       string // Token lacking the scope, "" when any token will do
+	}{
+		{method: "POST", path: "/debug/components", granted: "debug-pusher", denied: "debug-reader"},
+		{method: "POST", path: "/debug/reset", granted: "debug-admin", denied: "debug-pusher"},
+		{method: "PUT", path: "/debug/snapshot", body: snapshot, granted: "debug-admin", denied: "debug-pusher"},
+		{method: "GET", path: "/debug/snapshot", granted: "debug-reader"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			send := func(token string) int {
+				req, err := http.NewRequest(tt.method, server.URL+tt.path, bytes.NewReader(tt.body))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if token != "" {
+					req.Header.Set("Authorization", "Bearer "+token)
+				}
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				return resp.StatusCode
+			}
+
+			before := takeSnapshot()
+			if status := send(""); status != http.StatusUnauthorized {
+				t.Errorf("without a token: status %d, want 401", status)
+			}
+			if tt.denied != "" {
+				if status := send(tt.denied); status != http.StatusUnauthorized {
+					t.Errorf("with %s: status %d, want 401", tt.denied, status)
+				}
+			}
+			if after := takeSnapshot(); len(after.Components) != len(before.Components) {
+				t.Errorf("rejected requests changed the registry: %d components, had %d", len(after.Components), len(before.Components))
+			}
+
+			if status := send(tt.granted); status >= 300 {
+				t.Errorf("with %s: status %d, want success", tt.granted, status)
+			}
+		})
+	}
+
+	// Listing stays open, like the catalog
+	resp, body := do(t, "GET", server.URL+"/debug/components", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /debug/components without a token: status %d (%s), want 200", resp.StatusCode, body)
+	}
+}
+
+func TestParseServerArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantAddr     string
+		wantSnapshot string
+		wantErr      bool
+	}{
+		{name: "defaults", wantAddr: ":5001"},
+		{name: "address", args: []string{"127.0.0.1:6000"}, wantAddr: "127.0.0.1:6000"},
+		{
+			name:         "every flag",
+			args:         []string{"--enable-admin-gc", "--access-log", "--snapshot=fixture.json", "--bearer-token=ci=pull,push", ":7000"},
+			wantAddr:     ":7000",
+			wantSnapshot: "fixture.json",
+		},
+		{name: "misspelled flag", args: []string{"--bearer-tokn=ci=pull"}, wantErr: true},
+		{name: "misspelled flag after the address", args: []string{":7000", "--acces-log"}, wantErr: true},
+		{name: "bad token scope", args: []string{"--bearer-token=ci=admin"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTestRegistry(t)
+			savedGC, savedLog, savedMode := enableAdminGC, accessLogEnabled, authMode
+			t.Cleanup(func() { enableAdminGC, accessLogEnabled, authMode = savedGC, savedLog, savedMode })
+
+			addr, snapshotPath, err := parseServerArgs(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseServerArgs(%q) = %q, want an error", tt.args, addr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if addr != tt.wantAddr || snapshotPath != tt.wantSnapshot {
+				t.Errorf("parseServerArgs = %q, %q, want %q, %q", addr, snapshotPath, tt.wantAddr, tt.wantSnapshot)
+			}
+		})
+	}
+}
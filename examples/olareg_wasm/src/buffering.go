@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Under WASI every write is a host call, so small writes are batched.
+// responseBufferSize bounds how much of a response is held before it is
+// written through; 0 disables buffering. Set with OLAREG_BUFFER_SIZE.
+var responseBufferSize = 32 * 1024
+
+// Access logging, opt-in via --access-log. Lines go through a buffered
+// stdout writer that is flushed once per request rather than once per line.
+var (
+	accessLogEnabled bool
+	accessLogMu      sync.Mutex
+	accessLog        = bufio.NewWriterSize(os.Stdout, 4096)
+)
+
+// flushErrorCount counts responses or log lines lost to a failed flush
+var flushErrorCount atomic.Uint32
+
+// configureBuffering reads OLAREG_BUFFER_SIZE, exiting on bad input
+func configureBuffering() {
+	value := os.Getenv("OLAREG_BUFFER_SIZE")
+	if value == "" {
+		return
+	}
+
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 {
+		fmt.Printf("❌ Invalid OLAREG_BUFFER_SIZE %q: expected a byte count, 0 to disable\n", value)
+		os.Exit(1)
+	}
+	responseBufferSize = size
+	accessLog = bufio.NewWriterSize(os.Stdout, max(size, 4096))
+}
+
+// bufferedResponseWriter batches response writes. Flush also flushes the
+// underlying writer, so streaming handlers keep working through it.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	buf *bufio.Writer
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	return b.buf.Write(p)
+}
+
+// Flush implements http.Flusher
+func (b *bufferedResponseWriter) Flush() {
+	if err := b.flush(); err != nil {
+		reportFlushError("response", err)
+	}
+}
+
+func (b *bufferedResponseWriter) flush() error {
+	if err := b.buf.Flush(); err != nil {
+		return err
+	}
+	if flusher, ok := b.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// withBuffering wraps every response in a buffered writer and flushes it
+// when the handler returns. A failed flush is reported, never dropped.
+func withBuffering(next http.Handler) http.Handler {
+	if responseBufferSize == 0 {
+		return next
+	}
+
+	// Buffers are reused across requests so batching writes does not cost
+	// an allocation per response
+	pool := sync.Pool{New: func() any { return bufio.NewWriterSize(nil, responseBufferSize) }}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := pool.Get().(*bufio.Writer)
+		buf.Reset(w)
+		defer func() {
+			buf.Reset(nil)
+			pool.Put(buf)
+		}()
+
+		next.ServeHTTP(&bufferedResponseWriter{ResponseWriter: w, buf: buf}, r)
+		if err := buf.Flush(); err != nil {
+			reportFlushError(r.Method+" "+r.URL.Path, err)
+		}
+	})
+}
+
+// statusRecorder captures the status code and body size for the access log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+func (s *statusRecorder) Write(p []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	n, err := s.ResponseWriter.Write(p)
+	s.bytes += n
+	return n, err
+}
+
+// Flush implements http.Flusher
+func (s *statusRecorder) Flush() {
+	if flusher, ok := s.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// withAccessLog writes one line per request to the buffered access log and
+// flushes it at the end of the request
+func withAccessLog(next http.Handler) http.Handler {
+	if !accessLogEnabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		accessLogMu.Lock()
+		fmt.Fprintf(accessLog, "📝 %s %s %d %dB %s\n", r.Method, r.URL.Path, rec.status, rec.bytes, time.Since(start).Round(time.Microsecond))
+		err := accessLog.Flush()
+		if err != nil {
+			accessLog.Reset(os.Stdout) // Drop the unwritable line rather than retrying it forever
+		}
+		accessLogMu.Unlock()
+
+		if err != nil {
+			reportFlushError("access log", err)
+		}
+	})
+}
+
+// reportFlushError surfaces a failed flush on stderr and in the metrics
+func reportFlushError(what string, err error) {
+	flushErrorCount.Add(1)
+	fmt.Fprintf(os.Stderr, "❌ Flush failed for %s: %v\n", what, err)
+}
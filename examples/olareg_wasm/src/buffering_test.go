@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// hostWriter stands in for a WASI response stream, where each Write is a
+// host call. It counts the writes and can be made to fail.
+type hostWriter struct {
+	header http.Header
+	writes int
+	bytes  int
+	err    error
+}
+
+func (h *hostWriter) Header() http.Header {
+	if h.header == nil {
+		h.header = make(http.Header)
+	}
+	return h.header
+}
+
+func (h *hostWriter) WriteHeader(int) {}
+
+func (h *hostWriter) Write(p []byte) (int, error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	h.writes++
+	h.bytes += len(p)
+	return len(p), nil
+}
+
+// smallWrites writes a JSON tag list one small piece at a time, the way the
+// handlers' encoders do
+var smallWrites = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprint(w, `{"name":"repo","tags":[`)
+	for i := 0; i < 200; i++ {
+		if i > 0 {
+			fmt.Fprint(w, ",")
+		}
+		fmt.Fprintf(w, `"v%d"`, i)
+	}
+	fmt.Fprint(w, "]}")
+})
+
+// withBufferSize wraps next with buffering of the given size
+func withBufferSize(tb testing.TB, size int, next http.Handler) http.Handler {
+	tb.Helper()
+	saved := responseBufferSize
+	responseBufferSize = size
+	tb.Cleanup(func() { responseBufferSize = saved })
+	return withBuffering(next)
+}
+
+func TestWithBufferingBatchesWrites(t *testing.T) {
+	unbuffered := &hostWriter{}
+	withBufferSize(t, 0, smallWrites).ServeHTTP(unbuffered, httptest.NewRequest("GET", "/v2/repo/tags/list", nil))
+
+	buffered := &hostWriter{}
+	withBufferSize(t, 32*1024, smallWrites).ServeHTTP(buffered, httptest.NewRequest("GET", "/v2/repo/tags/list", nil))
+
+	if buffered.bytes != unbuffered.bytes {
+		t.Errorf("buffered response has %d bytes, unbuffered %d", buffered.bytes, unbuffered.bytes)
+	}
+	if buffered.writes != 1 {
+		t.Errorf("buffered response took %d writes, want 1 (unbuffered took %d)", buffered.writes, unbuffered.writes)
+	}
+}
+
+func TestWithBufferingReportsFlushError(t *testing.T) {
+	before := flushErrorCount.Load()
+
+	failing := &hostWriter{err: errors.New("stream closed")}
+	withBufferSize(t, 32*1024, smallWrites).ServeHTTP(failing, httptest.NewRequest("GET", "/v2/repo/tags/list", nil))
+
+	if got := flushErrorCount.Load() - before; got != 1 {
+		t.Errorf("flush errors counted = %d, want 1", got)
+	}
+}
+
+// BenchmarkResponseWriting serves a burst of small responses with and
+// without buffering, reporting host writes per response
+func BenchmarkResponseWriting(b *testing.B) {
+	for _, size := range []int{0, 4096, 32 * 1024} {
+		b.Run(fmt.Sprintf("buffer=%d", size), func(b *testing.B) {
+			handler := withBufferSize(b, size, smallWrites)
+			r := httptest.NewRequest("GET", "/v2/repo/tags/list", nil)
+			w := &hostWriter{}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				handler.ServeHTTP(w, r)
+			}
+			b.ReportMetric(float64(w.writes)/float64(b.N), "writes/op")
+		})
+	}
+}
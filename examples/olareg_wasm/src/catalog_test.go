@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"testing"
+)
+
+// ndjsonLines decodes an NDJSON body into its string values
+func ndjsonLines(t *testing.T, body []byte) []string {
+	t.Helper()
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		var line string
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("line %q is not a JSON string: %v", scanner.Text(), err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// TestCatalogStream checks that ?format=ndjson writes one JSON string per
+// repository, honours annotation filters, and works past a flush boundary
+func TestCatalogStream(t *testing.T) {
+	server := newTestServer(t)
+
+	var want []string
+	for i := 0; i < catalogStreamFlushEvery+5; i++ {
+		reference := fmt.Sprintf("v%d", i)
+		pushManifest(t, server, "stream/app", reference, []byte(fmt.Sprintf(`{"schemaVersion":2,"tag":%q}`, reference)))
+		want = append(want, componentKey("stream/app", reference))
+	}
+	pushManifest(t, server, "stream/lib", "v1", []byte(`{"schemaVersion":2,"annotations":{"team":"core"}}`))
+	want = append(want, "stream/lib:v1")
+	sort.Strings(want)
+
+	t.Run("all", func(t *testing.T) {
+		resp, body := do(t, "GET", server.URL+"/v2/_catalog?format=ndjson", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+		}
+		if got := resp.Header.Get("Content-Type"); got != "application/x-ndjson" {
+			t.Errorf("Content-Type = %q, want application/x-ndjson", got)
+		}
+		got := ndjsonLines(t, body)
+		sort.Strings(got)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("got %d lines, want %d: %v", len(got), len(want), got)
+		}
+	})
+
+	t.Run("filtered", func(t *testing.T) {
+		_, body := do(t, "GET", server.URL+"/v2/_catalog?format=ndjson&annotation=team=core", nil)
+		if got := ndjsonLines(t, body); len(got) != 1 || got[0] != "stream/lib:v1" {
+			t.Errorf("filtered stream = %v, want [stream/lib:v1]", got)
+		}
+	})
+
+	t.Run("no matches", func(t *testing.T) {
+		resp, body := do(t, "GET", server.URL+"/v2/_catalog?format=ndjson&annotation=team=none", nil)
+		if resp.StatusCode != http.StatusOK || len(body) != 0 {
+			t.Errorf("status %d body %q, want 200 with an empty body", resp.StatusCode, body)
+		}
+	})
+}
+
+// TestPutManifestByDigest checks that a manifest pushed by digest can be
+// fetched by that digest but does not appear as a tag
+func TestPutManifestByDigest(t *testing.T) {
+	server := newTestServer(t)
+	pushManifest(t, server, "app", "v1", []byte(`{"schemaVersion":2,"tag":"v1"}`))
+
+	manifest := []byte(`{"schemaVersion":2,"untagged":true}`)
+	digest := calculateDigest(manifest)
+	if got := pushManifest(t, server, "app", digest, manifest); got != digest {
+		t.Fatalf("Docker-Content-Digest = %q, want %q", got, digest)
+	}
+
+	resp, body := do(t, "GET", server.URL+"/v2/app/manifests/"+digest, nil)
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(body, manifest) {
+		t.Errorf("GET by digest: status %d body %s, want 200 with the manifest", resp.StatusCode, body)
+	}
+
+	_, body = do(t, "GET", server.URL+"/v2/_catalog", nil)
+	var catalog struct {
+		Repositories []string `json:"repositories"`
+	}
+	if err := json.Unmarshal(body, &catalog); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(catalog.Repositories) != "[app:v1]" {
+		t.Errorf("catalog = %v, want only the app:v1 tag", catalog.Repositories)
+	}
+
+	_, body = do(t, "GET", server.URL+"/v2/_catalog?format=ndjson", nil)
+	if got := ndjsonLines(t, body); fmt.Sprint(got) != "[app:v1]" {
+		t.Errorf("NDJSON catalog = %v, want only the app:v1 tag", got)
+	}
+
+	wrong := calculateDigest([]byte("something else"))
+	resp, body = do(t, "PUT", server.URL+"/v2/app/manifests/"+wrong, manifest)
+	if resp.StatusCode != http.StatusBadRequest || ociErrorCode(body) != "DIGEST_INVALID" {
+		t.Errorf("PUT under the wrong digest: status %d body %s, want 400 DIGEST_INVALID", resp.StatusCode, body)
+	}
+}
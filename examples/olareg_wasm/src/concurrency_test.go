@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// newTestRegistry starts from an empty, running registry that accepts pushes
+// and deletes without auth
+func newTestRegistry(t *testing.T) {
+	t.Helper()
+	initRegistry()
+	resetRegistry()
+	authMode = "none"
+	t.Cleanup(func() { resetRegistry() })
+}
+
+// serve runs one request through the /v2/ router
+func serve(method, path string, body []byte) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handleV2Root(w, httptest.NewRequest(method, path, bytes.NewReader(body)))
+	return w
+}
+
+// TestConcurrentAccess drives the handlers from many goroutines while GC
+// sweeps, as the scheduler does. Run with -race to check the registry maps.
+func TestConcurrentAccess(t *testing.T) {
+	newTestRegistry(t)
+
+	const workers = 8
+	const rounds = 25
+
+	stop := make(chan struct{})
+	var gc sync.WaitGroup
+	gc.Add(1)
+	go func() {
+		defer gc.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				garbageCollect()
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			repo := fmt.Sprintf("repo%d", worker)
+			for round := 0; round < rounds; round++ {
+				blob := []byte(fmt.Sprintf("layer %d/%d", worker, round))
+				digest := calculateDigest(blob)
+
+				start := serve("POST", "/v2/"+repo+"/blobs/uploads/", nil)
+				if start.Code != http.StatusAccepted {
+					t.Errorf("starting upload: status %d", start.Code)
+					return
+				}
+				if w := serve("PUT", start.Header().Get("Location")+"?digest="+digest, blob); w.Code != http.StatusCreated {
+					t.Errorf("completing upload: status %d", w.Code)
+					return
+				}
+
+				manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"layers":[{"digest":%q}]}`, digest))
+				tag := fmt.Sprintf("v%d", round)
+				if w := serve("PUT", "/v2/"+repo+"/manifests/"+tag, manifest); w.Code != http.StatusCreated {
+					t.Errorf("putting manifest: status %d", w.Code)
+					return
+				}
+
+				serve("GET", "/v2/"+repo+"/manifests/"+tag, nil)
+				serve("HEAD", "/v2/"+repo+"/manifests/"+calculateDigest(manifest), nil)
+				serve("GET", "/v2/"+repo+"/blobs/"+digest, nil)
+				serve("GET", "/v2/"+repo+"/referrers/"+digest, nil)
+				serve("GET", "/v2/"+repo+"/annotations/"+tag, nil)
+				handleCatalog(httptest.NewRecorder(), httptest.NewRequest("GET", "/v2/_catalog", nil))
+				if round%5 == 4 {
+					serve("DELETE", "/v2/"+repo+"/manifests/"+tag, nil)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+	close(stop)
+	gc.Wait()
+
+	if got, want := getComponentCount(), uint32(workers*(rounds-rounds/5)); got != want {
+		t.Errorf("component count = %d, want %d", got, want)
+	}
+}
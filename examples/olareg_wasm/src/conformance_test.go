@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// setupRoutesOnce guards the routes registered on http.DefaultServeMux, which
+// panics when a pattern is registered twice
+var setupRoutesOnce sync.Once
+
+// newTestServer serves a fresh registry over HTTP through the same handler
+// chain as main
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	newTestRegistry(t)
+	setupRoutesOnce.Do(func() {
+		// Register the opt-in admin routes too, so they can be tested
+		enableAdminGC = true
+		setupRoutes()
+	})
+
+	server := httptest.NewServer(withBuffering(http.DefaultServeMux))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// do sends one request and returns the response with its body read
+func do(t *testing.T, method, url string, body []byte) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, data
+}
+
+// pushBlob uploads blob through a POST, PATCH, PUT upload session
+func pushBlob(t *testing.T, server *httptest.Server, repo string, blob []byte) string {
+	t.Helper()
+	digest := calculateDigest(blob)
+
+	resp, _ := do(t, "POST", server.URL+"/v2/"+repo+"/blobs/uploads/", nil)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST upload: status %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	location := resp.Header.Get("Location")
+
+	resp, _ = do(t, "PATCH", server.URL+location, blob)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("PATCH upload: status %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	if got, want := resp.Header.Get("Range"), fmt.Sprintf("0-%d", len(blob)-1); got != want {
+		t.Fatalf("PATCH upload: Range %q, want %q", got, want)
+	}
+
+	resp, _ = do(t, "PUT", server.URL+location+"?digest="+digest, nil)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("PUT upload: status %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	if got := resp.Header.Get("Docker-Content-Digest"); got != digest {
+		t.Fatalf("PUT upload: Docker-Content-Digest %q, want %q", got, digest)
+	}
+	return digest
+}
+
+// pushManifest stores manifest under reference
+func pushManifest(t *testing.T, server *httptest.Server, repo, reference string, manifest []byte) string {
+	t.Helper()
+	resp, _ := do(t, "PUT", server.URL+"/v2/"+repo+"/manifests/"+reference, manifest)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("PUT manifest %s: status %d, want %d", reference, resp.StatusCode, http.StatusCreated)
+	}
+	return resp.Header.Get("Docker-Content-Digest")
+}
+
+// ociErrorCode returns the code of the first error in an OCI error body
+func ociErrorCode(body []byte) string {
+	var response struct {
+		Errors []struct {
+			Code string `json:"code"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &response) != nil || len(response.Errors) == 0 {
+		return ""
+	}
+	return response.Errors[0].Code
+}
+
+// TestConformance drives the distribution flows the registry supports over
+// HTTP and checks status codes, headers and error codes against the OCI
+// distribution spec. Add a case to cover a new endpoint.
+func TestConformance(t *testing.T) {
+	server := newTestServer(t)
+
+	const repo = "conformance/app"
+	const sigType = "application/vnd.example.signature"
+	layerDigest := pushBlob(t, server, repo, []byte("layer contents"))
+	manifest := []byte(fmt.Sprintf(`{"schemaVersion":2,"mediaType":%q,"layers":[{"digest":%q}]}`,
+		defaultManifestMediaType, layerDigest))
+	manifestDigest := pushManifest(t, server, repo, "v1", manifest)
+	signature := []byte(fmt.Sprintf(`{"schemaVersion":2,"artifactType":%q,"subject":{"digest":%q}}`,
+		sigType, manifestDigest))
+	signatureDigest := pushManifest(t, server, repo, "sig", signature)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       []byte
+		wantStatus int
+		wantHeader map[string]string
+		wantCode   string
+		check      func(t *testing.T, resp *http.Response, body []byte)
+	}{
+		{
+			name:       "base endpoint",
+			method:     "GET",
+			path:       "/v2/",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "catalog",
+			method:     "GET",
+			path:       "/v2/_catalog",
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Content-Type": "application/json"},
+		},
+		{
+			name:       "catalog page links to the next",
+			method:     "GET",
+			path:       "/v2/_catalog?n=1",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, resp *http.Response, body []byte) {
+				if resp.Header.Get("Link") == "" {
+					t.Error("missing Link header on a partial page")
+				}
+			},
+		},
+		{
+			name:       "manifest by tag",
+			method:     "GET",
+			path:       "/v2/" + repo + "/manifests/v1",
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{
+				"Content-Type":          defaultManifestMediaType,
+				"Docker-Content-Digest": manifestDigest,
+			},
+			check: func(t *testing.T, resp *http.Response, body []byte) {
+				if !bytes.Equal(body, manifest) {
+					t.Errorf("body = %s, want %s", body, manifest)
+				}
+			},
+		},
+		{
+			name:       "manifest by digest",
+			method:     "GET",
+			path:       "/v2/" + repo + "/manifests/" + manifestDigest,
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Docker-Content-Digest": manifestDigest},
+		},
+		{
+			name:       "manifest HEAD",
+			method:     "HEAD",
+			path:       "/v2/" + repo + "/manifests/v1",
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{
+				"Content-Length":        strconv.Itoa(len(manifest)),
+				"Docker-Content-Digest": manifestDigest,
+			},
+		},
+		{
+			name:       "manifest HEAD unknown",
+			method:     "HEAD",
+			path:       "/v2/" + repo + "/manifests/missing",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "manifest unknown",
+			method:     "GET",
+			path:       "/v2/" + repo + "/manifests/missing",
+			wantStatus: http.StatusNotFound,
+			wantCode:   "MANIFEST_UNKNOWN",
+		},
+		{
+			name:       "manifest digest mismatch",
+			method:     "PUT",
+			path:       "/v2/" + repo + "/manifests/" + layerDigest,
+			body:       manifest,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "DIGEST_INVALID",
+		},
+		{
+			name:       "manifest without repository name",
+			method:     "GET",
+			path:       "/v2/manifests/v1",
+			wantStatus: http.StatusNotFound,
+			wantCode:   "NAME_UNKNOWN",
+		},
+		{
+			name:       "blob",
+			method:     "GET",
+			path:       "/v2/" + repo + "/blobs/" + layerDigest,
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{
+				"Content-Length":        strconv.Itoa(len("layer contents")),
+				"Docker-Content-Digest": layerDigest,
+			},
+		},
+		{
+			name:       "blob HEAD",
+			method:     "HEAD",
+			path:       "/v2/" + repo + "/blobs/" + layerDigest,
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Docker-Content-Digest": layerDigest},
+		},
+		{
+			name:       "blob unknown",
+			method:     "GET",
+			path:       "/v2/" + repo + "/blobs/" + calculateDigest([]byte("missing")),
+			wantStatus: http.StatusNotFound,
+			wantCode:   "BLOB_UNKNOWN",
+		},
+		{
+			name:       "blob without repository name",
+			method:     "GET",
+			path:       "/v2/blobs/sha256:abc",
+			wantStatus: http.StatusNotFound,
+			wantCode:   "BLOB_UNKNOWN",
+		},
+		{
+			name:       "upload without repository name",
+			method:     "POST",
+			path:       "/v2/blobs/uploads/",
+			wantStatus: http.StatusNotFound,
+			wantCode:   "NAME_UNKNOWN",
+		},
+		{
+			name:       "upload session unknown",
+			method:     "PATCH",
+			path:       "/v2/" + repo + "/blobs/uploads/unknown",
+			body:       []byte("chunk"),
+			wantStatus: http.StatusNotFound,
+			wantCode:   "BLOB_UPLOAD_UNKNOWN",
+		},
+		{
+			name:       "referrers",
+			method:     "GET",
+			path:       "/v2/" + repo + "/referrers/" + manifestDigest,
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"Content-Type": ociImageIndexMediaType},
+			check: func(t *testing.T, resp *http.Response, body []byte) {
+				var index struct {
+					Manifests []referrerDescriptor `json:"manifests"`
+				}
+				if err := json.Unmarshal(body, &index); err != nil {
+					t.Fatal(err)
+				}
+				if len(index.Manifests) != 1 || index.Manifests[0].Digest != signatureDigest || index.Manifests[0].ArtifactType != sigType {
+					t.Errorf("manifests = %+v, want the signature %s", index.Manifests, signatureDigest)
+				}
+			},
+		},
+		{
+			name:       "referrers filtered by artifact type",
+			method:     "GET",
+			path:       "/v2/" + repo + "/referrers/" + manifestDigest + "?artifactType=application/vnd.example.sbom",
+			wantStatus: http.StatusOK,
+			wantHeader: map[string]string{"OCI-Filters-Applied": "artifactType"},
+			check: func(t *testing.T, resp *http.Response, body []byte) {
+				if bytes.Contains(body, []byte(signatureDigest)) {
+					t.Errorf("filtered index lists the signature: %s", body)
+				}
+			},
+		},
+		{
+			name:       "referrers of a tag",
+			method:     "GET",
+			path:       "/v2/" + repo + "/referrers/v1",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "DIGEST_INVALID",
+		},
+		{
+			name:       "referrers without repository name",
+			method:     "GET",
+			path:       "/v2/referrers/sha256:abc",
+			wantStatus: http.StatusNotFound,
+			wantCode:   "NAME_UNKNOWN",
+		},
+		{
+			name:       "annotations without repository name",
+			method:     "GET",
+			path:       "/v2/annotations/v1",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, body := do(t, tt.method, server.URL+tt.path, tt.body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (body %s)", resp.StatusCode, tt.wantStatus, body)
+			}
+			for header, want := range tt.wantHeader {
+				if got := resp.Header.Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
+			if tt.wantCode != "" {
+				if got := ociErrorCode(body); got != tt.wantCode {
+					t.Errorf("error code = %q, want %q (body %s)", got, tt.wantCode, body)
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, resp, body)
+			}
+		})
+	}
+}
+
+// TestConformanceDeleteManifest checks that a deleted tag is gone
+func TestConformanceDeleteManifest(t *testing.T) {
+	server := newTestServer(t)
+	pushManifest(t, server, "app", "v1", []byte(`{"schemaVersion":2}`))
+
+	if resp, _ := do(t, "DELETE", server.URL+"/v2/app/manifests/v1", nil); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("DELETE: status %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	resp, body := do(t, "GET", server.URL+"/v2/app/manifests/v1", nil)
+	if resp.StatusCode != http.StatusNotFound || ociErrorCode(body) != "MANIFEST_UNKNOWN" {
+		t.Errorf("GET after DELETE: status %d body %s, want 404 MANIFEST_UNKNOWN", resp.StatusCode, body)
+	}
+}
+
+// TestConformanceBearerAuth checks the 401 challenge and scope checks
+func TestConformanceBearerAuth(t *testing.T) {
+	server := newTestServer(t)
+	authMode = "bearer"
+	registerToken("reader", []string{scopePull})
+
+	resp, body := do(t, "GET", server.URL+"/v2/app/manifests/v1", nil)
+	if resp.StatusCode != http.StatusUnauthorized || resp.Header.Get("WWW-Authenticate") == "" {
+		t.Errorf("anonymous GET: status %d, WWW-Authenticate %q, want 401 with a challenge (body %s)",
+			resp.StatusCode, resp.Header.Get("WWW-Authenticate"), body)
+	}
+
+	req, _ := http.NewRequest("POST", server.URL+"/v2/app/blobs/uploads/", nil)
+	req.Header.Set("Authorization", "Bearer reader")
+	push, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	push.Body.Close()
+	if push.StatusCode != http.StatusUnauthorized {
+		t.Errorf("push with a pull token: status %d, want %d", push.StatusCode, http.StatusUnauthorized)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// gcResponse is the body of POST /admin/gc
+type gcResponse struct {
+	Result    int32  `json:"result"`
+	Message   string `json:"message"`
+	Reclaimed uint32 `json:"reclaimed"`
+	Remaining uint32 `json:"remaining"`
+}
+
+// runGC triggers a collection over HTTP
+func runGC(t *testing.T, url string) gcResponse {
+	t.Helper()
+	resp, body := do(t, "POST", url+"/admin/gc", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /admin/gc: status %d, want %d (body %s)", resp.StatusCode, http.StatusOK, body)
+	}
+	var gc gcResponse
+	if err := json.Unmarshal(body, &gc); err != nil {
+		t.Fatalf("POST /admin/gc: %v (body %s)", err, body)
+	}
+	return gc
+}
+
+// TestAdminGCReclaimsUnreferencedBlobs pushes blobs, deletes the manifest
+// referencing some of them, and checks that GC reclaims exactly those
+func TestAdminGCReclaimsUnreferencedBlobs(t *testing.T) {
+	server := newTestServer(t)
+
+	kept := pushBlob(t, server, "app", []byte("kept layer"))
+	dropped := pushBlob(t, server, "app", []byte("dropped layer"))
+	droppedConfig := pushBlob(t, server, "app", []byte("dropped config"))
+	pushBlob(t, server, "app", []byte("never referenced"))
+
+	pushManifest(t, server, "app", "v1", []byte(fmt.Sprintf(`{"schemaVersion":2,"layers":[{"digest":%q}]}`, kept)))
+	pushManifest(t, server, "app", "v2", []byte(fmt.Sprintf(`{"schemaVersion":2,"config":{"digest":%q},"layers":[{"digest":%q}]}`,
+		droppedConfig, dropped)))
+
+	if gc := runGC(t, server.URL); gc.Reclaimed != 1 || gc.Remaining != 3 {
+		t.Fatalf("GC with both manifests = %+v, want 1 reclaimed and 3 remaining", gc)
+	}
+
+	if resp, _ := do(t, "DELETE", server.URL+"/v2/app/manifests/v2", nil); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("DELETE v2: status %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	if gc := runGC(t, server.URL); gc.Reclaimed != 2 || gc.Remaining != 1 {
+		t.Fatalf("GC after deleting v2 = %+v, want 2 reclaimed and 1 remaining", gc)
+	}
+
+	if resp, _ := do(t, "GET", server.URL+"/v2/app/blobs/"+kept, nil); resp.StatusCode != http.StatusOK {
+		t.Errorf("referenced blob: status %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	for _, digest := range []string{dropped, droppedConfig} {
+		if resp, _ := do(t, "GET", server.URL+"/v2/app/blobs/"+digest, nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("reclaimed blob %s: status %d, want %d", digest, resp.StatusCode, http.StatusNotFound)
+		}
+	}
+
+	if gc := runGC(t, server.URL); gc.Reclaimed != 0 || gc.Remaining != 1 {
+		t.Errorf("second GC = %+v, want nothing reclaimed", gc)
+	}
+}
+
+// TestAdminGCSkipsUnparseableManifest checks that a manifest GC cannot read
+// stops the sweep instead of risking blobs it may reference
+func TestAdminGCSkipsUnparseableManifest(t *testing.T) {
+	server := newTestServer(t)
+	pushBlob(t, server, "app", []byte("maybe referenced"))
+	pushManifest(t, server, "app", "v1", []byte("not json"))
+
+	if gc := runGC(t, server.URL); gc.Reclaimed != 0 || gc.Remaining != 1 {
+		t.Errorf("GC = %+v, want the sweep skipped", gc)
+	}
+}
+
+// TestAdminGCRequiresDeleteScope checks /admin/gc is guarded in bearer mode
+func TestAdminGCRequiresDeleteScope(t *testing.T) {
+	server := newTestServer(t)
+	authMode = "bearer"
+	registerToken("reader", []string{scopePull})
+
+	if resp, _ := do(t, "POST", server.URL+"/admin/gc", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("anonymous GC: status %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	req, _ := http.NewRequest("POST", server.URL+"/admin/gc", nil)
+	req.Header.Set("Authorization", "Bearer reader")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GC with a pull token: status %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
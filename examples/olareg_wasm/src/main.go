@@ -1,12 +1,20 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"io"
+	"math/rand/v2"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,6 +29,8 @@ type Component struct {
 	Manifest  []byte
 	Signature []byte
 	Timestamp time.Time
+	// Annotations are the manifest's top-level OCI annotations
+	Annotations map[string]string
 }
 
 // Blob represents stored blob data
@@ -40,7 +50,9 @@ type ErrorSimulation struct {
 type LatencySimulation struct {
 	Operation string
 	LatencyMs uint32
-	Enabled   bool
+	// JitterMs adds a random extra delay of up to this many milliseconds
+	JitterMs uint32
+	Enabled  bool
 }
 
 var (
@@ -52,7 +64,11 @@ var (
 	enablePush      bool
 	enableDelete    bool
 
-	// In-memory storage
+	// In-memory storage. Handlers run concurrently with each other and with
+	// the GC scheduler, so components, blobs and publicKeys are only touched
+	// under storeMu. Stored *Component values are never modified in place;
+	// updates store a new one, so a pointer read under the lock stays valid.
+	storeMu    sync.RWMutex
 	components map[string]*Component = make(map[string]*Component)
 	blobs      map[string]*Blob      = make(map[string]*Blob)
 
@@ -61,15 +77,34 @@ var (
 	errorSimulations   []ErrorSimulation
 	latencySimulations []LatencySimulation
 
-	// Metrics
-	uploadCount   uint32
-	downloadCount uint32
-	deleteCount   uint32
+	// Ed25519 public keys signed uploads are verified against, by name:tag
+	publicKeys map[string]ed25519.PublicKey = make(map[string]ed25519.PublicKey)
+
+	// Metrics, updated from concurrent handlers so always accessed atomically
+	uploadCount   atomic.Uint32
+	downloadCount atomic.Uint32
+	deleteCount   atomic.Uint32
+
+	// Admin endpoints
+	enableAdminGC bool
 )
 
 // Helper functions
-func componentKey(name, tag string) string {
-	return name + ":" + tag
+
+// componentKey is where name at reference is stored. Tags are kept under
+// name:tag; manifests pushed by digest are kept under name@digest, so they
+// stay reachable by digest without appearing as a tag.
+func componentKey(name, reference string) string {
+	if isDigestReference(reference) {
+		return name + "@" + reference
+	}
+	return name + ":" + reference
+}
+
+// isDigestReference reports whether a manifest reference is a digest rather
+// than a tag
+func isDigestReference(reference string) bool {
+	return strings.HasPrefix(reference, "sha256:")
 }
 
 func calculateDigest(data []byte) string {
@@ -86,14 +121,21 @@ func checkErrorSimulation(operation string) (bool, string) {
 	return false, ""
 }
 
+// applyLatencySimulation sleeps for the combined delay of every enabled
+// simulation matching operation, each randomized by its jitter
 func applyLatencySimulation(operation string) {
+	var delay time.Duration
 	for _, sim := range latencySimulations {
 		if sim.Enabled && sim.Operation == operation {
-			// In a real implementation, this would add actual delay
-			// For testing, we just track that latency would be applied
-			break
+			delay += time.Duration(sim.LatencyMs) * time.Millisecond
+			if sim.JitterMs > 0 {
+				delay += time.Duration(rand.Int64N(int64(sim.JitterMs)+1)) * time.Millisecond
+			}
 		}
 	}
+	if delay > 0 {
+		time.Sleep(delay)
+	}
 }
 
 // Basic server lifecycle exports
@@ -103,6 +145,21 @@ func startServer(addr, dataDir string, readOnlyFlag, enablePushFlag, enableDelet
 		return 0, "Registry is already running"
 	}
 
+	// Initialize storage, picking up where a previous run left off
+	storeMu.Lock()
+	components = make(map[string]*Component)
+	blobs = make(map[string]*Blob)
+	storeMu.Unlock()
+	restored := ""
+	if dataDir != "" && hasState(dataDir) {
+		if err := loadState(dataDir); err != nil {
+			return 0, "Failed to load state from " + dataDir + ": " + err.Error()
+		}
+		storeMu.RLock()
+		restored = fmt.Sprintf(" (restored %d components, %d blobs)", len(components), len(blobs))
+		storeMu.RUnlock()
+	}
+
 	registryAddr = addr
 	registryDataDir = dataDir
 	registryRunning = true
@@ -110,11 +167,7 @@ func startServer(addr, dataDir string, readOnlyFlag, enablePushFlag, enableDelet
 	enablePush = enablePushFlag
 	enableDelete = enableDeleteFlag
 
-	// Initialize storage
-	components = make(map[string]*Component)
-	blobs = make(map[string]*Blob)
-
-	return 1, "Registry started on " + addr + ", data dir: " + dataDir
+	return 1, "Registry started on " + addr + ", data dir: " + dataDir + restored
 }
 
 func stopServer() (int32, string) {
@@ -122,6 +175,13 @@ func stopServer() (int32, string) {
 		return 0, "Registry is not running"
 	}
 
+	// Keep running if the state cannot be saved, so nothing is lost
+	if registryDataDir != "" {
+		if err := saveState(registryDataDir); err != nil {
+			return 0, "Failed to save state to " + registryDataDir + ": " + err.Error()
+		}
+	}
+
 	registryRunning = false
 	registryAddr = ""
 	registryDataDir = ""
@@ -158,17 +218,86 @@ func uploadComponent(name, tag string, componentData []byte) (int32, string) {
 	applyLatencySimulation("upload")
 
 	key := componentKey(name, tag)
+	storeMu.Lock()
 	components[key] = &Component{
 		Name:      name,
 		Tag:       tag,
 		Data:      componentData,
 		Timestamp: time.Now(),
 	}
+	storeMu.Unlock()
 
-	uploadCount++
+	uploadCount.Add(1)
 	return 1, "Component uploaded successfully"
 }
 
+// uploadComponentAtomic stages a component under a temporary key, validates
+// its WASM magic and its signature against the key registered for name:tag,
+// and only then swaps it into place under name:tag. On validation failure
+// the existing component is left untouched. The third result reports
+// whether a swap occurred.
+func uploadComponentAtomic(name, tag string, componentData, signature []byte) (int32, string, bool) {
+	if !registryRunning {
+		return 0, "Registry is not running", false
+	}
+
+	if readOnly || !enablePush {
+		return 0, "Registry is read-only or push disabled", false
+	}
+
+	if hasError, errorType := checkErrorSimulation("upload"); hasError {
+		return 0, "Simulated error: " + errorType, false
+	}
+
+	applyLatencySimulation("upload")
+
+	key := componentKey(name, tag)
+	stagingKey := key + "#staging"
+	storeMu.Lock()
+	defer storeMu.Unlock()
+	components[stagingKey] = &Component{
+		Name:      name,
+		Tag:       tag,
+		Data:      componentData,
+		Signature: signature,
+		Timestamp: time.Now(),
+	}
+
+	staged := components[stagingKey]
+	if !hasWasmMagic(staged.Data) {
+		delete(components, stagingKey)
+		return 0, "Staged component is not a WASM binary", false
+	}
+
+	publicKey, hasKey := publicKeys[key]
+	if !hasKey {
+		delete(components, stagingKey)
+		return 0, "No public key registered for " + key, false
+	}
+	if !validateSignature(staged.Data, staged.Signature, publicKey) {
+		delete(components, stagingKey)
+		return 0, "Staged component signature is invalid", false
+	}
+
+	// Preserve a manifest uploaded separately for the previous version, and
+	// the annotations parsed from it
+	if previous, exists := components[key]; exists {
+		staged.Manifest = previous.Manifest
+		staged.Annotations = previous.Annotations
+	}
+
+	components[key] = staged
+	delete(components, stagingKey)
+
+	uploadCount.Add(1)
+	return 1, "Component verified and swapped into place", true
+}
+
+// hasWasmMagic reports whether data starts with the WASM "\0asm" preamble
+func hasWasmMagic(data []byte) bool {
+	return len(data) >= 8 && string(data[:4]) == "\x00asm"
+}
+
 func downloadComponent(name, tag string) (int32, string, []byte) {
 	if !registryRunning {
 		return 0, "Registry is not running", nil
@@ -181,12 +310,14 @@ func downloadComponent(name, tag string) (int32, string, []byte) {
 	applyLatencySimulation("download")
 
 	key := componentKey(name, tag)
+	storeMu.RLock()
 	component, exists := components[key]
+	storeMu.RUnlock()
 	if !exists {
 		return 0, "Component not found", nil
 	}
 
-	downloadCount++
+	downloadCount.Add(1)
 	return 1, "Component downloaded successfully", component.Data
 }
 
@@ -195,20 +326,67 @@ func listComponents() (int32, string, []string) {
 		return 0, "Registry is not running", nil
 	}
 
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
 	var componentList []string
-	for key := range components {
-		componentList = append(componentList, key)
+	for key, component := range components {
+		if !isDigestReference(component.Tag) {
+			componentList = append(componentList, key)
+		}
 	}
 
 	return 1, "Components listed successfully", componentList
 }
 
+// listComponentsPaged returns up to limit component names in lexical order,
+// starting after start. A start that no longer exists, e.g. a deleted
+// component, resumes from the next name after it. next is the last name
+// returned when more remain, to pass as the following start, and empty on
+// the final page. A negative limit returns every remaining name.
+func listComponentsPaged(start string, limit int) (names []string, next string) {
+	if !registryRunning {
+		return nil, ""
+	}
+
+	storeMu.RLock()
+	keys := make([]string, 0, len(components))
+	for key, component := range components {
+		if !isDigestReference(component.Tag) {
+			keys = append(keys, key)
+		}
+	}
+	storeMu.RUnlock()
+	sort.Strings(keys)
+
+	return pageNames(keys, start, limit)
+}
+
+// pageNames slices the sorted names after start to at most limit entries
+func pageNames(sorted []string, start string, limit int) (page []string, next string) {
+	from := 0
+	if start != "" {
+		from = sort.Search(len(sorted), func(i int) bool { return sorted[i] > start })
+	}
+	page = sorted[from:]
+
+	if limit >= 0 && len(page) > limit {
+		page = page[:limit]
+		if limit > 0 {
+			next = page[limit-1]
+		}
+	}
+	return page, next
+}
+
 func componentExists(name, tag string) bool {
 	if !registryRunning {
 		return false
 	}
 
 	key := componentKey(name, tag)
+	storeMu.RLock()
+	defer storeMu.RUnlock()
 	_, exists := components[key]
 	return exists
 }
@@ -223,12 +401,14 @@ func deleteComponent(name, tag string) (int32, string) {
 	}
 
 	key := componentKey(name, tag)
+	storeMu.Lock()
+	defer storeMu.Unlock()
 	if _, exists := components[key]; !exists {
 		return 0, "Component not found"
 	}
 
 	delete(components, key)
-	deleteCount++
+	deleteCount.Add(1)
 	return 1, "Component deleted successfully"
 }
 
@@ -243,30 +423,97 @@ func uploadManifest(name, tag string, manifestData []byte) (int32, string) {
 		return 0, "Registry is read-only or push disabled"
 	}
 
+	annotations := parseManifestAnnotations(manifestData)
+
 	key := componentKey(name, tag)
+	storeMu.Lock()
+	defer storeMu.Unlock()
 	if component, exists := components[key]; exists {
-		component.Manifest = manifestData
+		updated := *component
+		updated.Manifest = manifestData
+		updated.Annotations = annotations
+		components[key] = &updated
 		return 1, "Manifest uploaded successfully"
 	}
 
 	// Create component with manifest only
 	components[key] = &Component{
-		Name:      name,
-		Tag:       tag,
-		Manifest:  manifestData,
-		Timestamp: time.Now(),
+		Name:        name,
+		Tag:         tag,
+		Manifest:    manifestData,
+		Timestamp:   time.Now(),
+		Annotations: annotations,
 	}
 
 	return 1, "Manifest uploaded successfully"
 }
 
-func downloadManifest(name, tag string) (int32, string, []byte) {
+// parseManifestAnnotations extracts the top-level annotations map of an OCI
+// manifest. Manifests that are not JSON or carry no annotations yield nil.
+func parseManifestAnnotations(manifestData []byte) map[string]string {
+	var manifest struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil
+	}
+	return manifest.Annotations
+}
+
+// getComponentAnnotations returns a component's manifest annotations as
+// key/value pairs sorted by key
+func getComponentAnnotations(name, tag string) (int32, string, [][2]string) {
 	if !registryRunning {
 		return 0, "Registry is not running", nil
 	}
 
-	key := componentKey(name, tag)
-	component, exists := components[key]
+	storeMu.RLock()
+	component, exists := components[componentKey(name, tag)]
+	storeMu.RUnlock()
+	if !exists {
+		return 0, "Component not found", nil
+	}
+
+	keys := make([]string, 0, len(component.Annotations))
+	for key := range component.Annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	annotations := make([][2]string, 0, len(keys))
+	for _, key := range keys {
+		annotations = append(annotations, [2]string{key, component.Annotations[key]})
+	}
+
+	return 1, "Annotations retrieved successfully", annotations
+}
+
+// matchesAnnotations reports whether a component carries every filter. Each
+// filter is "key=value", or a bare "key" that only has to be present.
+func matchesAnnotations(component *Component, filters []string) bool {
+	for _, filter := range filters {
+		key, value, hasValue := strings.Cut(filter, "=")
+		actual, exists := component.Annotations[key]
+		if !exists || (hasValue && actual != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// downloadManifest returns the manifest stored for name at reference, which
+// is either a tag or a sha256: digest of the manifest itself
+func downloadManifest(name, reference string) (int32, string, []byte) {
+	if !registryRunning {
+		return 0, "Registry is not running", nil
+	}
+
+	storeMu.RLock()
+	component, exists := components[componentKey(name, reference)]
+	if !exists && isDigestReference(reference) {
+		component, exists = manifestByDigest(name, reference)
+	}
+	storeMu.RUnlock()
 	if !exists {
 		return 0, "Component not found", nil
 	}
@@ -278,6 +525,24 @@ func downloadManifest(name, tag string) (int32, string, []byte) {
 	return 1, "Manifest downloaded successfully", component.Manifest
 }
 
+// findManifestByDigest scans name's stored manifests for one whose digest is
+// digest. Several tags may share a manifest; any of them will do.
+func findManifestByDigest(name, digest string) (*Component, bool) {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+	return manifestByDigest(name, digest)
+}
+
+// manifestByDigest is findManifestByDigest for callers holding storeMu
+func manifestByDigest(name, digest string) (*Component, bool) {
+	for _, component := range components {
+		if component.Name == name && len(component.Manifest) > 0 && calculateDigest(component.Manifest) == digest {
+			return component, true
+		}
+	}
+	return nil, false
+}
+
 func uploadBlob(digest string, blobData []byte) (int32, string) {
 	if !registryRunning {
 		return 0, "Registry is not running"
@@ -293,10 +558,12 @@ func uploadBlob(digest string, blobData []byte) (int32, string) {
 		return 0, "Digest mismatch"
 	}
 
+	storeMu.Lock()
 	blobs[digest] = &Blob{
 		Digest: digest,
 		Data:   blobData,
 	}
+	storeMu.Unlock()
 
 	return 1, "Blob uploaded successfully"
 }
@@ -306,7 +573,9 @@ func downloadBlob(digest string) (int32, string, []byte) {
 		return 0, "Registry is not running", nil
 	}
 
+	storeMu.RLock()
 	blob, exists := blobs[digest]
+	storeMu.RUnlock()
 	if !exists {
 		return 0, "Blob not found", nil
 	}
@@ -319,10 +588,100 @@ func blobExists(digest string) bool {
 		return false
 	}
 
+	storeMu.RLock()
+	defer storeMu.RUnlock()
 	_, exists := blobs[digest]
 	return exists
 }
 
+// garbageCollect deletes blobs that no component references, either through
+// its manifest or as its own data, and returns the number reclaimed. A
+// manifest that is not valid JSON might reference anything, so it stops the
+// sweep rather than risk deleting a blob that is still needed.
+func garbageCollect() (int32, string, uint32) {
+	if !registryRunning {
+		return 0, "Registry is not running", 0
+	}
+
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
+	// Mark
+	referenced := make(map[string]bool)
+	for key, component := range components {
+		if len(component.Data) > 0 {
+			referenced[calculateDigest(component.Data)] = true
+		}
+		if len(component.Manifest) == 0 {
+			continue
+		}
+
+		digests, err := manifestDigests(component.Manifest)
+		if err != nil {
+			return 1, fmt.Sprintf("Skipped sweep: manifest of %s is unparseable and may reference any blob", key), 0
+		}
+		for _, digest := range digests {
+			referenced[digest] = true
+		}
+	}
+
+	// Sweep
+	var reclaimed uint32
+	for digest := range blobs {
+		if !referenced[digest] {
+			delete(blobs, digest)
+			reclaimed++
+		}
+	}
+
+	return 1, fmt.Sprintf("Reclaimed %d blobs", reclaimed), reclaimed
+}
+
+// manifestDigests returns every "digest" value in a manifest, wherever it
+// appears: the config, layers, the manifests of an index, the subject, or
+// descriptors this registry does not know about yet
+func manifestDigests(manifest []byte) ([]string, error) {
+	var document interface{}
+	if err := json.Unmarshal(manifest, &document); err != nil {
+		return nil, err
+	}
+
+	var digests []string
+	var walk func(node interface{})
+	walk = func(node interface{}) {
+		switch node := node.(type) {
+		case map[string]interface{}:
+			for key, value := range node {
+				if digest, ok := value.(string); ok && key == "digest" {
+					digests = append(digests, digest)
+					continue
+				}
+				walk(value)
+			}
+		case []interface{}:
+			for _, value := range node {
+				walk(value)
+			}
+		}
+	}
+	walk(document)
+
+	return digests, nil
+}
+
+// startGCScheduler runs garbageCollect every interval in the background
+func startGCScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, msg, reclaimed := garbageCollect(); reclaimed > 0 {
+				fmt.Printf("🧹 GC: %s\n", msg)
+			}
+		}
+	}()
+}
+
 // Test lifecycle management exports
 
 func createTestData(componentSpecs []string) (int32, string) {
@@ -330,6 +689,9 @@ func createTestData(componentSpecs []string) (int32, string) {
 		return 0, "Registry is not running"
 	}
 
+	storeMu.Lock()
+	defer storeMu.Unlock()
+
 	for _, spec := range componentSpecs {
 		parts := strings.Split(spec, ":")
 		if len(parts) != 2 {
@@ -357,11 +719,18 @@ func resetRegistry() (int32, string) {
 		return 0, "Registry is not running"
 	}
 
+	storeMu.Lock()
 	components = make(map[string]*Component)
 	blobs = make(map[string]*Blob)
-	uploadCount = 0
-	downloadCount = 0
-	deleteCount = 0
+	publicKeys = make(map[string]ed25519.PublicKey)
+	storeMu.Unlock()
+	uploadsMu.Lock()
+	uploads = make(map[string]*UploadSession)
+	uploadsSweepAt = minUploadSweep
+	uploadsMu.Unlock()
+	uploadCount.Store(0)
+	downloadCount.Store(0)
+	deleteCount.Store(0)
 
 	// Clear simulations
 	errorSimulations = nil
@@ -375,8 +744,12 @@ func getMetrics() (int32, string) {
 		return 0, "Registry is not running"
 	}
 
-	metrics := fmt.Sprintf("uploads:%d,downloads:%d,deletes:%d,components:%d,blobs:%d",
-		uploadCount, downloadCount, deleteCount, len(components), len(blobs))
+	storeMu.RLock()
+	componentCount, blobCount := len(components), len(blobs)
+	storeMu.RUnlock()
+
+	metrics := fmt.Sprintf("uploads:%d,downloads:%d,deletes:%d,components:%d,blobs:%d,flush_errors:%d",
+		uploadCount.Load(), downloadCount.Load(), deleteCount.Load(), componentCount, blobCount, flushErrorCount.Load())
 
 	return 1, metrics
 }
@@ -385,6 +758,8 @@ func getComponentCount() uint32 {
 	if !registryRunning {
 		return 0
 	}
+	storeMu.RLock()
+	defer storeMu.RUnlock()
 	return uint32(len(components))
 }
 
@@ -392,6 +767,8 @@ func getBlobCount() uint32 {
 	if !registryRunning {
 		return 0
 	}
+	storeMu.RLock()
+	defer storeMu.RUnlock()
 	return uint32(len(blobs))
 }
 
@@ -411,7 +788,7 @@ func simulateFailure(operation, errorType string) (int32, string) {
 	return 1, "Error simulation configured for " + operation
 }
 
-func setLatency(operation string, latencyMs uint32) (int32, string) {
+func setLatency(operation string, latencyMs, jitterMs uint32) (int32, string) {
 	if !registryRunning {
 		return 0, "Registry is not running"
 	}
@@ -419,6 +796,7 @@ func setLatency(operation string, latencyMs uint32) (int32, string) {
 	latencySimulations = append(latencySimulations, LatencySimulation{
 		Operation: operation,
 		LatencyMs: latencyMs,
+		JitterMs:  jitterMs,
 		Enabled:   true,
 	})
 
@@ -447,13 +825,36 @@ func setAuthMode(mode string) (int32, string) {
 	return 1, "Auth mode set to " + mode
 }
 
-func validateSignature(componentData, signature []byte) bool {
+// setComponentPublicKey registers the ed25519 key that signed uploads of
+// name:tag must verify against. The key may be set before the component is
+// first uploaded.
+func setComponentPublicKey(name, tag string, pubKey []byte) (int32, string) {
+	if !registryRunning {
+		return 0, "Registry is not running"
+	}
+
+	if len(pubKey) != ed25519.PublicKeySize {
+		return 0, fmt.Sprintf("Invalid public key: expected %d bytes, got %d", ed25519.PublicKeySize, len(pubKey))
+	}
+
+	storeMu.Lock()
+	publicKeys[componentKey(name, tag)] = ed25519.PublicKey(pubKey)
+	storeMu.Unlock()
+	return 1, "Public key registered for " + componentKey(name, tag)
+}
+
+// validateSignature verifies a detached ed25519 signature over componentData.
+// Keys and signatures of the wrong length are rejected rather than passed to
+// ed25519.Verify, which panics on a malformed key.
+func validateSignature(componentData, signature, publicKey []byte) bool {
 	if !registryRunning {
 		return false
 	}
 
-	// Basic signature validation - in real implementation would use crypto
-	return len(signature) > 0 && len(componentData) > 0
+	if len(publicKey) != ed25519.PublicKeySize || len(signature) != ed25519.SignatureSize {
+		return false
+	}
+	return ed25519.Verify(publicKey, componentData, signature)
 }
 
 func getComponentSignature(name, tag string) (int32, string, []byte) {
@@ -462,7 +863,9 @@ func getComponentSignature(name, tag string) (int32, string, []byte) {
 	}
 
 	key := componentKey(name, tag)
+	storeMu.RLock()
 	component, exists := components[key]
+	storeMu.RUnlock()
 	if !exists {
 		return 0, "Component not found", nil
 	}
@@ -474,25 +877,80 @@ func getComponentSignature(name, tag string) (int32, string, []byte) {
 	return 1, "Signature retrieved successfully", component.Signature
 }
 
+// parseServerArgs applies the server flags and returns the listen address,
+// the only positional argument, and the --snapshot path. Unknown flags are
+// rejected rather than taken for the address.
+func parseServerArgs(args []string) (addr, snapshotPath string, err error) {
+	addr = ":5001"
+	for _, arg := range args {
+		switch {
+		case arg == "--enable-admin-gc":
+			enableAdminGC = true
+		case arg == "--access-log":
+			accessLogEnabled = true
+		case strings.HasPrefix(arg, "--snapshot="):
+			snapshotPath = strings.TrimPrefix(arg, "--snapshot=")
+		case strings.HasPrefix(arg, "--bearer-token="):
+			// Any token switches the registry to bearer auth
+			token, scopes, err := parseTokenFlag(strings.TrimPrefix(arg, "--bearer-token="))
+			if err != nil {
+				return "", "", fmt.Errorf("Invalid --bearer-token: %v", err)
+			}
+			registerToken(token, scopes)
+			authMode = "bearer"
+		case strings.HasPrefix(arg, "-"):
+			return "", "", fmt.Errorf("Unknown flag %s (expected --enable-admin-gc, --access-log, --snapshot=PATH or --bearer-token=TOKEN=SCOPES)", arg)
+		default:
+			addr = arg
+		}
+	}
+	return addr, snapshotPath, nil
+}
+
 func main() {
-	// Parse command line arguments
-	addr := ":5001"
-	if len(os.Args) > 1 {
-		addr = os.Args[1]
+	if runSnapshotCommand(os.Args[1:]) {
+		return
+	}
+
+	addr, snapshotPath, err := parseServerArgs(os.Args[1:])
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
 	}
 
 	// Initialize registry
+	configureBuffering()
 	initRegistry()
 
+	// Seed a known state, e.g. a CI fixture written by export-snapshot
+	if snapshotPath != "" {
+		if err := importSnapshot(snapshotPath); err != nil {
+			fmt.Printf("❌ Failed to load snapshot: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("📥 Loaded snapshot %s (%d components, %d blobs)\n", snapshotPath, len(components), len(blobs))
+	}
+
 	// Setup HTTP routes
 	setupRoutes()
 
+	// Optional periodic GC, e.g. OLAREG_GC_INTERVAL=5m
+	if value := os.Getenv("OLAREG_GC_INTERVAL"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			fmt.Printf("❌ Invalid OLAREG_GC_INTERVAL %q: expected a positive duration\n", value)
+			os.Exit(1)
+		}
+		startGCScheduler(interval)
+		fmt.Printf("🧹 Automatic GC every %s\n", interval)
+	}
+
 	fmt.Printf("🚀 Olareg WASM Registry starting on %s\n", addr)
 	fmt.Println("📦 In-memory OCI registry for testing and development")
 	fmt.Println("🔗 Ready to accept OCI registry API calls")
 
 	// Start HTTP server
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	if err := http.ListenAndServe(addr, withAccessLog(withBuffering(http.DefaultServeMux))); err != nil {
 		fmt.Printf("❌ Server failed to start: %v\n", err)
 		os.Exit(1)
 	}
@@ -506,7 +964,7 @@ func initRegistry() {
 	// Set registry as running
 	registryRunning = true
 	registryAddr = ":5001"
-	registryDataDir = "/tmp"
+	registryDataDir = "" // In-memory only; startServer with a data dir persists state
 	readOnly = false
 	enablePush = true
 	enableDelete = true
@@ -523,9 +981,38 @@ func setupRoutes() {
 	http.HandleFunc("/health", handleHealth)
 	http.HandleFunc("/metrics", handleMetrics)
 
-	// Test/debug endpoints
-	http.HandleFunc("/debug/components", handleDebugComponents)
-	http.HandleFunc("/debug/reset", handleDebugReset)
+	// Test/debug endpoints. Reads stay open, except the snapshot, which
+	// holds every blob; anything that changes state needs a token in
+	// bearer mode like the /v2/ API does.
+	http.HandleFunc("/debug/components", requireAuthFor(map[string]string{"POST": scopePush}, handleDebugComponents))
+	http.HandleFunc("/debug/reset", requireAuthFor(map[string]string{"POST": scopeDelete}, handleDebugReset))
+	http.HandleFunc("/debug/snapshot", requireAuthFor(map[string]string{"GET": scopePull, "PUT": scopeDelete}, handleDebugSnapshot))
+
+	// Admin endpoints, opt-in via --enable-admin-gc
+	if enableAdminGC {
+		http.HandleFunc("/admin/gc", requireAuth(scopeDelete, handleAdminGC))
+	}
+}
+
+// requireAuth rejects requests not granted scope unless auth is disabled
+func requireAuth(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !authorize(w, r, "", scope) {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requireAuthFor rejects requests not granted the scope their method needs.
+// Methods without an entry in scopes pass through unchecked.
+func requireAuthFor(scopes map[string]string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if scope, ok := scopes[r.Method]; ok && !authorize(w, r, "", scope) {
+			return
+		}
+		next(w, r)
+	}
 }
 
 func printUsage() {
@@ -544,6 +1031,8 @@ func printUsage() {
 	fmt.Println("  create-test-data <component1:tag1,component2:tag2,...>")
 	fmt.Println("  reset-registry")
 	fmt.Println("  get-metrics")
+	fmt.Println("  export-snapshot <path> [component:tag,...]")
+	fmt.Println("  import-snapshot <path>")
 }
 
 // CLI wrapper functions that call the original implementations
@@ -600,16 +1089,135 @@ func handleV2Root(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`{"message": "Olareg WASM Registry"}`))
 		return
 	}
+	if strings.Contains(r.URL.Path, "/annotations/") {
+		handleAnnotations(w, r)
+		return
+	}
+	if strings.Contains(r.URL.Path, "/referrers/") {
+		handleReferrers(w, r)
+		return
+	}
+	if strings.Contains(r.URL.Path, "/blobs/uploads/") {
+		handleBlobUpload(w, r)
+		return
+	}
+	if strings.Contains(r.URL.Path, "/blobs/") {
+		handleBlob(w, r)
+		return
+	}
+	if strings.Contains(r.URL.Path, "/manifests/") {
+		handleManifest(w, r)
+		return
+	}
 	http.NotFound(w, r)
 }
 
+// splitRepoPath splits a /v2/<name>/<segment>/<rest> request path at the
+// last segment, which must be preceded by a repository name. ok is false
+// for paths such as /v2/<segment>/<rest> that lack the name.
+func splitRepoPath(path, segment string) (name, rest string, ok bool) {
+	path = strings.TrimPrefix(path, "/v2/")
+	sep := strings.LastIndex(path, "/"+segment+"/")
+	if sep <= 0 {
+		return "", "", false
+	}
+	return path[:sep], path[sep+len(segment)+2:], true
+}
+
+// handleAnnotations serves GET /v2/<name>/annotations/<tag> with the stored
+// manifest annotations, so clients need not fetch the whole manifest
+func handleAnnotations(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, tag, ok := splitRepoPath(r.URL.Path, "annotations")
+	if !ok || tag == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if !authorize(w, r, name, scopePull) {
+		return
+	}
+
+	result, _, pairs := getComponentAnnotations(name, tag)
+	if result == 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	annotations := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		annotations[pair[0]] = pair[1]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"name":        name,
+		"tag":         tag,
+		"annotations": annotations,
+	})
+}
+
 func handleCatalog(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "GET" {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	_, _, componentList := listComponents()
+	if !authorize(w, r, "", scopePull) {
+		return
+	}
+
+	// ?annotation=key=value (repeatable) keeps only matching components
+	filters := r.URL.Query()["annotation"]
+
+	if r.URL.Query().Get("format") == "ndjson" {
+		handleCatalogStream(w, filters)
+		return
+	}
+
+	// ?n=<count>&last=<name> pages through the catalog as in the OCI
+	// distribution spec; without n every matching name is returned
+	limit := -1
+	if value := r.URL.Query().Get("n"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			http.Error(w, "Invalid n: expected a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	last := r.URL.Query().Get("last")
+
+	var componentList []string
+	var next string
+	if len(filters) == 0 {
+		componentList, next = listComponentsPaged(last, limit)
+	} else if registryRunning {
+		storeMu.RLock()
+		var matching []string
+		for key, component := range components {
+			if !isDigestReference(component.Tag) && matchesAnnotations(component, filters) {
+				matching = append(matching, key)
+			}
+		}
+		storeMu.RUnlock()
+		sort.Strings(matching)
+		componentList, next = pageNames(matching, last, limit)
+	}
+	if componentList == nil {
+		componentList = []string{}
+	}
+
+	if next != "" {
+		query := url.Values{"n": {strconv.Itoa(limit)}, "last": {next}}
+		if len(filters) > 0 {
+			query["annotation"] = filters
+		}
+		w.Header().Set("Link", fmt.Sprintf(`</v2/_catalog?%s>; rel="next"`, query.Encode()))
+	}
 
 	catalog := struct {
 		Repositories []string `json:"repositories"`
@@ -621,24 +1229,204 @@ func handleCatalog(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(catalog)
 }
 
+// catalogStreamFlushEvery is how many repository lines are written between flushes
+const catalogStreamFlushEvery = 100
+
+// handleCatalogStream serves GET /v2/_catalog?format=ndjson, writing one JSON
+// string per line so large catalogs can be consumed incrementally
+func handleCatalogStream(w http.ResponseWriter, filters []string) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	if !registryRunning {
+		return
+	}
+
+	// Collect the names first, so a slow client does not hold storeMu
+	storeMu.RLock()
+	var matching []string
+	for key, component := range components {
+		if !isDigestReference(component.Tag) && matchesAnnotations(component, filters) {
+			matching = append(matching, key)
+		}
+	}
+	storeMu.RUnlock()
+
+	flusher, canFlush := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	written := 0
+	for _, key := range matching {
+		if err := encoder.Encode(key); err != nil {
+			return // Client went away
+		}
+		written++
+		if canFlush && written%catalogStreamFlushEvery == 0 {
+			flusher.Flush()
+		}
+	}
+
+	if canFlush {
+		flusher.Flush()
+	}
+}
+
+// defaultManifestMediaType is served for manifests that do not declare one
+const defaultManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+// handleManifest serves GET, HEAD, PUT and DELETE
+// /v2/<name>/manifests/<reference>, where reference is a tag or a sha256:
+// digest. DELETE only accepts tags.
 func handleManifest(w http.ResponseWriter, r *http.Request) {
-	// Parse URL path to extract name and reference (tag/digest)
-	// Format: /v2/<name>/manifests/<reference>
+	name, reference, ok := splitRepoPath(r.URL.Path, "manifests")
+	if !ok {
+		writeOCIError(w, http.StatusNotFound, "NAME_UNKNOWN", "repository name missing", r.URL.Path)
+		return
+	}
+	if reference == "" {
+		writeOCIError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", "manifest unknown", r.URL.Path)
+		return
+	}
+
+	scope := scopePull
+	switch r.Method {
+	case "PUT":
+		scope = scopePush
+	case "DELETE":
+		scope = scopeDelete
+	}
+	if !authorize(w, r, name, scope) {
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		result, _, manifest := downloadManifest(name, reference)
+		if result == 0 {
+			writeOCIError(w, http.StatusNotFound, "MANIFEST_UNKNOWN", "manifest unknown", name+":"+reference)
+			return
+		}
+
+		w.Header().Set("Content-Type", manifestMediaType(manifest))
+		w.Header().Set("Docker-Content-Digest", calculateDigest(manifest))
+		w.Write(manifest)
+
+	case "HEAD":
+		exists := componentExists(name, reference)
+		if !exists && isDigestReference(reference) {
+			_, exists = findManifestByDigest(name, reference)
+		}
+		var manifest []byte
+		if exists {
+			_, _, manifest = downloadManifest(name, reference)
+		}
+		if len(manifest) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", manifestMediaType(manifest))
+		w.Header().Set("Content-Length", strconv.Itoa(len(manifest)))
+		w.Header().Set("Docker-Content-Digest", calculateDigest(manifest))
+		w.WriteHeader(http.StatusOK)
+
+	case "PUT":
+		manifest, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeOCIError(w, http.StatusBadRequest, "MANIFEST_INVALID", "failed to read manifest", err.Error())
+			return
+		}
+
+		digest := calculateDigest(manifest)
+		if isDigestReference(reference) && reference != digest {
+			writeOCIError(w, http.StatusBadRequest, "DIGEST_INVALID", "manifest digest does not match reference", digest)
+			return
+		}
+
+		if result, msg := uploadManifest(name, reference, manifest); result == 0 {
+			writeOCIError(w, http.StatusForbidden, "DENIED", msg, name+":"+reference)
+			return
+		}
 
-	// This is a simplified implementation
-	// Full OCI registry would handle complex manifest operations
-	w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
-	w.Write([]byte(`{"mediaType": "application/vnd.docker.distribution.manifest.v2+json"}`))
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Header().Set("Location", "/v2/"+name+"/manifests/"+digest)
+		w.WriteHeader(http.StatusCreated)
+
+	case "DELETE":
+		if result, msg := deleteComponent(name, reference); result == 0 {
+			status, code := http.StatusNotFound, "MANIFEST_UNKNOWN"
+			if msg != "Component not found" {
+				status, code = http.StatusMethodNotAllowed, "UNSUPPORTED"
+			}
+			writeOCIError(w, status, code, msg, name+":"+reference)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// manifestMediaType reads the mediaType a manifest declares for itself
+func manifestMediaType(manifest []byte) string {
+	var header struct {
+		MediaType string `json:"mediaType"`
+	}
+	if json.Unmarshal(manifest, &header) == nil && header.MediaType != "" {
+		return header.MediaType
+	}
+	return defaultManifestMediaType
+}
+
+// writeOCIError writes an error body in the format of the OCI distribution spec
+func writeOCIError(w http.ResponseWriter, status int, code, message string, detail interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]interface{}{{
+			"code":    code,
+			"message": message,
+			"detail":  detail,
+		}},
+	})
 }
 
+// handleBlob serves GET and HEAD /v2/<name>/blobs/<digest>. Blobs are
+// stored by digest alone, so any repository name finds them.
 func handleBlob(w http.ResponseWriter, r *http.Request) {
-	// Parse URL path to extract name and digest
-	// Format: /v2/<name>/blobs/<digest>
+	name, digest, ok := splitRepoPath(r.URL.Path, "blobs")
+	if !ok || digest == "" {
+		writeOCIError(w, http.StatusNotFound, "BLOB_UNKNOWN", "blob unknown", r.URL.Path)
+		return
+	}
+	if !authorize(w, r, name, scopePull) {
+		return
+	}
+
+	switch r.Method {
+	case "GET", "HEAD":
+		if !blobExists(digest) {
+			if r.Method == "HEAD" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writeOCIError(w, http.StatusNotFound, "BLOB_UNKNOWN", "blob unknown", digest)
+			return
+		}
+		_, _, data := downloadBlob(digest)
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusOK)
+		if r.Method == "GET" {
+			w.Write(data)
+		}
 
-	// This is a simplified implementation
-	// Full OCI registry would handle blob upload/download
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Write([]byte("mock blob data"))
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
 }
 
 func handleHealth(w http.ResponseWriter, r *http.Request) {
@@ -710,3 +1498,20 @@ func handleDebugReset(w http.ResponseWriter, r *http.Request) {
 		"message": msg,
 	})
 }
+
+func handleAdminGC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	result, msg, reclaimed := garbageCollect()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"result":    result,
+		"message":   msg,
+		"reclaimed": reclaimed,
+		"remaining": getBlobCount(),
+	})
+}
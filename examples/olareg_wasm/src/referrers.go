@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// ociImageIndexMediaType is the media type of the referrers response
+const ociImageIndexMediaType = "application/vnd.oci.image.index.v1+json"
+
+// referrerManifest holds the manifest fields the referrers API reports
+type referrerManifest struct {
+	MediaType    string `json:"mediaType"`
+	ArtifactType string `json:"artifactType"`
+	Config       struct {
+		MediaType string `json:"mediaType"`
+	} `json:"config"`
+	Subject *struct {
+		Digest string `json:"digest"`
+	} `json:"subject"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// referrerDescriptor is one entry of the referrers image index
+type referrerDescriptor struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       string            `json:"digest"`
+	Size         int               `json:"size"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// listReferrers returns the digests of name's manifests whose subject is
+// digest, sorted and without duplicates when several tags share a manifest
+func listReferrers(name, digest string) []string {
+	if !registryRunning {
+		return nil
+	}
+
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	seen := make(map[string]bool)
+	var referrers []string
+	for _, component := range components {
+		if component.Name != name || len(component.Manifest) == 0 {
+			continue
+		}
+		var manifest referrerManifest
+		if json.Unmarshal(component.Manifest, &manifest) != nil || manifest.Subject == nil || manifest.Subject.Digest != digest {
+			continue
+		}
+		if referrer := calculateDigest(component.Manifest); !seen[referrer] {
+			seen[referrer] = true
+			referrers = append(referrers, referrer)
+		}
+	}
+	sort.Strings(referrers)
+	return referrers
+}
+
+// referrerDescriptorFor describes a referrer manifest. Its artifact type is
+// the manifest's artifactType, or its config media type when that is unset,
+// as the OCI distribution spec prescribes.
+func referrerDescriptorFor(manifestData []byte) referrerDescriptor {
+	var manifest referrerManifest
+	json.Unmarshal(manifestData, &manifest)
+
+	artifactType := manifest.ArtifactType
+	if artifactType == "" {
+		artifactType = manifest.Config.MediaType
+	}
+	return referrerDescriptor{
+		MediaType:    manifestMediaType(manifestData),
+		Digest:       calculateDigest(manifestData),
+		Size:         len(manifestData),
+		ArtifactType: artifactType,
+		Annotations:  manifest.Annotations,
+	}
+}
+
+// handleReferrers serves GET /v2/<name>/referrers/<digest> as an image index
+// of the manifests whose subject is digest, optionally narrowed to one
+// ?artifactType=
+func handleReferrers(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name, digest, ok := splitRepoPath(r.URL.Path, "referrers")
+	if !ok {
+		writeOCIError(w, http.StatusNotFound, "NAME_UNKNOWN", "repository name missing", r.URL.Path)
+		return
+	}
+	if !strings.HasPrefix(digest, "sha256:") {
+		writeOCIError(w, http.StatusBadRequest, "DIGEST_INVALID", "referrers require a digest", digest)
+		return
+	}
+	if !authorize(w, r, name, scopePull) {
+		return
+	}
+
+	artifactType := r.URL.Query().Get("artifactType")
+	descriptors := []referrerDescriptor{}
+	for _, referrer := range listReferrers(name, digest) {
+		component, exists := findManifestByDigest(name, referrer)
+		if !exists {
+			continue
+		}
+		descriptor := referrerDescriptorFor(component.Manifest)
+		if artifactType != "" && descriptor.ArtifactType != artifactType {
+			continue
+		}
+		descriptors = append(descriptors, descriptor)
+	}
+
+	if artifactType != "" {
+		w.Header().Set("OCI-Filters-Applied", "artifactType")
+	}
+	w.Header().Set("Content-Type", ociImageIndexMediaType)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     ociImageIndexMediaType,
+		"manifests":     descriptors,
+	})
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotVersion is bumped whenever the snapshot layout changes
+const snapshotVersion = 1
+
+// registrySnapshot is the complete registry state as a single JSON document.
+// Byte slices are base64-encoded by encoding/json, and entries are sorted so
+// the same state always serializes to the same bytes.
+type registrySnapshot struct {
+	Version    int                 `json:"version"`
+	Components []snapshotComponent `json:"components"`
+	Blobs      []snapshotBlob      `json:"blobs"`
+	Metrics    snapshotMetrics     `json:"metrics"`
+}
+
+type snapshotComponent struct {
+	Name        string            `json:"name"`
+	Tag         string            `json:"tag"`
+	Digest      string            `json:"digest"`
+	Data        []byte            `json:"data"`
+	Manifest    []byte            `json:"manifest,omitempty"`
+	Signature   []byte            `json:"signature,omitempty"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type snapshotBlob struct {
+	Digest string `json:"digest"`
+	Data   []byte `json:"data"`
+}
+
+type snapshotMetrics struct {
+	Uploads   uint32 `json:"uploads"`
+	Downloads uint32 `json:"downloads"`
+	Deletes   uint32 `json:"deletes"`
+}
+
+// takeSnapshot captures the current registry state
+func takeSnapshot() *registrySnapshot {
+	storeMu.RLock()
+	defer storeMu.RUnlock()
+
+	snapshot := &registrySnapshot{
+		Version:    snapshotVersion,
+		Components: make([]snapshotComponent, 0, len(components)),
+		Blobs:      make([]snapshotBlob, 0, len(blobs)),
+		Metrics: snapshotMetrics{
+			Uploads:   uploadCount.Load(),
+			Downloads: downloadCount.Load(),
+			Deletes:   deleteCount.Load(),
+		},
+	}
+
+	for _, component := range components {
+		snapshot.Components = append(snapshot.Components, snapshotComponent{
+			Name:        component.Name,
+			Tag:         component.Tag,
+			Digest:      calculateDigest(component.Data),
+			Data:        component.Data,
+			Manifest:    component.Manifest,
+			Signature:   component.Signature,
+			Timestamp:   component.Timestamp.UTC(),
+			Annotations: component.Annotations,
+		})
+	}
+	sort.Slice(snapshot.Components, func(i, j int) bool {
+		a, b := snapshot.Components[i], snapshot.Components[j]
+		return componentKey(a.Name, a.Tag) < componentKey(b.Name, b.Tag)
+	})
+
+	for _, blob := range blobs {
+		snapshot.Blobs = append(snapshot.Blobs, snapshotBlob{Digest: blob.Digest, Data: blob.Data})
+	}
+	sort.Slice(snapshot.Blobs, func(i, j int) bool {
+		return snapshot.Blobs[i].Digest < snapshot.Blobs[j].Digest
+	})
+
+	return snapshot
+}
+
+// restoreSnapshot replaces the registry state with the snapshot's. Every
+// digest is checked first, so a corrupt snapshot leaves the registry as it
+// was.
+func restoreSnapshot(snapshot *registrySnapshot) error {
+	if snapshot.Version != snapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d (expected %d)", snapshot.Version, snapshotVersion)
+	}
+
+	restoredComponents := make(map[string]*Component, len(snapshot.Components))
+	for _, entry := range snapshot.Components {
+		if actual := calculateDigest(entry.Data); actual != entry.Digest {
+			return fmt.Errorf("component %s:%s digest mismatch: expected %s, got %s", entry.Name, entry.Tag, entry.Digest, actual)
+		}
+		restoredComponents[componentKey(entry.Name, entry.Tag)] = &Component{
+			Name:        entry.Name,
+			Tag:         entry.Tag,
+			Data:        entry.Data,
+			Manifest:    entry.Manifest,
+			Signature:   entry.Signature,
+			Timestamp:   entry.Timestamp,
+			Annotations: entry.Annotations,
+		}
+	}
+
+	restoredBlobs := make(map[string]*Blob, len(snapshot.Blobs))
+	for _, entry := range snapshot.Blobs {
+		if actual := calculateDigest(entry.Data); actual != entry.Digest {
+			return fmt.Errorf("blob digest mismatch: expected %s, got %s", entry.Digest, actual)
+		}
+		restoredBlobs[entry.Digest] = &Blob{Digest: entry.Digest, Data: entry.Data}
+	}
+
+	storeMu.Lock()
+	components = restoredComponents
+	blobs = restoredBlobs
+	storeMu.Unlock()
+	uploadCount.Store(snapshot.Metrics.Uploads)
+	downloadCount.Store(snapshot.Metrics.Downloads)
+	deleteCount.Store(snapshot.Metrics.Deletes)
+	return nil
+}
+
+// encodeSnapshot serializes the current state
+func encodeSnapshot() ([]byte, error) {
+	data, err := json.MarshalIndent(takeSnapshot(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// exportSnapshot writes the current state to path through a temp file
+func exportSnapshot(path string) error {
+	data, err := encodeSnapshot()
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeFileAtomic writes data to path through a temp file in the same
+// directory, so readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// importSnapshot loads the state saved at path
+func importSnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var snapshot registrySnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return fmt.Errorf("parsing snapshot %s: %w", path, err)
+	}
+	return restoreSnapshot(&snapshot)
+}
+
+// runSnapshotCommand handles the export-snapshot and import-snapshot CLI
+// commands, returning false for any other command.
+//
+//	export-snapshot <path> [component:tag,...]  Write a snapshot, seeded with test components
+//	import-snapshot <path>                      Verify and load a snapshot, printing what it holds
+func runSnapshotCommand(args []string) bool {
+	if len(args) == 0 || (args[0] != "export-snapshot" && args[0] != "import-snapshot") {
+		return false
+	}
+	if len(args) < 2 {
+		fmt.Printf("❌ Usage: %s <path>\n", args[0])
+		os.Exit(1)
+	}
+
+	initRegistry()
+	path := args[1]
+
+	if args[0] == "export-snapshot" {
+		if len(args) > 2 {
+			_, msg := createTestData(strings.Split(args[2], ","))
+			fmt.Printf("🧪 %s\n", msg)
+		}
+		if err := exportSnapshot(path); err != nil {
+			fmt.Printf("❌ Failed to export snapshot: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("📸 Exported %d components and %d blobs to %s\n", len(components), len(blobs), path)
+		return true
+	}
+
+	if err := importSnapshot(path); err != nil {
+		fmt.Printf("❌ Failed to import snapshot: %v\n", err)
+		os.Exit(1)
+	}
+	_, metrics := getMetrics()
+	fmt.Printf("📥 Imported %s: %s\n", path, metrics)
+	return true
+}
+
+func handleDebugSnapshot(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		data, err := encodeSnapshot()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+
+	case "PUT":
+		var snapshot registrySnapshot
+		if err := json.NewDecoder(r.Body).Decode(&snapshot); err != nil {
+			http.Error(w, "Invalid snapshot: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := restoreSnapshot(&snapshot); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
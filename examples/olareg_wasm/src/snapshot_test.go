@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// seedSnapshotState fills the registry with a tagged component carrying a
+// manifest and annotations, a second tag, a standalone blob and some
+// download traffic, so every part of the snapshot has something in it
+func seedSnapshotState(t *testing.T) {
+	t.Helper()
+	manifest := []byte(`{"schemaVersion":2,"annotations":{"org.opencontainers.image.version":"1.0.0"}}`)
+	steps := []func() (int32, string){
+		func() (int32, string) {
+			return uploadComponent("calc", "v1", []byte("\x00asm\x0d\x00\x01\x00 calc v1"))
+		},
+		func() (int32, string) { return uploadManifest("calc", "v1", manifest) },
+		func() (int32, string) {
+			return uploadComponent("calc", "v2", []byte("\x00asm\x0d\x00\x01\x00 calc v2"))
+		},
+		func() (int32, string) { return uploadComponent("other/nested", "latest", []byte("other")) },
+		func() (int32, string) {
+			blob := []byte("layer contents")
+			return uploadBlob(calculateDigest(blob), blob)
+		},
+	}
+	for _, step := range steps {
+		if status, msg := step(); status != 1 {
+			t.Fatalf("seeding registry: %s", msg)
+		}
+	}
+	if status, msg, _ := downloadComponent("calc", "v1"); status != 1 {
+		t.Fatalf("downloadComponent: %s", msg)
+	}
+}
+
+// TestSnapshotRoundTrip exports a populated registry, wipes it, imports the
+// file back and checks the state, and its encoding, are unchanged
+func TestSnapshotRoundTrip(t *testing.T) {
+	newTestRegistry(t)
+	seedSnapshotState(t)
+
+	original := takeSnapshot()
+	encoded, err := encodeSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "registry.json")
+	if err := exportSnapshot(path); err != nil {
+		t.Fatalf("exportSnapshot: %v", err)
+	}
+
+	resetRegistry()
+	if got := takeSnapshot(); len(got.Components) != 0 || len(got.Blobs) != 0 {
+		t.Fatalf("reset left %d components and %d blobs", len(got.Components), len(got.Blobs))
+	}
+
+	if err := importSnapshot(path); err != nil {
+		t.Fatalf("importSnapshot: %v", err)
+	}
+	if got := takeSnapshot(); !reflect.DeepEqual(got, original) {
+		t.Errorf("restored state differs:\n got %+v\nwant %+v", got, original)
+	}
+	reencoded, err := encodeSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(reencoded, encoded) {
+		t.Errorf("re-exported snapshot differs from the original:\n%s\nvs\n%s", reencoded, encoded)
+	}
+	if status, msg, annotations := getComponentAnnotations("calc", "v1"); status != 1 || len(annotations) != 1 {
+		t.Errorf("annotations after import = %d %q (%s), want the manifest's one", status, annotations, msg)
+	}
+}
+
+// TestSaveLoadStateRoundTrip does the same through the data directory
+// layout, where content lives in files next to the index
+func TestSaveLoadStateRoundTrip(t *testing.T) {
+	newTestRegistry(t)
+	seedSnapshotState(t)
+
+	original := takeSnapshot()
+	dataDir := t.TempDir()
+	if err := saveState(dataDir); err != nil {
+		t.Fatalf("saveState: %v", err)
+	}
+
+	resetRegistry()
+	if err := loadState(dataDir); err != nil {
+		t.Fatalf("loadState: %v", err)
+	}
+	if got := takeSnapshot(); !reflect.DeepEqual(got, original) {
+		t.Errorf("restored state differs:\n got %+v\nwant %+v", got, original)
+	}
+}
+
+// TestImportSnapshotRejectsCorruption checks a damaged snapshot is refused
+// and the registry keeps what it held before the import
+func TestImportSnapshotRejectsCorruption(t *testing.T) {
+	newTestRegistry(t)
+	seedSnapshotState(t)
+
+	before := takeSnapshot()
+	encode := func(modify func(*registrySnapshot)) []byte {
+		snapshot := takeSnapshot()
+		modify(snapshot)
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+		wantErr string
+	}{
+		{
+			name:    "tampered component",
+			content: encode(func(s *registrySnapshot) { s.Components[1].Data = []byte("tampered") }),
+			wantErr: "digest mismatch",
+		},
+		{
+			name:    "tampered blob",
+			content: encode(func(s *registrySnapshot) { s.Blobs[0].Data = []byte("tampered") }),
+			wantErr: "blob digest mismatch",
+		},
+		{
+			name:    "future version",
+			content: encode(func(s *registrySnapshot) { s.Version = snapshotVersion + 1 }),
+			wantErr: "unsupported snapshot version",
+		},
+		{
+			name:    "not json",
+			content: []byte("{"),
+			wantErr: "parsing snapshot",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "registry.json")
+			if err := os.WriteFile(path, tt.content, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			err := importSnapshot(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("importSnapshot error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+			if got := takeSnapshot(); !reflect.DeepEqual(got, before) {
+				t.Error("rejected import changed the registry")
+			}
+		})
+	}
+}
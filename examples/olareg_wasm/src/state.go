@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// On-disk registry layout under dataDir: index.json is a snapshot whose
+// component data has been moved out into content-addressed files, so large
+// components are stored once and the index stays small
+//
+//	<dataDir>/index.json
+//	<dataDir>/blobs/sha256/<hex>
+const (
+	stateIndexFile = "index.json"
+	stateBlobDir   = "blobs"
+)
+
+// stateBlobPath is where the content with the given sha256: digest is kept
+func stateBlobPath(dataDir, digest string) (string, error) {
+	algorithm, hex, ok := strings.Cut(digest, ":")
+	if !ok || algorithm != "sha256" || len(hex) != 64 || strings.ContainsAny(hex, `/\.`) {
+		return "", fmt.Errorf("invalid digest %q", digest)
+	}
+	return filepath.Join(dataDir, stateBlobDir, algorithm, hex), nil
+}
+
+// hasState reports whether dataDir holds a saved registry
+func hasState(dataDir string) bool {
+	_, err := os.Stat(filepath.Join(dataDir, stateIndexFile))
+	return err == nil
+}
+
+// saveState writes every component and blob under dataDir. Content files are
+// written before the index that refers to them, and files no longer
+// referenced are removed afterwards, so an interrupted save leaves the
+// previous state loadable.
+func saveState(dataDir string) error {
+	snapshot := takeSnapshot()
+
+	if err := os.MkdirAll(filepath.Join(dataDir, stateBlobDir, "sha256"), 0755); err != nil {
+		return err
+	}
+
+	kept := make(map[string]bool)
+	writeContent := func(digest string, data []byte) error {
+		path, err := stateBlobPath(dataDir, digest)
+		if err != nil {
+			return err
+		}
+		kept[path] = true
+		if _, err := os.Stat(path); err == nil {
+			return nil // Content-addressed, so an existing file is already right
+		}
+		return writeFileAtomic(path, data)
+	}
+
+	for i := range snapshot.Components {
+		entry := &snapshot.Components[i]
+		if err := writeContent(entry.Digest, entry.Data); err != nil {
+			return fmt.Errorf("component %s:%s: %w", entry.Name, entry.Tag, err)
+		}
+		entry.Data = nil
+	}
+	for i := range snapshot.Blobs {
+		entry := &snapshot.Blobs[i]
+		if err := writeContent(entry.Digest, entry.Data); err != nil {
+			return fmt.Errorf("blob %s: %w", entry.Digest, err)
+		}
+		entry.Data = nil
+	}
+
+	index, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(dataDir, stateIndexFile), append(index, '\n')); err != nil {
+		return err
+	}
+
+	return pruneStateBlobs(dataDir, kept)
+}
+
+// pruneStateBlobs removes content files the index no longer refers to
+func pruneStateBlobs(dataDir string, kept map[string]bool) error {
+	dir := filepath.Join(dataDir, stateBlobDir, "sha256")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !kept[path] {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// loadState replaces the registry state with the one saved under dataDir.
+// Content is verified against its digest by restoreSnapshot, so a damaged
+// data directory leaves the registry as it was.
+func loadState(dataDir string) error {
+	index, err := os.ReadFile(filepath.Join(dataDir, stateIndexFile))
+	if err != nil {
+		return err
+	}
+
+	var snapshot registrySnapshot
+	if err := json.Unmarshal(index, &snapshot); err != nil {
+		return fmt.Errorf("parsing %s: %w", stateIndexFile, err)
+	}
+
+	readContent := func(digest string) ([]byte, error) {
+		path, err := stateBlobPath(dataDir, digest)
+		if err != nil {
+			return nil, err
+		}
+		return os.ReadFile(path)
+	}
+
+	for i := range snapshot.Components {
+		entry := &snapshot.Components[i]
+		if entry.Data, err = readContent(entry.Digest); err != nil {
+			return fmt.Errorf("component %s:%s: %w", entry.Name, entry.Tag, err)
+		}
+	}
+	for i := range snapshot.Blobs {
+		entry := &snapshot.Blobs[i]
+		if entry.Data, err = readContent(entry.Digest); err != nil {
+			return fmt.Errorf("blob %s: %w", entry.Digest, err)
+		}
+	}
+
+	return restoreSnapshot(&snapshot)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// UploadSession accumulates a blob pushed in chunks: POST starts it, each
+// PATCH appends at the current offset and PUT completes it with the digest
+type UploadSession struct {
+	ID         string
+	Name       string
+	Data       []byte
+	Offset     int
+	Started    time.Time
+	LastActive time.Time
+}
+
+// uploadIdleTTL is how long a session may go without a chunk before it is
+// dropped. Clients that abandon an upload would otherwise hold its data for
+// the life of the registry.
+var uploadIdleTTL = 30 * time.Minute
+
+// minUploadSweep is the session count at which idle sessions are first swept
+const minUploadSweep = 64
+
+// In-progress chunked uploads by session ID. PATCH requests for different
+// sessions can arrive concurrently, so the map has its own lock. Like the
+// rate limiter's buckets, idle sessions are swept whenever the map doubles
+// rather than by a background goroutine, which may be unavailable under WASI.
+var (
+	uploads        = make(map[string]*UploadSession)
+	uploadsSweepAt = minUploadSweep
+	uploadsMu      sync.Mutex
+)
+
+// sweepUploads drops the sessions idle for longer than uploadIdleTTL. The
+// caller holds uploadsMu.
+func sweepUploads(now time.Time) {
+	for id, session := range uploads {
+		if now.Sub(session.LastActive) > uploadIdleTTL {
+			delete(uploads, id)
+		}
+	}
+	uploadsSweepAt = max(minUploadSweep, 2*len(uploads))
+}
+
+// liveUpload looks up a session, dropping it if it has gone idle since the
+// last sweep. The caller holds uploadsMu.
+func liveUpload(sessionID string, now time.Time) (*UploadSession, bool) {
+	session, exists := uploads[sessionID]
+	if !exists {
+		return nil, false
+	}
+	if now.Sub(session.LastActive) > uploadIdleTTL {
+		delete(uploads, sessionID)
+		return nil, false
+	}
+	return session, true
+}
+
+// startBlobUpload opens a chunked upload for repository name. An empty
+// session ID means the registry is stopped or does not accept pushes.
+func startBlobUpload(name string) (sessionID string) {
+	if !registryRunning || readOnly || !enablePush {
+		return ""
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	sessionID = hex.EncodeToString(id)
+
+	now := time.Now()
+	uploadsMu.Lock()
+	defer uploadsMu.Unlock()
+	if len(uploads) >= uploadsSweepAt {
+		sweepUploads(now)
+	}
+	uploads[sessionID] = &UploadSession{ID: sessionID, Name: name, Started: now, LastActive: now}
+	return sessionID
+}
+
+// patchBlobChunk appends data to an upload. Chunks must be contiguous: offset
+// has to equal the number of bytes received so far.
+func patchBlobChunk(sessionID string, offset int, data []byte) (int32, string) {
+	if !registryRunning {
+		return 0, "Registry is not running"
+	}
+
+	now := time.Now()
+	uploadsMu.Lock()
+	defer uploadsMu.Unlock()
+
+	session, exists := liveUpload(sessionID, now)
+	if !exists {
+		return 0, "Upload session not found"
+	}
+	if offset != session.Offset {
+		return 0, fmt.Sprintf("Chunk out of order: expected offset %d, got %d", session.Offset, offset)
+	}
+
+	session.Data = append(session.Data, data...)
+	session.Offset += len(data)
+	session.LastActive = now
+	return 1, fmt.Sprintf("Chunk accepted, %d bytes received", session.Offset)
+}
+
+// completeBlobUpload verifies the assembled blob against digest and stores
+// it through the single-shot path. The session ends whether or not the
+// digest matches, as a client that sent the wrong bytes has to start over.
+func completeBlobUpload(sessionID, digest string) (int32, string) {
+	if !registryRunning {
+		return 0, "Registry is not running"
+	}
+
+	uploadsMu.Lock()
+	session, exists := liveUpload(sessionID, time.Now())
+	delete(uploads, sessionID)
+	uploadsMu.Unlock()
+
+	if !exists {
+		return 0, "Upload session not found"
+	}
+
+	return uploadBlob(digest, session.Data)
+}
+
+// uploadOffset returns the bytes received so far by a session
+func uploadOffset(sessionID string) (int, bool) {
+	uploadsMu.Lock()
+	defer uploadsMu.Unlock()
+
+	session, exists := liveUpload(sessionID, time.Now())
+	if !exists {
+		return 0, false
+	}
+	return session.Offset, true
+}
+
+// handleBlobUpload serves the chunked upload flow of the OCI distribution
+// spec under /v2/<name>/blobs/uploads/: POST starts a session, PATCH sends a
+// chunk, optionally with a Content-Range, and PUT ?digest= completes it
+func handleBlobUpload(w http.ResponseWriter, r *http.Request) {
+	name, sessionID, ok := splitRepoPath(r.URL.Path, "blobs/uploads")
+	if !ok {
+		writeOCIError(w, http.StatusNotFound, "NAME_UNKNOWN", "repository name missing", r.URL.Path)
+		return
+	}
+	if !authorize(w, r, name, scopePush) {
+		return
+	}
+	location := "/v2/" + name + "/blobs/uploads/" + sessionID
+
+	if r.Method == "POST" && sessionID == "" {
+		sessionID = startBlobUpload(name)
+		if sessionID == "" {
+			writeOCIError(w, http.StatusForbidden, "DENIED", "Registry is read-only or push disabled", name)
+			return
+		}
+		w.Header().Set("Location", location+sessionID)
+		w.Header().Set("Docker-Upload-UUID", sessionID)
+		w.Header().Set("Range", "0-0")
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	offset, exists := uploadOffset(sessionID)
+	if !exists {
+		writeOCIError(w, http.StatusNotFound, "BLOB_UPLOAD_UNKNOWN", "blob upload unknown", sessionID)
+		return
+	}
+
+	switch r.Method {
+	case "PATCH", "PUT":
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeOCIError(w, http.StatusBadRequest, "BLOB_UPLOAD_INVALID", "failed to read chunk", err.Error())
+			return
+		}
+
+		// Without a Content-Range the chunk continues where the last ended
+		if contentRange := r.Header.Get("Content-Range"); contentRange != "" {
+			offset, err = parseContentRange(contentRange, len(data))
+			if err != nil {
+				writeOCIError(w, http.StatusBadRequest, "BLOB_UPLOAD_INVALID", err.Error(), contentRange)
+				return
+			}
+		}
+		if len(data) > 0 || r.Method == "PATCH" {
+			if result, msg := patchBlobChunk(sessionID, offset, data); result == 0 {
+				writeOCIError(w, http.StatusRequestedRangeNotSatisfiable, "BLOB_UPLOAD_INVALID", msg, sessionID)
+				return
+			}
+		}
+
+		if r.Method == "PATCH" {
+			received, _ := uploadOffset(sessionID)
+			w.Header().Set("Location", location)
+			w.Header().Set("Docker-Upload-UUID", sessionID)
+			w.Header().Set("Range", fmt.Sprintf("0-%d", max(received-1, 0)))
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+
+		digest := r.URL.Query().Get("digest")
+		if result, msg := completeBlobUpload(sessionID, digest); result == 0 {
+			writeOCIError(w, http.StatusBadRequest, "DIGEST_INVALID", msg, digest)
+			return
+		}
+		w.Header().Set("Location", "/v2/"+name+"/blobs/"+digest)
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// parseContentRange reads a chunk's "<start>-<end>" range, which is
+// inclusive and must cover exactly the size bytes sent with it
+func parseContentRange(contentRange string, size int) (int, error) {
+	startText, endText, ok := strings.Cut(strings.TrimPrefix(contentRange, "bytes "), "-")
+	start, startErr := strconv.Atoi(startText)
+	end, endErr := strconv.Atoi(endText)
+	if !ok || startErr != nil || endErr != nil || start < 0 || end < start {
+		return 0, fmt.Errorf("invalid Content-Range %q: expected <start>-<end>", contentRange)
+	}
+	if end-start+1 != size {
+		return 0, fmt.Errorf("Content-Range %q covers %d bytes but the chunk has %d", contentRange, end-start+1, size)
+	}
+	return start, nil
+}
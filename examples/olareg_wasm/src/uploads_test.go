@@ -0,0 +1,84 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// backdateUpload makes a session look idle for the given duration
+func backdateUpload(t *testing.T, sessionID string, idle time.Duration) {
+	t.Helper()
+	uploadsMu.Lock()
+	defer uploadsMu.Unlock()
+	session, exists := uploads[sessionID]
+	if !exists {
+		t.Fatalf("session %s not found", sessionID)
+	}
+	session.LastActive = time.Now().Add(-idle)
+}
+
+// TestIdleUploadExpires checks a session that has gone quiet for longer
+// than the TTL is refused, while one with recent chunks carries on
+func TestIdleUploadExpires(t *testing.T) {
+	newTestRegistry(t)
+
+	idle := startBlobUpload("calc")
+	active := startBlobUpload("calc")
+	if idle == "" || active == "" {
+		t.Fatal("startBlobUpload failed")
+	}
+	for _, id := range []string{idle, active} {
+		if status, msg := patchBlobChunk(id, 0, []byte("chunk")); status != 1 {
+			t.Fatalf("patchBlobChunk: %s", msg)
+		}
+	}
+	backdateUpload(t, idle, uploadIdleTTL+time.Minute)
+	backdateUpload(t, active, uploadIdleTTL-time.Minute)
+
+	if _, exists := uploadOffset(idle); exists {
+		t.Error("idle session still reported")
+	}
+	if status, _ := patchBlobChunk(idle, 5, []byte("more")); status != 0 {
+		t.Error("chunk accepted for an expired session")
+	}
+	if status, msg := patchBlobChunk(active, 5, []byte("more")); status != 1 {
+		t.Errorf("chunk for an active session refused: %s", msg)
+	}
+	if status, msg := completeBlobUpload(active, calculateDigest([]byte("chunkmore"))); status != 1 {
+		t.Errorf("completing an active session failed: %s", msg)
+	}
+}
+
+// TestUploadSweep checks abandoned sessions are dropped once the map
+// reaches the sweep threshold, without waiting for anyone to look them up
+func TestUploadSweep(t *testing.T) {
+	newTestRegistry(t)
+
+	var abandoned []string
+	for range minUploadSweep - 1 {
+		id := startBlobUpload("calc")
+		backdateUpload(t, id, uploadIdleTTL+time.Minute)
+		abandoned = append(abandoned, id)
+	}
+	kept := startBlobUpload("calc")
+
+	// The next session reaches the threshold and triggers the sweep
+	latest := startBlobUpload("calc")
+
+	uploadsMu.Lock()
+	defer uploadsMu.Unlock()
+	if len(uploads) != 2 {
+		t.Errorf("%d sessions after the sweep, want the 2 active ones", len(uploads))
+	}
+	for _, id := range []string{kept, latest} {
+		if _, exists := uploads[id]; !exists {
+			t.Errorf("active session %s swept", id)
+		}
+	}
+	if _, exists := uploads[abandoned[0]]; exists {
+		t.Errorf("abandoned session %s kept", abandoned[0])
+	}
+	if uploadsSweepAt != minUploadSweep {
+		t.Errorf("uploadsSweepAt = %d, want it back at %d", uploadsSweepAt, minUploadSweep)
+	}
+}
@@ -175,6 +175,7 @@ def _go_wasm_component_impl(ctx):
             - ctx.files.srcs: Go source files (.go)
             - ctx.file.go_mod: go.mod file for module definition
             - ctx.file.go_sum: go.sum file for dependency checksums
+            - ctx.attr.go_packages: Library sources laid out in module subdirectories
             - ctx.attr.wit: WIT library for interface bindings
             - ctx.attr.world: WIT world name to implement
             - ctx.attr.optimization: Optimization level (debug/release/size)
@@ -424,6 +425,7 @@ def _prepare_go_module(ctx, tinygo_toolchain):
     2. Generate WIT bindings if conditions are met
     3. Call setup_go_module_action to create workspace with:
        - Go source files
+       - Library packages from go_packages in their subdirectories
        - go.mod and go.sum
        - WIT file (if using manual WIT approach)
        - Generated bindings directory (if using wit-bindgen-go)
@@ -465,6 +467,10 @@ def _prepare_go_module(ctx, tinygo_toolchain):
     module_dir = setup_go_module_action(
         ctx,
         sources = ctx.files.srcs,  # Use original sources, bindings handled separately
+        packages = {
+            subdir: target.files.to_list()
+            for target, subdir in ctx.attr.go_packages.items()
+        },
         go_mod = ctx.file.go_mod,
         go_sum = ctx.file.go_sum,
         wit_file = wit_file,
@@ -817,6 +823,10 @@ go_wasm_component = rule(
             allow_single_file = ["go.sum"],
             doc = "Go module checksum file",
         ),
+        "go_packages": attr.label_keyed_string_dict(
+            allow_files = [".go"],
+            doc = "Library packages imported by srcs, mapping each package's sources to its directory under the go_mod module root",
+        ),
         "wit": attr.label(
             providers = [WitInfo],
             doc = "WIT library for binding generation",
@@ -243,12 +243,14 @@ def prepare_workspace_action(ctx, config):
 
     return workspace_dir
 
-def setup_go_module_action(ctx, sources, go_mod = None, go_sum = None, wit_file = None, bindings_dir = None, go_binary = None):
+def setup_go_module_action(ctx, sources, packages = None, go_mod = None, go_sum = None, wit_file = None, bindings_dir = None, go_binary = None):
     """Set up a Go module workspace for TinyGo compilation
 
     Args:
         ctx: Bazel rule context
         sources: List of Go source files
+        packages: Optional dict from a directory relative to the module root to
+            the Go source files of the library package placed there
         go_mod: Optional go.mod file
         go_sum: Optional go.sum file
         wit_file: Optional WIT file for binding generation
@@ -269,6 +271,16 @@ def setup_go_module_action(ctx, sources, go_mod = None, go_sum = None, wit_file
             "preserve_permissions": False,
         })
 
+    # Library packages keep their path under the module root so their import
+    # paths resolve against go.mod
+    for subdir, package_srcs in (packages or {}).items():
+        for src in package_srcs:
+            sources_config.append({
+                "source": src,
+                "destination": subdir + "/" + src.basename,
+                "preserve_permissions": False,
+            })
+
     config = {
         "work_dir": ctx.label.name + "_gomod",
         "workspace_type": "go",
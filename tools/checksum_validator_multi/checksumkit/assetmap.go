@@ -0,0 +1,160 @@
+// Package checksumkit is the plumbing shared by go_downloader and
+// production_checksum_updater: release asset matching, GitHub API retries
+// and errors, buffered file hashing, bounded worker pools and leveled
+// progress logging.
+package checksumkit
+
+import "strings"
+
+// Release asset naming differs between projects: wasm-tools and wasmtime use
+// x86_64-linux / aarch64-macos, wac and wkg use Rust target triples such as
+// x86_64-unknown-linux-musl / aarch64-apple-darwin, and tinygo uses
+// linux-amd64. AssetPlatform maps all of them onto our platform names.
+
+// SupportedPlatforms are the platform names assets are mapped onto
+var SupportedPlatforms = []string{
+	"darwin_amd64",
+	"darwin_arm64",
+	"linux_amd64",
+	"linux_arm64",
+	"windows_amd64",
+}
+
+// AssetMatch is how an asset name maps onto a platform
+type AssetMatch struct {
+	OS        string // darwin, linux or windows
+	Arch      string // amd64, arm64, or universal for macOS fat binaries
+	Libc      string // musl or gnu on Linux when the name says so
+	Supported bool
+}
+
+// Asset names that are never the tool itself: checksums, signatures, SBOMs,
+// installers and library-only archives such as wasmtime's c-api builds
+var (
+	nonBinarySuffixes = []string{
+		".sha256", ".sha512", ".sha256sum", ".sig", ".asc", ".pem", ".crt",
+		".txt", ".json", ".sbom", ".spdx", ".intoto.jsonl", ".md",
+		".msi", ".deb", ".rpm",
+	}
+	nonBinaryMarkers = []string{"src", "source", "c-api"}
+)
+
+// ClassifyAsset reads the operating system, architecture and libc from an
+// asset name. Non-binary assets are reported as unsupported.
+func ClassifyAsset(name string) AssetMatch {
+	lower := strings.ToLower(name)
+
+	for _, marker := range nonBinaryMarkers {
+		if strings.Contains(lower, marker) {
+			return AssetMatch{}
+		}
+	}
+	for _, suffix := range nonBinarySuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return AssetMatch{}
+		}
+	}
+
+	var m AssetMatch
+	switch {
+	case strings.Contains(lower, "windows") || strings.HasSuffix(lower, ".exe"):
+		m.OS = "windows"
+	case strings.Contains(lower, "darwin") || strings.Contains(lower, "macos") ||
+		strings.Contains(lower, "apple") || strings.Contains(lower, "osx"):
+		m.OS = "darwin"
+	case strings.Contains(lower, "linux"):
+		m.OS = "linux"
+	default:
+		return AssetMatch{}
+	}
+
+	switch {
+	case strings.Contains(lower, "aarch64") || strings.Contains(lower, "arm64"):
+		m.Arch = "arm64"
+	case strings.Contains(lower, "x86_64") || strings.Contains(lower, "x86-64") ||
+		strings.Contains(lower, "amd64") || strings.Contains(lower, "x64"):
+		m.Arch = "amd64"
+	case m.OS == "darwin" && strings.Contains(lower, "universal"):
+		m.Arch = "universal"
+	default:
+		return AssetMatch{}
+	}
+
+	if m.OS == "linux" {
+		switch {
+		case strings.Contains(lower, "musl"):
+			m.Libc = "musl"
+		case strings.Contains(lower, "gnu"):
+			m.Libc = "gnu"
+		}
+	}
+
+	m.Supported = true
+	return m
+}
+
+// AssetPlatform returns the platform name an asset is built for, or "" when
+// it is not a binary for a supported platform. Universal macOS binaries
+// report darwin_arm64; use AssetRank to test them against darwin_amd64.
+func AssetPlatform(name string) string {
+	m := ClassifyAsset(name)
+	if !m.Supported {
+		return ""
+	}
+	arch := m.Arch
+	if arch == "universal" {
+		arch = "arm64"
+	}
+	platform := m.OS + "_" + arch
+	for _, supported := range SupportedPlatforms {
+		if supported == platform {
+			return platform
+		}
+	}
+	return ""
+}
+
+// AssetRank scores how well an asset fits a platform; 0 means it does not.
+// An exact architecture beats a universal macOS binary, and on Linux a
+// statically linked musl build beats an unspecified one, which beats gnu,
+// since musl binaries also run on glibc hosts.
+func AssetRank(name, platform string) int {
+	m := ClassifyAsset(name)
+	if !m.Supported {
+		return 0
+	}
+
+	goos, arch, _ := strings.Cut(platform, "_")
+	if m.OS != goos {
+		return 0
+	}
+
+	rank := 10
+	switch {
+	case m.Arch == arch:
+	case m.Arch == "universal":
+		rank = 5
+	default:
+		return 0
+	}
+
+	switch m.Libc {
+	case "musl":
+		rank += 2
+	case "":
+		rank++
+	}
+	return rank
+}
+
+// SelectAsset returns the index of the asset name best suited to platform,
+// or -1 when none fits. Ties go to the earlier asset.
+func SelectAsset(names []string, platform string) int {
+	best, bestRank := -1, 0
+	for i, name := range names {
+		if rank := AssetRank(name, platform); rank > bestRank {
+			best, bestRank = i, rank
+		}
+	}
+	return best
+}
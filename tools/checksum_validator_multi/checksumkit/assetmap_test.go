@@ -0,0 +1,288 @@
+package checksumkit
+
+import "testing"
+
+// releaseAssets are the binaries each tool publishes per platform, as named
+// in its GitHub releases
+var releaseAssets = map[string]map[string]string{
+	"wasm-tools": {
+		"darwin_amd64":  "wasm-tools-1.246.2-x86_64-macos.tar.gz",
+		"darwin_arm64":  "wasm-tools-1.246.2-aarch64-macos.tar.gz",
+		"linux_amd64":   "wasm-tools-1.246.2-x86_64-linux.tar.gz",
+		"linux_arm64":   "wasm-tools-1.246.2-aarch64-linux.tar.gz",
+		"windows_amd64": "wasm-tools-1.246.2-x86_64-windows.zip",
+	},
+	"wasmtime": {
+		"darwin_amd64":  "wasmtime-v45.0.1-x86_64-macos.tar.xz",
+		"darwin_arm64":  "wasmtime-v45.0.1-aarch64-macos.tar.xz",
+		"linux_amd64":   "wasmtime-v45.0.1-x86_64-linux.tar.xz",
+		"linux_arm64":   "wasmtime-v45.0.1-aarch64-linux.tar.xz",
+		"windows_amd64": "wasmtime-v45.0.1-x86_64-windows.zip",
+	},
+	"wit-bindgen": {
+		"darwin_amd64":  "wit-bindgen-0.58.0-x86_64-macos.tar.gz",
+		"darwin_arm64":  "wit-bindgen-0.58.0-aarch64-macos.tar.gz",
+		"linux_amd64":   "wit-bindgen-0.58.0-x86_64-linux.tar.gz",
+		"linux_arm64":   "wit-bindgen-0.58.0-aarch64-linux.tar.gz",
+		"windows_amd64": "wit-bindgen-0.58.0-x86_64-windows.zip",
+	},
+	"wac": {
+		"darwin_amd64":  "wac-cli-x86_64-apple-darwin",
+		"darwin_arm64":  "wac-cli-aarch64-apple-darwin",
+		"linux_amd64":   "wac-cli-x86_64-unknown-linux-musl",
+		"linux_arm64":   "wac-cli-aarch64-unknown-linux-musl",
+		"windows_amd64": "wac-cli-x86_64-pc-windows-gnu",
+	},
+	"wkg": {
+		"darwin_amd64":  "wkg-x86_64-apple-darwin",
+		"darwin_arm64":  "wkg-aarch64-apple-darwin",
+		"linux_amd64":   "wkg-x86_64-unknown-linux-gnu",
+		"linux_arm64":   "wkg-aarch64-unknown-linux-gnu",
+		"windows_amd64": "wkg-x86_64-pc-windows-gnu",
+	},
+	"wrpc": {
+		"darwin_amd64":  "wrpc-wasmtime-x86_64-apple-darwin",
+		"darwin_arm64":  "wrpc-wasmtime-aarch64-apple-darwin",
+		"linux_amd64":   "wrpc-wasmtime-x86_64-unknown-linux-musl",
+		"linux_arm64":   "wrpc-wasmtime-aarch64-unknown-linux-musl",
+		"windows_amd64": "wrpc-wasmtime-x86_64-pc-windows-gnu.exe",
+	},
+	"tinygo": {
+		"darwin_amd64":  "tinygo0.40.1.darwin-amd64.tar.gz",
+		"darwin_arm64":  "tinygo0.40.1.darwin-arm64.tar.gz",
+		"linux_amd64":   "tinygo0.40.1.linux-amd64.tar.gz",
+		"linux_arm64":   "tinygo0.40.1.linux-arm64.tar.gz",
+		"windows_amd64": "tinygo0.40.1.windows-amd64.zip",
+	},
+	"binaryen": {
+		"darwin_amd64":  "binaryen-version_129-x86_64-macos.tar.gz",
+		"darwin_arm64":  "binaryen-version_129-arm64-macos.tar.gz",
+		"linux_amd64":   "binaryen-version_129-x86_64-linux.tar.gz",
+		"linux_arm64":   "binaryen-version_129-aarch64-linux.tar.gz",
+		"windows_amd64": "binaryen-version_129-x86_64-windows.tar.gz",
+	},
+	"wasi-sdk": {
+		"darwin_amd64":  "wasi-sdk-32.0-x86_64-macos.tar.gz",
+		"darwin_arm64":  "wasi-sdk-32.0-arm64-macos.tar.gz",
+		"linux_amd64":   "wasi-sdk-32.0-x86_64-linux.tar.gz",
+		"linux_arm64":   "wasi-sdk-32.0-arm64-linux.tar.gz",
+		"windows_amd64": "wasi-sdk-32.0-x86_64-windows.tar.gz",
+	},
+	"componentize-py": {
+		"darwin_amd64":  "componentize-py-canary-macos-amd64.tar.gz",
+		"darwin_arm64":  "componentize-py-canary-macos-aarch64.tar.gz",
+		"linux_amd64":   "componentize-py-canary-linux-amd64.tar.gz",
+		"linux_arm64":   "componentize-py-canary-linux-aarch64.tar.gz",
+		"windows_amd64": "componentize-py-canary-windows-amd64.tar.gz",
+	},
+	"wsc": {
+		"darwin_amd64":  "wsc-macos-x86_64",
+		"darwin_arm64":  "wsc-macos-aarch64",
+		"linux_amd64":   "wsc-linux-x86_64",
+		"linux_arm64":   "wsc-linux-aarch64",
+		"windows_amd64": "wsc-windows-x86_64.exe",
+	},
+	"witness": {
+		"darwin_amd64":  "witness-v0.22.0-x86_64-apple-darwin.tar.gz",
+		"darwin_arm64":  "witness-v0.22.0-aarch64-apple-darwin.tar.gz",
+		"linux_amd64":   "witness-v0.22.0-x86_64-unknown-linux-gnu.tar.gz",
+		"linux_arm64":   "witness-v0.22.0-aarch64-unknown-linux-gnu.tar.gz",
+		"windows_amd64": "witness-v0.22.0-x86_64-pc-windows-msvc.zip",
+	},
+}
+
+// releaseExtras sit next to the binaries in the same releases and must never
+// be picked: checksum sidecars, signatures, sources, library builds, and
+// binaries for platforms we do not support
+var releaseExtras = []string{
+	"wasm-tools-1.246.2-x86_64-linux.tar.gz.sha256",
+	"wasm-tools-1.246.2-aarch64-macos.tar.gz.sha256",
+	"wasm-tools-1.246.2-wasm32-wasip1.tar.gz",
+	"wasm-tools-1.246.2-x86_64-windows.zip.sig",
+	"wasmtime-v45.0.1-src.tar.gz",
+	"wasmtime-v45.0.1-x86_64-linux-c-api.tar.xz",
+	"wasmtime-v45.0.1-aarch64-macos-c-api.tar.xz",
+	"wasmtime-v45.0.1-x86_64-windows-c-api.zip",
+	"wasmtime-v45.0.1-x86_64-mingw.zip",
+	"wasmtime-v45.0.1-riscv64gc-linux.tar.xz",
+	"wasmtime-v45.0.1-s390x-linux.tar.xz",
+	"wasmtime-v45.0.1-x86_64-android.tar.xz",
+	"wasmtime-v45.0.1-aarch64-windows.zip",
+	"wasmtime-v45.0.1-x86_64-windows.msi",
+	"wasmtime-v45.0.1-i686-windows.zip",
+	"wac-cli-x86_64-unknown-linux-musl.sha256",
+	"wkg-x86_64-unknown-linux-gnu.sha256sum",
+	"tinygo_0.40.1_amd64.deb",
+	"tinygo0.40.1.linux-amd64.tar.gz.sbom.spdx",
+	"checksums.txt",
+	"SHA256SUMS.asc",
+	"wsc-linux-x86_64.intoto.jsonl",
+	"witness-v0.22.0-x86_64-unknown-linux-gnu.tar.gz.pem",
+	"Source code (tar.gz)",
+}
+
+// TestAssetPlatformTable maps every published binary onto its platform and
+// checks SelectAsset picks it out of the whole release, extras included
+func TestAssetPlatformTable(t *testing.T) {
+	for tool, assets := range releaseAssets {
+		release := append([]string(nil), releaseExtras...)
+		for _, platform := range SupportedPlatforms {
+			name, ok := assets[platform]
+			if !ok {
+				t.Fatalf("%s has no %s asset in the table", tool, platform)
+			}
+			release = append(release, name)
+		}
+
+		for _, platform := range SupportedPlatforms {
+			name := assets[platform]
+			t.Run(tool+"/"+platform, func(t *testing.T) {
+				if got := AssetPlatform(name); got != platform {
+					t.Errorf("AssetPlatform(%q) = %q, want %q", name, got, platform)
+				}
+				if got := SelectAsset(release, platform); got < 0 || release[got] != name {
+					picked := "nothing"
+					if got >= 0 {
+						picked = release[got]
+					}
+					t.Errorf("SelectAsset for %s picked %s, want %s", platform, picked, name)
+				}
+			})
+		}
+	}
+}
+
+func TestAssetPlatformRejectsExtras(t *testing.T) {
+	for _, name := range releaseExtras {
+		t.Run(name, func(t *testing.T) {
+			if got := AssetPlatform(name); got != "" {
+				t.Errorf("AssetPlatform(%q) = %q, want no platform", name, got)
+			}
+			for _, platform := range SupportedPlatforms {
+				if rank := AssetRank(name, platform); rank != 0 {
+					t.Errorf("AssetRank(%q, %s) = %d, want 0", name, platform, rank)
+				}
+			}
+		})
+	}
+}
+
+// TestSelectAssetDisambiguation covers releases that publish several builds
+// for one platform. On Linux a musl build ranks above an unspecified one,
+// which ranks above gnu, as static musl binaries also run on glibc hosts.
+// A musl build never stands in for another operating system.
+func TestSelectAssetDisambiguation(t *testing.T) {
+	tests := []struct {
+		name     string
+		assets   []string
+		platform string
+		want     string
+	}{
+		{
+			name:     "musl over gnu",
+			assets:   []string{"wac-cli-x86_64-unknown-linux-gnu", "wac-cli-x86_64-unknown-linux-musl"},
+			platform: "linux_amd64",
+			want:     "wac-cli-x86_64-unknown-linux-musl",
+		},
+		{
+			name:     "musl over unspecified",
+			assets:   []string{"tool-aarch64-linux.tar.gz", "tool-aarch64-linux-musl.tar.gz"},
+			platform: "linux_arm64",
+			want:     "tool-aarch64-linux-musl.tar.gz",
+		},
+		{
+			name:     "unspecified over gnu",
+			assets:   []string{"tool-x86_64-linux-gnu.tar.gz", "tool-x86_64-linux.tar.gz"},
+			platform: "linux_amd64",
+			want:     "tool-x86_64-linux.tar.gz",
+		},
+		{
+			name:     "musl ignored off Linux",
+			assets:   []string{"wac-cli-x86_64-unknown-linux-musl", "wac-cli-x86_64-apple-darwin"},
+			platform: "darwin_amd64",
+			want:     "wac-cli-x86_64-apple-darwin",
+		},
+		{
+			name:     "musl for the wrong architecture",
+			assets:   []string{"wac-cli-aarch64-unknown-linux-musl", "wac-cli-x86_64-unknown-linux-gnu"},
+			platform: "linux_amd64",
+			want:     "wac-cli-x86_64-unknown-linux-gnu",
+		},
+		{
+			name:     "apple silicon over universal",
+			assets:   []string{"tool-universal-apple-darwin.tar.gz", "tool-aarch64-apple-darwin.tar.gz"},
+			platform: "darwin_arm64",
+			want:     "tool-aarch64-apple-darwin.tar.gz",
+		},
+		{
+			name:     "intel over universal",
+			assets:   []string{"tool-universal-apple-darwin.tar.gz", "tool-x86_64-apple-darwin.tar.gz", "tool-aarch64-apple-darwin.tar.gz"},
+			platform: "darwin_amd64",
+			want:     "tool-x86_64-apple-darwin.tar.gz",
+		},
+		{
+			name:     "universal when nothing else fits",
+			assets:   []string{"tool-universal-macos.zip", "tool-aarch64-apple-darwin.tar.gz"},
+			platform: "darwin_amd64",
+			want:     "tool-universal-macos.zip",
+		},
+		{
+			name:     "ties go to the earlier asset",
+			assets:   []string{"tool-x86_64-pc-windows-msvc.zip", "tool-x86_64-pc-windows-gnu.zip"},
+			platform: "windows_amd64",
+			want:     "tool-x86_64-pc-windows-msvc.zip",
+		},
+		{
+			name:     "no fit",
+			assets:   []string{"tool-x86_64-linux.tar.gz", "tool-x86_64-linux.tar.gz.sha256"},
+			platform: "linux_arm64",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			if i := SelectAsset(tt.assets, tt.platform); i >= 0 {
+				got = tt.assets[i]
+			}
+			if got != tt.want {
+				t.Errorf("SelectAsset(%s) = %q, want %q", tt.platform, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyAsset(t *testing.T) {
+	tests := []struct {
+		name string
+		want AssetMatch
+	}{
+		{name: "wac-cli-x86_64-unknown-linux-musl", want: AssetMatch{OS: "linux", Arch: "amd64", Libc: "musl", Supported: true}},
+		{name: "wkg-aarch64-unknown-linux-gnu", want: AssetMatch{OS: "linux", Arch: "arm64", Libc: "gnu", Supported: true}},
+		{name: "wasm-tools-1.246.2-x86_64-linux.tar.gz", want: AssetMatch{OS: "linux", Arch: "amd64", Supported: true}},
+		{name: "wkg-x86_64-pc-windows-gnu", want: AssetMatch{OS: "windows", Arch: "amd64", Supported: true}},
+		{name: "tool-universal-apple-darwin.tar.gz", want: AssetMatch{OS: "darwin", Arch: "universal", Supported: true}},
+		{name: "tool.exe", want: AssetMatch{}},
+		{name: "tool-universal-linux.tar.gz", want: AssetMatch{}},
+		{name: "wac-cli-x86_64-unknown-linux-musl.sha256", want: AssetMatch{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyAsset(tt.name); got != tt.want {
+				t.Errorf("ClassifyAsset(%q) = %+v, want %+v", tt.name, got, tt.want)
+			}
+		})
+	}
+
+	// Universal macOS binaries report Apple silicon, and rank lower than an
+	// exact match on either architecture
+	if got := AssetPlatform("tool-universal-apple-darwin.tar.gz"); got != "darwin_arm64" {
+		t.Errorf("AssetPlatform of a universal binary = %q, want darwin_arm64", got)
+	}
+	universal := AssetRank("tool-universal-apple-darwin.tar.gz", "darwin_amd64")
+	exact := AssetRank("tool-x86_64-apple-darwin.tar.gz", "darwin_amd64")
+	if universal == 0 || universal >= exact {
+		t.Errorf("universal rank %d, exact rank %d: want 0 < universal < exact", universal, exact)
+	}
+}
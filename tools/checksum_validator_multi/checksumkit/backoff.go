@@ -0,0 +1,135 @@
+package checksumkit
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Backoff strategies for retrying GitHub API calls. Full jitter spreads
+// parallel retries out so rate-limited callers do not all come back at once.
+const (
+	BackoffConstant          = "constant"
+	BackoffExponential       = "exponential"
+	BackoffExponentialJitter = "exponential-jitter"
+)
+
+// BackoffConfig controls how rate-limited and failed requests are retried
+type BackoffConfig struct {
+	Strategy string
+	Base     time.Duration // Wait before the first retry
+	Max      time.Duration // Upper bound on a computed wait
+	Retries  int           // Retries after the first attempt
+}
+
+// Backoff is shared by every GitHub API caller and by file downloads
+var Backoff = BackoffConfig{
+	Strategy: BackoffExponentialJitter,
+	Base:     time.Second,
+	Max:      time.Minute,
+	Retries:  3,
+}
+
+// sleep waits between attempts; tests replace it to record the waits
+var sleep = time.Sleep
+
+// maxServerWait bounds a wait requested by the server, so a rate limit reset
+// an hour away fails the call instead of stalling it
+const maxServerWait = 15 * time.Minute
+
+// ValidBackoffStrategy reports whether strategy names a known strategy
+func ValidBackoffStrategy(strategy string) bool {
+	switch strategy {
+	case BackoffConstant, BackoffExponential, BackoffExponentialJitter:
+		return true
+	}
+	return false
+}
+
+// delay is the computed wait before retry number attempt (0 for the first
+// retry). With full jitter it is uniform in [0, exponential delay].
+func (c BackoffConfig) delay(attempt int) time.Duration {
+	if c.Strategy == BackoffConstant {
+		return c.Base
+	}
+
+	wait := c.Max
+	if attempt < 62 && c.Base<<attempt > 0 && c.Base<<attempt < c.Max {
+		wait = c.Base << attempt
+	}
+
+	if c.Strategy == BackoffExponentialJitter && wait > 0 {
+		wait = time.Duration(rand.Int63n(int64(wait) + 1))
+	}
+	return wait
+}
+
+// serverWait reads how long the server asked us to wait: Retry-After as
+// seconds or an HTTP date, or X-RateLimit-Reset once the rate limit is
+// exhausted. It reports false when the response carries neither.
+func serverWait(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil {
+		return 0, false
+	}
+
+	if value := resp.Header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+			return time.Duration(seconds) * time.Second, true
+		}
+		if at, err := http.ParseTime(value); err == nil {
+			return max(at.Sub(now), 0), true
+		}
+	}
+
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(now), 0), true
+		}
+	}
+
+	return 0, false
+}
+
+// retryable reports whether a failed call is worth repeating: transport
+// errors, 429, 5xx, and 403s caused by an exhausted rate limit
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return true
+	case resp.StatusCode == http.StatusForbidden:
+		return resp.Header.Get("X-RateLimit-Remaining") == "0"
+	}
+	return false
+}
+
+// RetryWithBackoff calls send until it returns a response that is not
+// retryable or the retries run out. A server-requested wait takes precedence
+// over the computed one.
+func RetryWithBackoff(send func() (*http.Response, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := send()
+		if attempt >= Backoff.Retries || !retryable(resp, err) {
+			return resp, err
+		}
+
+		wait, fromServer := serverWait(resp, time.Now())
+		if !fromServer {
+			wait = Backoff.delay(attempt)
+		} else if wait > maxServerWait {
+			return resp, err
+		}
+
+		reason := fmt.Sprint(err)
+		if resp != nil {
+			reason = resp.Status
+			resp.Body.Close()
+		}
+		Warnf("⏳ %s, retrying in %s (%d/%d)", reason, wait.Round(time.Millisecond), attempt+1, Backoff.Retries)
+		sleep(wait)
+	}
+}
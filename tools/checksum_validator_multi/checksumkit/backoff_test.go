@@ -0,0 +1,213 @@
+package checksumkit
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// withBackoff installs config and a sleep that records its waits instead
+// of sleeping, restoring both when the test ends
+func withBackoff(t *testing.T, config BackoffConfig) *[]time.Duration {
+	t.Helper()
+	savedBackoff, savedSleep, savedOutput := Backoff, sleep, logOutput
+	t.Cleanup(func() { Backoff, sleep, logOutput = savedBackoff, savedSleep, savedOutput })
+
+	var waits []time.Duration
+	Backoff = config
+	sleep = func(d time.Duration) { waits = append(waits, d) }
+	logOutput = io.Discard
+	return &waits
+}
+
+// scriptedServer answers the nth request with the nth response, repeating
+// the last one once the script runs out
+type scriptedResponse struct {
+	status  int
+	headers map[string]string
+}
+
+func scriptedServer(t *testing.T, script ...scriptedResponse) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(calls.Add(1)) - 1
+		response := script[min(n, len(script)-1)]
+		for key, value := range response.headers {
+			w.Header().Set(key, value)
+		}
+		w.WriteHeader(response.status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	const base = 5 * time.Millisecond
+	rateLimited := map[string]string{"X-RateLimit-Remaining": "0"}
+	ok := scriptedResponse{status: http.StatusOK}
+
+	tests := []struct {
+		name       string
+		script     []scriptedResponse
+		wantStatus int
+		wantCalls  int32
+		wantWaits  []time.Duration
+	}{
+		{
+			name:       "rate-limited 403",
+			script:     []scriptedResponse{{status: http.StatusForbidden, headers: rateLimited}, ok},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+			wantWaits:  []time.Duration{base},
+		},
+		{
+			name:       "429",
+			script:     []scriptedResponse{{status: http.StatusTooManyRequests}, ok},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+			wantWaits:  []time.Duration{base},
+		},
+		{
+			name:       "5xx",
+			script:     []scriptedResponse{{status: http.StatusInternalServerError}, {status: http.StatusBadGateway}, ok},
+			wantStatus: http.StatusOK,
+			wantCalls:  3,
+			wantWaits:  []time.Duration{base, base},
+		},
+		{
+			name:       "Retry-After seconds",
+			script:     []scriptedResponse{{status: http.StatusTooManyRequests, headers: map[string]string{"Retry-After": "7"}}, ok},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+			wantWaits:  []time.Duration{7 * time.Second},
+		},
+		{
+			name:       "Retry-After in the past",
+			script:     []scriptedResponse{{status: http.StatusServiceUnavailable, headers: map[string]string{"Retry-After": "Mon, 02 Jan 2006 15:04:05 GMT"}}, ok},
+			wantStatus: http.StatusOK,
+			wantCalls:  2,
+			wantWaits:  []time.Duration{0},
+		},
+		{
+			name:       "404 is not retried",
+			script:     []scriptedResponse{{status: http.StatusNotFound}, ok},
+			wantStatus: http.StatusNotFound,
+			wantCalls:  1,
+		},
+		{
+			name:       "403 without an exhausted rate limit is not retried",
+			script:     []scriptedResponse{{status: http.StatusForbidden, headers: map[string]string{"X-RateLimit-Remaining": "12"}}, ok},
+			wantStatus: http.StatusForbidden,
+			wantCalls:  1,
+		},
+		{
+			name:       "attempt cap",
+			script:     []scriptedResponse{{status: http.StatusInternalServerError}},
+			wantStatus: http.StatusInternalServerError,
+			wantCalls:  4,
+			wantWaits:  []time.Duration{base, base, base},
+		},
+		{
+			name:       "reset too far away",
+			script:     []scriptedResponse{{status: http.StatusForbidden, headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)}}, ok},
+			wantStatus: http.StatusForbidden,
+			wantCalls:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waits := withBackoff(t, BackoffConfig{Strategy: BackoffConstant, Base: base, Retries: 3})
+			server, calls := scriptedServer(t, tt.script...)
+
+			resp, err := RetryWithBackoff(func() (*http.Response, error) { return http.Get(server.URL) })
+			if err != nil {
+				t.Fatalf("RetryWithBackoff: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("%d requests, want %d", got, tt.wantCalls)
+			}
+			if len(*waits)+len(tt.wantWaits) > 0 && !reflect.DeepEqual(*waits, tt.wantWaits) {
+				t.Errorf("waits = %v, want %v", *waits, tt.wantWaits)
+			}
+		})
+	}
+}
+
+// TestRetryWithBackoffRateLimitReset checks the wait follows the reset time
+// GitHub sends with an exhausted rate limit
+func TestRetryWithBackoffRateLimitReset(t *testing.T) {
+	waits := withBackoff(t, BackoffConfig{Strategy: BackoffConstant, Base: time.Millisecond, Retries: 3})
+	reset := time.Now().Add(2 * time.Minute)
+	server, calls := scriptedServer(t,
+		scriptedResponse{status: http.StatusForbidden, headers: map[string]string{
+			"X-RateLimit-Remaining": "0",
+			"X-RateLimit-Reset":     strconv.FormatInt(reset.Unix(), 10),
+		}},
+		scriptedResponse{status: http.StatusOK},
+	)
+
+	resp, err := RetryWithBackoff(func() (*http.Response, error) { return http.Get(server.URL) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || calls.Load() != 2 {
+		t.Fatalf("status %d after %d requests, want 200 after 2", resp.StatusCode, calls.Load())
+	}
+	// The header has whole seconds, so the wait is up to a second short
+	if len(*waits) != 1 || (*waits)[0] <= time.Minute || (*waits)[0] > 2*time.Minute {
+		t.Errorf("waits = %v, want one of about 2m", *waits)
+	}
+}
+
+func TestRetryWithBackoffTransportErrors(t *testing.T) {
+	waits := withBackoff(t, BackoffConfig{Strategy: BackoffConstant, Base: time.Millisecond, Retries: 2})
+	failure := errors.New("connection reset")
+
+	var calls int
+	_, err := RetryWithBackoff(func() (*http.Response, error) {
+		calls++
+		return nil, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("error = %v, want the transport error", err)
+	}
+	if calls != 3 || len(*waits) != 2 {
+		t.Errorf("%d calls and %d waits, want 3 and 2", calls, len(*waits))
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	exponential := BackoffConfig{Strategy: BackoffExponential, Base: time.Second, Max: 10 * time.Second}
+	for attempt, want := range map[int]time.Duration{0: time.Second, 1: 2 * time.Second, 3: 8 * time.Second, 4: 10 * time.Second, 70: 10 * time.Second} {
+		if got := exponential.delay(attempt); got != want {
+			t.Errorf("exponential delay(%d) = %s, want %s", attempt, got, want)
+		}
+	}
+
+	jitter := BackoffConfig{Strategy: BackoffExponentialJitter, Base: time.Second, Max: 10 * time.Second}
+	for i := 0; i < 100; i++ {
+		if got := jitter.delay(2); got < 0 || got > 4*time.Second {
+			t.Fatalf("jittered delay(2) = %s, want within [0, 4s]", got)
+		}
+	}
+
+	constant := BackoffConfig{Strategy: BackoffConstant, Base: 3 * time.Second, Max: time.Second}
+	if got := constant.delay(5); got != 3*time.Second {
+		t.Errorf("constant delay(5) = %s, want the base", got)
+	}
+}
@@ -0,0 +1,53 @@
+package checksumkit
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// Result is the outcome of one item processed by RunBounded
+type Result[R any] struct {
+	Value R
+	Err   error
+}
+
+// RunBounded applies fn to every item with at most limit calls in flight.
+// Results come back in input order whatever order the calls finish in, and
+// a panic in fn becomes that item's error instead of crashing the process.
+func RunBounded[T, R any](items []T, limit int, fn func(T) (R, error)) []Result[R] {
+	results := make([]Result[R], len(items))
+	sem := make(chan struct{}, max(limit, 1))
+	var wg sync.WaitGroup
+
+	for i, item := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, item T) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			defer func() {
+				if r := recover(); r != nil {
+					results[i].Err = fmt.Errorf("panic: %v\n%s", r, debug.Stack())
+				}
+			}()
+
+			results[i].Value, results[i].Err = fn(item)
+		}(i, item)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// JoinErrors combines the errors of a RunBounded call, nil if none failed
+func JoinErrors[R any](results []Result[R]) error {
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,91 @@
+package checksumkit
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBoundedOrder(t *testing.T) {
+	items := make([]int, 20)
+	for i := range items {
+		items[i] = i
+	}
+
+	for _, limit := range []int{0, 1, 3, 20, 50} {
+		t.Run(fmt.Sprintf("limit=%d", limit), func(t *testing.T) {
+			var inFlight, peak atomic.Int32
+			results := RunBounded(items, limit, func(n int) (string, error) {
+				current := inFlight.Add(1)
+				defer inFlight.Add(-1)
+				for {
+					seen := peak.Load()
+					if current <= seen || peak.CompareAndSwap(seen, current) {
+						break
+					}
+				}
+
+				// Later items finish first, so completion order is reversed
+				time.Sleep(time.Duration(len(items)-n) * time.Millisecond)
+				if n%7 == 3 {
+					return "", fmt.Errorf("item %d failed", n)
+				}
+				return fmt.Sprintf("item-%d", n), nil
+			})
+
+			if len(results) != len(items) {
+				t.Fatalf("got %d results, want %d", len(results), len(items))
+			}
+			for i, result := range results {
+				if i%7 == 3 {
+					if result.Err == nil || result.Err.Error() != fmt.Sprintf("item %d failed", i) {
+						t.Errorf("result %d error = %v, want item %d's error", i, result.Err, i)
+					}
+					continue
+				}
+				if result.Err != nil || result.Value != fmt.Sprintf("item-%d", i) {
+					t.Errorf("result %d = %q, %v; want item-%d", i, result.Value, result.Err, i)
+				}
+			}
+
+			if want := int32(max(limit, 1)); peak.Load() > want {
+				t.Errorf("%d calls in flight, limit %d", peak.Load(), want)
+			}
+		})
+	}
+}
+
+func TestRunBoundedPanic(t *testing.T) {
+	results := RunBounded([]string{"ok", "boom", "also ok"}, 2, func(s string) (int, error) {
+		if s == "boom" {
+			panic("worker exploded")
+		}
+		return len(s), nil
+	})
+
+	if results[0].Err != nil || results[0].Value != 2 {
+		t.Errorf("result 0 = %v, %v; want 2", results[0].Value, results[0].Err)
+	}
+	if results[2].Err != nil || results[2].Value != 7 {
+		t.Errorf("result 2 = %v, %v; want 7", results[2].Value, results[2].Err)
+	}
+	if results[1].Err == nil || !strings.Contains(results[1].Err.Error(), "panic: worker exploded") {
+		t.Errorf("result 1 error = %v, want the recovered panic", results[1].Err)
+	}
+}
+
+func TestJoinErrors(t *testing.T) {
+	first, second := errors.New("first"), errors.New("second")
+	results := []Result[int]{{Value: 1}, {Err: first}, {Value: 3}, {Err: second}}
+
+	err := JoinErrors(results)
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Errorf("JoinErrors = %v, want both errors", err)
+	}
+	if err := JoinErrors(results[:1]); err != nil {
+		t.Errorf("JoinErrors with no failures = %v, want nil", err)
+	}
+}
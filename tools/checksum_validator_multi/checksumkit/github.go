@@ -0,0 +1,47 @@
+package checksumkit
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitHubAPIBase is the root of the GitHub REST API. Both tools override it
+// from GITHUB_API_BASE or --github-api-base for GitHub Enterprise Server,
+// which serves the API elsewhere, e.g. https://ghe.example.com/api/v3.
+var GitHubAPIBase = "https://api.github.com"
+
+// LatestReleaseURL is the API endpoint describing repo's latest release
+func LatestReleaseURL(repo string) string {
+	return fmt.Sprintf("%s/repos/%s/releases/latest", strings.TrimSuffix(GitHubAPIBase, "/"), repo)
+}
+
+// AuthorizeGitHub attaches GITHUB_TOKEN, when set, to a GitHub API request.
+// The token is optional: unauthenticated calls work but share the 60
+// requests per hour limit, which CI runners exhaust quickly.
+func AuthorizeGitHub(req *http.Request) {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// GitHubAPIError describes a failed GitHub API response, saying when the
+// rate limit resets rather than reporting a bare 403 or 429
+func GitHubAPIError(resp *http.Response) error {
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return fmt.Errorf("GitHub API error: %s", resp.Status)
+	}
+
+	hint := ""
+	if os.Getenv("GITHUB_TOKEN") == "" {
+		hint = "; set GITHUB_TOKEN for a higher limit"
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("GitHub API rate limited (%s)%s", resp.Status, hint)
+	}
+	return fmt.Errorf("GitHub API rate limited, resets at %s%s", time.Unix(reset, 0).Format(time.RFC3339), hint)
+}
@@ -0,0 +1,199 @@
+package checksumkit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// hashSizes cross the buffer sizes used below and the page size
+var hashSizes = []int{0, 1, 4095, 4096, 1<<20 - 1, 1 << 20, 3<<20 + 7}
+
+// withHashSettings sets the hashing strategy for the rest of the test
+func withHashSettings(tb testing.TB, bufferSize int, useMmap bool) {
+	tb.Helper()
+	oldSize, oldMmap := HashBufferSize, HashUseMmap
+	HashBufferSize, HashUseMmap = bufferSize, useMmap
+	tb.Cleanup(func() { HashBufferSize, HashUseMmap = oldSize, oldMmap })
+}
+
+// writeHashInput writes size bytes of a non-repeating pattern to a temp file
+func writeHashInput(tb testing.TB, size int) (string, []byte) {
+	tb.Helper()
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i*7 + i>>8)
+	}
+	path := filepath.Join(tb.TempDir(), fmt.Sprintf("input-%d", size))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		tb.Fatal(err)
+	}
+	return path, data
+}
+
+// hashStrategies are the ways HashFile can read a file
+var hashStrategies = []struct {
+	name       string
+	bufferSize int
+	useMmap    bool
+}{
+	{name: "buffered-1MiB", bufferSize: 1 << 20},
+	{name: "buffered-4KiB", bufferSize: 4 << 10},
+	{name: "buffered-16B", bufferSize: 16},
+	{name: "mmap", bufferSize: 1 << 20, useMmap: true},
+}
+
+func TestHashFileStrategiesAgree(t *testing.T) {
+	for _, size := range hashSizes {
+		path, data := writeHashInput(t, size)
+		sum := sha256.Sum256(data)
+		want := hex.EncodeToString(sum[:])
+
+		for _, strategy := range hashStrategies {
+			t.Run(fmt.Sprintf("%s/%d", strategy.name, size), func(t *testing.T) {
+				withHashSettings(t, strategy.bufferSize, strategy.useMmap)
+
+				digest, n, err := HashFile(path, sha256.New())
+				if err != nil {
+					t.Fatalf("HashFile: %v", err)
+				}
+				if digest != want || n != int64(size) {
+					t.Errorf("HashFile = %s, %d, want %s, %d", digest, n, want, size)
+				}
+			})
+		}
+	}
+}
+
+// TestMmapFile checks that the mmap strategy really maps files where it is
+// supported, so TestHashFileStrategiesAgree is not comparing the buffered
+// path with itself
+func TestMmapFile(t *testing.T) {
+	path, data := writeHashInput(t, 3<<20+7)
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	mapped, unmap, ok := mmapFile(file)
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" {
+		if ok {
+			unmap()
+			t.Fatalf("mmapFile succeeded on %s", runtime.GOOS)
+		}
+		return
+	}
+	if !ok {
+		t.Fatalf("mmapFile failed on %s", runtime.GOOS)
+	}
+	defer unmap()
+	if !bytes.Equal(mapped, data) {
+		t.Error("mapped bytes differ from the file")
+	}
+
+	// Empty files cannot be mapped and fall back to buffered reads
+	empty, _ := writeHashInput(t, 0)
+	emptyFile, err := os.Open(empty)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer emptyFile.Close()
+	if _, _, ok := mmapFile(emptyFile); ok {
+		t.Error("mmapFile mapped an empty file")
+	}
+}
+
+func TestHashFileMissing(t *testing.T) {
+	for _, useMmap := range []bool{false, true} {
+		withHashSettings(t, 1<<20, useMmap)
+		if _, _, err := HashFile(filepath.Join(t.TempDir(), "missing"), sha256.New()); !os.IsNotExist(err) {
+			t.Errorf("mmap=%v: err = %v, want not-exist", useMmap, err)
+		}
+	}
+}
+
+// BenchmarkHashFile compares the buffered and mmap paths across file sizes.
+// Run with -benchtime to taste; the files are in the page cache after the
+// first iteration, so this measures hashing rather than the disk.
+func BenchmarkHashFile(b *testing.B) {
+	for _, size := range []int{4 << 10, 1 << 20, 16 << 20, 64 << 20} {
+		path, _ := writeHashInput(b, size)
+		for _, strategy := range []struct {
+			name    string
+			useMmap bool
+		}{{"buffered", false}, {"mmap", true}} {
+			b.Run(fmt.Sprintf("%s/%s", strategy.name, formatSize(size)), func(b *testing.B) {
+				withHashSettings(b, 1<<20, strategy.useMmap)
+				b.SetBytes(int64(size))
+				for i := 0; i < b.N; i++ {
+					if _, _, err := HashFile(path, sha256.New()); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkHashReader compares HashReader's bufio reader at several buffer
+// sizes with a plain io.Copy, which reads in 32 KiB chunks
+func BenchmarkHashReader(b *testing.B) {
+	for _, size := range []int{1 << 20, 16 << 20, 64 << 20} {
+		path, _ := writeHashInput(b, size)
+		file, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Cleanup(func() { file.Close() })
+
+		rewind := func(b *testing.B) {
+			if _, err := file.Seek(0, io.SeekStart); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		b.Run("io.Copy/"+formatSize(size), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				rewind(b)
+				// onlyReader hides *os.File's WriteTo so io.Copy uses its own buffer
+				if _, err := io.Copy(sha256.New(), onlyReader{file}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		for _, bufferSize := range []int{32 << 10, 256 << 10, 1 << 20, 4 << 20} {
+			b.Run(fmt.Sprintf("bufio-%s/%s", formatSize(bufferSize), formatSize(size)), func(b *testing.B) {
+				withHashSettings(b, bufferSize, false)
+				b.SetBytes(int64(size))
+				for i := 0; i < b.N; i++ {
+					rewind(b)
+					if _, _, err := HashReader(file, sha256.New()); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
+
+type onlyReader struct{ r io.Reader }
+
+func (o onlyReader) Read(p []byte) (int, error) { return o.r.Read(p) }
+
+func formatSize(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%dMiB", n>>20)
+	case n >= 1<<10:
+		return fmt.Sprintf("%dKiB", n>>10)
+	}
+	return fmt.Sprintf("%dB", n)
+}
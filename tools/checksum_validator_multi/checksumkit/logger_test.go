@@ -0,0 +1,145 @@
+package checksumkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withLogBuffer routes log output into a buffer and restores the logging
+// settings when the test ends
+func withLogBuffer(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	savedThreshold, savedFormat, savedOutput := logThreshold, logFormat, logOutput
+	t.Cleanup(func() { logThreshold, logFormat, logOutput = savedThreshold, savedFormat, savedOutput })
+
+	var buf bytes.Buffer
+	logThreshold, logFormat, logOutput = levelInfo, LogFormatText, &buf
+	return &buf
+}
+
+// logEveryLevel prints one message at each level
+func logEveryLevel() {
+	Debugf("🔍 debug %d", 1)
+	Infof("📥 info %d &", 2)
+	Warnf("⚠️  warn %d\n", 3)
+	Errorf("❌ error %d", 4)
+}
+
+func logLines(buf *bytes.Buffer) []string {
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+func TestLogLevels(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+		want  []string
+	}{
+		{name: "default", want: []string{"📥 info 2 &", "⚠️  warn 3", "❌ error 4"}},
+		{name: "debug", flags: []string{"--log-level=debug"}, want: []string{"🔍 debug 1", "📥 info 2 &", "⚠️  warn 3", "❌ error 4"}},
+		{name: "warn", flags: []string{"--log-level=WARN"}, want: []string{"⚠️  warn 3", "❌ error 4"}},
+		{name: "error", flags: []string{"--log-level=error"}, want: []string{"❌ error 4"}},
+		{name: "quiet", flags: []string{"--quiet"}, want: []string{"⚠️  warn 3", "❌ error 4"}},
+		{name: "later flag wins", flags: []string{"--quiet", "--log-level=info"}, want: []string{"📥 info 2 &", "⚠️  warn 3", "❌ error 4"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := withLogBuffer(t)
+			for _, flag := range tt.flags {
+				if matched, err := ApplyLogFlag(flag); !matched || err != nil {
+					t.Fatalf("ApplyLogFlag(%s) = %v, %v", flag, matched, err)
+				}
+			}
+
+			logEveryLevel()
+			if got := logLines(buf); strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("output lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogFormatJSON(t *testing.T) {
+	buf := withLogBuffer(t)
+	for _, flag := range []string{"--log-format=json", "--quiet"} {
+		if _, err := ApplyLogFlag(flag); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	before := time.Now().Add(-time.Second)
+	logEveryLevel()
+
+	want := []logEntry{{Level: "warn", Message: "⚠️  warn 3"}, {Level: "error", Message: "❌ error 4"}}
+	lines := logLines(buf)
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), buf)
+	}
+	for i, line := range lines {
+		var fields map[string]any
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			t.Fatalf("line %q is not JSON: %v", line, err)
+		}
+		if len(fields) != 3 {
+			t.Errorf("line %q has fields %v, want time, level and msg", line, fields)
+		}
+
+		var entry logEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatal(err)
+		}
+		if entry.Level != want[i].Level || entry.Message != want[i].Message {
+			t.Errorf("line %d = %s %q, want %s %q", i, entry.Level, entry.Message, want[i].Level, want[i].Message)
+		}
+		if at, err := time.Parse(time.RFC3339Nano, entry.Time); err != nil || at.Before(before) {
+			t.Errorf("line %d time = %q, want a current RFC 3339 time", i, entry.Time)
+		}
+	}
+
+	// Messages are not HTML-escaped, so they stay readable in raw logs
+	buf.Reset()
+	Errorf("❌ fetch <url> & retry")
+	if !strings.Contains(buf.String(), `"msg":"❌ fetch <url> & retry"`) {
+		t.Errorf("line = %q, want the message unescaped", buf)
+	}
+}
+
+func TestApplyLogFlagRejects(t *testing.T) {
+	withLogBuffer(t)
+	for _, flag := range []string{"--log-level=verbose", "--log-format=xml"} {
+		if matched, err := ApplyLogFlag(flag); !matched || err == nil {
+			t.Errorf("ApplyLogFlag(%s) = %v, %v, want a matched flag with an error", flag, matched, err)
+		}
+	}
+	for _, arg := range []string{"--quieter", "--log-levels=warn", "wasm-tools"} {
+		if matched, _ := ApplyLogFlag(arg); matched {
+			t.Errorf("ApplyLogFlag(%s) matched an unrelated argument", arg)
+		}
+	}
+	if logThreshold != levelInfo || logFormat != LogFormatText {
+		t.Errorf("rejected flags changed the settings to %s/%s", logThreshold, logFormat)
+	}
+}
+
+func TestApplyLogEnv(t *testing.T) {
+	buf := withLogBuffer(t)
+	t.Setenv("LOG_LEVEL", "error")
+	t.Setenv("LOG_FORMAT", "json")
+	if err := ApplyLogEnv(); err != nil {
+		t.Fatal(err)
+	}
+
+	logEveryLevel()
+	if !strings.HasPrefix(buf.String(), `{"time":`) || strings.Count(buf.String(), "\n") != 1 {
+		t.Errorf("output = %q, want one JSON error line", buf)
+	}
+
+	t.Setenv("LOG_FORMAT", "yaml")
+	if err := ApplyLogEnv(); err == nil || !strings.Contains(err.Error(), "LOG_FORMAT") {
+		t.Errorf("ApplyLogEnv error = %v, want one naming LOG_FORMAT", err)
+	}
+}
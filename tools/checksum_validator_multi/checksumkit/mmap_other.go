@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package checksumkit
+
+import "os"
+
+// mmapFile is not implemented on this platform (including WASI), so hashing
+// always uses buffered reads
+func mmapFile(file *os.File) ([]byte, func(), bool) {
+	return nil, nil, false
+}
@@ -0,0 +1,23 @@
+//go:build linux || darwin
+
+package checksumkit
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile maps file read-only, returning false when it cannot be mapped so
+// the caller falls back to buffered reads
+func mmapFile(file *os.File) ([]byte, func(), bool) {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 || int64(int(info.Size())) != info.Size() {
+		return nil, nil, false
+	}
+
+	data, err := syscall.Mmap(int(file.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, false
+	}
+	return data, func() { syscall.Munmap(data) }, true
+}
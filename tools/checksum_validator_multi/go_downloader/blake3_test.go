@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// blake3Vectors are from test_vectors.json in the BLAKE3 repository: the
+// default 32-byte hash of input_len bytes of the repeating sequence 0..250.
+// The lengths cross the block (64) and chunk (1024) boundaries and build
+// trees a few levels deep.
+var blake3Vectors = []struct {
+	inputLen int
+	hash     string
+}{
+	{0, "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+	{1, "2d3adedff11b61f14c886e35afa036736dcd87a74d27b5c1510225d0f592e213"},
+	{1023, "10108970eeda3eb932baac1428c7a2163b0e924c9a9e25b35bba72b28f70bd11"},
+	{1024, "42214739f095a406f3fc83deb889744ac00df831c10daa55189b5d121c855af7"},
+	{1025, "d00278ae47eb27b34faecf67b4fe263f82d5412916c1ffd97c8cb7fb814b8444"},
+	{2048, "e776b6028c7cd22a4d0ba182a8bf62205d2ef576467e838ed6f2529b85fba24a"},
+	{2049, "5f4d72f40d7a5f82b15ca2b2e44b1de3c2ef86c426c95c1af0b6879522563030"},
+	{3072, "b98cb0ff3623be03326b373de6b9095218513e64f1ee2edd2525c7ad1e5cffd2"},
+	{4097, "9b4052b38f1c5fc8b1f9ff7ac7b27cd242487b3d890d15c96a1c25b8aa0fb995"},
+	{8193, "bab6c09cb8ce8cf459261398d2e7aef35700bf488116ceb94a36d0f5f1b7bc3b"},
+	{31744, "62b6960e1a44bcc1eb1a611a8d6235b6b4b78f32e7abc4fb4c6cdcce94895c47"},
+	{100000, "d93c23eedaf165a7e0be908ba86f1a7a520d568d2d13cde787c8580c5c72cc54"},
+}
+
+// blake3VectorInput is the test vector input of length n
+func blake3VectorInput(n int) []byte {
+	input := make([]byte, n)
+	for i := range input {
+		input[i] = byte(i % 251)
+	}
+	return input
+}
+
+func TestBLAKE3Vectors(t *testing.T) {
+	for _, tt := range blake3Vectors {
+		input := blake3VectorInput(tt.inputLen)
+
+		h := newBLAKE3()
+		h.Write(input)
+		if got := hex.EncodeToString(h.Sum(nil)); got != tt.hash {
+			t.Errorf("BLAKE3 of %d bytes = %s, want %s", tt.inputLen, got, tt.hash)
+		}
+
+		// Writes that split blocks and chunks unevenly hash the same
+		for _, step := range []int{1, 63, 65, 1000, 1025} {
+			h.Reset()
+			for rest := input; len(rest) > 0; {
+				n := min(step, len(rest))
+				h.Write(rest[:n])
+				rest = rest[n:]
+			}
+			if got := hex.EncodeToString(h.Sum(nil)); got != tt.hash {
+				t.Errorf("BLAKE3 of %d bytes in %d-byte writes = %s, want %s", tt.inputLen, step, got, tt.hash)
+			}
+		}
+	}
+}
+
+func TestBLAKE3HashInterface(t *testing.T) {
+	h := newBLAKE3()
+	if h.Size() != 32 || h.BlockSize() != 64 {
+		t.Errorf("Size %d, BlockSize %d, want 32 and 64", h.Size(), h.BlockSize())
+	}
+
+	// Sum appends and leaves the state alone, so writing can continue
+	h.Write(blake3VectorInput(1024))
+	prefix := []byte("digest:")
+	first := h.Sum(prefix)
+	if !bytes.HasPrefix(first, prefix) || len(first) != len(prefix)+32 {
+		t.Fatalf("Sum(prefix) = %x, want the prefix followed by 32 bytes", first)
+	}
+	if got := hex.EncodeToString(first[len(prefix):]); got != blake3Vectors[3].hash {
+		t.Errorf("Sum after 1024 bytes = %s, want %s", got, blake3Vectors[3].hash)
+	}
+	h.Write(blake3VectorInput(1025)[1024:])
+	if got := hex.EncodeToString(h.Sum(nil)); got != blake3Vectors[4].hash {
+		t.Errorf("Sum after writing on = %s, want the 1025-byte hash %s", got, blake3Vectors[4].hash)
+	}
+}
+
+func TestResolveAlgorithm(t *testing.T) {
+	sha256Hex := "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"
+	sha512Hex := sha256Hex + sha256Hex
+
+	tests := []struct {
+		name       string
+		algo       string
+		expected   string
+		want       string
+		wantDigest string
+		wantErr    bool
+	}{
+		{name: "64 hex characters", expected: sha256Hex, want: algoSHA256, wantDigest: sha256Hex},
+		{name: "128 hex characters", expected: sha512Hex, want: algoSHA512, wantDigest: sha512Hex},
+		{name: "other lengths default to sha256", expected: "abc", want: algoSHA256, wantDigest: "abc"},
+		{name: "explicit blake3 for 64 characters", algo: algoBLAKE3, expected: sha256Hex, want: algoBLAKE3, wantDigest: sha256Hex},
+		{name: "explicit sha256 beats the length", algo: algoSHA256, expected: sha512Hex, want: algoSHA256, wantDigest: sha512Hex},
+		{name: "explicit sha512", algo: algoSHA512, expected: sha256Hex, want: algoSHA512, wantDigest: sha256Hex},
+		{name: "blake3 prefix", expected: "blake3:" + sha256Hex, want: algoBLAKE3, wantDigest: sha256Hex},
+		{name: "sha256 prefix", expected: "sha256:" + sha256Hex, want: algoSHA256, wantDigest: sha256Hex},
+		{name: "sha512 prefix", expected: "sha512:" + sha512Hex, want: algoSHA512, wantDigest: sha512Hex},
+		{name: "prefix beats the length", expected: "sha256:" + sha512Hex, want: algoSHA256, wantDigest: sha512Hex},
+		{name: "uppercase prefix", expected: "BLAKE3:" + sha256Hex, want: algoBLAKE3, wantDigest: sha256Hex},
+		{name: "prefix matching algo", algo: algoBLAKE3, expected: "blake3:" + sha256Hex, want: algoBLAKE3, wantDigest: sha256Hex},
+		{name: "prefix conflicting with algo", algo: algoSHA256, expected: "blake3:" + sha256Hex, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, digest, err := resolveAlgorithm(tt.algo, tt.expected)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolveAlgorithm(%q, %q) = %q, want an error", tt.algo, tt.expected, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveAlgorithm(%q, %q): %v", tt.algo, tt.expected, err)
+			}
+			if got != tt.want || digest != tt.wantDigest {
+				t.Errorf("resolveAlgorithm(%q, %q) = %q, %q, want %q, %q", tt.algo, tt.expected, got, digest, tt.want, tt.wantDigest)
+			}
+		})
+	}
+}
+
+func TestValidateChecksumPrefixedDigest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "file.bin")
+	input := blake3VectorInput(1025)
+	if err := os.WriteFile(path, input, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	blake3Hex := blake3Vectors[4].hash
+
+	if result := validateChecksum(path, "blake3:"+strings.ToUpper(blake3Hex), ""); !result.Valid || result.Algorithm != algoBLAKE3 {
+		t.Errorf("blake3-prefixed digest: valid=%v algorithm=%q error=%q, want a blake3 match", result.Valid, result.Algorithm, result.Error)
+	}
+	// Without the prefix a 64-character digest means SHA-256
+	if result := validateChecksum(path, blake3Hex, ""); result.Valid {
+		t.Errorf("unprefixed BLAKE3 digest matched as %s", result.Algorithm)
+	}
+	if result := validateChecksum(path, "blake3:"+blake3Hex, algoSHA256); result.Valid || result.Error == "" {
+		t.Errorf("conflicting --algo: valid=%v error=%q, want an error", result.Valid, result.Error)
+	}
+	if result := validateChecksum(path, "md5:"+blake3Hex, ""); result.Valid || result.Error == "" {
+		t.Errorf("unknown prefix: valid=%v error=%q, want an error", result.Valid, result.Error)
+	}
+}
+
+func TestTakeAlgoFlag(t *testing.T) {
+	rest, algo := takeAlgoFlag([]string{"file.tar.gz", "--algo=BLAKE3", "abc"})
+	if algo != algoBLAKE3 || len(rest) != 2 || rest[0] != "file.tar.gz" || rest[1] != "abc" {
+		t.Errorf("takeAlgoFlag = %q, %q, want the flag lowercased and removed", rest, algo)
+	}
+
+	// The name comes through unchecked; newHasher rejects unknown ones
+	_, algo = takeAlgoFlag([]string{"--algo=md5"})
+	if _, err := newHasher(algo); err == nil {
+		t.Errorf("newHasher(%q) succeeded", algo)
+	}
+	for _, name := range []string{algoSHA256, algoSHA512, algoBLAKE3} {
+		h, err := newHasher(name)
+		if err != nil {
+			t.Errorf("newHasher(%q): %v", name, err)
+			continue
+		}
+		if want := map[string]int{algoSHA256: 32, algoSHA512: 64, algoBLAKE3: 32}[name]; h.Size() != want {
+			t.Errorf("newHasher(%q).Size() = %d, want %d", name, h.Size(), want)
+		}
+	}
+}
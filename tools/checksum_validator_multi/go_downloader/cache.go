@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// cacheDir, when set, receives every artifact that passes checksum
+// validation, stored by digest as <cacheDir>/sha256/<hex>. Each download URL
+// also gets a record under <cacheDir>/urls so download-and-validate can
+// serve it again.
+var cacheDir string
+
+// cacheTTL is how long a cached download is served without asking upstream.
+// Older entries are revalidated with a HEAD request; zero always revalidates.
+var cacheTTL = 24 * time.Hour
+
+// cacheRecord ties a download URL to the cached digest and the validators
+// upstream reported for it
+type cacheRecord struct {
+	URL         string    `json:"url"`
+	SHA256      string    `json:"sha256"`
+	Size        int64     `json:"size"`
+	ETag        string    `json:"etag,omitempty"`
+	ValidatedAt time.Time `json:"validated_at"`
+}
+
+var sha256HexRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// cachePath returns where an artifact with the given SHA-256 is stored
+func cachePath(dir, digest string) string {
+	return filepath.Join(dir, "sha256", strings.ToLower(digest))
+}
+
+// storeInCache copies a verified artifact into the digest-addressed cache.
+// The copy goes through a temp file so readers never see a partial entry.
+func storeInCache(dir, srcPath, digest string) error {
+	destPath := cachePath(dir, digest)
+	if _, err := os.Stat(destPath); err == nil {
+		return nil // Content-addressed, so an existing entry is identical
+	}
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+		return err
+	}
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(destPath), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), destPath)
+}
+
+func newCacheRecord(result DownloadResult) cacheRecord {
+	return cacheRecord{
+		URL:         result.URL,
+		SHA256:      strings.ToLower(result.SHA256),
+		Size:        result.Size,
+		ETag:        result.ETag,
+		ValidatedAt: time.Now().UTC(),
+	}
+}
+
+// cacheRecordPath returns where the record for a URL is kept
+func cacheRecordPath(dir, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, "urls", hex.EncodeToString(sum[:])+".json")
+}
+
+func loadCacheRecord(dir, url string) (cacheRecord, error) {
+	var record cacheRecord
+	data, err := os.ReadFile(cacheRecordPath(dir, url))
+	if err != nil {
+		return record, err
+	}
+	if err := json.Unmarshal(data, &record); err != nil {
+		return record, err
+	}
+	if record.URL != url {
+		return record, fmt.Errorf("cache record is for %s", record.URL)
+	}
+	return record, nil
+}
+
+// saveCacheRecord writes a URL record through a temp file, like storeInCache
+func saveCacheRecord(dir string, record cacheRecord) error {
+	path := cacheRecordPath(dir, record.URL)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// downloadFromCache copies a cached copy of url to outputPath when the cache
+// holds the expected digest for it. Entries older than cacheTTL are first
+// revalidated against upstream; if upstream reports a different ETag or
+// size, false is returned so the caller downloads afresh. If upstream cannot
+// be reached the cached copy is still used, since its digest is known.
+func downloadFromCache(url, outputPath, expectedSHA256 string) (DownloadResult, bool) {
+	startTime := time.Now()
+
+	record, err := loadCacheRecord(cacheDir, url)
+	if err != nil || record.SHA256 != strings.ToLower(expectedSHA256) {
+		return DownloadResult{}, false
+	}
+
+	if age := time.Since(record.ValidatedAt); age >= cacheTTL {
+		checksumkit.Infof("🔁 Cached copy is %s old, revalidating: %s", age.Round(time.Second), url)
+		fresh, err := revalidateCacheRecord(record)
+		switch {
+		case err != nil:
+			checksumkit.Warnf("⚠️  Could not revalidate (%v), using cached copy", err)
+		case !fresh:
+			checksumkit.Infof("🔄 Upstream changed, downloading again")
+			return DownloadResult{}, false
+		default:
+			record.ValidatedAt = time.Now().UTC()
+			if err := saveCacheRecord(cacheDir, record); err != nil {
+				checksumkit.Warnf("⚠️  Failed to record cache entry: %v", err)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return DownloadResult{}, false
+	}
+	if err := copyCacheEntry(cachePath(cacheDir, record.SHA256), outputPath); err != nil {
+		checksumkit.Warnf("⚠️  Failed to read cache entry (%v), downloading", err)
+		return DownloadResult{}, false
+	}
+
+	checksumkit.Infof("🗄️  Using cached copy of %s", url)
+	result := DownloadResult{
+		URL:          url,
+		LocalPath:    outputPath,
+		Size:         record.Size,
+		SHA256:       record.SHA256,
+		ETag:         record.ETag,
+		DownloadTime: time.Since(startTime).Milliseconds(),
+		Success:      true,
+		FromCache:    true,
+	}
+	// Misses fall through to downloadFile, which records its own telemetry
+	recordDownload(result, time.Since(startTime))
+	return result, true
+}
+
+// revalidateCacheRecord asks upstream, with a HEAD request, whether the
+// artifact behind record.URL is still the one cached. The ETag is compared
+// when both sides have one, and the size whenever upstream reports it; with
+// neither available the entry cannot be confirmed and is treated as stale.
+func revalidateCacheRecord(record cacheRecord) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, record.URL, nil)
+	if err != nil {
+		return false, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, nil
+	}
+
+	compared := false
+	if etag := resp.Header.Get("ETag"); etag != "" && record.ETag != "" {
+		if etag != record.ETag {
+			return false, nil
+		}
+		compared = true
+	}
+	if resp.ContentLength >= 0 {
+		if resp.ContentLength != record.Size {
+			return false, nil
+		}
+		compared = true
+	}
+
+	return compared, nil
+}
+
+// copyCacheEntry copies a cache entry to dst through a temp file, so an
+// interrupted copy never leaves a truncated artifact behind
+func copyCacheEntry(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), dst)
+}
+
+func handleServeCache() {
+	if len(os.Args) < 4 {
+		checksumkit.Errorf("❌ Usage: serve-cache <cache-dir> <addr>")
+		return
+	}
+
+	dir := os.Args[2]
+	addr := os.Args[3]
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/by-digest/", func(w http.ResponseWriter, r *http.Request) {
+		serveByDigest(w, r, dir)
+	})
+
+	checksumkit.Infof("🗄️  Serving artifact cache %s on %s", dir, addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		checksumkit.Errorf("❌ Cache server failed: %v", err)
+		os.Exit(1)
+	}
+}
+
+// serveByDigest answers GET/HEAD /by-digest/<sha256> from the cache, using the
+// digest as a strong ETag so clients can revalidate with If-None-Match
+func serveByDigest(w http.ResponseWriter, r *http.Request, dir string) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	digest := strings.ToLower(strings.TrimPrefix(r.URL.Path, "/by-digest/"))
+	if !sha256HexRegex.MatchString(digest) {
+		http.Error(w, "Invalid sha256 digest", http.StatusBadRequest)
+		return
+	}
+
+	file, err := os.Open(cachePath(dir, digest))
+	if err != nil {
+		http.NotFound(w, r)
+		checksumkit.Infof("miss %s", digest)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, "Failed to stat cache entry", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", `"`+digest+`"`)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeContent(w, r, "", info.ModTime(), file)
+	checksumkit.Infof("hit  %s", digest)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mutableUpstream serves one artifact under a tag whose content, and so its
+// ETag, can change between requests, like a "latest" release asset
+type mutableUpstream struct {
+	mu    sync.Mutex
+	body  string
+	etag  string
+	heads int
+}
+
+func (u *mutableUpstream) set(body, etag string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.body, u.etag = body, etag
+}
+
+func (u *mutableUpstream) headCount() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.heads
+}
+
+func (u *mutableUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	u.mu.Lock()
+	body, etag := u.body, u.etag
+	if r.Method == http.MethodHead {
+		u.heads++
+	}
+	u.mu.Unlock()
+
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method != http.MethodHead {
+		w.Write([]byte(body))
+	}
+}
+
+// withCache points --cache-dir at a fresh directory and sets --cache-ttl
+func withCache(t *testing.T, ttl time.Duration) {
+	t.Helper()
+	originalDir, originalTTL := cacheDir, cacheTTL
+	cacheDir, cacheTTL = t.TempDir(), ttl
+	t.Cleanup(func() { cacheDir, cacheTTL = originalDir, originalTTL })
+}
+
+// cacheDownload downloads url and caches it as download-and-validate does,
+// recording it as validated at validatedAt
+func cacheDownload(t *testing.T, url string, validatedAt time.Time) DownloadResult {
+	t.Helper()
+	outputPath := filepath.Join(t.TempDir(), "artifact")
+	result := downloadFile(url, outputPath, false, "")
+	if !result.Success {
+		t.Fatalf("download failed: %s", result.Error)
+	}
+	if err := storeInCache(cacheDir, outputPath, result.SHA256); err != nil {
+		t.Fatal(err)
+	}
+	record := newCacheRecord(result)
+	record.ValidatedAt = validatedAt
+	if err := saveCacheRecord(cacheDir, record); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// TestCacheTTLRevalidation caches a download, changes the artifact
+// upstream, and checks the stale copy is only noticed once the TTL elapses
+func TestCacheTTLRevalidation(t *testing.T) {
+	withCache(t, time.Hour)
+	upstream := &mutableUpstream{}
+	upstream.set("wasm-tools v1", `"v1"`)
+	server := httptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+	url := server.URL + "/latest/wasm-tools.tar.gz"
+
+	fresh := cacheDownload(t, url, time.Now().UTC())
+	if fresh.ETag != `"v1"` {
+		t.Fatalf("download recorded ETag %q, want %q", fresh.ETag, `"v1"`)
+	}
+	upstream.set("wasm-tools v2", `"v2"`)
+
+	// Within the TTL the cached copy is served without asking upstream
+	outputPath := filepath.Join(t.TempDir(), "artifact")
+	if _, cached := downloadFromCache(url, outputPath, fresh.SHA256); !cached {
+		t.Fatal("cache miss within the TTL")
+	}
+	if got, _ := os.ReadFile(outputPath); string(got) != "wasm-tools v1" {
+		t.Errorf("served %q, want the cached v1", got)
+	}
+	if n := upstream.headCount(); n != 0 {
+		t.Errorf("%d HEAD requests within the TTL, want none", n)
+	}
+
+	// Once the TTL has elapsed the changed ETag forces a fresh download
+	if err := saveCacheRecord(cacheDir, cacheRecord{
+		URL:         url,
+		SHA256:      fresh.SHA256,
+		Size:        fresh.Size,
+		ETag:        fresh.ETag,
+		ValidatedAt: time.Now().UTC().Add(-2 * time.Hour),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := downloadFromCache(url, filepath.Join(t.TempDir(), "artifact"), fresh.SHA256); cached {
+		t.Error("stale cache entry served after upstream changed")
+	}
+	if n := upstream.headCount(); n != 1 {
+		t.Errorf("%d HEAD requests after the TTL, want 1", n)
+	}
+}
+
+// TestCacheTTLRevalidationUnchanged checks an expired entry whose upstream
+// is unchanged is served and its validation time renewed
+func TestCacheTTLRevalidationUnchanged(t *testing.T) {
+	withCache(t, time.Hour)
+	upstream := &mutableUpstream{}
+	upstream.set("wasm-tools v1", `"v1"`)
+	server := httptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+	url := server.URL + "/latest/wasm-tools.tar.gz"
+
+	result := cacheDownload(t, url, time.Now().UTC().Add(-2*time.Hour))
+	before := time.Now()
+	if _, cached := downloadFromCache(url, filepath.Join(t.TempDir(), "artifact"), result.SHA256); !cached {
+		t.Fatal("unchanged artifact not served from the cache")
+	}
+	if n := upstream.headCount(); n != 1 {
+		t.Errorf("%d HEAD requests, want 1", n)
+	}
+
+	record, err := loadCacheRecord(cacheDir, url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.ValidatedAt.Before(before.Add(-time.Second)) {
+		t.Errorf("validated_at = %s, want it renewed", record.ValidatedAt)
+	}
+
+	// Renewed, so the next request stays local
+	if _, cached := downloadFromCache(url, filepath.Join(t.TempDir(), "artifact"), result.SHA256); !cached || upstream.headCount() != 1 {
+		t.Errorf("second request: cached %v after %d HEAD requests, want a local hit", cached, upstream.headCount())
+	}
+}
+
+func TestCacheTTLUpstreamUnreachable(t *testing.T) {
+	withCache(t, 0)
+	upstream := &mutableUpstream{}
+	upstream.set("wasm-tools v1", `"v1"`)
+	server := httptest.NewServer(upstream)
+	url := server.URL + "/latest/wasm-tools.tar.gz"
+	result := cacheDownload(t, url, time.Now().UTC())
+	server.Close()
+
+	// The digest is known, so an offline run still uses the cached copy
+	if _, cached := downloadFromCache(url, filepath.Join(t.TempDir(), "artifact"), result.SHA256); !cached {
+		t.Error("cached copy not used while upstream is unreachable")
+	}
+}
+
+func TestRevalidateCacheRecord(t *testing.T) {
+	tests := []struct {
+		name   string
+		record cacheRecord
+		etag   string
+		size   string // Content-Length upstream reports, "" for none
+		status int
+		want   bool
+	}{
+		{name: "same etag and size", record: cacheRecord{ETag: `"a"`, Size: 5}, etag: `"a"`, size: "5", want: true},
+		{name: "etag changed", record: cacheRecord{ETag: `"a"`, Size: 5}, etag: `"b"`, size: "5"},
+		{name: "size changed", record: cacheRecord{ETag: `"a"`, Size: 5}, etag: `"a"`, size: "6"},
+		{name: "size only", record: cacheRecord{Size: 5}, size: "5", want: true},
+		{name: "etag only", record: cacheRecord{ETag: `"a"`, Size: 5}, etag: `"a"`, want: true},
+		{name: "nothing to compare", record: cacheRecord{Size: 5}},
+		{name: "gone upstream", record: cacheRecord{ETag: `"a"`, Size: 5}, etag: `"a"`, size: "5", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodHead {
+					t.Errorf("revalidation sent %s, want HEAD", r.Method)
+				}
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				if tt.size != "" {
+					w.Header().Set("Content-Length", tt.size)
+				} else {
+					// Without a length the response is chunked and its size unknown
+					w.Header().Set("Transfer-Encoding", "chunked")
+					w.(http.Flusher).Flush()
+				}
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+			}))
+			t.Cleanup(server.Close)
+
+			record := tt.record
+			record.URL = server.URL + "/artifact"
+			got, err := revalidateCacheRecord(record)
+			if err != nil {
+				t.Fatalf("revalidateCacheRecord: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("revalidateCacheRecord = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
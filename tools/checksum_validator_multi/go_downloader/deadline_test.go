@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// withRequestTimeout sets --request-timeout and turns retries off, so a
+// stalled request fails once instead of being retried
+func withRequestTimeout(t *testing.T, timeout time.Duration) {
+	t.Helper()
+	originalTimeout, originalBackoff := requestTimeout, checksumkit.Backoff
+	requestTimeout = timeout
+	checksumkit.Backoff.Retries = 0
+	t.Cleanup(func() { requestTimeout, checksumkit.Backoff = originalTimeout, originalBackoff })
+}
+
+// stallingServer sends headers and then the first part of a body, if any,
+// and stalls until the client gives up
+func stallingServer(t *testing.T, stallBeforeHeaders bool, partial string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !stallBeforeHeaders {
+			w.Header().Set("Content-Length", "1048576")
+			w.Write([]byte(partial))
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDownloadFileRequestDeadline(t *testing.T) {
+	tests := []struct {
+		name               string
+		stallBeforeHeaders bool
+		resume             bool
+		wantError          string
+		wantPartial        bool // Whether the bytes received are kept
+	}{
+		{name: "no response", stallBeforeHeaders: true, wantError: "HTTP request failed: timed out after"},
+		{name: "stalled body", wantError: "Failed to copy data: timed out after"},
+		{name: "stalled body kept for resume", resume: true, wantError: "Failed to copy data: timed out after", wantPartial: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withRequestTimeout(t, 200*time.Millisecond)
+			server := stallingServer(t, tt.stallBeforeHeaders, "first bytes")
+
+			outputPath := filepath.Join(t.TempDir(), "asset.tar.gz")
+			start := time.Now()
+			result := downloadFile(server.URL+"/asset.tar.gz", outputPath, tt.resume, "")
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("download took %s despite a 200ms deadline", elapsed)
+			}
+
+			if result.Success || !result.TimedOut {
+				t.Fatalf("result = %+v, want a timed out failure", result)
+			}
+			if !strings.HasPrefix(result.Error, tt.wantError) {
+				t.Errorf("error = %q, want prefix %q", result.Error, tt.wantError)
+			}
+
+			data, err := os.ReadFile(outputPath)
+			switch {
+			case tt.wantPartial && string(data) != "first bytes":
+				t.Errorf("partial file = %q (err %v), want the bytes received kept for a resume", data, err)
+			case !tt.wantPartial && !os.IsNotExist(err):
+				t.Errorf("partial file left behind after the deadline (%d bytes, err %v)", len(data), err)
+			}
+		})
+	}
+}
+
+// TestDownloadFileOtherFailuresAreNotTimeouts checks that a dropped
+// connection is reported as a failure, not as a deadline
+func TestDownloadFileOtherFailuresAreNotTimeouts(t *testing.T) {
+	withRequestTimeout(t, time.Minute)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1048576")
+		w.Write([]byte("first bytes"))
+		w.(http.Flusher).Flush()
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	outputPath := filepath.Join(t.TempDir(), "asset.tar.gz")
+	result := downloadFile(server.URL+"/asset.tar.gz", outputPath, false, "")
+	if result.Success || result.TimedOut {
+		t.Fatalf("result = %+v, want a failure that is not a timeout", result)
+	}
+	if !strings.HasPrefix(result.Error, "Failed to copy data: ") || strings.Contains(result.Error, "timed out") {
+		t.Errorf("error = %q, want a copy failure", result.Error)
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Errorf("partial file left behind (stat err %v)", err)
+	}
+}
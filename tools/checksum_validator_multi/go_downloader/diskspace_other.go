@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package main
+
+// availableDiskSpace is not implemented on this platform (including WASI),
+// so the preflight check is skipped
+func availableDiskSpace(dir string) (uint64, bool) {
+	return 0, false
+}
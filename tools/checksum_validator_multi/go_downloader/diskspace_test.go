@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// withFreeDiskSpace reports available bytes of free space for every
+// directory, or an unknown amount when ok is false
+func withFreeDiskSpace(t *testing.T, available uint64, ok bool) {
+	t.Helper()
+	original := freeDiskSpace
+	freeDiskSpace = func(string) (uint64, bool) { return available, ok }
+	t.Cleanup(func() { freeDiskSpace = original })
+}
+
+func TestCheckDiskSpace(t *testing.T) {
+	const mib = 1 << 20
+
+	tests := []struct {
+		name          string
+		contentLength int64
+		available     uint64
+		queryable     bool
+		wantErr       bool
+	}{
+		{name: "unknown length is skipped", contentLength: -1, available: 0, queryable: true},
+		{name: "empty body is skipped", contentLength: 0, available: 0, queryable: true},
+		{name: "unknown free space is skipped", contentLength: 100 * mib, queryable: false},
+		{name: "plenty of room", contentLength: 10 * mib, available: 1 << 40, queryable: true},
+		// Small downloads need the 1 MiB minimum margin on top
+		{name: "small download with minimum margin", contentLength: 1000, available: 1000 + mib, queryable: true},
+		{name: "small download without minimum margin", contentLength: 1000, available: 1000 + mib - 1, queryable: true, wantErr: true},
+		// Large downloads need 10% on top
+		{name: "large download with 10% margin", contentLength: 100 * mib, available: 110 * mib, queryable: true},
+		{name: "large download without 10% margin", contentLength: 100 * mib, available: 110*mib - 1, queryable: true, wantErr: true},
+		{name: "tiny quota", contentLength: 100 * mib, available: 4096, queryable: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withFreeDiskSpace(t, tt.available, tt.queryable)
+
+			err := checkDiskSpace("/downloads", tt.contentLength)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkDiskSpace(%d) with %d free = %v, wantErr %v", tt.contentLength, tt.available, err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "insufficient disk space in /downloads") {
+				t.Errorf("error %q does not name the destination", err)
+			}
+		})
+	}
+}
+
+// TestDownloadFileFailsEarlyOnTinyQuota checks that a download larger than
+// the free space is refused before anything is written
+func TestDownloadFileFailsEarlyOnTinyQuota(t *testing.T) {
+	body := strings.Repeat("x", 64*1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	withFreeDiskSpace(t, 1024, true)
+
+	outputPath := filepath.Join(t.TempDir(), "asset.tar.gz")
+	result := downloadFile(server.URL+"/asset.tar.gz", outputPath, false, "")
+	if result.Success {
+		t.Fatal("download succeeded despite the tiny quota")
+	}
+	if !strings.Contains(result.Error, "insufficient disk space") {
+		t.Errorf("error = %q, want an insufficient disk space error", result.Error)
+	}
+	if _, err := os.Stat(outputPath); !os.IsNotExist(err) {
+		t.Errorf("output file exists after the refused download (stat err %v)", err)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// fetchRequest is a single arbitrary HTTP request built from fetch arguments
+type fetchRequest struct {
+	Method          string
+	URL             string
+	Body            string
+	Headers         http.Header
+	FollowRedirects bool
+	OutputPath      string // Write the body here instead of printing it
+}
+
+// parseFetchArgs reads `fetch <method> <url>` and its options:
+//
+//	--data=BODY or --data=@FILE   Request body
+//	--header="Name: value"        Repeatable
+//	--follow-redirects=false      Return 3xx responses instead of following them
+//	--output=PATH                 Save the response body to PATH
+func parseFetchArgs(args []string) (*fetchRequest, error) {
+	req := &fetchRequest{Headers: make(http.Header), FollowRedirects: true}
+
+	var positional []string
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "--data="):
+			body := strings.TrimPrefix(arg, "--data=")
+			if strings.HasPrefix(body, "@") {
+				data, err := os.ReadFile(body[1:])
+				if err != nil {
+					return nil, fmt.Errorf("reading request body: %w", err)
+				}
+				body = string(data)
+			}
+			req.Body = body
+		case strings.HasPrefix(arg, "--header="):
+			name, value, ok := strings.Cut(strings.TrimPrefix(arg, "--header="), ":")
+			if !ok || strings.TrimSpace(name) == "" {
+				return nil, fmt.Errorf("invalid %s: expected \"Name: value\"", arg)
+			}
+			req.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+		case strings.HasPrefix(arg, "--follow-redirects="):
+			switch strings.TrimPrefix(arg, "--follow-redirects=") {
+			case "true":
+				req.FollowRedirects = true
+			case "false":
+				req.FollowRedirects = false
+			default:
+				return nil, fmt.Errorf("invalid %s: expected true or false", arg)
+			}
+		case strings.HasPrefix(arg, "--output="):
+			req.OutputPath = strings.TrimPrefix(arg, "--output=")
+		default:
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) != 2 {
+		return nil, fmt.Errorf("expected <method> <url>")
+	}
+	req.Method = strings.ToUpper(positional[0])
+	req.URL = positional[1]
+	return req, nil
+}
+
+// doFetch sends the request through the shared client. Redirects are left
+// unfollowed on a shallow copy of the client, so the pooled transport is
+// still reused.
+func doFetch(ctx context.Context, f *fetchRequest) (*http.Response, error) {
+	var body io.Reader
+	if f.Body != "" {
+		body = strings.NewReader(f.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, f.Method, f.URL, body)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid request: %v", err)
+	}
+	req.Header = f.Headers.Clone()
+	if f.Body != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := httpClient
+	if !f.FollowRedirects {
+		noRedirect := *httpClient
+		noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		client = &noRedirect
+	}
+
+	return client.Do(req)
+}
+
+func handleFetch() {
+	f, err := parseFetchArgs(os.Args[2:])
+	if err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		checksumkit.Errorf("❌ Usage: fetch <method> <url> [--data=BODY|@FILE] [--header=\"Name: value\"]... [--follow-redirects=false] [--output=PATH]")
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	checksumkit.Infof("📡 %s %s", f.Method, f.URL)
+	resp, err := doFetch(ctx, f)
+	if err != nil {
+		checksumkit.Errorf("❌ Request failed: %v", err)
+		os.Exit(1)
+	}
+	defer resp.Body.Close()
+
+	checksumkit.Infof("📊 Status: %s", resp.Status)
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range resp.Header[name] {
+			checksumkit.Debugf("  %s: %s", name, value)
+		}
+	}
+
+	if f.OutputPath != "" {
+		out, err := os.Create(f.OutputPath)
+		if err != nil {
+			checksumkit.Errorf("❌ Failed to create %s: %v", f.OutputPath, err)
+			os.Exit(1)
+		}
+		size, err := io.Copy(out, resp.Body)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			checksumkit.Errorf("❌ Failed to save response body: %v", err)
+			os.Exit(1)
+		}
+		checksumkit.Infof("💾 Saved %s to %s", formatBytes(size), f.OutputPath)
+	} else if _, err := io.Copy(os.Stdout, resp.Body); err != nil {
+		checksumkit.Errorf("❌ Failed to read response body: %v", err)
+		os.Exit(1)
+	}
+
+	if resp.StatusCode >= 400 {
+		os.Exit(1)
+	}
+}
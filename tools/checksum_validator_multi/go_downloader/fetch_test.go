@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFetchArgs(t *testing.T) {
+	bodyFile := filepath.Join(t.TempDir(), "body.json")
+	if err := os.WriteFile(bodyFile, []byte(`{"artifact":"wasm-tools"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    *fetchRequest
+		wantErr string
+	}{
+		{
+			name: "defaults",
+			args: []string{"get", "https://registry.example/v1/artifacts"},
+			want: &fetchRequest{Method: "GET", URL: "https://registry.example/v1/artifacts", Headers: http.Header{}, FollowRedirects: true},
+		},
+		{
+			name: "every option",
+			args: []string{
+				"--header=Authorization: Bearer abc",
+				"post", "https://registry.example/v1/resolve",
+				"--data=@" + bodyFile,
+				"--header=X-Trace:  one ",
+				"--header=X-Trace: two",
+				"--follow-redirects=false",
+				"--output=out.bin",
+			},
+			want: &fetchRequest{
+				Method:          "POST",
+				URL:             "https://registry.example/v1/resolve",
+				Body:            `{"artifact":"wasm-tools"}`,
+				Headers:         http.Header{"Authorization": {"Bearer abc"}, "X-Trace": {"one", "two"}},
+				FollowRedirects: false,
+				OutputPath:      "out.bin",
+			},
+		},
+		{name: "inline body", args: []string{"PUT", "https://x", "--data={}"}, want: &fetchRequest{Method: "PUT", URL: "https://x", Body: "{}", Headers: http.Header{}, FollowRedirects: true}},
+		{name: "missing url", args: []string{"GET"}, wantErr: "expected <method> <url>"},
+		{name: "extra positional", args: []string{"GET", "https://x", "https://y"}, wantErr: "expected <method> <url>"},
+		{name: "header without colon", args: []string{"GET", "https://x", "--header=Authorization"}, wantErr: "invalid --header"},
+		{name: "header without name", args: []string{"GET", "https://x", "--header=: value"}, wantErr: "invalid --header"},
+		{name: "bad follow-redirects", args: []string{"GET", "https://x", "--follow-redirects=no"}, wantErr: "expected true or false"},
+		{name: "missing body file", args: []string{"POST", "https://x", "--data=@" + filepath.Join(t.TempDir(), "missing")}, wantErr: "reading request body"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFetchArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one mentioning %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFetchArgs = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDoFetchPostWithBody(t *testing.T) {
+	var gotMethod, gotBody, gotType, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotBody = r.Method, string(body)
+		gotType, gotAuth = r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"url":"https://cdn.example/wasm-tools.tar.gz"}`)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		headers  http.Header
+		wantType string
+	}{
+		{name: "defaults to JSON", headers: http.Header{"Authorization": {"Bearer abc"}}, wantType: "application/json"},
+		{name: "keeps explicit content type", headers: http.Header{"Authorization": {"Bearer abc"}, "Content-Type": {"text/plain"}}, wantType: "text/plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := doFetch(context.Background(), &fetchRequest{
+				Method:          "POST",
+				URL:             server.URL + "/resolve",
+				Body:            `{"artifact":"wasm-tools"}`,
+				Headers:         tt.headers,
+				FollowRedirects: true,
+			})
+			if err != nil {
+				t.Fatalf("doFetch: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if gotMethod != "POST" || gotBody != `{"artifact":"wasm-tools"}` {
+				t.Errorf("server saw %s %q, want the POST body", gotMethod, gotBody)
+			}
+			if gotType != tt.wantType || gotAuth != "Bearer abc" {
+				t.Errorf("server saw Content-Type %q and Authorization %q, want %q and the bearer token", gotType, gotAuth, tt.wantType)
+			}
+			if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "cdn.example") {
+				t.Errorf("response = %s %q", resp.Status, body)
+			}
+		})
+	}
+}
+
+func TestDoFetchRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/download" {
+			http.Redirect(w, r, "/blob", http.StatusFound)
+			return
+		}
+		io.WriteString(w, "artifact bytes")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		follow     bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "followed", follow: true, wantStatus: http.StatusOK, wantBody: "artifact bytes"},
+		{name: "not followed", follow: false, wantStatus: http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := doFetch(context.Background(), &fetchRequest{Method: "GET", URL: server.URL + "/download", Headers: http.Header{}, FollowRedirects: tt.follow})
+			if err != nil {
+				t.Fatalf("doFetch: %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantBody != "" && string(body) != tt.wantBody {
+				t.Errorf("body = %q, want %q", body, tt.wantBody)
+			}
+			if !tt.follow && resp.Header.Get("Location") != "/blob" {
+				t.Errorf("Location = %q, want the redirect target", resp.Header.Get("Location"))
+			}
+		})
+	}
+
+	// Turning redirects off for one request must not change the shared client
+	if httpClient.CheckRedirect != nil {
+		t.Error("doFetch modified the shared client's redirect policy")
+	}
+}
@@ -1,6 +0,0 @@
-module checksum-validator-go
-
-go 1.21
-
-// WebAssembly Component for HTTP downloading and checksum validation
-// Built with TinyGo for WASI Preview 2 compatibility
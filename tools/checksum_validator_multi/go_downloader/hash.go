@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// Digest algorithms a checksum can be validated with
+const (
+	algoSHA256 = "sha256"
+	algoSHA512 = "sha512"
+	algoBLAKE3 = "blake3"
+)
+
+// newHasher returns a hash for the named algorithm
+func newHasher(algo string) (hash.Hash, error) {
+	switch algo {
+	case algoSHA256:
+		return sha256.New(), nil
+	case algoSHA512:
+		return sha512.New(), nil
+	case algoBLAKE3:
+		return newBLAKE3(), nil
+	}
+	return nil, fmt.Errorf("unknown algorithm %q (expected sha256, sha512 or blake3)", algo)
+}
+
+// resolveAlgorithm returns the algorithm to check expected with and the
+// digest without its prefix. An OCI-style prefix such as "sha512:" names the
+// algorithm; otherwise it is algo, or when that is empty too the algorithm
+// implied by the digest's length. SHA-256 and BLAKE3 digests are both 64 hex
+// characters, so BLAKE3 has to be asked for with --algo or a prefix.
+func resolveAlgorithm(algo, expected string) (string, string, error) {
+	if prefix, digest, ok := strings.Cut(expected, ":"); ok {
+		prefix = strings.ToLower(prefix)
+		if algo != "" && algo != prefix {
+			return "", "", fmt.Errorf("--algo=%s conflicts with the %s: digest prefix", algo, prefix)
+		}
+		return prefix, digest, nil
+	}
+	if algo != "" {
+		return algo, expected, nil
+	}
+	if len(expected) == sha512.Size*2 {
+		return algoSHA512, expected, nil
+	}
+	return algoSHA256, expected, nil
+}
+
+// takeAlgoFlag removes a --algo=NAME argument from args, returning the
+// remaining arguments and the name, which is empty when the flag is absent
+func takeAlgoFlag(args []string) ([]string, string) {
+	var rest []string
+	algo := ""
+	for _, arg := range args {
+		if value, ok := strings.CutPrefix(arg, "--algo="); ok {
+			algo = strings.ToLower(value)
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, algo
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// Connection pool settings for the shared client. The defaults suit batches
+// of concurrent downloads against a handful of GitHub and CDN hosts, where
+// net/http's default of 2 idle connections per host forces constant redials.
+var (
+	maxIdleConns        = 100
+	maxIdleConnsPerHost = 16
+	idleConnTimeout     = 90 * time.Second
+)
+
+// requestTimeout bounds each request from dial to the last body byte, so a
+// stalled transfer fails instead of hanging the whole batch
+var requestTimeout = 10 * time.Minute
+
+// httpClient is shared by every request so pooled connections are reused.
+// It is rebuilt by main once global flags have been applied.
+var httpClient = newHTTPClient()
+
+// newHTTPClient builds a client whose transport uses the pool settings above
+func newHTTPClient() *http.Client {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
+		IdleConnTimeout:       idleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+
+	return &http.Client{Transport: transport}
+}
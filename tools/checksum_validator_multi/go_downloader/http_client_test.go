@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// countingServer serves a body of the given size over TLS, as GitHub and
+// its CDN do, and counts the connections clients open to it
+func countingServer(tb testing.TB, size int) (*httptest.Server, *atomic.Int64) {
+	tb.Helper()
+	body := strings.Repeat("x", size)
+	var conns atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	// Dials the transport abandons for a freed connection can still be
+	// handshaking when the server closes
+	server.Config.ErrorLog = log.New(io.Discard, "", 0)
+	server.StartTLS()
+	tb.Cleanup(server.Close)
+	return server, &conns
+}
+
+// defaultClient mirrors what go_downloader used before the tuned transport:
+// net/http's defaults, which keep 2 idle connections per host
+func defaultClient() *http.Client {
+	return &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+}
+
+// trusting makes client accept the test server's certificate
+func trusting(client *http.Client, server *httptest.Server) *http.Client {
+	client.Transport.(*http.Transport).TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig
+	return client
+}
+
+// getAll fetches url and drains the body so the connection can be reused
+func getAll(client *http.Client, url string) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return err
+}
+
+// TestHTTPClientReusesConnections runs rounds of 16 concurrent requests, as
+// a batch of downloads would, and checks the tuned client keeps its
+// connections open between rounds while net/http's defaults redial
+func TestHTTPClientReusesConnections(t *testing.T) {
+	const workers, rounds = 16, 5
+
+	run := func(newClient func() *http.Client) int64 {
+		server, conns := countingServer(t, 1024)
+		client := trusting(newClient(), server)
+		for round := 0; round < rounds; round++ {
+			var wg sync.WaitGroup
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					if err := getAll(client, server.URL); err != nil {
+						t.Error(err)
+					}
+				}()
+			}
+			wg.Wait()
+		}
+		client.CloseIdleConnections()
+		return conns.Load()
+	}
+
+	if tuned := run(newHTTPClient); tuned > workers {
+		t.Errorf("tuned client opened %d connections for %d rounds of %d, want at most %d", tuned, rounds, workers, workers)
+	}
+	// Only 2 of the 16 connections survive each round with the defaults
+	if baseline := run(defaultClient); baseline <= workers {
+		t.Errorf("default client opened %d connections, want redials beyond %d", baseline, workers)
+	}
+}
+
+// BenchmarkHTTPClientThroughput compares the tuned transport with net/http's
+// defaults. Each op is a batch of 16 concurrent downloads from one host, as
+// a multi-item command run with --concurrency=16 issues; dials/op shows how
+// often each client has to open a connection and pay for a TLS handshake.
+func BenchmarkHTTPClientThroughput(b *testing.B) {
+	const batch = 16
+
+	for _, size := range []int{4 << 10, 256 << 10} {
+		for _, tc := range []struct {
+			name   string
+			client func() *http.Client
+		}{
+			{"default", defaultClient},
+			{"tuned", newHTTPClient},
+		} {
+			b.Run(fmt.Sprintf("%s/%dKiB", tc.name, size>>10), func(b *testing.B) {
+				server, conns := countingServer(b, size)
+				client := trusting(tc.client(), server)
+				defer client.CloseIdleConnections()
+
+				b.SetBytes(int64(batch * size))
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					var wg sync.WaitGroup
+					for j := 0; j < batch; j++ {
+						wg.Add(1)
+						go func() {
+							defer wg.Done()
+							if err := getAll(client, server.URL); err != nil {
+								b.Error(err)
+							}
+						}()
+					}
+					wg.Wait()
+				}
+				b.ReportMetric(float64(conns.Load())/float64(b.N), "dials/op")
+			})
+		}
+	}
+}
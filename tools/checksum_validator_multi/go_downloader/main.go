@@ -1,29 +1,46 @@
 package main
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
 )
 
+// githubDownloadBase serves release assets. Like checksumkit.GitHubAPIBase
+// it is overridable via flags or environment for GitHub Enterprise Server,
+// which serves the API and downloads from different hosts.
+var githubDownloadBase = "https://github.com"
+
+// concurrency bounds how many items a multi-item command processes at once
+var concurrency = 4
+
 // GitHubRelease represents a GitHub release
 type GitHubRelease struct {
-	TagName     string `json:"tag_name"`
-	Name        string `json:"name"`
-	PublishedAt string `json:"published_at"`
-	Assets      []struct {
-		Name               string `json:"name"`
-		BrowserDownloadURL string `json:"browser_download_url"`
-		Size               int64  `json:"size"`
-		ContentType        string `json:"content_type"`
-	} `json:"assets"`
+	TagName     string         `json:"tag_name"`
+	Name        string         `json:"name"`
+	PublishedAt string         `json:"published_at"`
+	Assets      []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a downloadable file attached to a GitHub release
+type ReleaseAsset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+	Size               int64  `json:"size"`
+	ContentType        string `json:"content_type"`
 }
 
 // DownloadResult represents the result of a download operation
@@ -35,6 +52,20 @@ type DownloadResult struct {
 	DownloadTime int64  `json:"download_time_ms"`
 	Success      bool   `json:"success"`
 	Error        string `json:"error,omitempty"`
+	// TimedOut is set when the request hit --request-timeout rather than
+	// failing for another reason
+	TimedOut bool `json:"timed_out,omitempty"`
+	// ETag is the validator upstream sent, kept with cache entries
+	ETag string `json:"etag,omitempty"`
+	// FromCache is set when the artifact was copied from --cache-dir
+	FromCache bool `json:"from_cache,omitempty"`
+	// Attempts counts the requests made, including retries
+	Attempts int `json:"attempts,omitempty"`
+	// Digest is the file's digest under Algorithm, set when an algorithm
+	// other than sha256 was requested; SHA256 is always computed, as the
+	// cache is keyed by it
+	Algorithm string `json:"algorithm,omitempty"`
+	Digest    string `json:"digest,omitempty"`
 }
 
 // ChecksumValidationRequest represents a validation request
@@ -46,9 +77,12 @@ type ChecksumValidationRequest struct {
 	Platform       string `json:"platform"`
 }
 
-// ChecksumValidationResult represents validation results
+// ChecksumValidationResult represents validation results. The digest fields
+// keep their sha256 names for existing consumers but hold digests under
+// Algorithm.
 type ChecksumValidationResult struct {
 	FilePath       string `json:"file_path"`
+	Algorithm      string `json:"algorithm"`
 	ActualSHA256   string `json:"actual_sha256"`
 	ExpectedSHA256 string `json:"expected_sha256"`
 	Valid          bool   `json:"valid"`
@@ -58,9 +92,12 @@ type ChecksumValidationResult struct {
 }
 
 func main() {
-	fmt.Println("🌐 Multi-Language WebAssembly Checksum Validator")
-	fmt.Println("=================================================")
-	fmt.Println("🔧 Go Component: HTTP Downloader & GitHub API Client")
+	// Strip global flags so command handlers see only positional arguments
+	os.Args = parseGlobalFlags(os.Args)
+	httpClient = newHTTPClient()
+
+	checksumkit.Infof("🌐 Multi-Language WebAssembly Checksum Validator")
+	checksumkit.Infof("🔧 Go Component: HTTP Downloader & GitHub API Client")
 
 	if len(os.Args) < 2 {
 		showHelp()
@@ -73,56 +110,298 @@ func main() {
 		handleDownload()
 	case "fetch-release-info":
 		handleFetchReleaseInfo()
+	case "download-release":
+		handleDownloadRelease()
+	case "download-for-platform":
+		handleDownloadForPlatform()
 	case "validate-checksum":
 		handleValidateChecksum()
+	case "validate-against-sums":
+		handleValidateAgainstSums()
 	case "download-and-validate":
 		handleDownloadAndValidate()
 	case "test-connection":
 		handleTestConnection()
+	case "serve-cache":
+		handleServeCache()
+	case "repack":
+		handleRepack()
+	case "fetch":
+		handleFetch()
 	default:
-		fmt.Printf("❌ Unknown command: %s\n", command)
+		checksumkit.Errorf("❌ Unknown command: %s", command)
 		showHelp()
 	}
 }
 
+// parseGlobalFlags applies the GitHub endpoint overrides from the environment
+// and command line, returning the arguments with the global flags removed.
+//
+//	--github-api-base=URL       (env GITHUB_API_BASE)
+//	--github-download-base=URL  (env GITHUB_DOWNLOAD_BASE)
+//
+// It also applies the connection pool settings used by newHTTPClient:
+//
+//	--max-idle-conns=N
+//	--max-idle-conns-per-host=N
+//	--idle-conn-timeout=DURATION
+//
+// the per-request deadline:
+//
+//	--request-timeout=DURATION
+//
+// the artifact cache used by download-and-validate:
+//
+//	--cache-dir=DIR             (env GO_DOWNLOADER_CACHE_DIR)
+//	--cache-ttl=DURATION
+//
+// per-download telemetry:
+//
+//	--telemetry-out=PATH        (env GO_DOWNLOADER_TELEMETRY_OUT)
+//
+// how files are read for hashing:
+//
+//	--hash-buffer-size=BYTES
+//	--hash-mmap
+//
+// how many items multi-item commands process at once:
+//
+//	--concurrency=N
+//
+// how GitHub API calls and downloads are retried:
+//
+//	--backoff=constant|exponential|exponential-jitter
+//	--backoff-base=DURATION
+//	--backoff-max=DURATION
+//	--retries=N
+//
+// and which progress messages reach stderr:
+//
+//	--log-level=debug|info|warn|error  (env LOG_LEVEL)
+//	--log-format=text|json             (env LOG_FORMAT)
+//	--quiet                            Same as --log-level=warn
+func parseGlobalFlags(args []string) []string {
+	if base := os.Getenv("GITHUB_API_BASE"); base != "" {
+		checksumkit.GitHubAPIBase = base
+	}
+	if base := os.Getenv("GITHUB_DOWNLOAD_BASE"); base != "" {
+		githubDownloadBase = base
+	}
+	if dir := os.Getenv("GO_DOWNLOADER_CACHE_DIR"); dir != "" {
+		cacheDir = dir
+	}
+	if path := os.Getenv("GO_DOWNLOADER_TELEMETRY_OUT"); path != "" {
+		telemetryOut = path
+	}
+	if err := checksumkit.ApplyLogEnv(); err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		os.Exit(1)
+	}
+
+	filtered := make([]string, 0, len(args))
+	for _, arg := range args {
+		if matched, err := checksumkit.ApplyLogFlag(arg); matched {
+			if err != nil {
+				checksumkit.Errorf("❌ %v", err)
+				os.Exit(1)
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(arg, "--github-api-base="):
+			checksumkit.GitHubAPIBase = strings.TrimPrefix(arg, "--github-api-base=")
+		case strings.HasPrefix(arg, "--github-download-base="):
+			githubDownloadBase = strings.TrimPrefix(arg, "--github-download-base=")
+		case strings.HasPrefix(arg, "--cache-dir="):
+			cacheDir = strings.TrimPrefix(arg, "--cache-dir=")
+		case strings.HasPrefix(arg, "--cache-ttl="):
+			cacheTTL = parseNonNegativeDuration(arg, "--cache-ttl=")
+		case strings.HasPrefix(arg, "--telemetry-out="):
+			telemetryOut = strings.TrimPrefix(arg, "--telemetry-out=")
+		case strings.HasPrefix(arg, "--hash-buffer-size="):
+			checksumkit.HashBufferSize = parsePositiveInt(arg, "--hash-buffer-size=")
+		case arg == "--hash-mmap":
+			checksumkit.HashUseMmap = true
+		case strings.HasPrefix(arg, "--concurrency="):
+			concurrency = parsePositiveInt(arg, "--concurrency=")
+		case strings.HasPrefix(arg, "--backoff="):
+			checksumkit.Backoff.Strategy = strings.TrimPrefix(arg, "--backoff=")
+			if !checksumkit.ValidBackoffStrategy(checksumkit.Backoff.Strategy) {
+				checksumkit.Errorf("❌ Invalid %s: expected constant, exponential or exponential-jitter", arg)
+				os.Exit(1)
+			}
+		case strings.HasPrefix(arg, "--backoff-base="):
+			checksumkit.Backoff.Base = parsePositiveDuration(arg, "--backoff-base=")
+		case strings.HasPrefix(arg, "--backoff-max="):
+			checksumkit.Backoff.Max = parsePositiveDuration(arg, "--backoff-max=")
+		case strings.HasPrefix(arg, "--retries="):
+			checksumkit.Backoff.Retries = parseNonNegativeInt(arg, "--retries=")
+		case strings.HasPrefix(arg, "--max-idle-conns="):
+			maxIdleConns = parsePositiveInt(arg, "--max-idle-conns=")
+		case strings.HasPrefix(arg, "--max-idle-conns-per-host="):
+			maxIdleConnsPerHost = parsePositiveInt(arg, "--max-idle-conns-per-host=")
+		case strings.HasPrefix(arg, "--idle-conn-timeout="):
+			idleConnTimeout = parsePositiveDuration(arg, "--idle-conn-timeout=")
+		case strings.HasPrefix(arg, "--request-timeout="):
+			requestTimeout = parsePositiveDuration(arg, "--request-timeout=")
+		default:
+			filtered = append(filtered, arg)
+		}
+	}
+
+	checksumkit.GitHubAPIBase = strings.TrimSuffix(checksumkit.GitHubAPIBase, "/")
+	githubDownloadBase = strings.TrimSuffix(githubDownloadBase, "/")
+
+	return filtered
+}
+
+// parsePositiveInt reads the value of a --flag=N argument, exiting on bad input
+func parsePositiveInt(arg, prefix string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(arg, prefix))
+	if err != nil || n <= 0 {
+		checksumkit.Errorf("❌ Invalid %s: expected a positive integer", arg)
+		os.Exit(1)
+	}
+	return n
+}
+
+// parseNonNegativeInt reads the value of a --flag=N argument that may be zero
+func parseNonNegativeInt(arg, prefix string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(arg, prefix))
+	if err != nil || n < 0 {
+		checksumkit.Errorf("❌ Invalid %s: expected a non-negative integer", arg)
+		os.Exit(1)
+	}
+	return n
+}
+
+// parsePositiveDuration reads the value of a --flag=DURATION argument, exiting on bad input
+func parsePositiveDuration(arg, prefix string) time.Duration {
+	d, err := time.ParseDuration(strings.TrimPrefix(arg, prefix))
+	if err != nil || d <= 0 {
+		checksumkit.Errorf("❌ Invalid %s: expected a positive duration such as 90s", arg)
+		os.Exit(1)
+	}
+	return d
+}
+
+// parseNonNegativeDuration reads the value of a --flag=DURATION argument that may be zero
+func parseNonNegativeDuration(arg, prefix string) time.Duration {
+	d, err := time.ParseDuration(strings.TrimPrefix(arg, prefix))
+	if err != nil || d < 0 {
+		checksumkit.Errorf("❌ Invalid %s: expected a non-negative duration such as 12h", arg)
+		os.Exit(1)
+	}
+	return d
+}
+
 func showHelp() {
 	fmt.Println("Usage:")
-	fmt.Println("  download <url> <output-path>")
+	fmt.Println("  download <url> <output-path> [--resume] [--algo=sha256|sha512|blake3]")
+	fmt.Println("  download-release <github-repo> <version> <asset-name> <output-path>")
+	fmt.Println("  download-for-platform <github-repo> <version|latest> <platform|auto> <output-dir> [expected-sha256]")
 	fmt.Println("  fetch-release-info <github-repo>")
-	fmt.Println("  validate-checksum <file-path> <expected-sha256>")
-	fmt.Println("  download-and-validate <url> <output-path> <expected-sha256>")
+	fmt.Println("  validate-checksum <file-path> <expected-digest> [--algo=sha256|sha512|blake3]")
+	fmt.Println("  validate-against-sums <file-path> <sums-file> [--algo=sha256|sha512|blake3]")
+	fmt.Println("  download-and-validate <url> <output-path> <expected-digest> [--algo=sha256|sha512|blake3]")
 	fmt.Println("  test-connection")
+	fmt.Println("  serve-cache <cache-dir> <addr>")
+	fmt.Println("  repack <input-archive> <output.tar.gz>")
+	fmt.Println("  fetch <method> <url> [--data=BODY|@FILE] [--header=\"Name: value\"]... [--follow-redirects=false] [--output=PATH]")
 	fmt.Println()
 	fmt.Println("Examples:")
 	fmt.Println("  download https://github.com/bytecodealliance/wasm-tools/releases/download/v1.0.0/wasm-tools-1.0.0-x86_64-linux.tar.gz ./wasm-tools.tar.gz")
+	fmt.Println("  download-for-platform bytecodealliance/wasm-tools v1.0.0 auto ./tools")
 	fmt.Println("  fetch-release-info bytecodealliance/wasm-tools")
 	fmt.Println("  validate-checksum ./file.tar.gz abc123...")
+	fmt.Println("  validate-checksum ./file.tar.gz blake3:abc123...")
+	fmt.Println("  validate-against-sums ./node-v20.0.0-linux-x64.tar.xz ./SHASUMS256.txt")
 	fmt.Println("  test-connection")
+	fmt.Println()
+	fmt.Println("Global flags (GitHub Enterprise Server):")
+	fmt.Println("  --github-api-base=URL       API endpoint (env GITHUB_API_BASE, default https://api.github.com)")
+	fmt.Println("  --github-download-base=URL  Release download host (env GITHUB_DOWNLOAD_BASE, default https://github.com)")
+	fmt.Println("  GITHUB_TOKEN (env)          Token sent with API calls, raising the 60/hour rate limit")
+	fmt.Println()
+	fmt.Println("Connection pool flags:")
+	fmt.Println("  --max-idle-conns=N          Idle connections kept across all hosts (default 100)")
+	fmt.Println("  --max-idle-conns-per-host=N Idle connections kept per host (default 16)")
+	fmt.Println("  --idle-conn-timeout=DUR     How long idle connections stay open (default 90s)")
+	fmt.Println("  --request-timeout=DUR       Deadline for each request including the body (default 10m)")
+	fmt.Println()
+	fmt.Println("Artifact cache:")
+	fmt.Println("  --cache-dir=DIR             Store validated downloads by digest (env GO_DOWNLOADER_CACHE_DIR)")
+	fmt.Println("  --cache-ttl=DUR             Serve cached downloads without asking upstream for this long (default 24h)")
+	fmt.Println()
+	fmt.Println("Telemetry:")
+	fmt.Println("  --telemetry-out=PATH        Append a JSON line per download (env GO_DOWNLOADER_TELEMETRY_OUT)")
+	fmt.Println()
+	fmt.Println("Hashing:")
+	fmt.Println("  --hash-buffer-size=BYTES    Read buffer for hashing (default 1048576)")
+	fmt.Println("  --hash-mmap                 Hash files through a memory map where supported")
+	fmt.Println()
+	fmt.Println("Concurrency:")
+	fmt.Println("  --concurrency=N             Items processed at once by multi-item commands (default 4)")
+	fmt.Println()
+	fmt.Println("GitHub API retries:")
+	fmt.Println("  --backoff=STRATEGY          constant, exponential or exponential-jitter (default)")
+	fmt.Println("  --backoff-base=DUR          Wait before the first retry (default 1s)")
+	fmt.Println("  --backoff-max=DUR           Longest computed wait (default 1m)")
+	fmt.Println("  --retries=N                 Retries after the first attempt (default 3)")
+	fmt.Println()
+	fmt.Println("Logging:")
+	fmt.Println("  --log-level=LEVEL           debug, info (default), warn or error; progress goes to stderr (env LOG_LEVEL)")
+	fmt.Println("  --quiet                     Same as --log-level=warn")
+	fmt.Println("  --log-format=FORMAT         text (default) or json, one object per line (env LOG_FORMAT)")
 }
 
 func handleDownload() {
-	if len(os.Args) < 4 {
-		fmt.Println("❌ Usage: download <url> <output-path>")
+	var args []string
+	resume := false
+	flagArgs, algo := takeAlgoFlag(os.Args[2:])
+	for _, arg := range flagArgs {
+		if arg == "--resume" {
+			resume = true
+			continue
+		}
+		args = append(args, arg)
+	}
+	if len(args) < 2 {
+		checksumkit.Errorf("❌ Usage: download <url> <output-path> [--resume] [--algo=sha256|sha512|blake3]")
 		return
 	}
 
-	url := os.Args[2]
-	outputPath := os.Args[3]
+	url := args[0]
+	outputPath := args[1]
 
-	result := downloadFile(url, outputPath)
+	result := downloadFile(url, outputPath, resume, algo)
+	printDownloadResult(result)
+}
+
+func handleDownloadRelease() {
+	if len(os.Args) < 6 {
+		checksumkit.Errorf("❌ Usage: download-release <github-repo> <version> <asset-name> <output-path>")
+		return
+	}
+
+	url := releaseAssetURL(os.Args[2], os.Args[3], os.Args[4])
+	outputPath := os.Args[5]
+
+	result := downloadFile(url, outputPath, false, "")
 	printDownloadResult(result)
 }
 
 func handleFetchReleaseInfo() {
 	if len(os.Args) < 3 {
-		fmt.Println("❌ Usage: fetch-release-info <github-repo>")
+		checksumkit.Errorf("❌ Usage: fetch-release-info <github-repo>")
 		return
 	}
 
 	repo := os.Args[2]
 	release, err := fetchLatestRelease(repo)
 	if err != nil {
-		fmt.Printf("❌ Failed to fetch release info: %v\n", err)
+		checksumkit.Errorf("❌ Failed to fetch release info: %v", err)
 		return
 	}
 
@@ -130,41 +409,57 @@ func handleFetchReleaseInfo() {
 }
 
 func handleValidateChecksum() {
-	if len(os.Args) < 4 {
-		fmt.Println("❌ Usage: validate-checksum <file-path> <expected-sha256>")
+	args, algo := takeAlgoFlag(os.Args[2:])
+	if len(args) < 2 {
+		checksumkit.Errorf("❌ Usage: validate-checksum <file-path> <expected-digest> [--algo=sha256|sha512|blake3]")
 		return
 	}
 
-	filePath := os.Args[2]
-	expectedSHA256 := os.Args[3]
+	filePath := args[0]
+	expectedSHA256 := args[1]
 
-	result := validateChecksum(filePath, expectedSHA256)
+	result := validateChecksum(filePath, expectedSHA256, algo)
 	printValidationResult(result)
 }
 
 func handleDownloadAndValidate() {
-	if len(os.Args) < 5 {
-		fmt.Println("❌ Usage: download-and-validate <url> <output-path> <expected-sha256>")
+	args, algo := takeAlgoFlag(os.Args[2:])
+	if len(args) < 3 {
+		checksumkit.Errorf("❌ Usage: download-and-validate <url> <output-path> <expected-digest> [--algo=sha256|sha512|blake3]")
 		return
 	}
 
-	url := os.Args[2]
-	outputPath := os.Args[3]
-	expectedSHA256 := os.Args[4]
+	url := args[0]
+	outputPath := args[1]
+	expectedSHA256 := args[2]
 
-	// Download first
-	fmt.Println("📥 Step 1: Downloading file...")
-	downloadResult := downloadFile(url, outputPath)
+	// The cache is keyed by SHA-256, so other algorithms bypass it
+	algo, expectedDigest, err := resolveAlgorithm(algo, expectedSHA256)
+	if err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		return
+	}
+	useCache := cacheDir != "" && algo == algoSHA256
+
+	// Download first, unless the cache holds a fresh copy
+	checksumkit.Infof("📥 Step 1: Downloading file...")
+	downloadResult, cached := DownloadResult{}, false
+	if useCache {
+		downloadResult, cached = downloadFromCache(url, outputPath, expectedDigest)
+	}
+	if !cached {
+		downloadResult = downloadFile(url, outputPath, false, algo)
+	}
 	printDownloadResult(downloadResult)
 
 	if !downloadResult.Success {
-		fmt.Println("❌ Download failed, cannot validate checksum")
+		checksumkit.Errorf("❌ Download failed, cannot validate checksum")
 		return
 	}
 
 	// Then validate
-	fmt.Println("\n🔍 Step 2: Validating checksum...")
-	validationResult := validateChecksum(outputPath, expectedSHA256)
+	checksumkit.Infof("🔍 Step 2: Validating checksum...")
+	validationResult := validateChecksum(outputPath, expectedSHA256, algo)
 	printValidationResult(validationResult)
 
 	// Summary
@@ -173,45 +468,86 @@ func handleDownloadAndValidate() {
 	fmt.Printf("  SHA256: %s\n", downloadResult.SHA256)
 	if validationResult.Valid {
 		fmt.Println("  ✅ Checksum validation: PASSED")
+		if useCache && !cached {
+			if err := storeInCache(cacheDir, outputPath, validationResult.ActualSHA256); err != nil {
+				checksumkit.Warnf("⚠️  Failed to store in cache: %v", err)
+			} else if err := saveCacheRecord(cacheDir, newCacheRecord(downloadResult)); err != nil {
+				checksumkit.Warnf("⚠️  Failed to record cache entry: %v", err)
+			} else {
+				checksumkit.Infof("🗄️  Cached at %s", cachePath(cacheDir, validationResult.ActualSHA256))
+			}
+		}
 	} else {
 		fmt.Println("  ❌ Checksum validation: FAILED")
 	}
 }
 
 func handleTestConnection() {
-	fmt.Println("🔗 Testing network connectivity...")
+	checksumkit.Infof("🔗 Testing network connectivity...")
 
 	testURLs := []string{
-		"https://api.github.com",
-		"https://github.com",
+		checksumkit.GitHubAPIBase,
+		githubDownloadBase,
 		"https://httpbin.org/get",
 	}
 
-	for _, url := range testURLs {
+	// Hosts are probed concurrently; results print in the order listed
+	results := checksumkit.RunBounded(testURLs, concurrency, checkConnection)
+	for i, url := range testURLs {
 		fmt.Printf("  Testing %s... ", url)
-
-		client := &http.Client{Timeout: 10 * time.Second}
-		resp, err := client.Get(url)
-		if err != nil {
-			fmt.Printf("❌ Failed: %v\n", err)
+		if results[i].Err != nil {
+			fmt.Printf("❌ Failed: %v\n", results[i].Err)
 			continue
 		}
-		defer resp.Body.Close()
+		fmt.Printf("✅ %s\n", results[i].Value)
+	}
+}
+
+// checkConnection issues a GET to url and returns the response status
+func checkConnection(url string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-		fmt.Printf("✅ %s\n", resp.Status)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
 	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	return resp.Status, nil
 }
 
-func downloadFile(url, outputPath string) DownloadResult {
+// downloadFile saves url to outputPath. With resume, bytes already at
+// outputPath from an interrupted download are kept and only the rest is
+// requested; a partial file is then also kept if this transfer fails. An
+// algo other than sha256 also computes result.Digest in the same pass.
+func downloadFile(url, outputPath string, resume bool, algo string) (result DownloadResult) {
 	startTime := time.Now()
+	defer func() { recordDownload(result, time.Since(startTime)) }()
 
-	result := DownloadResult{
+	result = DownloadResult{
 		URL:       url,
 		LocalPath: outputPath,
 		Success:   false,
 	}
 
-	fmt.Printf("📥 Downloading: %s\n", url)
+	var digestHasher hash.Hash
+	if algo != "" && algo != algoSHA256 {
+		h, err := newHasher(algo)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		digestHasher = h
+		result.Algorithm = algo
+	}
+
+	checksumkit.Infof("📥 Downloading: %s", url)
 
 	// Create output directory if it doesn't exist
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
@@ -219,63 +555,260 @@ func downloadFile(url, outputPath string) DownloadResult {
 		return result
 	}
 
-	// Download file
-	resp, err := http.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		result.Error = fmt.Sprintf("HTTP request failed: %v", err)
+		result.Error = fmt.Sprintf("Invalid request: %v", err)
+		return result
+	}
+
+	var offset int64
+	if resume {
+		var validator string
+		offset, validator = partialDownload(outputPath)
+		if offset > 0 {
+			checksumkit.Infof("↪️  Resuming from byte %d", offset)
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			if validator != "" {
+				req.Header.Set("If-Range", validator)
+			}
+		}
+	}
+
+	// Download file, retrying transient failures. Each attempt gets its own
+	// request deadline, which also bounds reading the body of the last one.
+	cancel := context.CancelFunc(func() {})
+	defer func() { cancel() }()
+
+	resp, err := checksumkit.RetryWithBackoff(func() (*http.Response, error) {
+		cancel()
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(context.Background(), requestTimeout)
+		result.Attempts++
+		return httpClient.Do(req.WithContext(ctx))
+	})
+	if err != nil {
+		result.setRequestError("HTTP request failed", err)
 		return result
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the range or the file changed upstream
+		offset = 0
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if start, _, ok := contentRange(resp.Header.Get("Content-Range")); !ok || start != offset {
+			result.Error = fmt.Sprintf("Unexpected Content-Range %q for a resume from byte %d", resp.Header.Get("Content-Range"), offset)
+			return result
+		}
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		return completedPartial(result, resp, startTime, digestHasher)
+	default:
 		result.Error = fmt.Sprintf("HTTP error: %s", resp.Status)
 		return result
 	}
 
-	// Create output file
-	file, err := os.Create(outputPath)
+	// Fail early rather than part-way through a large write
+	if err := checkDiskSpace(filepath.Dir(outputPath), resp.ContentLength); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// Create output file, or reopen the partial one to append to it
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if offset > 0 {
+		flags = os.O_RDWR
+	}
+	file, err := os.OpenFile(outputPath, flags, 0644)
 	if err != nil {
 		result.Error = fmt.Sprintf("Failed to create file: %v", err)
 		return result
 	}
 	defer file.Close()
 
-	// Copy data and calculate SHA256
+	// The hash covers the whole file, so feed it the bytes already present
+	// first; reading them leaves the file positioned to append
 	hasher := sha256.New()
-	writer := io.MultiWriter(file, hasher)
+	prefixSink, bodySink := io.Writer(hasher), io.Writer(file)
+	if digestHasher != nil {
+		prefixSink = io.MultiWriter(hasher, digestHasher)
+		bodySink = io.MultiWriter(file, digestHasher)
+	}
+	if offset > 0 {
+		if _, err := io.CopyN(prefixSink, file, offset); err != nil {
+			result.Error = fmt.Sprintf("Failed to read partial file: %v", err)
+			return result
+		}
+	}
+	if resume {
+		saveResumeValidator(outputPath, resp)
+	}
 
-	size, err := io.Copy(writer, resp.Body)
+	// Copy data and calculate SHA256. Unless resuming, a partial file is
+	// never left behind, whether the transfer failed or ran out of time.
+	digest, size, err := checksumkit.HashReader(io.TeeReader(resp.Body, bodySink), hasher)
 	if err != nil {
-		result.Error = fmt.Sprintf("Failed to copy data: %v", err)
+		file.Close()
+		if !resume {
+			os.Remove(outputPath)
+		}
+		result.setRequestError("Failed to copy data", err)
 		return result
 	}
+	size += offset
+	os.Remove(resumeValidatorPath(outputPath))
 
 	result.Size = size
-	result.SHA256 = hex.EncodeToString(hasher.Sum(nil))
+	result.SHA256 = digest
+	if digestHasher != nil {
+		result.Digest = hex.EncodeToString(digestHasher.Sum(nil))
+	}
+	result.ETag = resp.Header.Get("ETag")
 	result.DownloadTime = time.Since(startTime).Milliseconds()
 	result.Success = true
 
 	return result
 }
 
+// completedPartial handles a 416 answer to a resume. When the server's total
+// length equals the partial file's, the earlier download had in fact
+// finished and the file is complete; otherwise the partial file cannot be
+// part of the current artifact and is removed.
+func completedPartial(result DownloadResult, resp *http.Response, startTime time.Time, digestHasher hash.Hash) DownloadResult {
+	_, total, ok := contentRange(resp.Header.Get("Content-Range"))
+	info, err := os.Stat(result.LocalPath)
+	if !ok || err != nil || total != info.Size() {
+		os.Remove(result.LocalPath)
+		os.Remove(resumeValidatorPath(result.LocalPath))
+		result.Error = fmt.Sprintf("HTTP error: %s (partial file removed)", resp.Status)
+		return result
+	}
+
+	digest, size, err := checksumkit.HashFile(result.LocalPath, sha256.New())
+	if err != nil {
+		result.Error = fmt.Sprintf("Failed to hash file: %v", err)
+		return result
+	}
+	if digestHasher != nil {
+		if result.Digest, _, err = checksumkit.HashFile(result.LocalPath, digestHasher); err != nil {
+			result.Error = fmt.Sprintf("Failed to hash file: %v", err)
+			return result
+		}
+	}
+	os.Remove(resumeValidatorPath(result.LocalPath))
+
+	result.Size = size
+	result.SHA256 = digest
+	result.DownloadTime = time.Since(startTime).Milliseconds()
+	result.Success = true
+	return result
+}
+
+// setRequestError records a failed request, calling out deadline expiry
+// separately so callers can tell a stalled transfer from other failures
+func (r *DownloadResult) setRequestError(action string, err error) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		r.TimedOut = true
+		r.Error = fmt.Sprintf("%s: timed out after %s", action, requestTimeout)
+		return
+	}
+	r.Error = fmt.Sprintf("%s: %v", action, err)
+}
+
+func latestReleaseURL(repo string) string {
+	return checksumkit.LatestReleaseURL(repo)
+}
+
+func releaseByTagURL(repo, tag string) string {
+	return fmt.Sprintf("%s/repos/%s/releases/tags/%s", checksumkit.GitHubAPIBase, repo, tag)
+}
+
+func releaseAssetURL(repo, version, assetName string) string {
+	return fmt.Sprintf("%s/%s/releases/download/%s/%s", githubDownloadBase, repo, version, assetName)
+}
+
+// freeDiskSpace queries free space for checkDiskSpace; tests replace it to
+// simulate a nearly full filesystem
+var freeDiskSpace = availableDiskSpace
+
+// minDiskSpaceMargin is the smallest headroom required beyond the download size
+const minDiskSpaceMargin = 1 << 20
+
+// checkDiskSpace verifies the destination filesystem can hold contentLength
+// bytes plus a safety margin of 10% (at least minDiskSpaceMargin). The check is
+// skipped when the length is unknown or free space cannot be queried.
+func checkDiskSpace(dir string, contentLength int64) error {
+	if contentLength <= 0 {
+		return nil
+	}
+
+	available, ok := freeDiskSpace(dir)
+	if !ok {
+		return nil
+	}
+
+	margin := max(contentLength/10, minDiskSpaceMargin)
+	required := uint64(contentLength + margin)
+	if available < required {
+		return fmt.Errorf("insufficient disk space in %s: need %s (download %s + margin), have %s",
+			dir, formatBytes(int64(required)), formatBytes(contentLength), formatBytes(int64(available)))
+	}
+
+	return nil
+}
+
 func fetchLatestRelease(repo string) (*GitHubRelease, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", repo)
+	return fetchRelease(latestReleaseURL(repo))
+}
+
+// fetchReleaseByTag fetches a specific release, accepting the version with or
+// without its "v" prefix
+func fetchReleaseByTag(repo, version string) (*GitHubRelease, error) {
+	if version == "latest" {
+		return fetchLatestRelease(repo)
+	}
+
+	release, err := fetchRelease(releaseByTagURL(repo, version))
+	if err != nil && !strings.HasPrefix(version, "v") {
+		if prefixed, prefixedErr := fetchRelease(releaseByTagURL(repo, "v"+version)); prefixedErr == nil {
+			return prefixed, nil
+		}
+	}
+	return release, err
+}
 
-	fmt.Printf("🔍 Fetching release info: %s\n", url)
+func fetchRelease(url string) (*GitHubRelease, error) {
+	checksumkit.Infof("🔍 Fetching release info: %s", url)
 
-	resp, err := http.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("HTTP request failed: %v", err)
+		return nil, fmt.Errorf("Invalid request: %v", err)
+	}
+	checksumkit.AuthorizeGitHub(req)
+
+	// Each attempt gets its own deadline; the last one stays live while the
+	// body is read
+	cancel := context.CancelFunc(func() {})
+	defer func() { cancel() }()
+
+	resp, err := checksumkit.RetryWithBackoff(func() (*http.Response, error) {
+		cancel()
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(context.Background(), requestTimeout)
+		return httpClient.Do(req.WithContext(ctx))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API error: %s", resp.Status)
+		return nil, checksumkit.GitHubAPIError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("Failed to read response: %v", err)
+		return nil, fmt.Errorf("Failed to read response: %w", err)
 	}
 
 	var release GitHubRelease
@@ -286,7 +819,10 @@ func fetchLatestRelease(repo string) (*GitHubRelease, error) {
 	return &release, nil
 }
 
-func validateChecksum(filePath, expectedSHA256 string) ChecksumValidationResult {
+// validateChecksum hashes filePath with the algorithm resolveAlgorithm picks
+// for algo and the expected digest, and compares the digests
+// case-insensitively
+func validateChecksum(filePath, expectedSHA256, algo string) ChecksumValidationResult {
 	startTime := time.Now()
 
 	result := ChecksumValidationResult{
@@ -295,6 +831,19 @@ func validateChecksum(filePath, expectedSHA256 string) ChecksumValidationResult
 		Valid:          false,
 	}
 
+	algorithm, expectedDigest, err := resolveAlgorithm(algo, expectedSHA256)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Algorithm = algorithm
+
+	hasher, err := newHasher(result.Algorithm)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
 	// Check if file exists
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
@@ -304,23 +853,16 @@ func validateChecksum(filePath, expectedSHA256 string) ChecksumValidationResult
 
 	result.FileSize = fileInfo.Size()
 
-	// Calculate SHA256
-	file, err := os.Open(filePath)
+	// Calculate the digest
+	digest, _, err := checksumkit.HashFile(filePath, hasher)
 	if err != nil {
-		result.Error = fmt.Sprintf("Failed to open file: %v", err)
-		return result
-	}
-	defer file.Close()
-
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, file); err != nil {
 		result.Error = fmt.Sprintf("Failed to read file: %v", err)
 		return result
 	}
 
-	result.ActualSHA256 = hex.EncodeToString(hasher.Sum(nil))
+	result.ActualSHA256 = digest
 	result.ValidationTime = time.Since(startTime).Milliseconds()
-	result.Valid = strings.EqualFold(result.ActualSHA256, expectedSHA256)
+	result.Valid = strings.EqualFold(result.ActualSHA256, expectedDigest)
 
 	return result
 }
@@ -333,11 +875,23 @@ func printDownloadResult(result DownloadResult) {
 		fmt.Printf("  ✅ Status: SUCCESS\n")
 		fmt.Printf("  📦 Size: %s\n", formatBytes(result.Size))
 		fmt.Printf("  🔐 SHA256: %s\n", result.SHA256)
+		if result.Digest != "" {
+			fmt.Printf("  🔐 %s: %s\n", strings.ToUpper(result.Algorithm), result.Digest)
+		}
 		fmt.Printf("  ⏱️  Time: %dms\n", result.DownloadTime)
+		if result.FromCache {
+			fmt.Printf("  🗄️  Source: cache\n")
+		}
+	} else if result.TimedOut {
+		fmt.Printf("  ⏰ Status: TIMED OUT\n")
+		fmt.Printf("  💥 Error: %s\n", result.Error)
 	} else {
 		fmt.Printf("  ❌ Status: FAILED\n")
 		fmt.Printf("  💥 Error: %s\n", result.Error)
 	}
+	if result.Attempts > 1 {
+		fmt.Printf("  🔁 Attempts: %d\n", result.Attempts)
+	}
 }
 
 func printReleaseInfo(release *GitHubRelease) {
@@ -366,8 +920,9 @@ func printValidationResult(result ChecksumValidationResult) {
 		return
 	}
 
-	fmt.Printf("  🔐 Expected SHA256: %s\n", result.ExpectedSHA256)
-	fmt.Printf("  🔐 Actual SHA256:   %s\n", result.ActualSHA256)
+	algo := strings.ToUpper(result.Algorithm)
+	fmt.Printf("  🔐 Expected %s: %s\n", algo, result.ExpectedSHA256)
+	fmt.Printf("  🔐 Actual %s:   %s\n", algo, result.ActualSHA256)
 	fmt.Printf("  ⏱️  Time: %dms\n", result.ValidationTime)
 
 	if result.Valid {
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// withGitHubBases restores the endpoint globals after a test changes them
+func withGitHubBases(t *testing.T) {
+	t.Helper()
+	api, download := checksumkit.GitHubAPIBase, githubDownloadBase
+	t.Cleanup(func() { checksumkit.GitHubAPIBase, githubDownloadBase = api, download })
+}
+
+func TestGitHubBaseOverrides(t *testing.T) {
+	tests := []struct {
+		name         string
+		env          map[string]string
+		args         []string
+		wantAPI      string
+		wantDownload string
+	}{
+		{
+			name:         "defaults",
+			wantAPI:      "https://api.github.com/repos/bytecodealliance/wasmtime/releases/latest",
+			wantDownload: "https://github.com/bytecodealliance/wasmtime/releases/download/v1.0.0/wasmtime.tar.xz",
+		},
+		{
+			name:         "flags",
+			args:         []string{"--github-api-base=https://ghe.example.com/api/v3", "--github-download-base=https://ghe.example.com"},
+			wantAPI:      "https://ghe.example.com/api/v3/repos/bytecodealliance/wasmtime/releases/latest",
+			wantDownload: "https://ghe.example.com/bytecodealliance/wasmtime/releases/download/v1.0.0/wasmtime.tar.xz",
+		},
+		{
+			name:         "environment",
+			env:          map[string]string{"GITHUB_API_BASE": "https://ghe.example.com/api/v3/", "GITHUB_DOWNLOAD_BASE": "https://ghe.example.com/"},
+			wantAPI:      "https://ghe.example.com/api/v3/repos/bytecodealliance/wasmtime/releases/latest",
+			wantDownload: "https://ghe.example.com/bytecodealliance/wasmtime/releases/download/v1.0.0/wasmtime.tar.xz",
+		},
+		{
+			name:         "flag beats environment",
+			env:          map[string]string{"GITHUB_API_BASE": "https://env.example.com/api/v3"},
+			args:         []string{"--github-api-base=https://flag.example.com/api/v3/"},
+			wantAPI:      "https://flag.example.com/api/v3/repos/bytecodealliance/wasmtime/releases/latest",
+			wantDownload: "https://github.com/bytecodealliance/wasmtime/releases/download/v1.0.0/wasmtime.tar.xz",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withGitHubBases(t)
+			t.Setenv("GITHUB_API_BASE", "")
+			t.Setenv("GITHUB_DOWNLOAD_BASE", "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			rest := parseGlobalFlags(append(tt.args, "latest", "bytecodealliance/wasmtime"))
+			if len(rest) != 2 {
+				t.Errorf("parseGlobalFlags left %v, want the command arguments only", rest)
+			}
+
+			if got := latestReleaseURL("bytecodealliance/wasmtime"); got != tt.wantAPI {
+				t.Errorf("latestReleaseURL = %s, want %s", got, tt.wantAPI)
+			}
+			if got := releaseAssetURL("bytecodealliance/wasmtime", "v1.0.0", "wasmtime.tar.xz"); got != tt.wantDownload {
+				t.Errorf("releaseAssetURL = %s, want %s", got, tt.wantDownload)
+			}
+		})
+	}
+}
+
+// TestFetchLatestReleaseUsesAPIBase points the API base at a local server
+// and checks fetchLatestRelease requests the release from it
+func TestFetchLatestReleaseUsesAPIBase(t *testing.T) {
+	withGitHubBases(t)
+
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		fmt.Fprint(w, `{"tag_name":"v9.9.9"}`)
+	}))
+	defer server.Close()
+
+	checksumkit.GitHubAPIBase = server.URL + "/api/v3"
+	release, err := fetchLatestRelease("bytecodealliance/wasmtime")
+	if err != nil {
+		t.Fatalf("fetchLatestRelease: %v", err)
+	}
+	if want := "/api/v3/repos/bytecodealliance/wasmtime/releases/latest"; requested != want {
+		t.Errorf("requested %s, want %s", requested, want)
+	}
+	if release.TagName != "v9.9.9" {
+		t.Errorf("tag = %s, want v9.9.9", release.TagName)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// resolvePlatform turns "auto" into the platform this binary runs on and
+// rejects platforms assets cannot be mapped onto
+func resolvePlatform(platform string) (string, error) {
+	if platform == "auto" {
+		platform = runtime.GOOS + "_" + runtime.GOARCH
+	}
+	for _, supported := range checksumkit.SupportedPlatforms {
+		if supported == platform {
+			return platform, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported platform %q", platform)
+}
+
+// findAssetForPlatform picks the release asset best suited to platform
+func findAssetForPlatform(assets []ReleaseAsset, platform string) *ReleaseAsset {
+	names := make([]string, len(assets))
+	for i, asset := range assets {
+		names[i] = asset.Name
+	}
+
+	if i := checksumkit.SelectAsset(names, platform); i >= 0 {
+		return &assets[i]
+	}
+	return nil
+}
+
+func handleDownloadForPlatform() {
+	if len(os.Args) < 6 {
+		checksumkit.Errorf("❌ Usage: download-for-platform <github-repo> <version|latest> <platform|auto> <output-dir> [expected-sha256]")
+		return
+	}
+
+	result, err := downloadForPlatform(os.Args[2], os.Args[3], os.Args[4], os.Args[5])
+	if err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		os.Exit(1)
+	}
+	printDownloadResult(result)
+	if !result.Success {
+		os.Exit(1)
+	}
+
+	if len(os.Args) > 6 {
+		validation := validateChecksum(result.LocalPath, os.Args[6], "")
+		printValidationResult(validation)
+		if !validation.Valid {
+			os.Exit(1)
+		}
+	}
+}
+
+// downloadForPlatform downloads the asset of repo's release version that
+// best suits platform, or the running platform for "auto", into outputDir.
+// An error means no download was attempted; a failed download is reported
+// in the result.
+func downloadForPlatform(repo, version, platform, outputDir string) (DownloadResult, error) {
+	platform, err := resolvePlatform(platform)
+	if err != nil {
+		return DownloadResult{}, err
+	}
+	checksumkit.Infof("🖥️  Platform: %s", platform)
+
+	release, err := fetchReleaseByTag(repo, version)
+	if err != nil {
+		return DownloadResult{}, fmt.Errorf("failed to fetch release info: %w", err)
+	}
+
+	asset := findAssetForPlatform(release.Assets, platform)
+	if asset == nil {
+		return DownloadResult{}, fmt.Errorf("no asset in %s %s matches platform %s", repo, release.TagName, platform)
+	}
+	checksumkit.Infof("📦 Selected asset: %s", asset.Name)
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return DownloadResult{}, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	url := asset.BrowserDownloadURL
+	if url == "" {
+		url = releaseAssetURL(repo, release.TagName, asset.Name)
+	}
+	return downloadFile(url, filepath.Join(outputDir, filepath.Base(asset.Name)), false, ""), nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// wasmToolsAssets is a wasm-tools style release, with sidecar files that
+// must never be picked
+var wasmToolsAssets = []string{
+	"wasm-tools-1.236.0-aarch64-linux.tar.gz",
+	"wasm-tools-1.236.0-aarch64-macos.tar.gz",
+	"wasm-tools-1.236.0-x86_64-linux.tar.gz",
+	"wasm-tools-1.236.0-x86_64-linux.tar.gz.sha256",
+	"wasm-tools-1.236.0-x86_64-macos.tar.gz",
+	"wasm-tools-1.236.0-x86_64-windows.zip",
+	"wasm-tools-1.236.0.tar.gz",
+}
+
+func TestResolvePlatform(t *testing.T) {
+	tests := []struct {
+		platform string
+		want     string
+		wantErr  bool
+	}{
+		{platform: "linux_amd64", want: "linux_amd64"},
+		{platform: "darwin_arm64", want: "darwin_arm64"},
+		{platform: "windows_amd64", want: "windows_amd64"},
+		{platform: "windows_arm64", wantErr: true},
+		{platform: "linux-amd64", wantErr: true},
+		{platform: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := resolvePlatform(tt.platform)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("resolvePlatform(%q) = %q, %v; want %q, wantErr %v", tt.platform, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	// auto resolves to the host, which is itself checked for support
+	host := runtime.GOOS + "_" + runtime.GOARCH
+	_, hostErr := resolvePlatform(host)
+	got, err := resolvePlatform("auto")
+	if (err != nil) != (hostErr != nil) || (err == nil && got != host) {
+		t.Errorf("resolvePlatform(auto) = %q, %v; want it to resolve like %s", got, err, host)
+	}
+}
+
+func TestFindAssetForPlatform(t *testing.T) {
+	assets := make([]ReleaseAsset, len(wasmToolsAssets))
+	for i, name := range wasmToolsAssets {
+		assets[i] = ReleaseAsset{Name: name}
+	}
+
+	want := map[string]string{
+		"darwin_amd64":  "wasm-tools-1.236.0-x86_64-macos.tar.gz",
+		"darwin_arm64":  "wasm-tools-1.236.0-aarch64-macos.tar.gz",
+		"linux_amd64":   "wasm-tools-1.236.0-x86_64-linux.tar.gz",
+		"linux_arm64":   "wasm-tools-1.236.0-aarch64-linux.tar.gz",
+		"windows_amd64": "wasm-tools-1.236.0-x86_64-windows.zip",
+	}
+	for _, platform := range checksumkit.SupportedPlatforms {
+		asset := findAssetForPlatform(assets, platform)
+		if asset == nil || asset.Name != want[platform] {
+			t.Errorf("findAssetForPlatform(%s) = %v, want %s", platform, asset, want[platform])
+		}
+	}
+
+	if asset := findAssetForPlatform(assets[:2], "windows_amd64"); asset != nil {
+		t.Errorf("findAssetForPlatform without a Windows asset = %s, want none", asset.Name)
+	}
+}
+
+// TestDownloadForPlatform serves a release from a local GitHub stand-in and
+// checks the platform's asset is the one downloaded
+func TestDownloadForPlatform(t *testing.T) {
+	withGitHubBases(t)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v3/repos/bytecodealliance/wasm-tools/releases/tags/v1.236.0" {
+			release := GitHubRelease{TagName: "v1.236.0"}
+			for _, name := range wasmToolsAssets {
+				release.Assets = append(release.Assets, ReleaseAsset{Name: name, BrowserDownloadURL: server.URL + "/download/" + name})
+			}
+			json.NewEncoder(w).Encode(release)
+			return
+		}
+		if name, ok := strings.CutPrefix(r.URL.Path, "/download/"); ok {
+			w.Write([]byte("contents of " + name))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	t.Cleanup(server.Close)
+	checksumkit.GitHubAPIBase = server.URL + "/api/v3"
+
+	outputDir := filepath.Join(t.TempDir(), "tools")
+	// Without the "v" prefix, as users often type it
+	result, err := downloadForPlatform("bytecodealliance/wasm-tools", "1.236.0", "linux_arm64", outputDir)
+	if err != nil {
+		t.Fatalf("downloadForPlatform: %v", err)
+	}
+	if !result.Success {
+		t.Fatalf("download failed: %s", result.Error)
+	}
+
+	wantPath := filepath.Join(outputDir, "wasm-tools-1.236.0-aarch64-linux.tar.gz")
+	if result.LocalPath != wantPath {
+		t.Errorf("LocalPath = %s, want %s", result.LocalPath, wantPath)
+	}
+	if got, _ := os.ReadFile(wantPath); string(got) != "contents of wasm-tools-1.236.0-aarch64-linux.tar.gz" {
+		t.Errorf("downloaded %q, want the linux_arm64 asset", got)
+	}
+
+	if _, err := downloadForPlatform("bytecodealliance/wasm-tools", "v1.236.0", "plan9_386", outputDir); err == nil {
+		t.Error("downloadForPlatform accepted an unsupported platform")
+	}
+	if _, err := downloadForPlatform("bytecodealliance/wasm-tools", "v9.9.9", "linux_amd64", outputDir); err == nil {
+		t.Error("downloadForPlatform succeeded for a missing release")
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// archiveEntry is one normalized member of an archive being repacked
+type archiveEntry struct {
+	name     string // Slash-separated, relative, without a trailing slash
+	dir      bool
+	symlink  string // Link target, for symlinks
+	exec     bool   // Any execute bit was set on the input
+	contents []byte
+}
+
+// repackArchive reads a .tar, .tar.gz/.tgz or .zip and writes its contents as
+// a byte-stable .tar.gz: entries sorted by name, parent directories made
+// explicit, timestamps zeroed, uid/gid 0 and modes fixed at 0755 for
+// directories and executables and 0644 otherwise.
+func repackArchive(inputPath, outputPath string) error {
+	entries, err := readArchiveEntries(inputPath)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(outputPath), ".repack-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := writeCanonicalTarGz(tmp, entries); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), outputPath)
+}
+
+func readArchiveEntries(inputPath string) (map[string]*archiveEntry, error) {
+	lower := strings.ToLower(inputPath)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return readZipEntries(inputPath)
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		file, err := os.Open(inputPath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading gzip: %w", err)
+		}
+		defer gz.Close()
+		return readTarEntries(gz)
+	case strings.HasSuffix(lower, ".tar"):
+		file, err := os.Open(inputPath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return readTarEntries(file)
+	default:
+		return nil, fmt.Errorf("unsupported archive type: %s", inputPath)
+	}
+}
+
+func readTarEntries(r io.Reader) (map[string]*archiveEntry, error) {
+	entries := make(map[string]*archiveEntry)
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading tar: %w", err)
+		}
+
+		name, err := safeArchivePath(header.Name)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			continue // The archive root itself
+		}
+
+		entry := &archiveEntry{name: name, exec: header.Mode&0111 != 0}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			entry.dir = true
+		case tar.TypeSymlink:
+			if err := checkSymlinkTarget(name, header.Linkname); err != nil {
+				return nil, err
+			}
+			entry.symlink = header.Linkname
+		case tar.TypeReg, tar.TypeRegA:
+			if entry.contents, err = io.ReadAll(tr); err != nil {
+				return nil, fmt.Errorf("reading %s: %w", header.Name, err)
+			}
+		case tar.TypeXGlobalHeader:
+			continue
+		default:
+			return nil, fmt.Errorf("unsupported tar entry type %q for %s", header.Typeflag, header.Name)
+		}
+		entries[name] = entry
+	}
+}
+
+func readZipEntries(inputPath string) (map[string]*archiveEntry, error) {
+	zr, err := zip.OpenReader(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading zip: %w", err)
+	}
+	defer zr.Close()
+
+	entries := make(map[string]*archiveEntry)
+	for _, file := range zr.File {
+		name, err := safeArchivePath(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			continue
+		}
+
+		mode := file.Mode()
+		entry := &archiveEntry{name: name, exec: mode&0111 != 0}
+		switch {
+		case mode.IsDir():
+			entry.dir = true
+		case mode&os.ModeSymlink != 0:
+			target, err := readZipFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if err := checkSymlinkTarget(name, string(target)); err != nil {
+				return nil, err
+			}
+			entry.symlink = string(target)
+		default:
+			if entry.contents, err = readZipFile(file); err != nil {
+				return nil, err
+			}
+		}
+		entries[name] = entry
+	}
+	return entries, nil
+}
+
+func readZipFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", file.Name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// safeArchivePath normalizes an entry name and rejects any that would land
+// outside the extraction root
+func safeArchivePath(name string) (string, error) {
+	slashed := strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(slashed, "/") || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("archive entry %q has an absolute path", name)
+	}
+
+	cleaned := path.Clean(slashed)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("archive entry %q escapes the archive root", name)
+	}
+	if cleaned == "." {
+		return "", nil
+	}
+	return cleaned, nil
+}
+
+// checkSymlinkTarget rejects links that point outside the extraction root
+func checkSymlinkTarget(name, target string) error {
+	if strings.HasPrefix(target, "/") {
+		return fmt.Errorf("symlink %s has absolute target %q", name, target)
+	}
+	resolved := path.Clean(path.Join(path.Dir(name), target))
+	if resolved == ".." || strings.HasPrefix(resolved, "../") {
+		return fmt.Errorf("symlink %s target %q escapes the archive root", name, target)
+	}
+	return nil
+}
+
+func writeCanonicalTarGz(w io.Writer, entries map[string]*archiveEntry) error {
+	// Make every parent directory explicit so the output does not depend on
+	// whether the input listed them
+	for name := range entries {
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if _, ok := entries[dir]; !ok {
+				entries[dir] = &archiveEntry{name: dir, dir: true}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// A zero gzip header carries no name or modification time
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	for _, name := range names {
+		entry := entries[name]
+		header := &tar.Header{Name: name, Mode: 0644, Format: tar.FormatPAX}
+		switch {
+		case entry.dir:
+			header.Typeflag = tar.TypeDir
+			header.Name += "/"
+			header.Mode = 0755
+		case entry.symlink != "":
+			header.Typeflag = tar.TypeSymlink
+			header.Linkname = entry.symlink
+			header.Mode = 0777
+		default:
+			header.Typeflag = tar.TypeReg
+			header.Size = int64(len(entry.contents))
+			if entry.exec {
+				header.Mode = 0755
+			}
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeReg {
+			if _, err := io.Copy(tw, bytes.NewReader(entry.contents)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func handleRepack() {
+	if len(os.Args) < 4 {
+		checksumkit.Errorf("❌ Usage: repack <input-archive> <output.tar.gz>")
+		return
+	}
+
+	inputPath := os.Args[2]
+	outputPath := os.Args[3]
+
+	checksumkit.Infof("📦 Repacking %s", inputPath)
+	if err := repackArchive(inputPath, outputPath); err != nil {
+		checksumkit.Errorf("❌ Repack failed: %v", err)
+		os.Exit(1)
+	}
+
+	digest, size, err := checksumkit.HashFile(outputPath, sha256.New())
+	if err != nil {
+		checksumkit.Errorf("❌ Failed to hash %s: %v", outputPath, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Wrote %s (%s)\n", outputPath, formatBytes(size))
+	fmt.Printf("🔐 SHA256: %s\n", digest)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testMember is one file placed in a generated input archive
+type testMember struct {
+	name     string
+	contents string
+	mode     int64
+	symlink  string
+}
+
+// writeTestTarGz writes members, in the given order, with the metadata a
+// release archive typically carries
+func writeTestTarGz(t *testing.T, path string, members []testMember, modTime time.Time, uid int) {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.ModTime = modTime
+	gz.Name = filepath.Base(path)
+	tw := tar.NewWriter(gz)
+	for _, m := range members {
+		header := &tar.Header{
+			Name:    m.name,
+			Mode:    m.mode,
+			Size:    int64(len(m.contents)),
+			ModTime: modTime,
+			Uid:     uid,
+			Gid:     uid,
+			Uname:   "builder",
+		}
+		if m.symlink != "" {
+			header.Typeflag, header.Linkname, header.Size = tar.TypeSymlink, m.symlink, 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if m.symlink == "" {
+			if _, err := io.WriteString(tw, m.contents); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeTestZip writes members as a zip archive
+func writeTestZip(t *testing.T, path string, members []testMember, modTime time.Time) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, m := range members {
+		header := &zip.FileHeader{Name: m.name, Method: zip.Deflate, Modified: modTime}
+		mode := os.FileMode(m.mode)
+		contents := m.contents
+		if m.symlink != "" {
+			mode, contents = mode|os.ModeSymlink, m.symlink
+		}
+		header.SetMode(mode)
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, contents); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+var repackMembers = []testMember{
+	{name: "wasm-tools-1.0.0/wasm-tools", contents: "\x7fELF binary", mode: 0700},
+	{name: "wasm-tools-1.0.0/README.md", contents: "# wasm-tools\n", mode: 0600},
+	{name: "wasm-tools-1.0.0/licenses/LICENSE-MIT", contents: "MIT\n", mode: 0664},
+	{name: "wasm-tools-1.0.0/bin/wasm-tools", symlink: "../wasm-tools", mode: 0777},
+}
+
+// repackTwice repacks input into two outputs and returns both
+func repackTwice(t *testing.T, input string) ([]byte, []byte) {
+	t.Helper()
+	dir := t.TempDir()
+	var outputs [2][]byte
+	for i := range outputs {
+		out := filepath.Join(dir, fmt.Sprintf("out%d.tar.gz", i))
+		if err := repackArchive(input, out); err != nil {
+			t.Fatalf("repackArchive(%s): %v", input, err)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		outputs[i] = data
+	}
+	return outputs[0], outputs[1]
+}
+
+func TestRepackDeterministic(t *testing.T) {
+	dir := t.TempDir()
+
+	reversed := make([]testMember, len(repackMembers))
+	for i, m := range repackMembers {
+		reversed[len(repackMembers)-1-i] = m
+	}
+
+	// The same files with different ordering, timestamps, owners and formats
+	tarA := filepath.Join(dir, "a.tar.gz")
+	writeTestTarGz(t, tarA, repackMembers, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), 1000)
+	tarB := filepath.Join(dir, "b.tgz")
+	writeTestTarGz(t, tarB, reversed, time.Date(2025, 6, 7, 8, 9, 10, 0, time.UTC), 501)
+	zipC := filepath.Join(dir, "c.zip")
+	writeTestZip(t, zipC, reversed, time.Date(2023, 11, 12, 13, 14, 16, 0, time.UTC))
+
+	first, second := repackTwice(t, tarA)
+	if !bytes.Equal(first, second) {
+		t.Fatal("two repacks of the same input differ")
+	}
+	for _, input := range []string{tarB, zipC} {
+		again, _ := repackTwice(t, input)
+		if !bytes.Equal(first, again) {
+			t.Errorf("repack of %s differs from repack of the same files in %s", filepath.Base(input), filepath.Base(tarA))
+		}
+	}
+
+	// The output is canonical, not merely stable
+	gz, err := gzip.NewReader(bytes.NewReader(first))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gz.Name != "" || !gz.ModTime.IsZero() {
+		t.Errorf("gzip header carries name %q, mtime %v", gz.Name, gz.ModTime)
+	}
+
+	want := []struct {
+		name string
+		mode int64
+	}{
+		{"wasm-tools-1.0.0/", 0755},
+		{"wasm-tools-1.0.0/README.md", 0644},
+		{"wasm-tools-1.0.0/bin/", 0755},
+		{"wasm-tools-1.0.0/bin/wasm-tools", 0777},
+		{"wasm-tools-1.0.0/licenses/", 0755},
+		{"wasm-tools-1.0.0/licenses/LICENSE-MIT", 0644},
+		{"wasm-tools-1.0.0/wasm-tools", 0755},
+	}
+	tr := tar.NewReader(gz)
+	for i := 0; ; i++ {
+		header, err := tr.Next()
+		if err == io.EOF {
+			if i != len(want) {
+				t.Errorf("got %d entries, want %d", i, len(want))
+			}
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i >= len(want) {
+			t.Errorf("unexpected entry %s", header.Name)
+			continue
+		}
+		if header.Name != want[i].name || header.Mode != want[i].mode {
+			t.Errorf("entry %d = %s %o, want %s %o", i, header.Name, header.Mode, want[i].name, want[i].mode)
+		}
+		if !header.ModTime.Equal(time.Unix(0, 0)) || header.Uid != 0 || header.Gid != 0 || header.Uname != "" {
+			t.Errorf("%s keeps metadata: mtime %v, uid %d, gid %d, uname %q", header.Name, header.ModTime, header.Uid, header.Gid, header.Uname)
+		}
+	}
+}
+
+func TestRepackRejectsTraversal(t *testing.T) {
+	tests := []struct {
+		name    string
+		member  testMember
+		wantErr string
+	}{
+		{"parent path", testMember{name: "../evil", contents: "x", mode: 0644}, "escapes the archive root"},
+		{"nested parent path", testMember{name: "pkg/../../evil", contents: "x", mode: 0644}, "escapes the archive root"},
+		{"absolute path", testMember{name: "/etc/passwd", contents: "x", mode: 0644}, "absolute path"},
+		{"escaping symlink", testMember{name: "pkg/link", symlink: "../../etc/passwd", mode: 0777}, "escapes the archive root"},
+		{"absolute symlink", testMember{name: "pkg/link", symlink: "/etc/passwd", mode: 0777}, "absolute target"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			members := []testMember{{name: "pkg/ok", contents: "ok", mode: 0644}, tt.member}
+
+			inputs := []string{filepath.Join(dir, "in.tar.gz"), filepath.Join(dir, "in.zip")}
+			writeTestTarGz(t, inputs[0], members, time.Unix(0, 0), 0)
+			writeTestZip(t, inputs[1], members, time.Unix(0, 0))
+
+			for _, input := range inputs {
+				out := filepath.Join(dir, "out.tar.gz")
+				err := repackArchive(input, out)
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("%s: repackArchive error = %v, want %q", filepath.Base(input), err, tt.wantErr)
+				}
+				if _, statErr := os.Stat(out); !os.IsNotExist(statErr) {
+					t.Errorf("%s: output written despite the error", filepath.Base(input))
+				}
+			}
+		})
+	}
+}
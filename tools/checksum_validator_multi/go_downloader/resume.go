@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// resumeValidatorPath holds the ETag or Last-Modified of the response a
+// partial file came from, sent back as If-Range so a file that changed
+// upstream is downloaded again instead of spliced together
+func resumeValidatorPath(outputPath string) string {
+	return outputPath + ".resume"
+}
+
+// partialDownload reports how many bytes of an interrupted download are
+// already at outputPath, and the validator saved for them if any
+func partialDownload(outputPath string) (int64, string) {
+	info, err := os.Stat(outputPath)
+	if err != nil || !info.Mode().IsRegular() || info.Size() == 0 {
+		return 0, ""
+	}
+
+	validator, err := os.ReadFile(resumeValidatorPath(outputPath))
+	if err != nil {
+		return info.Size(), ""
+	}
+	return info.Size(), strings.TrimSpace(string(validator))
+}
+
+// saveResumeValidator records the validator of resp next to outputPath.
+// Weak ETags cannot be used with If-Range, so Last-Modified is the fallback.
+func saveResumeValidator(outputPath string, resp *http.Response) {
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	if validator == "" {
+		os.Remove(resumeValidatorPath(outputPath))
+		return
+	}
+	if err := os.WriteFile(resumeValidatorPath(outputPath), []byte(validator+"\n"), 0644); err != nil {
+		checksumkit.Warnf("⚠️  Failed to save resume validator: %v", err)
+	}
+}
+
+// contentRange parses a "bytes <start>-<end>/<total>" or "bytes */<total>"
+// Content-Range header. A missing bound or an unknown total ("*") is -1.
+func contentRange(header string) (start, total int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	span, totalText, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+
+	total = -1
+	if totalText != "*" {
+		value, err := strconv.ParseInt(totalText, 10, 64)
+		if err != nil || value < 0 {
+			return 0, 0, false
+		}
+		total = value
+	}
+
+	start = -1
+	if span != "*" {
+		startText, _, found := strings.Cut(span, "-")
+		value, err := strconv.ParseInt(startText, 10, 64)
+		if !found || err != nil || value < 0 {
+			return 0, 0, false
+		}
+		start = value
+	}
+	return start, total, true
+}
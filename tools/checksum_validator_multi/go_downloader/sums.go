@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// parseChecksumFile reads a sha256sum-style file such as SHASUMS256.txt into
+// a map from file name to hex digest. Both "<hash>  <file>" (text mode) and
+// "<hash> *<file>" (binary mode) lines are accepted; blank lines and lines
+// starting with # are skipped.
+func parseChecksumFile(sumsPath string) (map[string]string, error) {
+	file, err := os.Open(sumsPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		digest, name, ok := strings.Cut(line, " ")
+		name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*")
+		if _, err := hex.DecodeString(digest); !ok || err != nil || name == "" {
+			return nil, fmt.Errorf("%s:%d: expected \"<hash>  <file>\", got %q", sumsPath, lineNum, line)
+		}
+		sums[name] = strings.ToLower(digest)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return sums, nil
+}
+
+// lookupChecksum finds the digest for a file by its base name. Entries may
+// carry a directory ("./dist/tool.tar.gz"), so those are matched on their
+// last element too.
+func lookupChecksum(sums map[string]string, filePath string) (string, bool) {
+	base := filepath.Base(filePath)
+	if digest, ok := sums[base]; ok {
+		return digest, true
+	}
+	for name, digest := range sums {
+		if path.Base(name) == base {
+			return digest, true
+		}
+	}
+	return "", false
+}
+
+func handleValidateAgainstSums() {
+	args, algo := takeAlgoFlag(os.Args[2:])
+	if len(args) < 2 {
+		checksumkit.Errorf("❌ Usage: validate-against-sums <file-path> <sums-file> [--algo=sha256|sha512|blake3]")
+		return
+	}
+
+	filePath := args[0]
+	sumsPath := args[1]
+
+	sums, err := parseChecksumFile(sumsPath)
+	if err != nil {
+		checksumkit.Errorf("❌ Failed to read checksum file: %v", err)
+		os.Exit(1)
+	}
+
+	expectedDigest, ok := lookupChecksum(sums, filePath)
+	if !ok {
+		checksumkit.Errorf("❌ No checksum for %s in %s", filepath.Base(filePath), sumsPath)
+		os.Exit(1)
+	}
+
+	result := validateChecksum(filePath, expectedDigest, algo)
+	printValidationResult(result)
+	if !result.Valid {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// telemetryOut, when set, receives one JSON line per finished download
+var telemetryOut string
+
+// telemetryMu serializes appends so concurrent downloads never interleave
+// partial lines in the telemetry file
+var telemetryMu sync.Mutex
+
+// DownloadTelemetry is the record appended to --telemetry-out for each download
+type DownloadTelemetry struct {
+	Timestamp     string  `json:"timestamp"`
+	URL           string  `json:"url"`
+	Bytes         int64   `json:"bytes"`
+	DurationMS    int64   `json:"duration_ms"`
+	ThroughputBps float64 `json:"throughput_bytes_per_sec"`
+	Retries       int     `json:"retries"`
+	CacheHit      bool    `json:"cache_hit"`
+	Status        string  `json:"status"` // success, timeout or error
+	Error         string  `json:"error,omitempty"`
+}
+
+// newDownloadTelemetry summarizes a finished download
+func newDownloadTelemetry(result DownloadResult, elapsed time.Duration) DownloadTelemetry {
+	record := DownloadTelemetry{
+		Timestamp:  time.Now().UTC().Format(time.RFC3339Nano),
+		URL:        result.URL,
+		Bytes:      result.Size,
+		DurationMS: elapsed.Milliseconds(),
+		Retries:    max(result.Attempts-1, 0),
+		CacheHit:   result.FromCache,
+		Status:     "success",
+		Error:      result.Error,
+	}
+
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		record.ThroughputBps = float64(result.Size) / seconds
+	}
+
+	switch {
+	case result.TimedOut:
+		record.Status = "timeout"
+	case !result.Success:
+		record.Status = "error"
+	}
+
+	return record
+}
+
+// recordDownload appends a telemetry line for the download when
+// --telemetry-out is set. Telemetry is best effort: a write failure is
+// reported but never fails the download itself.
+func recordDownload(result DownloadResult, elapsed time.Duration) {
+	if telemetryOut == "" {
+		return
+	}
+
+	if err := appendTelemetry(telemetryOut, newDownloadTelemetry(result, elapsed)); err != nil {
+		checksumkit.Warnf("⚠️  Failed to write telemetry: %v", err)
+	}
+}
+
+// appendTelemetry writes a record as a single JSON line. The file is opened
+// in append mode for each record, so separate processes sharing a path (for
+// example parallel CI steps) also append whole lines.
+func appendTelemetry(path string, record DownloadTelemetry) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	telemetryMu.Lock()
+	defer telemetryMu.Unlock()
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// withTelemetryOut points --telemetry-out at a fresh file and returns its path
+func withTelemetryOut(t *testing.T) string {
+	t.Helper()
+	original := telemetryOut
+	telemetryOut = filepath.Join(t.TempDir(), "telemetry.jsonl")
+	t.Cleanup(func() { telemetryOut = original })
+	return telemetryOut
+}
+
+// readTelemetry decodes every line of the telemetry file, failing the test
+// on any line that is not a whole record
+func readTelemetry(t *testing.T, path string) []DownloadTelemetry {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var records []DownloadTelemetry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record DownloadTelemetry
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("telemetry line %q: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// TestTelemetryConcurrentDownloads runs downloads in parallel, as batch mode
+// does, and checks each appends exactly one whole line
+func TestTelemetryConcurrentDownloads(t *testing.T) {
+	path := withTelemetryOut(t)
+	body := strings.Repeat("x", 64*1024)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/missing") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	const downloads = 32
+	dir := t.TempDir()
+	var wg sync.WaitGroup
+	for i := 0; i < downloads; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			url := fmt.Sprintf("%s/asset-%d", server.URL, i)
+			if i%8 == 0 {
+				url = fmt.Sprintf("%s/%d/missing", server.URL, i)
+			}
+			downloadFile(url, filepath.Join(dir, fmt.Sprint(i)), false, "")
+		}(i)
+	}
+	wg.Wait()
+
+	records := readTelemetry(t, path)
+	if len(records) != downloads {
+		t.Fatalf("got %d telemetry records, want %d", len(records), downloads)
+	}
+
+	seen := make(map[string]bool)
+	for _, record := range records {
+		if seen[record.URL] {
+			t.Errorf("duplicate record for %s", record.URL)
+		}
+		seen[record.URL] = true
+
+		if strings.HasSuffix(record.URL, "/missing") {
+			if record.Status != "error" || !strings.Contains(record.Error, "404") {
+				t.Errorf("%s: status %s error %q, want a 404 error", record.URL, record.Status, record.Error)
+			}
+			continue
+		}
+		if record.Status != "success" || record.Bytes != int64(len(body)) || record.CacheHit || record.Retries != 0 {
+			t.Errorf("%s: record %+v, want a successful %d byte download", record.URL, record, len(body))
+		}
+		if _, err := time.Parse(time.RFC3339Nano, record.Timestamp); err != nil {
+			t.Errorf("%s: timestamp %q: %v", record.URL, record.Timestamp, err)
+		}
+	}
+}
+
+func TestTelemetryRecordsRetriesAndCacheHits(t *testing.T) {
+	path := withTelemetryOut(t)
+	backoff := checksumkit.Backoff
+	checksumkit.Backoff = checksumkit.BackoffConfig{Strategy: checksumkit.BackoffConstant, Retries: 2}
+	t.Cleanup(func() { checksumkit.Backoff = backoff })
+
+	var mu sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		failing := requests <= 2
+		mu.Unlock()
+		if failing {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("artifact"))
+	}))
+	t.Cleanup(server.Close)
+
+	url := server.URL + "/artifact"
+	outputPath := filepath.Join(t.TempDir(), "artifact")
+	result := downloadFile(url, outputPath, false, "")
+	if !result.Success {
+		t.Fatalf("download failed: %s", result.Error)
+	}
+
+	// Serve the same URL from the cache
+	originalCacheDir := cacheDir
+	cacheDir = t.TempDir()
+	t.Cleanup(func() { cacheDir = originalCacheDir })
+	if err := storeInCache(cacheDir, outputPath, result.SHA256); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveCacheRecord(cacheDir, newCacheRecord(result)); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := downloadFromCache(url, filepath.Join(t.TempDir(), "artifact"), result.SHA256); !cached {
+		t.Fatal("cache miss for a freshly cached artifact")
+	}
+
+	records := readTelemetry(t, path)
+	if len(records) != 2 {
+		t.Fatalf("got %d telemetry records, want 2", len(records))
+	}
+	if got := records[0]; got.Retries != 2 || got.CacheHit || got.Status != "success" {
+		t.Errorf("download record = %+v, want 2 retries and no cache hit", got)
+	}
+	if got := records[1]; !got.CacheHit || got.Retries != 0 || got.Bytes != int64(len("artifact")) || got.Status != "success" {
+		t.Errorf("cache record = %+v, want a cache hit of %d bytes", got, len("artifact"))
+	}
+}
+
+func TestNewDownloadTelemetryStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		result DownloadResult
+		want   string
+	}{
+		{name: "success", result: DownloadResult{Success: true, Size: 2000}, want: "success"},
+		{name: "timeout", result: DownloadResult{TimedOut: true, Error: "timed out"}, want: "timeout"},
+		{name: "error", result: DownloadResult{Error: "HTTP error: 500"}, want: "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := newDownloadTelemetry(tt.result, 2*time.Second)
+			if record.Status != tt.want {
+				t.Errorf("status = %s, want %s", record.Status, tt.want)
+			}
+			if record.DurationMS != 2000 || record.ThroughputBps != float64(tt.result.Size)/2 {
+				t.Errorf("duration %dms throughput %v, want 2000ms and size/2", record.DurationMS, record.ThroughputBps)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// renameFile moves the finished temp file into place; tests replace it to
+// interrupt a write just before it lands
+var renameFile = os.Rename
+
+// writeFileAtomic replaces path with data so that readers, and a run killed
+// part way through, only ever see the old or the new content. The data is
+// written and synced to a temp file in the same directory, renamed over
+// path, and the directory is synced so the rename itself is durable.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := renameFile(tmp.Name(), path); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
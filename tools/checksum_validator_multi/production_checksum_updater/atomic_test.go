@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// dirEntries lists the names in dir
+func dirEntries(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wasm-tools.json")
+	if err := os.WriteFile(path, []byte(`{"old":true}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte(`{"new":true}`), 0644); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"new":true}` {
+		t.Errorf("content = %s, want the new data", got)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v, want 0644", info.Mode().Perm())
+	}
+	if names := dirEntries(t, dir); len(names) != 1 {
+		t.Errorf("directory holds %v, want only the target", names)
+	}
+}
+
+// TestWriteFileAtomicInterrupted fails the write just before the rename and
+// checks the original is untouched and no temp file is left behind
+func TestWriteFileAtomicInterrupted(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wasm-tools.json")
+	original := []byte(`{"old":true}`)
+	if err := os.WriteFile(path, original, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	interrupted := errors.New("interrupted")
+	var staged []byte
+	t.Cleanup(func() { renameFile = os.Rename })
+	renameFile = func(from, to string) error {
+		// By now the temp file is complete, next to the target
+		if filepath.Dir(from) != dir {
+			t.Errorf("temp file %s is not in %s", from, dir)
+		}
+		staged, _ = os.ReadFile(from)
+		return interrupted
+	}
+
+	if err := writeFileAtomic(path, []byte(`{"new":true}`), 0644); !errors.Is(err, interrupted) {
+		t.Fatalf("writeFileAtomic error = %v, want the interruption", err)
+	}
+	if string(staged) != `{"new":true}` {
+		t.Errorf("temp file held %q before the rename, want the full new data", staged)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(original) {
+		t.Errorf("content = %s, want the original left intact", got)
+	}
+	if names := dirEntries(t, dir); len(names) != 1 || names[0] != "wasm-tools.json" {
+		t.Errorf("directory holds %v, want the temp file removed", names)
+	}
+}
+
+func TestWriteFileAtomicMissingDirectory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "wasm-tools.json")
+	if err := writeFileAtomic(path, []byte("{}"), 0644); err == nil {
+		t.Error("writeFileAtomic succeeded in a missing directory")
+	}
+}
+
+func TestSyncDir(t *testing.T) {
+	if err := syncDir(t.TempDir()); err != nil {
+		t.Errorf("syncDir: %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+
+package main
+
+// syncDir is a no-op on this platform (including WASI and Windows), where
+// directories cannot be opened for syncing or renames are already durable
+func syncDir(dir string) error {
+	return nil
+}
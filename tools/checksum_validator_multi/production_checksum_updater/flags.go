@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// applyBackoffFlags reads --backoff=STRATEGY, --backoff-base=DURATION,
+// --backoff-max=DURATION and --retries=N
+func applyBackoffFlags(flags map[string]string) error {
+	if value, ok := flags["backoff"]; ok {
+		if !checksumkit.ValidBackoffStrategy(value) {
+			return fmt.Errorf("--backoff must be constant, exponential or exponential-jitter, got %q", value)
+		}
+		checksumkit.Backoff.Strategy = value
+	}
+	for _, name := range []string{"backoff-base", "backoff-max"} {
+		value, ok := flags[name]
+		if !ok {
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("--%s must be a positive duration, got %q", name, value)
+		}
+		if name == "backoff-base" {
+			checksumkit.Backoff.Base = d
+		} else {
+			checksumkit.Backoff.Max = d
+		}
+	}
+	if value, ok := flags["retries"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("--retries must be a non-negative integer, got %q", value)
+		}
+		checksumkit.Backoff.Retries = n
+	}
+	return nil
+}
+
+// applyHashFlags reads --hash-buffer-size=BYTES and --hash-mmap
+func applyHashFlags(flags map[string]string) error {
+	if value, ok := flags["hash-buffer-size"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return fmt.Errorf("--hash-buffer-size must be a positive integer, got %q", value)
+		}
+		checksumkit.HashBufferSize = n
+	}
+	checksumkit.HashUseMmap = flags["hash-mmap"] != ""
+	return nil
+}
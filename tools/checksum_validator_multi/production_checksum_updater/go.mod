@@ -1,6 +0,0 @@
-module production-checksum-updater
-
-go 1.21
-
-// Production checksum updater for CI system
-// Used by Bazel to manage tool dependencies
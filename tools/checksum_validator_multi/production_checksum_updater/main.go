@@ -1,16 +1,20 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
 )
 
 // Production tool for CI system - downloads and validates checksums for real tools
@@ -38,6 +42,8 @@ type ToolInfo struct {
 	LastChecked        string                 `json:"last_checked"`
 	SupportedPlatforms []string               `json:"supported_platforms"`
 	Versions           map[string]VersionInfo `json:"versions"`
+	// URLTemplate optionally describes asset names, e.g. "{tool}-v{version}-{suffix}"
+	URLTemplate string `json:"url_template,omitempty"`
 }
 
 type VersionInfo struct {
@@ -51,12 +57,28 @@ type PlatformInfo struct {
 }
 
 func main() {
+	if err := applyGlobalFlags(); err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		os.Exit(1)
+	}
+
 	if len(os.Args) < 2 {
 		fmt.Println("Production Checksum Updater for CI System")
 		fmt.Println("Usage:")
-		fmt.Println("  update-tool <tool-name> <checksums-dir>")
+		fmt.Println("  update-tool <tool-name> <checksums-dir> [--skip-existing] [--force] [--concurrency=N] [--sign-with=<key.pem>] [--backoff=STRATEGY] [--retries=N]")
+		fmt.Println("  update-all <checksums-dir> [--skip-existing] [--force] [--fail-fast] [--concurrency=N] [--sign-with=<key.pem>] [--backoff=STRATEGY] [--retries=N]")
 		fmt.Println("  validate-tool <tool-name> <version> <platform> <checksums-dir>")
 		fmt.Println("  check-latest <tool-name> <checksums-dir>")
+		fmt.Println("  verify-downloaded <tool-name> <version> <platform> <file> <checksums-dir> [--hash-buffer-size=BYTES] [--hash-mmap]")
+		fmt.Println("  verify-json <tool-name> <pubkey.pem> <checksums-dir>")
+		fmt.Println("  merge <out.json> <in1.json> <in2.json> ...")
+		fmt.Println()
+		fmt.Println("Commands taking <checksums-dir> accept --backend=json (one file per tool, default)")
+		fmt.Println("or --backend=bundle (every tool in <checksums-dir>/" + bundleFileName + ").")
+		fmt.Println("Every command accepts --log-level=debug|info|warn|error (env LOG_LEVEL), --quiet (same as")
+		fmt.Println("--log-level=warn) and --log-format=text|json (env LOG_FORMAT); progress goes to stderr.")
+		fmt.Println("Set GITHUB_TOKEN to authenticate GitHub API calls and raise their 60/hour rate limit.")
+		fmt.Println("Point --github-api-base=URL (env GITHUB_API_BASE) at a GitHub Enterprise Server API, e.g. https://ghe.example.com/api/v3.")
 		return
 	}
 
@@ -64,50 +86,234 @@ func main() {
 	switch command {
 	case "update-tool":
 		updateTool()
+	case "update-all":
+		updateAll()
 	case "validate-tool":
 		validateTool()
 	case "check-latest":
 		checkLatest()
+	case "verify-downloaded":
+		verifyDownloaded()
+	case "verify-json":
+		verifyJSON()
+	case "merge":
+		mergeChecksums()
 	default:
-		fmt.Printf("Unknown command: %s\n", command)
+		checksumkit.Errorf("Unknown command: %s", command)
 		os.Exit(1)
 	}
 }
 
+// applyGlobalFlags reads LOG_LEVEL, LOG_FORMAT and GITHUB_API_BASE and then
+// the logging flags and --github-api-base=URL, removing the flags from
+// os.Args so commands never see them
+func applyGlobalFlags() error {
+	if err := checksumkit.ApplyLogEnv(); err != nil {
+		return err
+	}
+	if base := os.Getenv("GITHUB_API_BASE"); base != "" {
+		checksumkit.GitHubAPIBase = base
+	}
+
+	args := os.Args[:1]
+	for _, arg := range os.Args[1:] {
+		if matched, err := checksumkit.ApplyLogFlag(arg); matched {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if base, ok := strings.CutPrefix(arg, "--github-api-base="); ok {
+			checksumkit.GitHubAPIBase = base
+			continue
+		}
+		args = append(args, arg)
+	}
+	os.Args = args
+	return nil
+}
+
+// UpdateOptions controls how update-tool refreshes platform checksums
+type UpdateOptions struct {
+	SkipExisting bool // Reuse checksums already recorded for the target version
+	Force        bool // Re-download every platform even when already recorded
+	// SigningKey, when set, signs the written tool JSON into <tool>.json.sig
+	SigningKey ed25519.PrivateKey
+	// Concurrency bounds how many platform assets are downloaded at once
+	Concurrency int
+}
+
+// defaultConcurrency covers the usual five supported platforms in one round
+const defaultConcurrency = 5
+
+// updateOptionsFromFlags builds UpdateOptions from update-tool/update-all flags
+func updateOptionsFromFlags(flags map[string]string) (UpdateOptions, error) {
+	opts := UpdateOptions{
+		SkipExisting: flags["skip-existing"] != "",
+		Force:        flags["force"] != "",
+		Concurrency:  defaultConcurrency,
+	}
+
+	if value, ok := flags["concurrency"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return opts, fmt.Errorf("--concurrency must be a positive integer, got %q", value)
+		}
+		opts.Concurrency = n
+	}
+
+	if err := applyBackoffFlags(flags); err != nil {
+		return opts, err
+	}
+
+	if keyPath, ok := flags["sign-with"]; ok {
+		if keyPath == "true" {
+			return opts, fmt.Errorf("--sign-with requires a key path (--sign-with=<key.pem>)")
+		}
+		key, err := loadSigningKey(keyPath)
+		if err != nil {
+			return opts, fmt.Errorf("failed to load signing key: %w", err)
+		}
+		opts.SigningKey = key
+	}
+
+	return opts, nil
+}
+
 func updateTool() {
-	if len(os.Args) < 4 {
-		fmt.Println("Usage: update-tool <tool-name> <checksums-dir>")
+	args, flags := splitArgs(os.Args[2:])
+	if len(args) < 2 {
+		checksumkit.Errorf("Usage: update-tool <tool-name> <checksums-dir> [--skip-existing] [--force] [--concurrency=N] [--sign-with=<key.pem>] [--backoff=STRATEGY] [--retries=N]")
 		return
 	}
 
-	toolName := os.Args[2]
-	checksumsDir := os.Args[3]
+	opts, err := updateOptionsFromFlags(flags)
+	if err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		os.Exit(1)
+	}
 
-	fmt.Printf("🔄 Updating checksums for %s\n", toolName)
+	store, err := storageFromFlags(args[1], flags)
+	if err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		os.Exit(1)
+	}
 
-	// Load existing tool info
-	toolPath := filepath.Join(checksumsDir, "tools", toolName+".json")
-	toolInfo, err := loadToolInfo(toolPath)
+	if err := updateToolChecksums(args[0], store, opts); err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		os.Exit(1)
+	}
+}
+
+// updateAll refreshes every tool in the checksums directory. By default each
+// failure is recorded and the run continues; --fail-fast stops at the first.
+// A platform whose download fails is not a tool failure: it is logged and
+// skipped, and --skip-existing fills it in on a later run.
+func updateAll() {
+	args, flags := splitArgs(os.Args[2:])
+	if len(args) < 1 {
+		checksumkit.Errorf("Usage: update-all <checksums-dir> [--skip-existing] [--force] [--fail-fast] [--concurrency=N] [--sign-with=<key.pem>] [--backoff=STRATEGY] [--retries=N]")
+		return
+	}
+
+	opts, err := updateOptionsFromFlags(flags)
 	if err != nil {
-		fmt.Printf("❌ Failed to load tool info: %v\n", err)
+		checksumkit.Errorf("❌ %v", err)
 		os.Exit(1)
 	}
+	failFast := flags["fail-fast"] != ""
 
-	// Fetch latest release from GitHub
-	fmt.Printf("📡 Fetching latest release from %s\n", toolInfo.GitHubRepo)
-	release, err := fetchLatestRelease(toolInfo.GitHubRepo)
+	store, err := storageFromFlags(args[0], flags)
 	if err != nil {
-		fmt.Printf("❌ Failed to fetch release: %v\n", err)
+		checksumkit.Errorf("❌ %v", err)
 		os.Exit(1)
 	}
 
-	// Check if we already have this version
-	if release.TagName == toolInfo.LatestVersion {
-		fmt.Printf("✅ Tool %s is already up to date (v%s)\n", toolName, release.TagName)
-		return
+	toolNames, err := store.ListTools()
+	if err != nil {
+		checksumkit.Errorf("❌ Failed to list tools: %v", err)
+		os.Exit(1)
 	}
 
-	fmt.Printf("🆕 New version found: %s → %s\n", toolInfo.LatestVersion, release.TagName)
+	failed := updateTools(toolNames, store, opts, failFast)
+	if failFast && len(failed) > 0 {
+		checksumkit.Errorf("❌ Aborting update-all, %s", failed[0])
+		os.Exit(1)
+	}
+
+	fmt.Printf("📊 Updated %d/%d tools\n", len(toolNames)-len(failed), len(toolNames))
+	if len(failed) > 0 {
+		fmt.Printf("❌ %d tools failed:\n", len(failed))
+		for _, failure := range failed {
+			fmt.Printf("  - %s\n", failure)
+		}
+		os.Exit(1)
+	}
+}
+
+// updateTools updates each tool in turn and returns a "<tool>: <error>"
+// entry for every failure. With failFast it stops at the first.
+func updateTools(toolNames []string, store StorageBackend, opts UpdateOptions, failFast bool) []string {
+	var failed []string
+	for _, toolName := range toolNames {
+		if err := updateToolChecksums(toolName, store, opts); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", toolName, err))
+			if failFast {
+				break
+			}
+			checksumkit.Errorf("❌ %s: %v", toolName, err)
+		}
+	}
+	return failed
+}
+
+// listTools returns the names of the tool JSON files in checksumsDir/tools
+func listTools(checksumsDir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(checksumsDir, "tools", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	toolNames := make([]string, 0, len(matches))
+	for _, match := range matches {
+		toolNames = append(toolNames, strings.TrimSuffix(filepath.Base(match), ".json"))
+	}
+
+	return toolNames, nil
+}
+
+// updateToolChecksums fetches the latest release of a tool and records the
+// checksum of each supported platform's asset in the tool's record
+func updateToolChecksums(toolName string, store StorageBackend, opts UpdateOptions) error {
+	checksumkit.Infof("🔄 Updating checksums for %s", toolName)
+
+	// Load existing tool info
+	toolPath := store.ToolPath(toolName)
+	toolInfo, err := store.LoadTool(toolName)
+	if err != nil {
+		return fmt.Errorf("failed to load tool info: %w", err)
+	}
+
+	// Fetch latest release from GitHub
+	checksumkit.Infof("📡 Fetching latest release from %s", toolInfo.GitHubRepo)
+	release, err := fetchLatestRelease(toolInfo.GitHubRepo)
+	if err != nil {
+		return fmt.Errorf("failed to fetch release: %w", err)
+	}
+
+	existing, hasExisting := toolInfo.Versions[release.TagName]
+
+	// Check if we already have this version. A partially-failed earlier run
+	// records the version with missing platforms, which --skip-existing fills in.
+	if release.TagName == toolInfo.LatestVersion && !opts.Force {
+		if !opts.SkipExisting || missingPlatforms(toolInfo, existing) == 0 {
+			checksumkit.Infof("✅ Tool %s is already up to date (v%s)", toolName, release.TagName)
+			return signToolInfo(toolPath, opts.SigningKey)
+		}
+		checksumkit.Infof("🩹 Completing %d missing platforms for v%s", missingPlatforms(toolInfo, existing), release.TagName)
+	} else {
+		checksumkit.Infof("🆕 New version found: %s → %s", toolInfo.LatestVersion, release.TagName)
+	}
 
 	// Download and calculate checksums for supported platforms
 	newVersionInfo := VersionInfo{
@@ -115,77 +321,150 @@ func updateTool() {
 		Platforms:   make(map[string]PlatformInfo),
 	}
 
+	// Platforms are independent downloads, so fetch them through a bounded
+	// worker pool. RunBounded hands results back in platform order, so the
+	// log reads the same however the downloads finish, and a failed platform
+	// is logged and skipped without stopping the others.
+	var pending []platformAsset
 	for _, platform := range toolInfo.SupportedPlatforms {
+		if opts.SkipExisting && !opts.Force && hasExisting {
+			if recorded, ok := existing.Platforms[platform]; ok && recorded.SHA256 != "" {
+				newVersionInfo.Platforms[platform] = recorded
+				checksumkit.Infof("⏭️  %s: reusing recorded %s", platform, recorded.SHA256)
+				continue
+			}
+		}
+
 		asset := findAssetForPlatform(release.Assets, platform, toolName)
 		if asset == nil {
-			fmt.Printf("⚠️  No asset found for platform %s\n", platform)
+			checksumkit.Warnf("⚠️  No asset found for platform %s", platform)
 			continue
 		}
 
-		fmt.Printf("📥 Downloading %s for %s...\n", asset.Name, platform)
-		sha256Hash, err := downloadAndHash(asset.BrowserDownloadURL)
-		if err != nil {
-			fmt.Printf("❌ Failed to download %s: %v\n", asset.Name, err)
+		checksumkit.Infof("📥 Downloading %s for %s...", asset.Name, platform)
+		pending = append(pending, platformAsset{Platform: platform, Asset: asset})
+	}
+
+	results := checksumkit.RunBounded(pending, opts.Concurrency, func(p platformAsset) (string, error) {
+		return downloadAndHash(p.Asset.BrowserDownloadURL)
+	})
+	var skipped []string
+	for i, p := range pending {
+		if results[i].Err != nil {
+			checksumkit.Errorf("❌ Failed to download %s: %v", p.Asset.Name, results[i].Err)
+			skipped = append(skipped, p.Platform)
 			continue
 		}
 
-		// Extract URL suffix from asset name
-		urlSuffix := extractURLSuffix(asset.Name, toolName, release.TagName)
-
-		newVersionInfo.Platforms[platform] = PlatformInfo{
+		sha256Hash := results[i].Value
+		newVersionInfo.Platforms[p.Platform] = PlatformInfo{
 			SHA256:    sha256Hash,
-			URLSuffix: urlSuffix,
+			URLSuffix: resolveURLSuffix(toolInfo, p.Asset.Name, release.TagName),
 		}
-
-		fmt.Printf("✅ %s: %s\n", platform, sha256Hash)
+		checksumkit.Infof("✅ %s: %s", p.Platform, sha256Hash)
 	}
 
 	// Update tool info
 	toolInfo.LatestVersion = release.TagName
 	toolInfo.LastChecked = time.Now().UTC().Format(time.RFC3339)
+	if toolInfo.Versions == nil {
+		toolInfo.Versions = make(map[string]VersionInfo)
+	}
 	toolInfo.Versions[release.TagName] = newVersionInfo
 
 	// Save updated tool info
-	err = saveToolInfo(toolPath, toolInfo)
-	if err != nil {
-		fmt.Printf("❌ Failed to save tool info: %v\n", err)
-		os.Exit(1)
+	if err := store.SaveTool(toolInfo); err != nil {
+		return fmt.Errorf("failed to save tool info: %w", err)
+	}
+
+	if err := signToolInfo(toolPath, opts.SigningKey); err != nil {
+		return fmt.Errorf("failed to sign tool info: %w", err)
+	}
+
+	if len(skipped) > 0 {
+		checksumkit.Warnf("⚠️  Skipped %d of %d platforms for %s (%s); rerun with --skip-existing to retry them",
+			len(skipped), len(pending), release.TagName, strings.Join(skipped, ", "))
+	}
+
+	checksumkit.Infof("🎉 Successfully updated %s to version %s", toolName, release.TagName)
+	return nil
+}
+
+// platformAsset is a platform whose release asset still has to be hashed
+type platformAsset struct {
+	Platform string
+	Asset    *Asset
+}
+
+// missingPlatforms counts supported platforms without a recorded checksum
+func missingPlatforms(toolInfo *ToolInfo, versionInfo VersionInfo) int {
+	missing := 0
+	for _, platform := range toolInfo.SupportedPlatforms {
+		if recorded, ok := versionInfo.Platforms[platform]; !ok || recorded.SHA256 == "" {
+			missing++
+		}
+	}
+	return missing
+}
+
+// splitArgs separates positional arguments from --flag and --flag=value
+// options. Bare flags are recorded with the value "true".
+func splitArgs(args []string) ([]string, map[string]string) {
+	positional := make([]string, 0, len(args))
+	flags := make(map[string]string)
+
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--") {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !hasValue {
+			value = "true"
+		}
+		flags[name] = value
 	}
 
-	fmt.Printf("🎉 Successfully updated %s to version %s\n", toolName, release.TagName)
+	return positional, flags
 }
 
 func validateTool() {
-	if len(os.Args) < 6 {
-		fmt.Println("Usage: validate-tool <tool-name> <version> <platform> <checksums-dir>")
+	args, flags := splitArgs(os.Args[2:])
+	if len(args) < 4 {
+		checksumkit.Errorf("Usage: validate-tool <tool-name> <version> <platform> <checksums-dir>")
 		return
 	}
 
-	toolName := os.Args[2]
-	version := os.Args[3]
-	platform := os.Args[4]
-	checksumsDir := os.Args[5]
+	toolName := args[0]
+	version := args[1]
+	platform := args[2]
 
-	fmt.Printf("🔍 Validating %s v%s for %s\n", toolName, version, platform)
+	store, err := storageFromFlags(args[3], flags)
+	if err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		os.Exit(1)
+	}
+
+	checksumkit.Infof("🔍 Validating %s v%s for %s", toolName, version, platform)
 
 	// Load tool info
-	toolPath := filepath.Join(checksumsDir, "tools", toolName+".json")
-	toolInfo, err := loadToolInfo(toolPath)
+	toolInfo, err := store.LoadTool(toolName)
 	if err != nil {
-		fmt.Printf("❌ Failed to load tool info: %v\n", err)
+		checksumkit.Errorf("❌ Failed to load tool info: %v", err)
 		os.Exit(1)
 	}
 
 	// Get expected checksum
 	versionInfo, exists := toolInfo.Versions[version]
 	if !exists {
-		fmt.Printf("❌ Version %s not found for %s\n", version, toolName)
+		checksumkit.Errorf("❌ Version %s not found for %s", version, toolName)
 		os.Exit(1)
 	}
 
 	platformInfo, exists := versionInfo.Platforms[platform]
 	if !exists {
-		fmt.Printf("❌ Platform %s not found for %s v%s\n", platform, toolName, version)
+		checksumkit.Errorf("❌ Platform %s not found for %s v%s", platform, toolName, version)
 		os.Exit(1)
 	}
 
@@ -193,27 +472,127 @@ func validateTool() {
 	fmt.Printf("✅ Checksum validation data available\n")
 }
 
+func verifyDownloaded() {
+	args, flags := splitArgs(os.Args[2:])
+	if len(args) < 5 {
+		checksumkit.Errorf("Usage: verify-downloaded <tool-name> <version> <platform> <file> <checksums-dir> [--hash-buffer-size=BYTES] [--hash-mmap]")
+		os.Exit(1)
+	}
+
+	if err := applyHashFlags(flags); err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		os.Exit(1)
+	}
+
+	toolName := args[0]
+	version := args[1]
+	platform := args[2]
+	filePath := args[3]
+
+	store, err := storageFromFlags(args[4], flags)
+	if err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		os.Exit(1)
+	}
+
+	checksumkit.Infof("🔍 Verifying %s against %s v%s for %s", filePath, toolName, version, platform)
+
+	// Load tool info
+	toolInfo, err := store.LoadTool(toolName)
+	if err != nil {
+		checksumkit.Errorf("❌ Failed to load tool info: %v", err)
+		os.Exit(1)
+	}
+
+	// Get expected checksum
+	versionInfo, exists := toolInfo.Versions[version]
+	if !exists {
+		checksumkit.Errorf("❌ Version %s not found for %s", version, toolName)
+		os.Exit(1)
+	}
+
+	platformInfo, exists := versionInfo.Platforms[platform]
+	if !exists {
+		checksumkit.Errorf("❌ Platform %s not found for %s v%s", platform, toolName, version)
+		os.Exit(1)
+	}
+
+	actualSHA256, _, err := checksumkit.HashFile(filePath, sha256.New())
+	if err != nil {
+		checksumkit.Errorf("❌ Failed to hash %s: %v", filePath, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("📋 Expected SHA256: %s\n", platformInfo.SHA256)
+	fmt.Printf("📋 Actual SHA256:   %s\n", actualSHA256)
+
+	if !strings.EqualFold(actualSHA256, platformInfo.SHA256) {
+		checksumkit.Errorf("❌ Checksum mismatch for %s", filePath)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Checksum verified\n")
+}
+
+// verifyJSON checks a tool JSON file against its detached ed25519 signature.
+// With the bundle backend the signature covers the whole bundle.
+func verifyJSON() {
+	args, flags := splitArgs(os.Args[2:])
+	if len(args) < 3 {
+		checksumkit.Errorf("Usage: verify-json <tool-name> <pubkey.pem> <checksums-dir>")
+		return
+	}
+
+	toolName := args[0]
+	pubKeyPath := args[1]
+
+	store, err := storageFromFlags(args[2], flags)
+	if err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		os.Exit(1)
+	}
+
+	key, err := loadVerifyKey(pubKeyPath)
+	if err != nil {
+		checksumkit.Errorf("❌ Failed to load public key: %v", err)
+		os.Exit(1)
+	}
+
+	toolPath := store.ToolPath(toolName)
+	if err := verifyToolInfo(toolPath, key); err != nil {
+		checksumkit.Errorf("❌ Signature verification failed: %v", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Signature valid for %s\n", toolPath)
+}
+
 func checkLatest() {
-	if len(os.Args) < 4 {
-		fmt.Println("Usage: check-latest <tool-name> <checksums-dir>")
+	args, flags := splitArgs(os.Args[2:])
+	if len(args) < 2 {
+		checksumkit.Errorf("Usage: check-latest <tool-name> <checksums-dir>")
 		return
 	}
 
-	toolName := os.Args[2]
-	checksumsDir := os.Args[3]
+	toolName := args[0]
+
+	store, err := storageFromFlags(args[1], flags)
+	if err != nil {
+		checksumkit.Errorf("❌ %v", err)
+		os.Exit(1)
+	}
 
 	// Load tool info
-	toolPath := filepath.Join(checksumsDir, "tools", toolName+".json")
-	toolInfo, err := loadToolInfo(toolPath)
+	toolInfo, err := store.LoadTool(toolName)
 	if err != nil {
-		fmt.Printf("❌ Failed to load tool info: %v\n", err)
+		checksumkit.Errorf("❌ Failed to load tool info: %v", err)
 		os.Exit(1)
 	}
 
 	// Fetch latest release
 	release, err := fetchLatestRelease(toolInfo.GitHubRepo)
 	if err != nil {
-		fmt.Printf("❌ Failed to fetch release: %v\n", err)
+		checksumkit.Errorf("❌ Failed to fetch release: %v", err)
 		os.Exit(1)
 	}
 
@@ -227,6 +606,7 @@ func checkLatest() {
 	}
 }
 
+// loadToolInfo reads one tool JSON file; with saveToolInfo it backs jsonBackend
 func loadToolInfo(path string) (*ToolInfo, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -242,27 +622,39 @@ func loadToolInfo(path string) (*ToolInfo, error) {
 	return &toolInfo, nil
 }
 
+// saveToolInfo writes tool info deterministically so that re-running an update
+// with the same data leaves the file byte-identical. Maps are already emitted
+// with sorted keys; slices are sorted here and the file ends with a newline.
+// The write is atomic, so an interrupted update-all leaves the previous file.
 func saveToolInfo(path string, toolInfo *ToolInfo) error {
+	sort.Strings(toolInfo.SupportedPlatforms)
+
 	data, err := json.MarshalIndent(toolInfo, "", "  ")
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, data, 0644)
+	return writeFileAtomic(path, append(data, '\n'), 0644)
 }
 
 func fetchLatestRelease(repo string) (*GitHubRelease, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", repo)
+	req, err := http.NewRequest(http.MethodGet, checksumkit.LatestReleaseURL(repo), nil)
+	if err != nil {
+		return nil, err
+	}
+	checksumkit.AuthorizeGitHub(req)
 
 	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Get(url)
+	resp, err := checksumkit.RetryWithBackoff(func() (*http.Response, error) {
+		return client.Do(req)
+	})
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API error: %s", resp.Status)
+		return nil, checksumkit.GitHubAPIError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -279,49 +671,29 @@ func fetchLatestRelease(repo string) (*GitHubRelease, error) {
 	return &release, nil
 }
 
+// findAssetForPlatform picks the release asset best suited to platform
 func findAssetForPlatform(assets []Asset, platform, toolName string) *Asset {
-	// Map our platform names to GitHub release patterns
-	patterns := map[string][]string{
-		"darwin_amd64":  {"macos", "darwin", "x86_64-apple"},
-		"darwin_arm64":  {"macos", "darwin", "aarch64-apple", "arm64-apple"},
-		"linux_amd64":   {"linux", "x86_64-unknown-linux"},
-		"linux_arm64":   {"linux", "aarch64-unknown-linux"},
-		"windows_amd64": {"windows", "x86_64-pc-windows"},
+	names := make([]string, len(assets))
+	for i, asset := range assets {
+		names[i] = asset.Name
 	}
 
-	platformPatterns := patterns[platform]
-	if platformPatterns == nil {
-		return nil
+	if i := checksumkit.SelectAsset(names, platform); i >= 0 {
+		return &assets[i]
 	}
-
-	for _, asset := range assets {
-		name := strings.ToLower(asset.Name)
-
-		// Skip source archives
-		if strings.Contains(name, "src") || strings.Contains(name, "source") {
-			continue
-		}
-
-		// Check if asset matches platform patterns
-		matchCount := 0
-		for _, pattern := range platformPatterns {
-			if strings.Contains(name, pattern) {
-				matchCount++
-			}
-		}
-
-		// Require at least one pattern match
-		if matchCount > 0 {
-			return &asset
-		}
-	}
-
 	return nil
 }
 
 func downloadAndHash(url string) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
 	client := &http.Client{Timeout: 5 * time.Minute}
-	resp, err := client.Get(url)
+	resp, err := checksumkit.RetryWithBackoff(func() (*http.Response, error) {
+		return client.Do(req)
+	})
 	if err != nil {
 		return "", err
 	}
@@ -331,13 +703,45 @@ func downloadAndHash(url string) (string, error) {
 		return "", fmt.Errorf("HTTP error: %s", resp.Status)
 	}
 
-	hasher := sha256.New()
-	_, err = io.Copy(hasher, resp.Body)
-	if err != nil {
-		return "", err
+	digest, _, err := checksumkit.HashReader(resp.Body, sha256.New())
+	return digest, err
+}
+
+// resolveURLSuffix derives the URL suffix from the tool's url_template when one
+// is configured, falling back to the extractURLSuffix heuristic otherwise
+func resolveURLSuffix(toolInfo *ToolInfo, assetName, version string) string {
+	if toolInfo.URLTemplate != "" {
+		suffix, ok := extractURLSuffixFromTemplate(assetName, toolInfo.URLTemplate, toolInfo.ToolName, version)
+		if ok {
+			return suffix
+		}
+		checksumkit.Warnf("⚠️  Asset %s does not match url_template %s, using heuristic", assetName, toolInfo.URLTemplate)
+	}
+
+	return extractURLSuffix(assetName, toolInfo.ToolName, version)
+}
+
+// extractURLSuffixFromTemplate matches an asset name against a template such as
+// "{tool}-v{version}-{suffix}" and returns the text matched by {suffix}.
+// {version} expands to the release version without a leading "v".
+func extractURLSuffixFromTemplate(assetName, template, toolName, version string) (string, bool) {
+	expanded := strings.NewReplacer(
+		"{tool}", toolName,
+		"{version}", strings.TrimPrefix(version, "v"),
+	).Replace(template)
+
+	prefix, postfix, found := strings.Cut(expanded, "{suffix}")
+	if !found {
+		return "", false
+	}
+
+	if len(assetName) <= len(prefix)+len(postfix) ||
+		!strings.HasPrefix(assetName, prefix) ||
+		!strings.HasSuffix(assetName, postfix) {
+		return "", false
 	}
 
-	return hex.EncodeToString(hasher.Sum(nil)), nil
+	return assetName[len(prefix) : len(assetName)-len(postfix)], true
 }
 
 func extractURLSuffix(assetName, toolName, version string) string {
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// goldenToolInfo is what saveToolInfo must write for goldenTool, whatever
+// order its platforms and versions were added in
+const goldenToolInfo = `{
+  "tool_name": "wasm-tools",
+  "github_repo": "bytecodealliance/wasm-tools",
+  "latest_version": "1.236.0",
+  "last_checked": "2026-10-01T00:00:00Z",
+  "supported_platforms": [
+    "darwin_amd64",
+    "darwin_arm64",
+    "linux_amd64",
+    "linux_arm64",
+    "windows_amd64"
+  ],
+  "versions": {
+    "1.235.0": {
+      "release_date": "2026-08-01",
+      "platforms": {
+        "darwin_arm64": {
+          "sha256": "1111111111111111111111111111111111111111111111111111111111111111",
+          "url_suffix": "aarch64-macos.tar.gz"
+        },
+        "linux_amd64": {
+          "sha256": "2222222222222222222222222222222222222222222222222222222222222222",
+          "url_suffix": "x86_64-linux.tar.gz"
+        }
+      }
+    },
+    "1.236.0": {
+      "release_date": "2026-09-01",
+      "platforms": {
+        "darwin_arm64": {
+          "sha256": "3333333333333333333333333333333333333333333333333333333333333333",
+          "url_suffix": "aarch64-macos.tar.gz"
+        },
+        "linux_amd64": {
+          "sha256": "4444444444444444444444444444444444444444444444444444444444444444",
+          "url_suffix": "x86_64-linux.tar.gz"
+        }
+      }
+    }
+  }
+}
+`
+
+// goldenTool builds the tool info behind goldenToolInfo, adding platforms
+// and versions in the given orders
+func goldenTool(platforms, versions []string) *ToolInfo {
+	platformInfo := map[string]map[string]PlatformInfo{
+		"1.235.0": {
+			"linux_amd64":  {SHA256: "2222222222222222222222222222222222222222222222222222222222222222", URLSuffix: "x86_64-linux.tar.gz"},
+			"darwin_arm64": {SHA256: "1111111111111111111111111111111111111111111111111111111111111111", URLSuffix: "aarch64-macos.tar.gz"},
+		},
+		"1.236.0": {
+			"linux_amd64":  {SHA256: "4444444444444444444444444444444444444444444444444444444444444444", URLSuffix: "x86_64-linux.tar.gz"},
+			"darwin_arm64": {SHA256: "3333333333333333333333333333333333333333333333333333333333333333", URLSuffix: "aarch64-macos.tar.gz"},
+		},
+	}
+	releaseDates := map[string]string{"1.235.0": "2026-08-01", "1.236.0": "2026-09-01"}
+
+	toolInfo := &ToolInfo{
+		ToolName:           "wasm-tools",
+		GitHubRepo:         "bytecodealliance/wasm-tools",
+		LatestVersion:      "1.236.0",
+		LastChecked:        "2026-10-01T00:00:00Z",
+		SupportedPlatforms: append([]string(nil), platforms...),
+		Versions:           make(map[string]VersionInfo),
+	}
+	for _, version := range versions {
+		info := VersionInfo{ReleaseDate: releaseDates[version], Platforms: make(map[string]PlatformInfo)}
+		for platform, recorded := range platformInfo[version] {
+			info.Platforms[platform] = recorded
+		}
+		toolInfo.Versions[version] = info
+	}
+	return toolInfo
+}
+
+func TestSaveToolInfoGolden(t *testing.T) {
+	tests := []struct {
+		name      string
+		platforms []string
+		versions  []string
+	}{
+		{
+			name:      "sorted input",
+			platforms: []string{"darwin_amd64", "darwin_arm64", "linux_amd64", "linux_arm64", "windows_amd64"},
+			versions:  []string{"1.235.0", "1.236.0"},
+		},
+		{
+			name:      "reversed input",
+			platforms: []string{"windows_amd64", "linux_arm64", "linux_amd64", "darwin_arm64", "darwin_amd64"},
+			versions:  []string{"1.236.0", "1.235.0"},
+		},
+		{
+			name:      "shuffled input",
+			platforms: []string{"linux_amd64", "windows_amd64", "darwin_amd64", "linux_arm64", "darwin_arm64"},
+			versions:  []string{"1.235.0", "1.236.0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "wasm-tools.json")
+
+			// Two updates with the same data, the second over the first's output
+			for run := 1; run <= 2; run++ {
+				if err := saveToolInfo(path, goldenTool(tt.platforms, tt.versions)); err != nil {
+					t.Fatalf("run %d: saveToolInfo: %v", run, err)
+				}
+				got, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(got, []byte(goldenToolInfo)) {
+					t.Fatalf("run %d wrote:\n%s\nwant:\n%s", run, got, goldenToolInfo)
+				}
+			}
+
+			// Loading and saving again is byte-identical too
+			loaded, err := loadToolInfo(path)
+			if err != nil {
+				t.Fatalf("loadToolInfo: %v", err)
+			}
+			if err := saveToolInfo(path, loaded); err != nil {
+				t.Fatalf("saveToolInfo after load: %v", err)
+			}
+			if got, _ := os.ReadFile(path); !bytes.Equal(got, []byte(goldenToolInfo)) {
+				t.Errorf("round trip wrote:\n%s\nwant:\n%s", got, goldenToolInfo)
+			}
+		})
+	}
+}
+
+func TestResolveURLSuffix(t *testing.T) {
+	tests := []struct {
+		name     string
+		tool     string
+		template string
+		asset    string
+		version  string
+		want     string
+	}{
+		{
+			name:     "template with bare version",
+			tool:     "wasm-tools",
+			template: "{tool}-{version}-{suffix}",
+			asset:    "wasm-tools-1.236.0-x86_64-linux.tar.gz",
+			version:  "v1.236.0",
+			want:     "x86_64-linux.tar.gz",
+		},
+		{
+			name:     "template with v-prefixed version",
+			tool:     "wasmtime",
+			template: "{tool}-v{version}-{suffix}",
+			asset:    "wasmtime-v35.0.0-aarch64-macos.tar.xz",
+			version:  "v35.0.0",
+			want:     "aarch64-macos.tar.xz",
+		},
+		{
+			name:     "template with fixed extension",
+			tool:     "tinygo",
+			template: "{tool}{version}.{suffix}.tar.gz",
+			asset:    "tinygo0.38.0.linux-amd64.tar.gz",
+			version:  "v0.38.0",
+			want:     "linux-amd64",
+		},
+		{
+			name:     "template mismatch falls back to heuristic",
+			tool:     "wkg",
+			template: "{tool}-v{version}-{suffix}",
+			asset:    "wkg-x86_64-unknown-linux-gnu",
+			version:  "v0.11.0",
+			want:     "wkg-x86_64-unknown-linux-gnu",
+		},
+		{
+			name:     "template without suffix placeholder falls back",
+			tool:     "wasmtime",
+			template: "{tool}-{version}",
+			asset:    "v35.0.0-x86_64-linux.tar.xz",
+			version:  "v35.0.0",
+			want:     "x86_64-linux.tar.xz",
+		},
+		{
+			name:    "heuristic strips the version",
+			tool:    "wasmtime",
+			asset:   "v35.0.0-x86_64-linux.tar.xz",
+			version: "v35.0.0",
+			want:    "x86_64-linux.tar.xz",
+		},
+		{
+			name:    "heuristic keeps versionless names",
+			tool:    "wkg",
+			asset:   "wkg-aarch64-apple-darwin",
+			version: "v0.11.0",
+			want:    "wkg-aarch64-apple-darwin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toolInfo := &ToolInfo{ToolName: tt.tool, URLTemplate: tt.template}
+			if got := resolveURLSuffix(toolInfo, tt.asset, tt.version); got != tt.want {
+				t.Errorf("resolveURLSuffix(%q) = %q, want %q", tt.asset, got, tt.want)
+			}
+		})
+	}
+}
+
+// withGitHubAPIBase restores the API base and os.Args after a test changes them
+func withGitHubAPIBase(t *testing.T) {
+	t.Helper()
+	base, args := checksumkit.GitHubAPIBase, os.Args
+	t.Cleanup(func() { checksumkit.GitHubAPIBase, os.Args = base, args })
+}
+
+func TestGitHubAPIBaseOverride(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		args    []string
+		wantAPI string
+	}{
+		{
+			name:    "default",
+			wantAPI: "https://api.github.com/repos/bytecodealliance/wasmtime/releases/latest",
+		},
+		{
+			name:    "flag",
+			args:    []string{"--github-api-base=https://ghe.example.com/api/v3"},
+			wantAPI: "https://ghe.example.com/api/v3/repos/bytecodealliance/wasmtime/releases/latest",
+		},
+		{
+			name:    "environment",
+			env:     "https://ghe.example.com/api/v3/",
+			wantAPI: "https://ghe.example.com/api/v3/repos/bytecodealliance/wasmtime/releases/latest",
+		},
+		{
+			name:    "flag beats environment",
+			env:     "https://env.example.com/api/v3",
+			args:    []string{"--github-api-base=https://flag.example.com/api/v3/"},
+			wantAPI: "https://flag.example.com/api/v3/repos/bytecodealliance/wasmtime/releases/latest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withGitHubAPIBase(t)
+			t.Setenv("GITHUB_API_BASE", tt.env)
+			t.Setenv("LOG_LEVEL", "")
+
+			os.Args = append([]string{"production_checksum_updater", "check-latest"}, tt.args...)
+			os.Args = append(os.Args, "wasmtime", "checksums")
+			if err := applyGlobalFlags(); err != nil {
+				t.Fatalf("applyGlobalFlags: %v", err)
+			}
+
+			if want := []string{"production_checksum_updater", "check-latest", "wasmtime", "checksums"}; !reflect.DeepEqual(os.Args, want) {
+				t.Errorf("os.Args = %q, want the command arguments only", os.Args)
+			}
+			if got := checksumkit.LatestReleaseURL("bytecodealliance/wasmtime"); got != tt.wantAPI {
+				t.Errorf("LatestReleaseURL = %s, want %s", got, tt.wantAPI)
+			}
+		})
+	}
+}
+
+// TestFetchLatestReleaseUsesAPIBase points the API base at a local server
+// and checks the updater requests the release from it
+func TestFetchLatestReleaseUsesAPIBase(t *testing.T) {
+	withGitHubAPIBase(t)
+
+	var requested string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.Path
+		fmt.Fprint(w, `{"tag_name":"v9.9.9"}`)
+	}))
+	defer server.Close()
+
+	checksumkit.GitHubAPIBase = server.URL + "/api/v3"
+	release, err := fetchLatestRelease("bytecodealliance/wasmtime")
+	if err != nil {
+		t.Fatalf("fetchLatestRelease: %v", err)
+	}
+	if want := "/api/v3/repos/bytecodealliance/wasmtime/releases/latest"; requested != want {
+		t.Errorf("requested %s, want %s", requested, want)
+	}
+	if release.TagName != "v9.9.9" {
+		t.Errorf("tag = %s, want v9.9.9", release.TagName)
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// ChecksumDatabase is the merged form of several tool JSON files, keyed by
+// tool name. merge accepts it as input too, so merged files can be merged
+// again, and the bundle storage backend keeps its tools in this format.
+type ChecksumDatabase struct {
+	Tools map[string]*ToolInfo `json:"tools"`
+}
+
+// MergeConflict is a tool+version+platform recorded with different checksums
+type MergeConflict struct {
+	Tool     string
+	Version  string
+	Platform string
+	SHA256   []string // The differing checksums, in input order
+	Sources  []string // The file each checksum came from
+}
+
+// mergeChecksums combines tool JSON files into one database. Entries are
+// unioned, but the same tool+version+platform with differing SHA256 values
+// is a supply-chain red flag: every conflict is reported, nothing is
+// written and the command exits non-zero.
+func mergeChecksums() {
+	if len(os.Args) < 4 {
+		checksumkit.Errorf("Usage: merge <out.json> <in1.json> <in2.json> ...")
+		return
+	}
+
+	outPath := os.Args[2]
+	inputs := os.Args[3:]
+
+	merger := newChecksumMerger()
+	for _, path := range inputs {
+		tools, err := loadMergeInput(path)
+		if err != nil {
+			checksumkit.Errorf("❌ Failed to load %s: %v", path, err)
+			os.Exit(1)
+		}
+		for _, tool := range tools {
+			merger.add(tool, path)
+		}
+	}
+
+	for _, warning := range merger.warnings {
+		checksumkit.Warnf("⚠️  %s", warning)
+	}
+
+	if len(merger.conflicts) > 0 {
+		checksumkit.Errorf("❌ %d checksum conflicts, refusing to write %s:", len(merger.conflicts), outPath)
+		for _, conflict := range merger.conflicts {
+			checksumkit.Errorf("  - %s %s %s:", conflict.Tool, conflict.Version, conflict.Platform)
+			for i, sha := range conflict.SHA256 {
+				checksumkit.Errorf("      %s  (%s)", sha, conflict.Sources[i])
+			}
+		}
+		os.Exit(1)
+	}
+
+	if err := saveBundle(outPath, &merger.db); err != nil {
+		checksumkit.Errorf("❌ Failed to write %s: %v", outPath, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✅ Merged %d files into %s (%d tools)\n", len(inputs), outPath, len(merger.db.Tools))
+}
+
+// loadMergeInput reads either a single tool JSON file or a merged database
+func loadMergeInput(path string) ([]*ToolInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+
+	if _, isDatabase := probe["tools"]; isDatabase {
+		var db ChecksumDatabase
+		if err := json.Unmarshal(data, &db); err != nil {
+			return nil, err
+		}
+		tools := make([]*ToolInfo, 0, len(db.Tools))
+		for _, name := range sortedToolNames(db.Tools) {
+			tool := db.Tools[name]
+			if tool.ToolName == "" {
+				tool.ToolName = name
+			}
+			tools = append(tools, tool)
+		}
+		return tools, nil
+	}
+
+	var tool ToolInfo
+	if err := json.Unmarshal(data, &tool); err != nil {
+		return nil, err
+	}
+	if tool.ToolName == "" {
+		return nil, fmt.Errorf("missing tool_name")
+	}
+	return []*ToolInfo{&tool}, nil
+}
+
+type checksumMerger struct {
+	db        ChecksumDatabase
+	sources   map[string]string // tool/version/platform -> file that recorded it
+	conflicts []MergeConflict
+	warnings  []string
+}
+
+func newChecksumMerger() *checksumMerger {
+	return &checksumMerger{
+		db:      ChecksumDatabase{Tools: make(map[string]*ToolInfo)},
+		sources: make(map[string]string),
+	}
+}
+
+func (m *checksumMerger) add(tool *ToolInfo, source string) {
+	merged, exists := m.db.Tools[tool.ToolName]
+	if !exists {
+		merged = &ToolInfo{
+			ToolName:    tool.ToolName,
+			GitHubRepo:  tool.GitHubRepo,
+			URLTemplate: tool.URLTemplate,
+			Versions:    make(map[string]VersionInfo),
+		}
+		m.db.Tools[tool.ToolName] = merged
+	}
+
+	if tool.GitHubRepo != "" && merged.GitHubRepo != "" && tool.GitHubRepo != merged.GitHubRepo {
+		m.warnings = append(m.warnings, fmt.Sprintf("%s: github_repo %s in %s differs from %s, keeping the first",
+			tool.ToolName, tool.GitHubRepo, source, merged.GitHubRepo))
+	}
+	if merged.GitHubRepo == "" {
+		merged.GitHubRepo = tool.GitHubRepo
+	}
+	if merged.URLTemplate == "" {
+		merged.URLTemplate = tool.URLTemplate
+	}
+
+	// The most recently checked input decides the latest version
+	if tool.LastChecked >= merged.LastChecked {
+		merged.LastChecked = tool.LastChecked
+		if tool.LatestVersion != "" {
+			merged.LatestVersion = tool.LatestVersion
+		}
+	}
+
+	merged.SupportedPlatforms = unionStrings(merged.SupportedPlatforms, tool.SupportedPlatforms)
+
+	for _, version := range sortedVersionNames(tool.Versions) {
+		incoming := tool.Versions[version]
+		current, exists := merged.Versions[version]
+		if !exists {
+			current = VersionInfo{ReleaseDate: incoming.ReleaseDate, Platforms: make(map[string]PlatformInfo)}
+		}
+		if current.ReleaseDate == "" {
+			current.ReleaseDate = incoming.ReleaseDate
+		}
+
+		for _, platform := range sortedPlatformNames(incoming.Platforms) {
+			info := incoming.Platforms[platform]
+			key := tool.ToolName + "/" + version + "/" + platform
+
+			recorded, exists := current.Platforms[platform]
+			if !exists {
+				current.Platforms[platform] = info
+				m.sources[key] = source
+				continue
+			}
+			if !strings.EqualFold(recorded.SHA256, info.SHA256) {
+				m.addConflict(tool.ToolName, version, platform, recorded.SHA256, m.sources[key], info.SHA256, source)
+			}
+		}
+
+		merged.Versions[version] = current
+	}
+}
+
+// addConflict records a differing checksum, folding repeated conflicts on the
+// same platform into one entry
+func (m *checksumMerger) addConflict(tool, version, platform, firstSHA, firstSource, sha, source string) {
+	for i := range m.conflicts {
+		c := &m.conflicts[i]
+		if c.Tool == tool && c.Version == version && c.Platform == platform {
+			c.SHA256 = append(c.SHA256, sha)
+			c.Sources = append(c.Sources, source)
+			return
+		}
+	}
+
+	m.conflicts = append(m.conflicts, MergeConflict{
+		Tool:     tool,
+		Version:  version,
+		Platform: platform,
+		SHA256:   []string{firstSHA, sha},
+		Sources:  []string{firstSource, source},
+	})
+}
+
+func unionStrings(a, b []string) []string {
+	seen := make(map[string]bool, len(a)+len(b))
+	result := make([]string, 0, len(a)+len(b))
+	for _, s := range append(append([]string{}, a...), b...) {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func sortedToolNames(tools map[string]*ToolInfo) []string {
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedVersionNames(versions map[string]VersionInfo) []string {
+	names := make([]string, 0, len(versions))
+	for name := range versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func sortedPlatformNames(platforms map[string]PlatformInfo) []string {
+	names := make([]string, 0, len(platforms))
+	for name := range platforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// mergeFiles loads each path as merge does and adds it to a new merger
+func mergeFiles(t *testing.T, paths ...string) *checksumMerger {
+	t.Helper()
+	merger := newChecksumMerger()
+	for _, path := range paths {
+		tools, err := loadMergeInput(path)
+		if err != nil {
+			t.Fatalf("loadMergeInput(%s): %v", path, err)
+		}
+		for _, tool := range tools {
+			merger.add(tool, path)
+		}
+	}
+	return merger
+}
+
+// writeToolFile saves toolInfo as a per-tool JSON file in dir
+func writeToolFile(t *testing.T, dir, name string, toolInfo *ToolInfo) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := saveToolInfo(path, toolInfo); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMergeClean(t *testing.T) {
+	dir := t.TempDir()
+
+	older := goldenTool([]string{"darwin_arm64"}, []string{"1.235.0"})
+	older.LatestVersion = "1.235.0"
+	older.LastChecked = "2026-08-02T00:00:00Z"
+	newer := goldenTool([]string{"linux_amd64"}, []string{"1.235.0", "1.236.0"})
+	// The same checksum in another case is not a conflict
+	linux := newer.Versions["1.235.0"].Platforms["linux_amd64"]
+	linux.SHA256 = strings.ToUpper(linux.SHA256)
+	newer.Versions["1.235.0"].Platforms["linux_amd64"] = linux
+	other := &ToolInfo{ToolName: "wac", GitHubRepo: "bytecodealliance/wac", LatestVersion: "0.7.0", Versions: map[string]VersionInfo{
+		"0.7.0": {Platforms: map[string]PlatformInfo{"linux_amd64": {SHA256: strings.Repeat("5", 64)}}},
+	}}
+
+	// Newest first, so the latest version must not come from input order
+	merger := mergeFiles(t,
+		writeToolFile(t, dir, "newer.json", newer),
+		writeToolFile(t, dir, "older.json", older),
+		writeToolFile(t, dir, "wac.json", other))
+
+	if len(merger.conflicts) != 0 || len(merger.warnings) != 0 {
+		t.Fatalf("conflicts %v, warnings %v; want none", merger.conflicts, merger.warnings)
+	}
+	if got := sortedToolNames(merger.db.Tools); !reflect.DeepEqual(got, []string{"wac", "wasm-tools"}) {
+		t.Errorf("tools = %v, want wac and wasm-tools", got)
+	}
+
+	merged := merger.db.Tools["wasm-tools"]
+	if merged.LatestVersion != "1.236.0" || merged.LastChecked != "2026-10-01T00:00:00Z" {
+		t.Errorf("latest = %s checked %s, want the most recently checked input's", merged.LatestVersion, merged.LastChecked)
+	}
+	if want := []string{"linux_amd64", "darwin_arm64"}; !reflect.DeepEqual(merged.SupportedPlatforms, want) {
+		t.Errorf("supported platforms = %v, want %v", merged.SupportedPlatforms, want)
+	}
+	if got := sortedVersionNames(merged.Versions); !reflect.DeepEqual(got, []string{"1.235.0", "1.236.0"}) {
+		t.Errorf("versions = %v", got)
+	}
+	if got := sortedPlatformNames(merged.Versions["1.235.0"].Platforms); !reflect.DeepEqual(got, []string{"darwin_arm64", "linux_amd64"}) {
+		t.Errorf("1.235.0 platforms = %v, want both inputs' platforms", got)
+	}
+
+	// The merged database is itself a valid input, and merging it with its
+	// sources again changes nothing
+	bundlePath := filepath.Join(dir, "merged.json")
+	if err := saveBundle(bundlePath, &merger.db); err != nil {
+		t.Fatal(err)
+	}
+	again := mergeFiles(t, bundlePath, filepath.Join(dir, "older.json"), filepath.Join(dir, "wac.json"))
+	if len(again.conflicts) != 0 {
+		t.Errorf("re-merging the output conflicts: %v", again.conflicts)
+	}
+	if !reflect.DeepEqual(again.db.Tools["wac"], merger.db.Tools["wac"]) {
+		t.Errorf("re-merged wac = %+v, want %+v", again.db.Tools["wac"], merger.db.Tools["wac"])
+	}
+}
+
+func TestMergeConflicts(t *testing.T) {
+	dir := t.TempDir()
+	withChecksum := func(sha string) *ToolInfo {
+		toolInfo := goldenTool(nil, []string{"1.235.0"})
+		if sha != "" {
+			toolInfo.Versions["1.235.0"].Platforms["linux_amd64"] = PlatformInfo{SHA256: sha}
+		}
+		return toolInfo
+	}
+
+	first := writeToolFile(t, dir, "first.json", withChecksum(""))
+	second := writeToolFile(t, dir, "second.json", withChecksum(strings.Repeat("a", 64)))
+	third := writeToolFile(t, dir, "third.json", withChecksum(strings.Repeat("b", 64)))
+	moved := withChecksum("")
+	moved.GitHubRepo = "someone/wasm-tools"
+	fourth := writeToolFile(t, dir, "fourth.json", moved)
+
+	merger := mergeFiles(t, first, second, third, fourth)
+
+	want := []MergeConflict{{
+		Tool:     "wasm-tools",
+		Version:  "1.235.0",
+		Platform: "linux_amd64",
+		SHA256:   []string{strings.Repeat("2", 64), strings.Repeat("a", 64), strings.Repeat("b", 64)},
+		Sources:  []string{first, second, third},
+	}}
+	if !reflect.DeepEqual(merger.conflicts, want) {
+		t.Errorf("conflicts = %+v, want %+v", merger.conflicts, want)
+	}
+
+	// The first checksum recorded is kept, never overwritten by a conflict
+	if got := merger.db.Tools["wasm-tools"].Versions["1.235.0"].Platforms["linux_amd64"].SHA256; got != strings.Repeat("2", 64) {
+		t.Errorf("recorded checksum = %s, want the first input's", got)
+	}
+
+	if len(merger.warnings) != 1 || !strings.Contains(merger.warnings[0], "github_repo someone/wasm-tools") {
+		t.Errorf("warnings = %v, want one about the differing github_repo", merger.warnings)
+	}
+}
+
+func TestLoadMergeInputErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := map[string]string{
+		"not JSON":          "checksums",
+		"missing tool_name": `{"github_repo":"example/tool","versions":{}}`,
+	}
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(name, " ", "_")+".json")
+			if err := writeFileAtomic(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := loadMergeInput(path); err == nil {
+				t.Errorf("loadMergeInput accepted %s", name)
+			}
+		})
+	}
+}
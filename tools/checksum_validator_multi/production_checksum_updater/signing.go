@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// Tool JSON files are signed over their exact bytes on disk, which
+// saveToolInfo writes deterministically. The detached signature is stored
+// base64-encoded in <tool>.json.sig next to the JSON file.
+
+// loadSigningKey reads a PEM-encoded PKCS#8 ed25519 private key, as produced
+// by `openssl genpkey -algorithm ed25519`
+func loadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key %s: %w", path, err)
+	}
+
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ed25519 private key", path)
+	}
+	return privateKey, nil
+}
+
+// loadVerifyKey reads a PEM-encoded PKIX ed25519 public key
+func loadVerifyKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEMBlock(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing public key %s: %w", path, err)
+	}
+
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ed25519 public key", path)
+	}
+	return publicKey, nil
+}
+
+func readPEMBlock(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s does not contain a PEM block", path)
+	}
+	return block, nil
+}
+
+// signToolInfo writes a detached signature for the tool JSON at toolPath.
+// It is a no-op when no signing key is configured.
+func signToolInfo(toolPath string, key ed25519.PrivateKey) error {
+	if key == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(toolPath)
+	if err != nil {
+		return err
+	}
+
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))
+	if err := os.WriteFile(toolPath+".sig", []byte(signature+"\n"), 0644); err != nil {
+		return err
+	}
+
+	checksumkit.Infof("🔏 Signed %s", toolPath)
+	return nil
+}
+
+// verifyToolInfo checks the detached signature of the tool JSON at toolPath
+func verifyToolInfo(toolPath string, key ed25519.PublicKey) error {
+	data, err := os.ReadFile(toolPath)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := os.ReadFile(toolPath + ".sig")
+	if err != nil {
+		return fmt.Errorf("reading signature: %w", err)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return fmt.Errorf("decoding signature: %w", err)
+	}
+
+	if !ed25519.Verify(key, data, signature) {
+		return fmt.Errorf("signature does not match %s", toolPath)
+	}
+	return nil
+}
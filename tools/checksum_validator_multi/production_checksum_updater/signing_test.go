@@ -0,0 +1,178 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeKeyPair generates an ed25519 key pair and writes it as the PEM files
+// openssl produces, returning their paths
+func writeKeyPair(t *testing.T) (privatePath, publicPath string) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	privatePath = filepath.Join(dir, "signing.pem")
+	publicPath = filepath.Join(dir, "signing.pub.pem")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return privatePath, publicPath
+}
+
+func TestSignAndVerifyToolInfo(t *testing.T) {
+	privatePath, publicPath := writeKeyPair(t)
+	_, otherPublicPath := writeKeyPair(t)
+
+	privateKey, err := loadSigningKey(privatePath)
+	if err != nil {
+		t.Fatalf("loadSigningKey: %v", err)
+	}
+	publicKey, err := loadVerifyKey(publicPath)
+	if err != nil {
+		t.Fatalf("loadVerifyKey: %v", err)
+	}
+	otherKey, err := loadVerifyKey(otherPublicPath)
+	if err != nil {
+		t.Fatalf("loadVerifyKey: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		tamper  func(t *testing.T, toolPath string)
+		key     ed25519.PublicKey
+		wantErr bool
+	}{
+		{name: "untouched", key: publicKey},
+		{name: "wrong public key", key: otherKey, wantErr: true},
+		{
+			name: "edited JSON",
+			key:  publicKey,
+			tamper: func(t *testing.T, toolPath string) {
+				toolInfo, err := loadToolInfo(toolPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				toolInfo.Versions["1.236.0"].Platforms["linux_amd64"] = PlatformInfo{SHA256: "5555555555555555555555555555555555555555555555555555555555555555"}
+				if err := saveToolInfo(toolPath, toolInfo); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "reformatted JSON",
+			key:  publicKey,
+			tamper: func(t *testing.T, toolPath string) {
+				data, _ := os.ReadFile(toolPath)
+				os.WriteFile(toolPath, append(data, '\n'), 0644)
+			},
+			wantErr: true,
+		},
+		{
+			name:    "missing signature",
+			key:     publicKey,
+			tamper:  func(t *testing.T, toolPath string) { os.Remove(toolPath + ".sig") },
+			wantErr: true,
+		},
+		{
+			name:    "garbled signature",
+			key:     publicKey,
+			tamper:  func(t *testing.T, toolPath string) { os.WriteFile(toolPath+".sig", []byte("not base64!\n"), 0644) },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toolPath := filepath.Join(t.TempDir(), "wasm-tools.json")
+			if err := saveToolInfo(toolPath, goldenTool(nil, []string{"1.235.0", "1.236.0"})); err != nil {
+				t.Fatal(err)
+			}
+			if err := signToolInfo(toolPath, privateKey); err != nil {
+				t.Fatalf("signToolInfo: %v", err)
+			}
+			if tt.tamper != nil {
+				tt.tamper(t, toolPath)
+			}
+
+			err := verifyToolInfo(toolPath, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyToolInfo = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignToolInfoWithoutKey(t *testing.T) {
+	toolPath := filepath.Join(t.TempDir(), "wasm-tools.json")
+	if err := saveToolInfo(toolPath, goldenTool(nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := signToolInfo(toolPath, nil); err != nil {
+		t.Fatalf("signToolInfo without a key: %v", err)
+	}
+	if _, err := os.Stat(toolPath + ".sig"); !os.IsNotExist(err) {
+		t.Errorf("signature written without a signing key (stat err %v)", err)
+	}
+}
+
+func TestLoadKeysRejectsWrongKinds(t *testing.T) {
+	privatePath, publicPath := writeKeyPair(t)
+	notPEM := filepath.Join(t.TempDir(), "key.txt")
+	os.WriteFile(notPEM, []byte("not a key\n"), 0600)
+
+	if _, err := loadSigningKey(publicPath); err == nil {
+		t.Error("loadSigningKey accepted a public key")
+	}
+	if _, err := loadVerifyKey(privatePath); err == nil {
+		t.Error("loadVerifyKey accepted a private key")
+	}
+	if _, err := loadSigningKey(notPEM); err == nil {
+		t.Error("loadSigningKey accepted a file without a PEM block")
+	}
+}
+
+// TestUpdateToolSignsSavedJSON checks --sign-with signs what update-tool
+// wrote, so verify-json accepts it
+func TestUpdateToolSignsSavedJSON(t *testing.T) {
+	privatePath, publicPath := writeKeyPair(t)
+	newFakeGitHub(t, "v2.0.0", testAssets)
+	store := newTestStore(t, "tool")
+
+	opts, err := updateOptionsFromFlags(map[string]string{"sign-with": privatePath})
+	if err != nil {
+		t.Fatalf("updateOptionsFromFlags: %v", err)
+	}
+	if err := updateToolChecksums("tool", store, opts); err != nil {
+		t.Fatalf("updateToolChecksums: %v", err)
+	}
+
+	publicKey, err := loadVerifyKey(publicPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyToolInfo(store.ToolPath("tool"), publicKey); err != nil {
+		t.Errorf("verifyToolInfo after update-tool --sign-with: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// StorageBackend is where tool checksum records are kept. The default keeps
+// one JSON file per tool under <checksums-dir>/tools; the bundle backend
+// keeps every tool in a single file for teams that want one indexed store.
+type StorageBackend interface {
+	LoadTool(toolName string) (*ToolInfo, error)
+	SaveTool(toolInfo *ToolInfo) error
+	ListTools() ([]string, error)
+	// ToolPath is the file holding the tool's record, which is what
+	// --sign-with signs and verify-json checks
+	ToolPath(toolName string) string
+}
+
+// bundleFileName is the bundle backend's file inside the checksums directory
+const bundleFileName = "tools_bundle.json"
+
+// newStorageBackend selects a backend by its --backend name
+func newStorageBackend(kind, checksumsDir string) (StorageBackend, error) {
+	switch kind {
+	case "", "json":
+		return &jsonBackend{dir: filepath.Join(checksumsDir, "tools")}, nil
+	case "bundle":
+		return &bundleBackend{path: filepath.Join(checksumsDir, bundleFileName)}, nil
+	default:
+		return nil, fmt.Errorf("unknown --backend %q (expected json or bundle)", kind)
+	}
+}
+
+// storageFromFlags builds the backend for a command's checksums directory
+func storageFromFlags(checksumsDir string, flags map[string]string) (StorageBackend, error) {
+	return newStorageBackend(flags["backend"], checksumsDir)
+}
+
+// jsonBackend stores each tool as <dir>/<tool>.json
+type jsonBackend struct {
+	dir string
+}
+
+func (b *jsonBackend) LoadTool(toolName string) (*ToolInfo, error) {
+	return loadToolInfo(b.ToolPath(toolName))
+}
+
+func (b *jsonBackend) SaveTool(toolInfo *ToolInfo) error {
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		return err
+	}
+	return saveToolInfo(b.ToolPath(toolInfo.ToolName), toolInfo)
+}
+
+func (b *jsonBackend) ListTools() ([]string, error) {
+	return listTools(filepath.Dir(b.dir))
+}
+
+func (b *jsonBackend) ToolPath(toolName string) string {
+	return filepath.Join(b.dir, toolName+".json")
+}
+
+// bundleBackend stores every tool in one ChecksumDatabase file keyed by tool
+// name, the same format merge writes
+type bundleBackend struct {
+	path string
+	mu   sync.Mutex // Saves rewrite the whole file
+}
+
+func (b *bundleBackend) LoadTool(toolName string) (*ToolInfo, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	db, err := loadBundle(b.path)
+	if err != nil {
+		return nil, err
+	}
+
+	toolInfo, exists := db.Tools[toolName]
+	if !exists {
+		return nil, fmt.Errorf("tool %s not found in %s", toolName, b.path)
+	}
+	if toolInfo.ToolName == "" {
+		toolInfo.ToolName = toolName
+	}
+	return toolInfo, nil
+}
+
+func (b *bundleBackend) SaveTool(toolInfo *ToolInfo) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	db, err := loadBundle(b.path)
+	if os.IsNotExist(err) {
+		db, err = &ChecksumDatabase{Tools: make(map[string]*ToolInfo)}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	db.Tools[toolInfo.ToolName] = toolInfo
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return err
+	}
+	return saveBundle(b.path, db)
+}
+
+func (b *bundleBackend) ListTools() ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	db, err := loadBundle(b.path)
+	if os.IsNotExist(err) {
+		return []string{}, nil // No tools saved yet, like an empty tools directory
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sortedToolNames(db.Tools), nil
+}
+
+func (b *bundleBackend) ToolPath(string) string {
+	return b.path
+}
+
+func loadBundle(path string) (*ChecksumDatabase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var db ChecksumDatabase
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, err
+	}
+	if db.Tools == nil {
+		db.Tools = make(map[string]*ToolInfo)
+	}
+	return &db, nil
+}
+
+// saveBundle writes a database with the same determinism and atomicity as
+// saveToolInfo
+func saveBundle(path string, db *ChecksumDatabase) error {
+	for _, toolInfo := range db.Tools {
+		sort.Strings(toolInfo.SupportedPlatforms)
+	}
+
+	data, err := json.MarshalIndent(db, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, append(data, '\n'), 0644)
+}
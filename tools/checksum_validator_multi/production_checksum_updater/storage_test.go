@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestStorageBackends runs the same operations against every backend,
+// starting from a checksums directory that does not exist yet
+func TestStorageBackends(t *testing.T) {
+	for _, kind := range []string{"json", "bundle"} {
+		t.Run(kind, func(t *testing.T) {
+			store, err := newStorageBackend(kind, filepath.Join(t.TempDir(), "checksums"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if names, err := store.ListTools(); err != nil || len(names) != 0 {
+				t.Fatalf("ListTools on an empty store = %v, %v; want no tools", names, err)
+			}
+			if _, err := store.LoadTool("wasm-tools"); err == nil {
+				t.Error("LoadTool of a missing tool succeeded")
+			}
+
+			wasmTools := goldenTool([]string{"linux_amd64", "darwin_arm64"}, []string{"1.235.0", "1.236.0"})
+			wac := &ToolInfo{ToolName: "wac", GitHubRepo: "bytecodealliance/wac", LatestVersion: "0.7.0",
+				SupportedPlatforms: []string{"linux_amd64"}, Versions: map[string]VersionInfo{}}
+			for _, toolInfo := range []*ToolInfo{wasmTools, wac} {
+				if err := store.SaveTool(toolInfo); err != nil {
+					t.Fatalf("SaveTool(%s): %v", toolInfo.ToolName, err)
+				}
+			}
+
+			names, err := store.ListTools()
+			if err != nil || !reflect.DeepEqual(names, []string{"wac", "wasm-tools"}) {
+				t.Errorf("ListTools = %v, %v; want [wac wasm-tools]", names, err)
+			}
+
+			loaded, err := store.LoadTool("wasm-tools")
+			if err != nil {
+				t.Fatalf("LoadTool: %v", err)
+			}
+			if !reflect.DeepEqual(loaded, wasmTools) {
+				t.Errorf("LoadTool = %+v, want %+v", loaded, wasmTools)
+			}
+
+			// Saving again replaces only that tool
+			wac.LatestVersion = "0.8.0"
+			if err := store.SaveTool(wac); err != nil {
+				t.Fatal(err)
+			}
+			if loaded, _ := store.LoadTool("wac"); loaded.LatestVersion != "0.8.0" {
+				t.Errorf("wac LatestVersion = %s after resave, want 0.8.0", loaded.LatestVersion)
+			}
+			if loaded, _ := store.LoadTool("wasm-tools"); !reflect.DeepEqual(loaded, wasmTools) {
+				t.Error("resaving wac changed wasm-tools")
+			}
+
+			// ToolPath is the file signing and verify-json operate on
+			data, err := os.ReadFile(store.ToolPath("wasm-tools"))
+			if err != nil || !strings.Contains(string(data), `"tool_name": "wasm-tools"`) {
+				t.Errorf("ToolPath does not hold the record (err %v)", err)
+			}
+		})
+	}
+}
+
+func TestNewStorageBackendRejectsUnknownKind(t *testing.T) {
+	if _, err := newStorageBackend("sqlite", t.TempDir()); err == nil || !strings.Contains(err.Error(), `unknown --backend "sqlite"`) {
+		t.Errorf("newStorageBackend(sqlite) = %v, want an unknown backend error", err)
+	}
+	if store, err := storageFromFlags(t.TempDir(), map[string]string{}); err != nil {
+		t.Errorf("default backend: %v", err)
+	} else if _, ok := store.(*jsonBackend); !ok {
+		t.Errorf("default backend = %T, want the JSON backend", store)
+	}
+}
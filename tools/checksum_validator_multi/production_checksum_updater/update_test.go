@@ -0,0 +1,303 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pulseengine/rules_wasm_component/tools/checksum_validator_multi/checksumkit"
+)
+
+// fakeGitHub serves every repository's latest release as tag, with one
+// asset per entry in assets, except example/missing, which has none.
+// Downloads of assets named in failing answer 500 until the test clears them.
+type fakeGitHub struct {
+	*httptest.Server
+	tag    string
+	assets map[string]string // asset name -> content
+
+	mu        sync.Mutex
+	failing   map[string]bool
+	downloads map[string]int
+}
+
+func newFakeGitHub(t *testing.T, tag string, assets map[string]string, failing ...string) *fakeGitHub {
+	t.Helper()
+	fake := &fakeGitHub{
+		tag:       tag,
+		assets:    assets,
+		failing:   make(map[string]bool),
+		downloads: make(map[string]int),
+	}
+	for _, name := range failing {
+		fake.failing[name] = true
+	}
+
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	t.Cleanup(fake.Close)
+
+	// No retries, so failing downloads fail at once
+	base, backoff := checksumkit.GitHubAPIBase, checksumkit.Backoff
+	checksumkit.GitHubAPIBase = fake.URL + "/api/v3"
+	checksumkit.Backoff.Retries = 0
+	t.Cleanup(func() { checksumkit.GitHubAPIBase, checksumkit.Backoff = base, backoff })
+	return fake
+}
+
+func (f *fakeGitHub) serve(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/api/v3/repos/example/missing/") {
+		http.NotFound(w, r)
+		return
+	}
+	if strings.HasSuffix(r.URL.Path, "/releases/latest") {
+		release := GitHubRelease{TagName: f.tag, PublishedAt: "2026-10-01T00:00:00Z"}
+		for name := range f.assets {
+			release.Assets = append(release.Assets, Asset{Name: name, BrowserDownloadURL: f.URL + "/download/" + name})
+		}
+		json.NewEncoder(w).Encode(release)
+		return
+	}
+
+	name, ok := strings.CutPrefix(r.URL.Path, "/download/")
+	content, exists := f.assets[name]
+	if !ok || !exists {
+		http.NotFound(w, r)
+		return
+	}
+
+	f.mu.Lock()
+	f.downloads[name]++
+	failing := f.failing[name]
+	f.mu.Unlock()
+	if failing {
+		http.Error(w, "upstream unavailable", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte(content))
+}
+
+func (f *fakeGitHub) recover(name string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.failing, name)
+}
+
+func (f *fakeGitHub) downloadCount(name string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.downloads[name]
+}
+
+// testAssets are one release asset for each of testPlatforms
+var (
+	testPlatforms = []string{"darwin_arm64", "linux_amd64"}
+	testAssets    = map[string]string{
+		"tool-2.0.0-aarch64-macos.tar.gz": "macos build",
+		"tool-2.0.0-x86_64-linux.tar.gz":  "linux build",
+	}
+)
+
+// newTestStore writes a record for each tool, last updated at v1.0.0
+func newTestStore(t *testing.T, toolNames ...string) StorageBackend {
+	t.Helper()
+	store, err := newStorageBackend("json", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(store.ToolPath("x")), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, toolName := range toolNames {
+		err := store.SaveTool(&ToolInfo{
+			ToolName:           toolName,
+			GitHubRepo:         "example/" + toolName,
+			LatestVersion:      "v1.0.0",
+			SupportedPlatforms: testPlatforms,
+			Versions: map[string]VersionInfo{
+				"v1.0.0": {ReleaseDate: "2026-01-01", Platforms: map[string]PlatformInfo{
+					"linux_amd64": {SHA256: strings.Repeat("1", 64), URLSuffix: "x86_64-linux.tar.gz"},
+				}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// TestUpdateToolChecksumsSkipsFailedPlatform checks that a failed download
+// is logged and skipped rather than failing the tool, and that
+// --skip-existing later fills in the missing platform
+func TestUpdateToolChecksumsSkipsFailedPlatform(t *testing.T) {
+	const (
+		macos = "tool-2.0.0-aarch64-macos.tar.gz"
+		linux = "tool-2.0.0-x86_64-linux.tar.gz"
+	)
+	fake := newFakeGitHub(t, "v2.0.0", testAssets, linux)
+	store := newTestStore(t, "tool")
+
+	if err := updateToolChecksums("tool", store, UpdateOptions{Concurrency: 2}); err != nil {
+		t.Fatalf("updateToolChecksums with a failed platform: %v", err)
+	}
+
+	toolInfo, _ := store.LoadTool("tool")
+	if toolInfo.LatestVersion != "v2.0.0" {
+		t.Errorf("LatestVersion = %s, want v2.0.0", toolInfo.LatestVersion)
+	}
+	if got := toolInfo.Versions["v2.0.0"].Platforms["darwin_arm64"].SHA256; got != sha256Hex(testAssets[macos]) {
+		t.Errorf("darwin_arm64 checksum = %q, want %q", got, sha256Hex(testAssets[macos]))
+	}
+	if _, recorded := toolInfo.Versions["v2.0.0"].Platforms["linux_amd64"]; recorded {
+		t.Error("linux_amd64 recorded although its download failed")
+	}
+
+	// Without --skip-existing the version counts as up to date
+	if err := updateToolChecksums("tool", store, UpdateOptions{Concurrency: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if n := fake.downloadCount(macos); n != 1 {
+		t.Errorf("%s downloaded %d times by an up-to-date run, want once", macos, n)
+	}
+
+	// Once the asset is back, --skip-existing completes the version without
+	// downloading the platform it already has
+	fake.recover(linux)
+	if err := updateToolChecksums("tool", store, UpdateOptions{SkipExisting: true, Concurrency: 2}); err != nil {
+		t.Fatalf("resumed update: %v", err)
+	}
+
+	toolInfo, _ = store.LoadTool("tool")
+	for platform, asset := range map[string]string{"darwin_arm64": macos, "linux_amd64": linux} {
+		if got := toolInfo.Versions["v2.0.0"].Platforms[platform].SHA256; got != sha256Hex(testAssets[asset]) {
+			t.Errorf("%s checksum = %q, want %q", platform, got, sha256Hex(testAssets[asset]))
+		}
+	}
+	if n := fake.downloadCount(macos); n != 1 {
+		t.Errorf("%s downloaded %d times, want once", macos, n)
+	}
+}
+
+func TestUpdateToolsFailFast(t *testing.T) {
+	tests := []struct {
+		name        string
+		failFast    bool
+		wantFailed  int
+		wantUpdated []string
+	}{
+		// alpha and gamma are fine, beta's repository has no release
+		{name: "collect", failFast: false, wantFailed: 1, wantUpdated: []string{"alpha", "gamma"}},
+		{name: "fail fast", failFast: true, wantFailed: 1, wantUpdated: []string{"alpha"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newFakeGitHub(t, "v2.0.0", testAssets)
+			store := newTestStore(t, "alpha", "beta", "gamma")
+			broken, _ := store.LoadTool("beta")
+			broken.GitHubRepo = "example/missing"
+			store.SaveTool(broken)
+
+			failed := updateTools([]string{"alpha", "beta", "gamma"}, store, UpdateOptions{Concurrency: 2}, tt.failFast)
+			if len(failed) != tt.wantFailed || !strings.HasPrefix(failed[0], "beta: ") {
+				t.Fatalf("failed = %q, want only beta", failed)
+			}
+
+			updated := map[string]bool{}
+			for _, toolName := range tt.wantUpdated {
+				updated[toolName] = true
+			}
+			for _, toolName := range []string{"alpha", "beta", "gamma"} {
+				toolInfo, _ := store.LoadTool(toolName)
+				want := "v1.0.0"
+				if updated[toolName] {
+					want = "v2.0.0"
+				}
+				if toolInfo.LatestVersion != want {
+					t.Errorf("%s LatestVersion = %s, want %s", toolName, toolInfo.LatestVersion, want)
+				}
+			}
+		})
+	}
+}
+
+// TestUpdateToolsFailFastIgnoresSkippedPlatforms checks that --fail-fast
+// only stops on tool failures, not on a platform that was skipped
+func TestUpdateToolsFailFastIgnoresSkippedPlatforms(t *testing.T) {
+	newFakeGitHub(t, "v2.0.0", testAssets, "tool-2.0.0-x86_64-linux.tar.gz")
+	store := newTestStore(t, "alpha", "beta")
+
+	if failed := updateTools([]string{"alpha", "beta"}, store, UpdateOptions{Concurrency: 2}, true); len(failed) != 0 {
+		t.Fatalf("failed = %q, want a skipped platform not to count as a failure", failed)
+	}
+	for _, toolName := range []string{"alpha", "beta"} {
+		if toolInfo, _ := store.LoadTool(toolName); toolInfo.LatestVersion != "v2.0.0" {
+			t.Errorf("%s LatestVersion = %s, want v2.0.0", toolName, toolInfo.LatestVersion)
+		}
+	}
+}
+
+// TestDownloadAndHashRetries checks that asset downloads retry transient
+// failures like the API calls do, and give up on a 404 at once
+func TestDownloadAndHashRetries(t *testing.T) {
+	backoff := checksumkit.Backoff
+	checksumkit.Backoff = checksumkit.BackoffConfig{Strategy: checksumkit.BackoffConstant, Retries: 2}
+	t.Cleanup(func() { checksumkit.Backoff = backoff })
+
+	tests := []struct {
+		name         string
+		failures     int // Leading requests answered with failStatus
+		failStatus   int
+		wantErr      bool
+		wantRequests int
+	}{
+		{name: "succeeds at once", wantRequests: 1},
+		{name: "recovers from 503", failures: 2, failStatus: http.StatusServiceUnavailable, wantRequests: 3},
+		{name: "recovers from 429", failures: 1, failStatus: http.StatusTooManyRequests, wantRequests: 2},
+		{name: "gives up after the retries", failures: 3, failStatus: http.StatusBadGateway, wantErr: true, wantRequests: 3},
+		{name: "404 is not retried", failures: 3, failStatus: http.StatusNotFound, wantErr: true, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests++
+				failing := requests <= tt.failures
+				mu.Unlock()
+				if failing {
+					w.Header().Set("Retry-After", "0")
+					http.Error(w, "try again", tt.failStatus)
+					return
+				}
+				w.Write([]byte("linux build"))
+			}))
+			t.Cleanup(server.Close)
+
+			digest, err := downloadAndHash(server.URL + "/tool.tar.gz")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("downloadAndHash error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && digest != sha256Hex("linux build") {
+				t.Errorf("digest = %s, want %s", digest, sha256Hex("linux build"))
+			}
+			if requests != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", requests, tt.wantRequests)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AuditEntry is one filesystem mutation attempted by an operation
+type AuditEntry struct {
+	Time      string   `json:"time"` // RFC 3339, UTC
+	Operation string   `json:"operation"`
+	Paths     []string `json:"paths"` // Absolute; sources first, destination last
+	Bytes     int64    `json:"bytes"`
+	Mode      string   `json:"mode,omitempty"`
+	Outcome   string   `json:"outcome"` // "ok" or "error"
+	Error     string   `json:"error,omitempty"`
+}
+
+// auditLog appends an AuditEntry per mutation to a JSON-lines file, whether
+// the mutation succeeded or not. Each entry is written and synced before
+// record returns, so nothing is lost when a failure path calls os.Exit.
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// auditTrail is nil unless audit_log or --audit-log is set
+var auditTrail *auditLog
+
+// openAuditLog opens path for appending; earlier runs' entries are kept
+func openAuditLog(path string) (*auditLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLog{file: file}, nil
+}
+
+// record logs one mutation of paths. A zero mode is omitted. Failing to
+// write the audit log is itself fatal, since a silent gap defeats its purpose.
+func (l *auditLog) record(operation string, paths []string, size int64, mode os.FileMode, opErr error) {
+	if l == nil {
+		return
+	}
+
+	entry := AuditEntry{
+		Time:      time.Now().UTC().Format(time.RFC3339Nano),
+		Operation: operation,
+		Paths:     make([]string, len(paths)),
+		Bytes:     size,
+		Outcome:   "ok",
+	}
+	for i, path := range paths {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		entry.Paths[i] = path
+	}
+	if mode != 0 {
+		entry.Mode = fmt.Sprintf("%04o", mode.Perm())
+	}
+	if opErr != nil {
+		entry.Outcome = "error"
+		entry.Error = opErr.Error()
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		log.Fatalf("Failed to encode audit entry: %v", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		log.Fatalf("Failed to write audit log: %v", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		log.Fatalf("Failed to sync audit log: %v", err)
+	}
+}
+
+func (l *auditLog) close() error {
+	if l == nil {
+		return nil
+	}
+	return l.file.Close()
+}
+
+// recordWrite logs a mutation whose destination, the last of paths, is a
+// file, taking its size and mode from the file once it has been written
+func (l *auditLog) recordWrite(operation string, paths []string, opErr error) {
+	if l == nil {
+		return
+	}
+
+	var size int64
+	var mode os.FileMode
+	if opErr == nil {
+		if info, err := os.Stat(paths[len(paths)-1]); err == nil {
+			size, mode = info.Size(), info.Mode()
+		}
+	}
+	l.record(operation, paths, size, mode, opErr)
+}
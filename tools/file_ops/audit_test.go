@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain lets a test re-run the binary's main, so that paths ending in
+// os.Exit can be observed from the parent test
+func TestMain(m *testing.M) {
+	if os.Getenv("FILE_OPS_RUN_MAIN") == "1" {
+		os.Args = append([]string{"file_ops"}, os.Args[len(os.Args)-2:]...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// TestAuditLogRecordsFailedOperation runs a config whose second operation
+// fails and exits, and checks both operations reached the audit log
+func TestAuditLogRecordsFailedOperation(t *testing.T) {
+	dir := t.TempDir()
+	workspace := filepath.Join(dir, "workspace")
+	missing := filepath.Join(dir, "missing.wit")
+	auditPath := filepath.Join(dir, "audit", "file_ops.jsonl")
+
+	config, err := json.Marshal(FileOpsConfig{
+		WorkspaceDir: "workspace", // Relative to the working directory
+		Operations: []interface{}{
+			map[string]interface{}{"type": "mkdir", "path": "wit"},
+			map[string]interface{}{"type": "copy_file", "src_path": missing, "dest_path": "wit/world.wit"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, config, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^$", configPath, "--audit-log="+auditPath)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "FILE_OPS_RUN_MAIN=1")
+	if out, err := cmd.CombinedOutput(); err == nil {
+		t.Fatalf("file_ops succeeded despite a missing source:\n%s", out)
+	}
+
+	file, err := os.Open(auditPath)
+	if err != nil {
+		t.Fatalf("audit log not written: %v", err)
+	}
+	defer file.Close()
+
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("malformed audit line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Workspace mkdir, the mkdir operation, then the failed copy
+	if len(entries) != 3 {
+		t.Fatalf("got %d audit entries, want 3: %+v", len(entries), entries)
+	}
+	if got := entries[1]; got.Operation != "mkdir" || got.Outcome != "ok" || got.Mode != "0755" {
+		t.Errorf("mkdir entry = %+v", got)
+	}
+
+	failed := entries[2]
+	if failed.Operation != "copy_file" || failed.Outcome != "error" || failed.Error == "" {
+		t.Errorf("copy_file entry = %+v, want an error outcome", failed)
+	}
+	wantPaths := []string{missing, filepath.Join(workspace, "wit", "world.wit")}
+	if len(failed.Paths) != len(wantPaths) || failed.Paths[0] != wantPaths[0] || failed.Paths[1] != wantPaths[1] {
+		t.Errorf("copy_file paths = %v, want %v", failed.Paths, wantPaths)
+	}
+	if failed.Time == "" {
+		t.Error("copy_file entry has no timestamp")
+	}
+}
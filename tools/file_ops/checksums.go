@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// FileChecksum is the provenance record for one file written by an operation
+type FileChecksum struct {
+	Path   string `json:"path"` // Relative to the workspace
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// checksumLog collects a FileChecksum for every file written, so staged
+// outputs can be compared across runs and machines. Digests are computed
+// while the file is written, never by reading it back. A file written more
+// than once keeps its final digest.
+type checksumLog struct {
+	mu    sync.Mutex
+	root  string
+	files map[string]FileChecksum
+}
+
+// writtenFiles is nil unless checksums_out or --checksums-out is set
+var writtenFiles *checksumLog
+
+func newChecksumLog(root string) *checksumLog {
+	return &checksumLog{root: root, files: make(map[string]FileChecksum)}
+}
+
+func (l *checksumLog) record(path, digest string, size int64) {
+	if l == nil {
+		return
+	}
+
+	rel, err := filepath.Rel(l.root, path)
+	if err != nil {
+		rel = path
+	}
+	rel = filepath.ToSlash(rel)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.files[rel] = FileChecksum{Path: rel, SHA256: digest, Size: size}
+}
+
+// save writes the log as a JSON array sorted by path
+func (l *checksumLog) save(path string) error {
+	l.mu.Lock()
+	records := make([]FileChecksum, 0, len(l.files))
+	for _, record := range l.files {
+		records = append(records, record)
+	}
+	l.mu.Unlock()
+
+	sort.Slice(records, func(i, j int) bool { return records[i].Path < records[j].Path })
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
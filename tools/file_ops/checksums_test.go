@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestChecksumLogMatchesWrittenFiles copies files into a workspace with the
+// checksum log enabled and checks every recorded digest and size against
+// the files actually on disk
+func TestChecksumLogMatchesWrittenFiles(t *testing.T) {
+	srcDir := t.TempDir()
+	workspace := t.TempDir()
+
+	writtenFiles = newChecksumLog(workspace)
+	t.Cleanup(func() { writtenFiles = nil })
+
+	sources := map[string]string{
+		"component.wasm":   "\x00asm\x0d\x00\x01\x00",
+		"wit/world.wit":    "package example:world;\n",
+		"wit/deps/api.wit": strings.Repeat("interface api {}\n", 1000),
+	}
+	for rel, content := range sources {
+		src := filepath.Join(srcDir, filepath.Base(rel))
+		if err := os.WriteFile(src, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		dest := filepath.Join(workspace, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			t.Fatal(err)
+		}
+		// Exercise both copy paths
+		if err := copyFile(src, dest, rel == "component.wasm"); err != nil {
+			t.Fatalf("copying %s: %v", rel, err)
+		}
+	}
+
+	// Overwriting a file keeps only its final digest
+	overwritten := filepath.Join(workspace, "wit/world.wit")
+	if _, err := writeFileAtomic(overwritten, strings.NewReader("package example:world@1.0.0;\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "checksums.json")
+	if err := writtenFiles.save(out); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []FileChecksum
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatalf("parsing %s: %v", out, err)
+	}
+
+	if len(records) != len(sources) {
+		t.Fatalf("recorded %d files, want %d: %+v", len(records), len(sources), records)
+	}
+	for i, record := range records {
+		if i > 0 && records[i-1].Path >= record.Path {
+			t.Errorf("records not sorted by path: %s before %s", records[i-1].Path, record.Path)
+		}
+
+		content, err := os.ReadFile(filepath.Join(workspace, filepath.FromSlash(record.Path)))
+		if err != nil {
+			t.Errorf("recorded path %s: %v", record.Path, err)
+			continue
+		}
+		sum := sha256.Sum256(content)
+		if want := hex.EncodeToString(sum[:]); record.SHA256 != want {
+			t.Errorf("%s: recorded sha256 %s, file has %s", record.Path, record.SHA256, want)
+		}
+		if record.Size != int64(len(content)) {
+			t.Errorf("%s: recorded size %d, file has %d", record.Path, record.Size, len(content))
+		}
+	}
+}
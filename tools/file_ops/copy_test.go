@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// failingReader yields data and then fails, like a source that disappears
+// or a disk that errors partway through a copy
+type failingReader struct {
+	data []byte
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestWriteFileAtomicInterrupted(t *testing.T) {
+	injected := errors.New("injected read failure")
+
+	tests := []struct {
+		name     string
+		existing []byte // Destination content before the copy, nil for none
+	}{
+		{name: "new destination"},
+		{name: "existing destination", existing: []byte("previous complete output\n")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			dest := filepath.Join(dir, "out.wasm")
+			if tt.existing != nil {
+				if err := os.WriteFile(dest, tt.existing, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			src := &failingReader{data: bytes.Repeat([]byte("x"), 64*1024), err: injected}
+			if _, err := writeFileAtomic(dest, src); !errors.Is(err, injected) {
+				t.Fatalf("writeFileAtomic error = %v, want %v", err, injected)
+			}
+
+			got, err := os.ReadFile(dest)
+			switch {
+			case tt.existing == nil && !os.IsNotExist(err):
+				t.Errorf("partial destination left behind (read error %v, %d bytes)", err, len(got))
+			case tt.existing != nil && !bytes.Equal(got, tt.existing):
+				t.Errorf("destination = %q, want untouched %q", got, tt.existing)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, entry := range entries {
+				if entry.Name() != "out.wasm" {
+					t.Errorf("temp file %s not cleaned up", entry.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestCopyFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dest := filepath.Join(dir, "dest.txt")
+	want := []byte("component bytes\n")
+	if err := os.WriteFile(src, want, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := copyFileAtomic(src, dest); err != nil {
+		t.Fatalf("copyFileAtomic: %v", err)
+	}
+
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("destination = %q, want %q", got, want)
+	}
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("destination mode = %v, want 0644", info.Mode().Perm())
+	}
+}
+
+// corruptingWriter flips the first byte it writes, like a faulty disk or
+// filesystem that acknowledges a write it did not store correctly
+type corruptingWriter struct {
+	w       io.Writer
+	flipped bool
+}
+
+func (c *corruptingWriter) Write(p []byte) (int, error) {
+	if !c.flipped && len(p) > 0 {
+		c.flipped = true
+		damaged := append([]byte(nil), p...)
+		damaged[0] ^= 0xff
+		return c.w.Write(damaged)
+	}
+	return c.w.Write(p)
+}
+
+func TestCopyFileVerified(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt bool
+		verify  bool
+		wantErr bool
+	}{
+		{name: "verified copy", verify: true},
+		{name: "verified copy of corrupted write", corrupt: true, verify: true, wantErr: true},
+		{name: "unverified copy of corrupted write", corrupt: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.corrupt {
+				original := destWriter
+				destWriter = func(file *os.File) io.Writer { return &corruptingWriter{w: file} }
+				t.Cleanup(func() { destWriter = original })
+			}
+
+			dir := t.TempDir()
+			src := filepath.Join(dir, "src.wasm")
+			dest := filepath.Join(dir, "dest.wasm")
+			if err := os.WriteFile(src, []byte("\x00asm component bytes"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			srcDigest, err := sha256File(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = copyFile(src, dest, tt.verify)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("copyFile error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+
+			destDigest, digestErr := sha256File(dest)
+			if digestErr != nil {
+				t.Fatal(digestErr)
+			}
+			for _, digest := range []string{srcDigest, destDigest} {
+				if !strings.Contains(err.Error(), digest) {
+					t.Errorf("error %q does not report digest %s", err, digest)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// patchOp is a single RFC 6902 operation
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// applyJSONPatchFile applies an RFC 6902 patch to the JSON file at target and
+// rewrites it. The target must already be valid JSON. Operations apply in
+// order and the file is left untouched if any of them fails.
+func applyJSONPatchFile(target string, patch interface{}) error {
+	data, err := os.ReadFile(target)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("target %s is not valid JSON: %w", target, err)
+	}
+
+	// The patch arrives already decoded as part of the config
+	patchData, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	var ops []patchOp
+	if err := json.Unmarshal(patchData, &ops); err != nil {
+		return fmt.Errorf("patch must be an array of operations: %w", err)
+	}
+
+	for i, op := range ops {
+		doc, err = applyPatchOp(doc, op)
+		if err != nil {
+			return fmt.Errorf("patch op %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err = writeFileAtomic(target, &buf)
+	return err
+}
+
+func applyPatchOp(doc interface{}, op patchOp) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) == 0 {
+			return nil, fmt.Errorf("missing value")
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add":
+			return pointerAdd(doc, path, value)
+		case "replace":
+			return pointerReplace(doc, path, value)
+		default:
+			actual, err := pointerGet(doc, path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(actual, value) {
+				return nil, fmt.Errorf("test failed: value is %s", compactJSON(actual))
+			}
+			return doc, nil
+		}
+
+	case "remove":
+		return pointerRemove(doc, path)
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+		value, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+
+		if op.Op == "copy" {
+			// Decouple the copy from the original so later ops don't alias
+			var clone interface{}
+			json.Unmarshal([]byte(compactJSON(value)), &clone)
+			return pointerAdd(doc, path, clone)
+		}
+
+		if op.From == op.Path {
+			return doc, nil
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move %s into its own child", op.From)
+		}
+		doc, err = pointerRemove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, value)
+
+	default:
+		return nil, fmt.Errorf("unknown op %q", op.Op)
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON pointer into unescaped reference
+// tokens. The empty pointer refers to the whole document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	node := doc
+	for _, token := range path {
+		var err error
+		if node, err = childOf(node, token); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+func pointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			p[key] = value
+			return p, nil
+		case []interface{}:
+			index := len(p)
+			if key != "-" {
+				var err error
+				if index, err = arrayIndex(key, len(p)+1); err != nil {
+					return nil, err
+				}
+			}
+			p = append(p, nil)
+			copy(p[index+1:], p[index:])
+			p[index] = value
+			return p, nil
+		}
+		return nil, fmt.Errorf("cannot add %q to a scalar", key)
+	})
+}
+
+func pointerRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the whole document")
+	}
+	return updateParent(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		switch p := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := p[key]; !ok {
+				return nil, fmt.Errorf("member %q not found", key)
+			}
+			delete(p, key)
+			return p, nil
+		case []interface{}:
+			index, err := arrayIndex(key, len(p))
+			if err != nil {
+				return nil, err
+			}
+			return append(p[:index], p[index+1:]...), nil
+		}
+		return nil, fmt.Errorf("cannot remove %q from a scalar", key)
+	})
+}
+
+func pointerReplace(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return updateParent(doc, path, func(parent interface{}, key string) (interface{}, error) {
+		if _, err := childOf(parent, key); err != nil {
+			return nil, err
+		}
+		return setChild(parent, key, value), nil
+	})
+}
+
+// updateParent walks to the container holding the last token of path, lets
+// update modify it, and stores the result back up the chain (appending to
+// or shrinking an array yields a new slice)
+func updateParent(node interface{}, path []string, update func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return update(node, path[0])
+	}
+
+	child, err := childOf(node, path[0])
+	if err != nil {
+		return nil, err
+	}
+	updated, err := updateParent(child, path[1:], update)
+	if err != nil {
+		return nil, err
+	}
+	return setChild(node, path[0], updated), nil
+}
+
+func childOf(node interface{}, key string) (interface{}, error) {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		child, ok := n[key]
+		if !ok {
+			return nil, fmt.Errorf("member %q not found", key)
+		}
+		return child, nil
+	case []interface{}:
+		index, err := arrayIndex(key, len(n))
+		if err != nil {
+			return nil, err
+		}
+		return n[index], nil
+	}
+	return nil, fmt.Errorf("cannot look up %q in a scalar", key)
+}
+
+// setChild replaces an existing member or element; callers have already
+// checked that key resolves
+func setChild(node interface{}, key string, value interface{}) interface{} {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		n[key] = value
+	case []interface{}:
+		index, _ := strconv.Atoi(key)
+		n[index] = value
+	}
+	return node
+}
+
+// arrayIndex parses an array reference token, which must be a plain decimal
+// index below limit
+func arrayIndex(key string, limit int) (int, error) {
+	index, err := strconv.Atoi(key)
+	if err != nil || index < 0 || strconv.Itoa(index) != key {
+		return 0, fmt.Errorf("invalid array index %q", key)
+	}
+	if index >= limit {
+		return 0, fmt.Errorf("array index %d out of range", index)
+	}
+	return index, nil
+}
+
+func compactJSON(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
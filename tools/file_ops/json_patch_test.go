@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestApplyPatchOps runs patches over documents, mostly the examples of
+// RFC 6902 appendix A
+func TestApplyPatchOps(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		patch   string
+		want    string // Compact JSON of the result
+		wantErr string // Substring of the expected error
+	}{
+		{
+			name:  "add object member",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"add","path":"/baz","value":"qux"}]`,
+			want:  `{"baz":"qux","foo":"bar"}`,
+		},
+		{
+			name:  "add array element",
+			doc:   `{"foo":["bar","baz"]}`,
+			patch: `[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			want:  `{"foo":["bar","qux","baz"]}`,
+		},
+		{
+			name:  "append with dash",
+			doc:   `{"foo":["bar"]}`,
+			patch: `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			want:  `{"foo":["bar",["abc","def"]]}`,
+		},
+		{
+			name:  "remove object member",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"remove","path":"/baz"}]`,
+			want:  `{"foo":"bar"}`,
+		},
+		{
+			name:  "remove array element",
+			doc:   `{"foo":["bar","qux","baz"]}`,
+			patch: `[{"op":"remove","path":"/foo/1"}]`,
+			want:  `{"foo":["bar","baz"]}`,
+		},
+		{
+			name:  "replace",
+			doc:   `{"baz":"qux","foo":"bar"}`,
+			patch: `[{"op":"replace","path":"/baz","value":"boo"}]`,
+			want:  `{"baz":"boo","foo":"bar"}`,
+		},
+		{
+			name:  "move value",
+			doc:   `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			patch: `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			want:  `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`,
+		},
+		{
+			name:  "move array element",
+			doc:   `{"foo":["all","grass","cows","eat"]}`,
+			patch: `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			want:  `{"foo":["all","cows","eat","grass"]}`,
+		},
+		{
+			name:  "copy does not alias",
+			doc:   `{"a":{"b":1}}`,
+			patch: `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`,
+			want:  `{"a":{"b":1},"c":{"b":2}}`,
+		},
+		{
+			name:  "test passes",
+			doc:   `{"baz":"qux","foo":["a",2,"c"]}`,
+			patch: `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			want:  `{"baz":"qux","foo":["a",2,"c"]}`,
+		},
+		{
+			name:  "escaped pointer tokens",
+			doc:   `{"a/b":1,"m~n":2}`,
+			patch: `[{"op":"replace","path":"/a~1b","value":3},{"op":"remove","path":"/m~0n"}]`,
+			want:  `{"a/b":3}`,
+		},
+		{
+			name:  "replace whole document",
+			doc:   `{"foo":"bar"}`,
+			patch: `[{"op":"replace","path":"","value":[1]}]`,
+			want:  `[1]`,
+		},
+		{
+			name:    "test fails",
+			doc:     `{"baz":"qux"}`,
+			patch:   `[{"op":"test","path":"/baz","value":"bar"}]`,
+			wantErr: `test failed: value is "qux"`,
+		},
+		{
+			name:    "add to missing parent",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			wantErr: `member "baz" not found`,
+		},
+		{
+			name:    "remove missing member",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"remove","path":"/baz"}]`,
+			wantErr: `member "baz" not found`,
+		},
+		{
+			name:    "replace missing member",
+			doc:     `{"foo":"bar"}`,
+			patch:   `[{"op":"replace","path":"/baz","value":1}]`,
+			wantErr: `member "baz" not found`,
+		},
+		{
+			name:    "array index out of range",
+			doc:     `{"foo":["bar"]}`,
+			patch:   `[{"op":"add","path":"/foo/2","value":"qux"}]`,
+			wantErr: "out of range",
+		},
+		{
+			name:    "leading zero index",
+			doc:     `{"foo":["a","b"]}`,
+			patch:   `[{"op":"remove","path":"/foo/01"}]`,
+			wantErr: `invalid array index "01"`,
+		},
+		{
+			name:    "move into own child",
+			doc:     `{"a":{"b":{}}}`,
+			patch:   `[{"op":"move","from":"/a","path":"/a/b/c"}]`,
+			wantErr: "into its own child",
+		},
+		{
+			name:    "missing value",
+			doc:     `{}`,
+			patch:   `[{"op":"add","path":"/a"}]`,
+			wantErr: "missing value",
+		},
+		{
+			name:    "pointer without leading slash",
+			doc:     `{"a":1}`,
+			patch:   `[{"op":"remove","path":"a"}]`,
+			wantErr: "invalid JSON pointer",
+		},
+		{
+			name:    "unknown op",
+			doc:     `{}`,
+			patch:   `[{"op":"merge","path":"/a","value":1}]`,
+			wantErr: `unknown op "merge"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc interface{}
+			if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+			var ops []patchOp
+			if err := json.Unmarshal([]byte(tt.patch), &ops); err != nil {
+				t.Fatal(err)
+			}
+
+			var err error
+			for _, op := range ops {
+				if doc, err = applyPatchOp(doc, op); err != nil {
+					break
+				}
+			}
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyPatchOp: %v", err)
+			}
+			if got := compactJSON(doc); got != tt.want {
+				t.Errorf("result = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchFile(t *testing.T) {
+	decode := func(s string) interface{} {
+		var v interface{}
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	t.Run("rewrites the target", func(t *testing.T) {
+		target := filepath.Join(t.TempDir(), "config.json")
+		os.WriteFile(target, []byte(`{"version":"0.0.0","url":"a&b"}`), 0644)
+
+		if err := applyJSONPatchFile(target, decode(`[{"op":"replace","path":"/version","value":"1.2.3"}]`)); err != nil {
+			t.Fatalf("applyJSONPatchFile: %v", err)
+		}
+		got, _ := os.ReadFile(target)
+		if want := "{\n  \"url\": \"a&b\",\n  \"version\": \"1.2.3\"\n}\n"; string(got) != want {
+			t.Errorf("target = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("failed op leaves the target untouched", func(t *testing.T) {
+		target := filepath.Join(t.TempDir(), "config.json")
+		original := []byte(`{"version":"0.0.0"}`)
+		os.WriteFile(target, original, 0644)
+
+		patch := decode(`[{"op":"replace","path":"/version","value":"1.2.3"},{"op":"remove","path":"/missing"}]`)
+		err := applyJSONPatchFile(target, patch)
+		if err == nil || !strings.Contains(err.Error(), "patch op 1 (remove /missing)") {
+			t.Fatalf("error = %v, want op 1 to fail", err)
+		}
+		if got, _ := os.ReadFile(target); string(got) != string(original) {
+			t.Errorf("target = %s, want it untouched", got)
+		}
+	})
+
+	t.Run("target must be JSON", func(t *testing.T) {
+		target := filepath.Join(t.TempDir(), "config.json")
+		os.WriteFile(target, []byte("not: json\n"), 0644)
+		if err := applyJSONPatchFile(target, decode(`[]`)); err == nil || !strings.Contains(err.Error(), "not valid JSON") {
+			t.Errorf("error = %v, want the target rejected", err)
+		}
+	})
+
+	t.Run("patch must be an array", func(t *testing.T) {
+		target := filepath.Join(t.TempDir(), "config.json")
+		os.WriteFile(target, []byte(`{}`), 0644)
+		if err := applyJSONPatchFile(target, decode(`{"op":"add"}`)); err == nil || !strings.Contains(err.Error(), "array of operations") {
+			t.Errorf("error = %v, want the patch rejected", err)
+		}
+	})
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmheader"
 )
 
 // Config structure for file operations
@@ -14,6 +21,15 @@ type FileOpsConfig struct {
 	Operations        []interface{} `json:"operations"`
 	WasmtimePath      string        `json:"wasmtime_path"`
 	WasmComponentPath string        `json:"wasm_component_path"`
+	// VerifyCopies re-reads every copied file and compares its SHA-256 with
+	// the source to catch silent corruption on flaky filesystems
+	VerifyCopies bool `json:"verify_copies"`
+	// ChecksumsOut, when set, receives the SHA-256 and size of every file
+	// written, for build provenance. --checksums-out=PATH overrides it.
+	ChecksumsOut string `json:"checksums_out"`
+	// AuditLog, when set, is appended a JSON line for every filesystem
+	// mutation, including failed ones. --audit-log=PATH overrides it.
+	AuditLog string `json:"audit_log"`
 }
 
 // Helper to panic on error
@@ -32,10 +48,20 @@ func must(s string, err error) string {
 func main() {
 	// Read configuration from JSON file (passed as first argument)
 	if len(os.Args) < 2 {
-		log.Fatalf("Usage: file_ops <config.json>")
+		log.Fatalf("Usage: file_ops <config.json> [--checksums-out=PATH] [--audit-log=PATH]")
 	}
 
 	configPath := os.Args[1]
+	checksumsOut := ""
+	auditLogPath := ""
+	for _, arg := range os.Args[2:] {
+		if strings.HasPrefix(arg, "--checksums-out=") {
+			checksumsOut = strings.TrimPrefix(arg, "--checksums-out=")
+		}
+		if strings.HasPrefix(arg, "--audit-log=") {
+			auditLogPath = strings.TrimPrefix(arg, "--audit-log=")
+		}
+	}
 
 	// Always log when invoked (for debugging)
 	log.Printf("file_ops wrapper started with config: %s", configPath)
@@ -66,22 +92,53 @@ func main() {
 	// Note: wasmtime_path and wasm_component_path are optional - they're only needed
 	// if the config uses WASM component execution. File-only operations don't need them.
 	// This file_ops wrapper now handles pure file operations directly in Go.
+	if config.WasmComponentPath != "" {
+		if err := checkExternalComponent(config.WasmComponentPath); err != nil {
+			log.Fatalf("Invalid wasm_component_path %s: %v", config.WasmComponentPath, err)
+		}
+	}
 
 	cwd, err := os.Getwd()
 	if err != nil {
 		log.Fatalf("Failed to get current working directory: %v", err)
 	}
 
+	if auditLogPath == "" {
+		auditLogPath = config.AuditLog
+	}
+	if auditLogPath != "" {
+		auditTrail, err = openAuditLog(auditLogPath)
+		if err != nil {
+			log.Fatalf("Failed to open audit log %s: %v", auditLogPath, err)
+		}
+		defer auditTrail.close()
+	}
+
 	// Convert workspace_dir to absolute path
 	workspaceFullPath := filepath.Join(cwd, config.WorkspaceDir)
-	if err := os.MkdirAll(workspaceFullPath, 0755); err != nil {
+	err = os.MkdirAll(workspaceFullPath, 0755)
+	auditTrail.record("mkdir", []string{workspaceFullPath}, 0, 0755, err)
+	if err != nil {
 		log.Fatalf("Failed to create workspace directory: %v", err)
 	}
 
+	if checksumsOut == "" {
+		checksumsOut = config.ChecksumsOut
+	}
+	if checksumsOut != "" {
+		writtenFiles = newChecksumLog(workspaceFullPath)
+	}
+
+	// Honor any declared `after` dependencies; otherwise keep config order
+	operations, err := orderOperations(config.Operations)
+	if err != nil {
+		log.Fatalf("Failed to order operations: %v", err)
+	}
+
 	// Process file operations directly in Go
-	log.Printf("DEBUG: Processing %d file operations", len(config.Operations))
+	log.Printf("DEBUG: Processing %d file operations", len(operations))
 
-	for i, op := range config.Operations {
+	for i, op := range operations {
 		opMap, ok := op.(map[string]interface{})
 		if !ok {
 			log.Printf("WARNING: Operation %d is not a map, skipping", i)
@@ -97,26 +154,26 @@ func main() {
 		log.Printf("DEBUG: Processing operation %d: %s", i, opType)
 
 		switch opType {
-		case "copy_file":
+		case "copy_file", "verify_copy":
 			srcPath := opMap["src_path"].(string)
 			destPath := filepath.Join(workspaceFullPath, opMap["dest_path"].(string))
 			// Ensure parent directory exists
 			os.MkdirAll(filepath.Dir(destPath), 0755)
 			// Copy file
-			data, err := ioutil.ReadFile(srcPath)
+			verify := config.VerifyCopies || opType == "verify_copy"
+			err := copyFile(srcPath, destPath, verify)
+			auditTrail.recordWrite(opType, []string{srcPath, destPath}, err)
 			if err != nil {
-				log.Printf("ERROR: Failed to read source file %s: %v", srcPath, err)
-				os.Exit(1)
-			}
-			if err := ioutil.WriteFile(destPath, data, 0644); err != nil {
-				log.Printf("ERROR: Failed to write destination file %s: %v", destPath, err)
+				log.Printf("ERROR: Failed to copy %s to %s: %v", srcPath, destPath, err)
 				os.Exit(1)
 			}
 			log.Printf("DEBUG: Copied %s to %s", srcPath, destPath)
 
 		case "mkdir":
 			dirPath := filepath.Join(workspaceFullPath, opMap["path"].(string))
-			if err := os.MkdirAll(dirPath, 0755); err != nil {
+			err := os.MkdirAll(dirPath, 0755)
+			auditTrail.record(opType, []string{dirPath}, 0, 0755, err)
+			if err != nil {
 				log.Printf("ERROR: Failed to create directory %s: %v", dirPath, err)
 				os.Exit(1)
 			}
@@ -128,8 +185,9 @@ func main() {
 			os.MkdirAll(destDir, 0755)
 
 			// Recursively copy all files/directories from source
-			filepath.Walk(srcDir, func(srcPath string, info os.FileInfo, err error) error {
+			err := filepath.Walk(srcDir, func(srcPath string, info os.FileInfo, err error) error {
 				if err != nil {
+					auditTrail.record(opType, []string{srcPath, destDir}, 0, 0, err)
 					return err
 				}
 
@@ -139,68 +197,317 @@ func main() {
 
 				if info.IsDir() {
 					// Create directory
-					return os.MkdirAll(destPath, 0755)
+					err := os.MkdirAll(destPath, 0755)
+					auditTrail.record(opType, []string{srcPath, destPath}, 0, 0755, err)
+					return err
 				} else {
 					// Copy file
 					os.MkdirAll(filepath.Dir(destPath), 0755)
-					data, err := ioutil.ReadFile(srcPath)
-					if err != nil {
-						return err
-					}
-					return ioutil.WriteFile(destPath, data, 0644)
+					err := copyFile(srcPath, destPath, config.VerifyCopies)
+					auditTrail.recordWrite(opType, []string{srcPath, destPath}, err)
+					return err
 				}
 			})
+			if err != nil {
+				log.Printf("ERROR: Failed to copy directory contents from %s to %s: %v", srcDir, destDir, err)
+				os.Exit(1)
+			}
 			log.Printf("DEBUG: Copied directory contents from %s to %s", srcDir, destDir)
 
 		case "concatenate_files":
 			// Concatenate multiple files into one
+			destPath := filepath.Join(workspaceFullPath, opMap["dest_path"].(string))
 			srcPaths, ok := opMap["src_paths"].([]interface{})
 			if !ok {
+				auditTrail.record(opType, []string{destPath}, 0, 0, fmt.Errorf("missing src_paths"))
 				log.Printf("ERROR: concatenate_files operation missing src_paths")
 				os.Exit(1)
 			}
 
-			destPath := filepath.Join(workspaceFullPath, opMap["dest_path"].(string))
+			// Sources then destination, for the audit log
+			auditPaths := make([]string, 0, len(srcPaths)+1)
+			for _, srcPath := range srcPaths {
+				auditPaths = append(auditPaths, fmt.Sprint(srcPath))
+			}
+			auditPaths = append(auditPaths, destPath)
+
 			os.MkdirAll(filepath.Dir(destPath), 0755)
 
 			// Open destination file for writing
 			destFile, err := os.Create(destPath)
 			if err != nil {
+				auditTrail.record(opType, auditPaths, 0, 0, err)
 				log.Printf("ERROR: Failed to create destination file %s: %v", destPath, err)
 				os.Exit(1)
 			}
 			defer destFile.Close()
 
-			// Concatenate each source file
+			// Concatenate each source file, hashing the output as it is written
+			hasher := sha256.New()
+			out := io.MultiWriter(destFile, hasher)
+			var written int64
 			for _, srcPath := range srcPaths {
 				srcPathStr, ok := srcPath.(string)
 				if !ok {
+					auditTrail.record(opType, auditPaths, written, 0, fmt.Errorf("invalid source path %v", srcPath))
 					log.Printf("ERROR: Invalid source path in concatenate_files")
 					os.Exit(1)
 				}
 
 				data, err := ioutil.ReadFile(srcPathStr)
 				if err != nil {
+					auditTrail.record(opType, auditPaths, written, 0, err)
 					log.Printf("ERROR: Failed to read source file %s: %v", srcPathStr, err)
 					os.Exit(1)
 				}
 
-				if _, err := destFile.Write(data); err != nil {
+				if _, err := out.Write(data); err != nil {
+					auditTrail.record(opType, auditPaths, written, 0, err)
 					log.Printf("ERROR: Failed to write to destination file %s: %v", destPath, err)
 					os.Exit(1)
 				}
+				written += int64(len(data))
 			}
+			writtenFiles.record(destPath, hex.EncodeToString(hasher.Sum(nil)), written)
+			auditTrail.recordWrite(opType, auditPaths, nil)
 
 			log.Printf("DEBUG: Concatenated %d files to %s", len(srcPaths), destPath)
 
+		case "json_patch":
+			// Apply an RFC 6902 patch to a JSON file already in the workspace
+			targetPath := filepath.Join(workspaceFullPath, opMap["target"].(string))
+			err := applyJSONPatchFile(targetPath, opMap["patch"])
+			auditTrail.recordWrite(opType, []string{targetPath}, err)
+			if err != nil {
+				log.Printf("ERROR: Failed to patch %s: %v", targetPath, err)
+				os.Exit(1)
+			}
+			log.Printf("DEBUG: Patched %s", targetPath)
+
 		default:
 			log.Printf("WARNING: Unknown operation type: %s", opType)
 		}
 	}
 
+	if writtenFiles != nil {
+		err := writtenFiles.save(checksumsOut)
+		auditTrail.recordWrite("checksums_out", []string{checksumsOut}, err)
+		if err != nil {
+			log.Fatalf("Failed to write checksums to %s: %v", checksumsOut, err)
+		}
+		log.Printf("DEBUG: Recorded checksums for %d files in %s", len(writtenFiles.files), checksumsOut)
+	}
+
 	log.Printf("DEBUG: All file operations completed successfully")
 }
 
+// checkExternalComponent verifies that path holds a WASM component rather
+// than a core module, a truncated download or some other file
+func checkExternalComponent(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	kind, _, err := wasmheader.Classify(file)
+	if err != nil {
+		return err
+	}
+	if kind != wasmheader.Component {
+		return fmt.Errorf("is a core %s, not a component", kind)
+	}
+	return nil
+}
+
+// copyFile copies src to dest atomically, optionally verifying the result
+func copyFile(src, dest string, verify bool) error {
+	if verify {
+		return copyFileVerified(src, dest)
+	}
+	return copyFileAtomic(src, dest)
+}
+
+// copyFileVerified copies src to dest, hashing the source as it is written,
+// then re-reads dest and fails with both digests if they differ
+func copyFileVerified(src, dest string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	srcDigest, err := writeFileAtomic(dest, srcFile)
+	if err != nil {
+		return err
+	}
+
+	destDigest, err := sha256File(dest)
+	if err != nil {
+		return fmt.Errorf("verifying %s: %w", dest, err)
+	}
+
+	if destDigest != srcDigest {
+		return fmt.Errorf("copy verification failed for %s: source sha256 %s, destination sha256 %s", dest, srcDigest, destDigest)
+	}
+
+	return nil
+}
+
+func sha256File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// copyFileAtomic copies src to dest via a temp file in the destination
+// directory that is renamed into place only once fully written, so an
+// interrupted copy never leaves a partial destination behind.
+func copyFileAtomic(src, dest string) error {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+
+	_, err = writeFileAtomic(dest, srcFile)
+	return err
+}
+
+// destWriter wraps the temp file writeFileAtomic writes through. Tests swap it
+// to damage bytes on their way to disk.
+var destWriter = func(file *os.File) io.Writer { return file }
+
+// writeFileAtomic streams r into dest through a temp file and renames it into
+// place on success, returning the SHA-256 of the bytes written. The temp file
+// is removed on any error.
+func writeFileAtomic(dest string, r io.Reader) (digest string, err error) {
+	tmpFile, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmpFile.Name()
+
+	defer func() {
+		if err != nil {
+			tmpFile.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(destWriter(tmpFile), hasher), r)
+	if err != nil {
+		return "", err
+	}
+	if err = tmpFile.Sync(); err != nil {
+		return "", err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return "", err
+	}
+	if err = os.Chmod(tmpPath, 0644); err != nil {
+		return "", err
+	}
+	if err = os.Rename(tmpPath, dest); err != nil {
+		return "", err
+	}
+
+	digest = hex.EncodeToString(hasher.Sum(nil))
+	writtenFiles.record(dest, digest, size)
+	return digest, nil
+}
+
+// orderOperations topologically sorts operations by their optional `id` and
+// `after: [ids]` fields. Among operations whose dependencies are satisfied,
+// config order wins, so configs without dependencies run unchanged.
+func orderOperations(ops []interface{}) ([]interface{}, error) {
+	ids := make(map[string]int)
+	for i, op := range ops {
+		opMap, ok := op.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if id, ok := opMap["id"].(string); ok && id != "" {
+			if prev, exists := ids[id]; exists {
+				return nil, fmt.Errorf("operations %d and %d share id %q", prev, i, id)
+			}
+			ids[id] = i
+		}
+	}
+
+	// dependents[i] lists operations that must wait for operation i
+	dependents := make([][]int, len(ops))
+	pending := make([]int, len(ops))
+	for i, op := range ops {
+		opMap, ok := op.(map[string]interface{})
+		if !ok || opMap["after"] == nil {
+			continue
+		}
+		after, ok := opMap["after"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("operation %d: after must be a list of ids", i)
+		}
+		for _, dep := range after {
+			depID, _ := dep.(string)
+			j, exists := ids[depID]
+			if !exists {
+				return nil, fmt.Errorf("operation %d: after references unknown id %v", i, dep)
+			}
+			dependents[j] = append(dependents[j], i)
+			pending[i]++
+		}
+	}
+
+	// Kahn's algorithm, always taking the earliest ready operation
+	ordered := make([]interface{}, 0, len(ops))
+	done := make([]bool, len(ops))
+	for len(ordered) < len(ops) {
+		next := -1
+		for i := range ops {
+			if !done[i] && pending[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next == -1 {
+			var cycle []string
+			for i := range ops {
+				if !done[i] {
+					cycle = append(cycle, operationName(ops[i], i))
+				}
+			}
+			return nil, fmt.Errorf("dependency cycle among operations: %s", strings.Join(cycle, ", "))
+		}
+
+		done[next] = true
+		ordered = append(ordered, ops[next])
+		for _, dependent := range dependents[next] {
+			pending[dependent]--
+		}
+	}
+
+	return ordered, nil
+}
+
+// operationName identifies an operation by id when it has one
+func operationName(op interface{}, index int) string {
+	if opMap, ok := op.(map[string]interface{}); ok {
+		if id, ok := opMap["id"].(string); ok && id != "" {
+			return id
+		}
+	}
+	return fmt.Sprintf("#%d", index)
+}
 
 // uniqueStrings returns unique strings from a slice
 func uniqueStrings(strs []string) []string {
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestOrderOperations(t *testing.T) {
+	tests := []struct {
+		name    string
+		ops     string // JSON operations list; every operation has an id
+		want    string // Resulting ids, comma-separated
+		wantErr string // Substring of the expected error
+	}{
+		{
+			name: "no dependencies keeps config order",
+			ops:  `[{"id":"c"},{"id":"a"},{"id":"b"}]`,
+			want: "c,a,b",
+		},
+		{
+			name: "dependency moves an operation later",
+			ops:  `[{"id":"copy","after":["mkdir"]},{"id":"mkdir"}]`,
+			want: "mkdir,copy",
+		},
+		{
+			name: "chain",
+			ops:  `[{"id":"c","after":["b"]},{"id":"b","after":["a"]},{"id":"a"}]`,
+			want: "a,b,c",
+		},
+		{
+			name: "diamond",
+			ops:  `[{"id":"join","after":["left","right"]},{"id":"right","after":["root"]},{"id":"left","after":["root"]},{"id":"root"}]`,
+			want: "root,right,left,join",
+		},
+		{
+			name: "earliest ready operation wins",
+			ops:  `[{"id":"late","after":["dep"]},{"id":"first"},{"id":"dep"},{"id":"last"}]`,
+			want: "first,dep,late,last",
+		},
+		{
+			name:    "two-operation cycle",
+			ops:     `[{"id":"a","after":["b"]},{"id":"b","after":["a"]},{"id":"free"}]`,
+			wantErr: "dependency cycle among operations: a, b",
+		},
+		{
+			name:    "self dependency",
+			ops:     `[{"id":"a","after":["a"]}]`,
+			wantErr: "dependency cycle among operations: a",
+		},
+		{
+			name:    "cycle reports operations blocked behind it",
+			ops:     `[{"id":"a","after":["c"]},{"id":"b","after":["a"]},{"id":"c","after":["b"]},{"id":"d","after":["c"]}]`,
+			wantErr: "dependency cycle among operations: a, b, c, d",
+		},
+		{
+			name:    "unknown id",
+			ops:     `[{"id":"a","after":["missing"]}]`,
+			wantErr: `after references unknown id missing`,
+		},
+		{
+			name:    "duplicate id",
+			ops:     `[{"id":"a"},{"id":"a"}]`,
+			wantErr: `operations 0 and 1 share id "a"`,
+		},
+		{
+			name:    "after is not a list",
+			ops:     `[{"id":"a"},{"id":"b","after":"a"}]`,
+			wantErr: "after must be a list of ids",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []interface{}
+			if err := json.Unmarshal([]byte(tt.ops), &ops); err != nil {
+				t.Fatal(err)
+			}
+
+			ordered, err := orderOperations(ops)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("orderOperations: %v", err)
+			}
+
+			var ids []string
+			for _, op := range ordered {
+				ids = append(ids, op.(map[string]interface{})["id"].(string))
+			}
+			if got := strings.Join(ids, ","); got != tt.want {
+				t.Errorf("order = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestOrderOperationsNamesUnlabelledOperations checks that operations
+// without an id are reported by index
+func TestOrderOperationsNamesUnlabelledOperations(t *testing.T) {
+	var ops []interface{}
+	if err := json.Unmarshal([]byte(`[{"id":"a","after":["a"]},{"type":"copy_file","after":["a"]}]`), &ops); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := orderOperations(ops)
+	if err == nil || !strings.HasSuffix(err.Error(), "operations: a, #1") {
+		t.Errorf("error = %v, want the cycle to name a and #1", err)
+	}
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 )
 
 // Schema definitions for our Bazel rules - AI agents can parse this
@@ -36,16 +40,77 @@ type Example struct {
 	Code        string `json:"code"`
 }
 
+// Exit codes. CI relies on these to tell a schema drift from a mistake in
+// how the tool was invoked, so they must stay stable.
+const (
+	exitOK       = 0 // Schemas written, or --verify found no drift
+	exitUsage    = 2 // Bad flags or unexpected arguments
+	exitMismatch = 3 // --verify file differs from the generated schemas
+	exitIO       = 4 // Reading, writing or encoding failed
+)
+
+// Prints the JSON schemas for all rules and providers, or writes them to
+// --output. With --verify, compares a committed schema file against freshly
+// generated output instead. Errors go to stderr and set the exit code.
 func main() {
-	schemas := generateComprehensiveSchemas()
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the tool and returns its exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("generate_schemas", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	outputPath := flags.String("output", "", "Write schemas to this file instead of stdout")
+	verifyPath := flags.String("verify", "", "Check that this file matches the generated schemas")
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "Error: unexpected arguments: %s\n", strings.Join(flags.Args(), " "))
+		return exitUsage
+	}
+	if *outputPath != "" && *verifyPath != "" {
+		fmt.Fprintln(stderr, "Error: --output and --verify cannot be combined")
+		return exitUsage
+	}
 
-	output, err := json.MarshalIndent(schemas, "", "  ")
+	output, err := json.MarshalIndent(generateComprehensiveSchemas(), "", "  ")
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error generating schemas: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(stderr, "Error generating schemas: %v\n", err)
+		return exitIO
+	}
+	output = append(output, '\n')
+
+	switch {
+	case *verifyPath != "":
+		existing, err := os.ReadFile(*verifyPath)
+		if err != nil {
+			fmt.Fprintf(stderr, "Error reading %s: %v\n", *verifyPath, err)
+			return exitIO
+		}
+		if !bytes.Equal(existing, output) {
+			fmt.Fprintf(stderr, "Schema drift: %s is out of date, regenerate it with generate_schemas --output\n", *verifyPath)
+			return exitMismatch
+		}
+
+	case *outputPath != "":
+		if err := os.WriteFile(*outputPath, output, 0644); err != nil {
+			fmt.Fprintf(stderr, "Error writing %s: %v\n", *outputPath, err)
+			return exitIO
+		}
+
+	default:
+		if _, err := stdout.Write(output); err != nil {
+			fmt.Fprintf(stderr, "Error writing schemas: %v\n", err)
+			return exitIO
+		}
 	}
 
-	fmt.Println(string(output))
+	return exitOK
 }
 
 func generateRuleSchemas() map[string]RuleSchema {
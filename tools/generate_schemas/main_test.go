@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// failingWriter rejects every write, as a closed stdout pipe would
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestRunExitCodes(t *testing.T) {
+	dir := t.TempDir()
+
+	var generated bytes.Buffer
+	if code := run(nil, &generated, new(bytes.Buffer)); code != exitOK {
+		t.Fatalf("generating schemas: exit %d", code)
+	}
+	current := filepath.Join(dir, "current.json")
+	stale := filepath.Join(dir, "stale.json")
+	if err := os.WriteFile(current, generated.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, []byte("{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStderr string
+	}{
+		{name: "print", args: nil, wantCode: exitOK},
+		{name: "help", args: []string{"--help"}, wantCode: exitOK},
+		{name: "write output", args: []string{"--output", filepath.Join(dir, "out.json")}, wantCode: exitOK},
+		{name: "verify current", args: []string{"--verify", current}, wantCode: exitOK},
+		{name: "unknown flag", args: []string{"--bogus"}, wantCode: exitUsage, wantStderr: "flag provided but not defined"},
+		{name: "stray argument", args: []string{"schemas.json"}, wantCode: exitUsage, wantStderr: "unexpected arguments: schemas.json"},
+		{name: "output with verify", args: []string{"--output", "a.json", "--verify", current}, wantCode: exitUsage, wantStderr: "cannot be combined"},
+		{name: "verify stale", args: []string{"--verify", stale}, wantCode: exitMismatch, wantStderr: "Schema drift"},
+		{name: "verify missing file", args: []string{"--verify", filepath.Join(dir, "missing.json")}, wantCode: exitIO, wantStderr: "Error reading"},
+		{name: "output to missing dir", args: []string{"--output", filepath.Join(dir, "no", "such", "dir.json")}, wantCode: exitIO, wantStderr: "Error writing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != tt.wantCode {
+				t.Errorf("run(%q) = %d, want %d (stderr: %s)", tt.args, code, tt.wantCode, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+		})
+	}
+
+	t.Run("stdout write failure", func(t *testing.T) {
+		var stderr bytes.Buffer
+		if code := run(nil, failingWriter{}, &stderr); code != exitIO {
+			t.Errorf("run() = %d, want %d (stderr: %s)", code, exitIO, stderr.String())
+		}
+	})
+}
@@ -0,0 +1,3 @@
+module github.com/pulseengine/rules_wasm_component/tools
+
+go 1.21
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmbinary"
+)
+
+// Check is one validation step and its outcome. Warnings are reported but
+// do not fail the component.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // passed, failed or warning
+	Detail string `json:"detail"`
+}
+
+// ValidationReport is the JSON emitted for the test rule
+type ValidationReport struct {
+	File    string   `json:"file"`
+	Size    int64    `json:"size"`
+	Imports []string `json:"imports"`
+	Exports []string `json:"exports"`
+	Checks  []Check  `json:"checks"`
+	Passed  bool     `json:"passed"`
+}
+
+// stringList collects a repeatable flag
+type stringList []string
+
+func (s *stringList) String() string     { return strings.Join(*s, ",") }
+func (s *stringList) Set(v string) error { *s = append(*s, v); return nil }
+
+// Section ids used by the validator
+const (
+	sectionCustom             = 0
+	componentSectionModule    = 1
+	componentSectionComponent = 4
+	componentSectionImport    = 10
+	componentSectionExport    = 11
+)
+
+// Validates a Go (TinyGo) WebAssembly component for go_wasm_component_test:
+// the file must be a component of plausible size that imports WASI Preview 2
+// and exports every --expect-export. Emits a JSON report of each check and
+// exits 1 when any check failed.
+func main() {
+	var expectExports stringList
+	flag.Var(&expectExports, "expect-export", "Export the component must provide, e.g. example:calc/math (repeatable; versions optional)")
+	var (
+		minSize = flag.Int64("min-size", 1000, "Smallest plausible component, in bytes")
+		maxSize = flag.Int64("max-size", 100000000, "Largest allowed component, in bytes")
+	)
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [--expect-export <name>]... [--min-size N] [--max-size N] <component.wasm>\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	wasmPath := flag.Arg(0)
+	data, err := os.ReadFile(wasmPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading WASM file: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := validate(wasmPath, data, expectExports, *minSize, *maxSize)
+
+	output, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(output))
+
+	if !report.Passed {
+		os.Exit(1)
+	}
+}
+
+func validate(path string, data []byte, expectExports []string, minSize, maxSize int64) *ValidationReport {
+	report := &ValidationReport{File: path, Size: int64(len(data)), Imports: []string{}, Exports: []string{}}
+	add := func(name, status, detail string) {
+		report.Checks = append(report.Checks, Check{Name: name, Status: status, Detail: detail})
+	}
+
+	switch {
+	case report.Size < minSize:
+		add("size", "failed", fmt.Sprintf("%d bytes is below %d, likely not a real build", report.Size, minSize))
+	case report.Size > maxSize:
+		add("size", "failed", fmt.Sprintf("%d bytes exceeds %d", report.Size, maxSize))
+	default:
+		add("size", "passed", fmt.Sprintf("%d bytes", report.Size))
+	}
+
+	if err := wasmbinary.CheckMagic(data); err != nil {
+		add("component_format", "failed", err.Error())
+		return finish(report)
+	}
+	if !wasmbinary.IsComponent(data) {
+		add("component_format", "failed", "core module, not a component; was it built with the wasip2 target?")
+		return finish(report)
+	}
+
+	var info componentInfo
+	if err := inspectComponent(data, &info, true); err != nil {
+		add("component_format", "failed", err.Error())
+		return finish(report)
+	}
+	add("component_format", "passed", fmt.Sprintf("component with %d core modules", info.modules))
+
+	report.Imports = info.imports
+	report.Exports = info.exports
+
+	var wasi []string
+	for _, name := range info.imports {
+		if strings.HasPrefix(name, "wasi:") {
+			wasi = append(wasi, name)
+		}
+	}
+	if len(wasi) > 0 {
+		add("wasi_preview2", "passed", fmt.Sprintf("imports %d WASI interfaces", len(wasi)))
+	} else {
+		add("wasi_preview2", "failed", "imports no wasi: interfaces")
+	}
+
+	for _, expected := range expectExports {
+		if name, ok := findExport(info.exports, expected); ok {
+			add("export "+expected, "passed", "exported as "+name)
+		} else {
+			add("export "+expected, "failed", "not exported")
+		}
+	}
+
+	if info.tinygo {
+		add("tinygo", "passed", "producers section names TinyGo")
+	} else {
+		add("tinygo", "warning", "no TinyGo producer recorded; the section may have been stripped")
+	}
+
+	return finish(report)
+}
+
+// finish sets the overall status from the individual checks
+func finish(report *ValidationReport) *ValidationReport {
+	report.Passed = true
+	for _, check := range report.Checks {
+		if check.Status == "failed" {
+			report.Passed = false
+		}
+	}
+	return report
+}
+
+// findExport matches an expected export against the exported names. An
+// expectation without a version matches any version of that name.
+func findExport(exports []string, expected string) (string, bool) {
+	for _, name := range exports {
+		if name == expected || (!strings.Contains(expected, "@") && strings.HasPrefix(name, expected+"@")) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// componentInfo is what the validator needs from a component binary
+type componentInfo struct {
+	imports []string // Top-level component imports
+	exports []string // Top-level component exports
+	modules int      // Core modules, including those in nested components
+	tinygo  bool     // A producers section mentions TinyGo
+}
+
+// inspectComponent walks a component's sections. Imports and exports are
+// only taken from the top level, since nested components' are internal.
+func inspectComponent(data []byte, info *componentInfo, topLevel bool) error {
+	err := wasmbinary.Walk(data, func(id byte, body []byte) error {
+		switch id {
+		case sectionCustom:
+			if isProducersSection(body) && bytes.Contains(bytes.ToLower(body), []byte("tinygo")) {
+				info.tinygo = true
+			}
+		case componentSectionModule:
+			info.modules++
+			if err := scanCoreCustomSections(body, info); err != nil {
+				return err
+			}
+		case componentSectionComponent:
+			if err := inspectComponent(body, info, false); err != nil {
+				return err
+			}
+		case componentSectionImport, componentSectionExport:
+			if !topLevel {
+				break
+			}
+			names, err := componentExternNames(body, id == componentSectionExport)
+			if err != nil {
+				return fmt.Errorf("section %d: %w", id, err)
+			}
+			if id == componentSectionImport {
+				info.imports = append(info.imports, names...)
+			} else {
+				info.exports = append(info.exports, names...)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(info.imports)
+	sort.Strings(info.exports)
+	return nil
+}
+
+// scanCoreCustomSections looks for a TinyGo producers section in a core module
+func scanCoreCustomSections(data []byte, info *componentInfo) error {
+	err := wasmbinary.Walk(data, func(id byte, body []byte) error {
+		if id == sectionCustom && isProducersSection(body) && bytes.Contains(bytes.ToLower(body), []byte("tinygo")) {
+			info.tinygo = true
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("core module: %w", err)
+	}
+	return nil
+}
+
+func isProducersSection(body []byte) bool {
+	name, _, ok := wasmbinary.CustomName(body)
+	return ok && name == "producers"
+}
+
+// componentExternNames reads the names from a component import or export
+// section. Imports are a name and an extern descriptor; exports are a name,
+// a sort and index, and an optional type ascription.
+func componentExternNames(body []byte, exports bool) ([]string, error) {
+	r := wasmbinary.NewReader(body)
+	count := r.ULEB()
+
+	var names []string
+	for i := uint64(0); i < count && r.Err() == nil; i++ {
+		r.Byte() // Name kind: 0x00 plain, 0x01 with version suffix (older encodings)
+		names = append(names, r.Name())
+
+		if !exports {
+			r.ExternDesc()
+			continue
+		}
+
+		if r.Byte() == 0x00 {
+			r.Byte() // Core sort
+		}
+		r.ULEB()
+		if r.Byte() == 0x01 {
+			r.ExternDesc()
+		}
+	}
+	return names, r.Err()
+}
@@ -11,7 +11,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -24,6 +26,24 @@ var client = &http.Client{
 	Timeout: 30 * time.Second,
 }
 
+// HTTPDoer sends HTTP requests. *http.Client satisfies it, and tests can
+// supply a fake that returns canned responses without touching the network.
+type HTTPDoer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+// doer is the transport every request goes through, the shared client by default
+var doer HTTPDoer = client
+
+// SetHTTPDoer replaces the transport used for requests. Passing nil restores
+// the default client.
+func SetHTTPDoer(d HTTPDoer) {
+	if d == nil {
+		d = client
+	}
+	doer = d
+}
+
 // main function is the entry point for the WebAssembly component
 func main() {
 	log.Println("🌐 HTTP Downloader WebAssembly Component initialized")
@@ -76,12 +96,24 @@ type HeaderPair struct {
 
 // DownloadGithubReleaseAsset downloads a specific asset from a GitHub release
 func DownloadGithubReleaseAsset(repo, version, assetName string) DownloadResult {
+	return DownloadGithubReleaseAssetWithContentType(repo, version, assetName, "")
+}
+
+// DownloadGithubReleaseAssetWithContentType downloads a release asset and, when
+// expectedContentType is non-empty, rejects responses whose Content-Type does
+// not match. This catches CDN error pages that are served with a 200 status.
+func DownloadGithubReleaseAssetWithContentType(repo, version, assetName, expectedContentType string) DownloadResult {
 	log.Printf("📥 Downloading GitHub asset: %s/%s - %s", repo, version, assetName)
 
 	// Construct download URL for GitHub release asset
 	url := fmt.Sprintf("%s/%s/releases/download/%s/%s", githubReleaseBase, repo, version, assetName)
 
-	return makeHTTPRequest("GET", url, "application/octet-stream")
+	result := makeHTTPRequest("GET", url, "application/octet-stream")
+	if expectedContentType == "" {
+		return result
+	}
+
+	return validateContentType(result, expectedContentType)
 }
 
 // DownloadGithubChecksums downloads checksums from a GitHub release
@@ -140,7 +172,7 @@ func makeHTTPRequest(method, url, acceptType string) DownloadResult {
 	req.Header.Set("User-Agent", "WebAssembly-Component-HTTP-Downloader/1.0")
 
 	// Make the request
-	resp, err := client.Do(req)
+	resp, err := doer.Do(req)
 	if err != nil {
 		return DownloadResult{
 			Error: fmt.Sprintf("HTTP request failed: %v", err),
@@ -189,6 +221,44 @@ func makeHTTPRequest(method, url, acceptType string) DownloadResult {
 	}
 }
 
+// validateContentType converts a successful result into an HTTP error when the
+// response Content-Type does not match the expected media type
+func validateContentType(result DownloadResult, expectedContentType string) DownloadResult {
+	if result.Success == nil {
+		return result
+	}
+
+	actual := ""
+	for _, header := range result.Success.Headers {
+		if strings.EqualFold(header.Name, "Content-Type") {
+			actual = header.Value
+			break
+		}
+	}
+
+	// Compare media types only, ignoring parameters such as charset
+	if mediaType(actual) != mediaType(expectedContentType) {
+		log.Printf("❌ Content-Type mismatch: expected %s, got %q", expectedContentType, actual)
+		return DownloadResult{
+			HTTPError: &HTTPErrorInfo{
+				Status:  result.Success.Status,
+				Message: fmt.Sprintf("Unexpected Content-Type: expected %s, got %q", expectedContentType, actual),
+			},
+		}
+	}
+
+	return result
+}
+
+// mediaType normalizes a Content-Type value to its lowercase media type
+func mediaType(contentType string) string {
+	parsed, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return parsed
+}
+
 // Wizer initialization function for pre-initialization
 //
 //export wizer.initialize
@@ -200,7 +270,7 @@ func wizerInitialize() {
 	req, _ := http.NewRequest("HEAD", "https://api.github.com", nil)
 	req.Header.Set("User-Agent", "WebAssembly-Component-HTTP-Downloader/1.0")
 
-	if resp, err := client.Do(req); err == nil {
+	if resp, err := doer.Do(req); err == nil {
 		resp.Body.Close()
 		log.Println("🌐 GitHub API connectivity verified during Wizer init")
 	}
@@ -213,6 +283,11 @@ func exportDownloadGithubReleaseAsset(repo, version, assetName string) DownloadR
 	return DownloadGithubReleaseAsset(repo, version, assetName)
 }
 
+//export download-github-release-asset-checked
+func exportDownloadGithubReleaseAssetChecked(repo, version, assetName, expectedContentType string) DownloadResult {
+	return DownloadGithubReleaseAssetWithContentType(repo, version, assetName, expectedContentType)
+}
+
 //export download-github-checksums
 func exportDownloadGithubChecksums(repo, version string) DownloadResult {
 	return DownloadGithubChecksums(repo, version)
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// doerFunc adapts a function to HTTPDoer
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+// withDoer routes requests through d for the rest of the test
+func withDoer(t *testing.T, d HTTPDoer) {
+	t.Helper()
+	SetHTTPDoer(d)
+	t.Cleanup(func() { SetHTTPDoer(nil) })
+}
+
+func cannedResponse(status int, contentType, body string) *http.Response {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestMakeHTTPRequest(t *testing.T) {
+	var got *http.Request
+	withDoer(t, doerFunc(func(req *http.Request) (*http.Response, error) {
+		got = req
+		return cannedResponse(http.StatusOK, "application/json", `{"tag_name":"v1.0.0"}`), nil
+	}))
+
+	result := GetLatestRelease("bytecodealliance/wasm-tools")
+	if result.Success == nil {
+		t.Fatalf("GetLatestRelease = %+v, want success", result)
+	}
+	if result.Success.Status != 200 || string(result.Success.Body) != `{"tag_name":"v1.0.0"}` {
+		t.Errorf("success = %d %q", result.Success.Status, result.Success.Body)
+	}
+	if len(result.Success.Headers) != 1 || result.Success.Headers[0] != (HeaderPair{Name: "Content-Type", Value: "application/json"}) {
+		t.Errorf("headers = %+v", result.Success.Headers)
+	}
+
+	if want := "https://api.github.com/repos/bytecodealliance/wasm-tools/releases/latest"; got.URL.String() != want {
+		t.Errorf("requested %s, want %s", got.URL, want)
+	}
+	if accept := got.Header.Get("Accept"); accept != "application/vnd.github.v3+json" {
+		t.Errorf("Accept = %q", accept)
+	}
+	if ua := got.Header.Get("User-Agent"); ua != "WebAssembly-Component-HTTP-Downloader/1.0" {
+		t.Errorf("User-Agent = %q", ua)
+	}
+}
+
+func TestMakeHTTPRequestErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		resp          *http.Response
+		err           error
+		wantStatus    uint16
+		wantMessage   string
+		wantTransport string
+	}{
+		{
+			name:        "not found",
+			resp:        cannedResponse(http.StatusNotFound, "text/plain", "Not Found"),
+			wantStatus:  404,
+			wantMessage: "HTTP 404: Not Found",
+		},
+		{
+			name:        "server error",
+			resp:        cannedResponse(http.StatusBadGateway, "", "upstream down"),
+			wantStatus:  502,
+			wantMessage: "HTTP 502: upstream down",
+		},
+		{
+			name:          "transport error",
+			err:           errors.New("dial tcp: connection refused"),
+			wantTransport: "HTTP request failed: dial tcp: connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDoer(t, doerFunc(func(*http.Request) (*http.Response, error) {
+				return tt.resp, tt.err
+			}))
+
+			result := DownloadGithubReleaseAsset("bytecodealliance/wasm-tools", "v1.0.0", "wasm-tools.tar.gz")
+			if result.Success != nil {
+				t.Fatalf("got success %+v, want a failure", result.Success)
+			}
+			if tt.wantTransport != "" {
+				if result.Error != tt.wantTransport || result.HTTPError != nil {
+					t.Errorf("result = %+v, want Error %q", result, tt.wantTransport)
+				}
+				return
+			}
+			if result.HTTPError == nil || result.Error != "" {
+				t.Fatalf("result = %+v, want an HTTPError", result)
+			}
+			if result.HTTPError.Status != tt.wantStatus || result.HTTPError.Message != tt.wantMessage {
+				t.Errorf("HTTPError = %+v, want %d %q", *result.HTTPError, tt.wantStatus, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestDownloadGithubReleaseAssetContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		expected    string
+		resp        *http.Response
+		wantSuccess bool
+		wantStatus  uint16
+		wantMessage string
+	}{
+		{
+			name:        "matching",
+			expected:    "application/octet-stream",
+			resp:        cannedResponse(http.StatusOK, "application/octet-stream", "\x00asm"),
+			wantSuccess: true,
+		},
+		{
+			name:        "parameters and case are ignored",
+			expected:    "application/gzip",
+			resp:        cannedResponse(http.StatusOK, "Application/GZip; charset=binary", "\x1f\x8b"),
+			wantSuccess: true,
+		},
+		{
+			name:        "unchecked when no type is expected",
+			resp:        cannedResponse(http.StatusOK, "text/html", "<html>"),
+			wantSuccess: true,
+		},
+		{
+			name:        "CDN error page served with 200",
+			expected:    "application/octet-stream",
+			resp:        cannedResponse(http.StatusOK, "text/html; charset=utf-8", "<html>rate limited</html>"),
+			wantStatus:  200,
+			wantMessage: `Unexpected Content-Type: expected application/octet-stream, got "text/html; charset=utf-8"`,
+		},
+		{
+			name:        "missing Content-Type",
+			expected:    "application/octet-stream",
+			resp:        cannedResponse(http.StatusOK, "", "data"),
+			wantStatus:  200,
+			wantMessage: `Unexpected Content-Type: expected application/octet-stream, got ""`,
+		},
+		{
+			name:        "HTTP errors pass through unchanged",
+			expected:    "application/octet-stream",
+			resp:        cannedResponse(http.StatusNotFound, "text/html", "Not Found"),
+			wantStatus:  404,
+			wantMessage: "HTTP 404: Not Found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withDoer(t, doerFunc(func(*http.Request) (*http.Response, error) { return tt.resp, nil }))
+
+			result := DownloadGithubReleaseAssetWithContentType("bytecodealliance/wasm-tools", "v1.0.0", "wasm-tools.tar.gz", tt.expected)
+			if tt.wantSuccess {
+				if result.Success == nil {
+					t.Fatalf("result = %+v, want success", result)
+				}
+				return
+			}
+			if result.HTTPError == nil {
+				t.Fatalf("result = %+v, want an HTTPError", result)
+			}
+			if result.HTTPError.Status != tt.wantStatus || result.HTTPError.Message != tt.wantMessage {
+				t.Errorf("HTTPError = %+v, want %d %q", *result.HTTPError, tt.wantStatus, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestDownloadGithubChecksumsFallback(t *testing.T) {
+	var tried []string
+	withDoer(t, doerFunc(func(req *http.Request) (*http.Response, error) {
+		name := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+		tried = append(tried, name)
+		if name == "checksums.txt" {
+			return cannedResponse(http.StatusOK, "text/plain", "abc  tool.tar.gz\n"), nil
+		}
+		return cannedResponse(http.StatusNotFound, "text/plain", "Not Found"), nil
+	}))
+
+	result := DownloadGithubChecksums("bytecodealliance/wasm-tools", "v1.0.0")
+	if result.Success == nil || string(result.Success.Body) != "abc  tool.tar.gz\n" {
+		t.Fatalf("result = %+v, want checksums.txt", result)
+	}
+	if want := "SHASUMS256.txt,SHA256SUMS.txt,checksums.txt"; strings.Join(tried, ",") != want {
+		t.Errorf("tried %v, want %s", tried, want)
+	}
+
+	withDoer(t, doerFunc(func(*http.Request) (*http.Response, error) {
+		return cannedResponse(http.StatusNotFound, "text/plain", "Not Found"), nil
+	}))
+	if result := DownloadGithubChecksums("bytecodealliance/wasm-tools", "v1.0.0"); result.Error == "" {
+		t.Errorf("result = %+v, want an error when no file exists", result)
+	}
+}
+
+func TestSetHTTPDoerNilRestoresClient(t *testing.T) {
+	SetHTTPDoer(doerFunc(func(*http.Request) (*http.Response, error) { return nil, errors.New("fake") }))
+	SetHTTPDoer(nil)
+	if doer != client {
+		t.Errorf("doer = %T after SetHTTPDoer(nil), want the shared client", doer)
+	}
+}
@@ -1,3 +1,5 @@
+//go:build ignore
+
 /*
 Simple Go WebAssembly Component
 
@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 )
 
 // loom_wrapper runs the loom.wasm optimizer component under wasmtime.
@@ -22,6 +23,10 @@ import (
 //
 //	loom_wrapper <wasmtime> <loom.wasm> <command> [args...]
 //
+// `--wasi-env KEY` (or `--wasi-env=KEY`), repeatable anywhere in args,
+// forwards host environment variable KEY into the guest. No host variables
+// are forwarded by default.
+//
 // e.g. loom_wrapper .../wasmtime .../loom.wasm optimize <input.wasm> -o <output.wasm> [flags]
 //
 // Both the wasmtime binary and the loom.wasm module path are passed by the
@@ -38,7 +43,7 @@ func main() {
 
 	wasmtimeBinary := os.Args[1]
 	loomWasm := os.Args[2]
-	loomArgs := os.Args[3:]
+	loomArgs, wasiEnv := extractWasiEnv(os.Args[3:])
 
 	if _, err := os.Stat(wasmtimeBinary); err != nil {
 		log.Fatalf("Wasmtime binary not found at %s: %v", wasmtimeBinary, err)
@@ -53,6 +58,7 @@ func main() {
 	for _, dir := range uniqueStrings(dirs) {
 		wasmtimeArgs = append(wasmtimeArgs, "--dir", dir)
 	}
+	wasmtimeArgs = append(wasmtimeArgs, wasiEnvArgs(wasiEnv)...)
 	wasmtimeArgs = append(wasmtimeArgs, loomWasm)
 	wasmtimeArgs = append(wasmtimeArgs, resolvedArgs...)
 
@@ -69,6 +75,40 @@ func main() {
 	}
 }
 
+// extractWasiEnv removes --wasi-env flags from args, returning the remaining
+// loom arguments and the allowlisted environment variable names.
+func extractWasiEnv(args []string) ([]string, []string) {
+	remaining := make([]string, 0, len(args))
+	var keys []string
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--wasi-env" && i+1 < len(args):
+			i++
+			keys = append(keys, args[i])
+		case strings.HasPrefix(args[i], "--wasi-env="):
+			keys = append(keys, strings.TrimPrefix(args[i], "--wasi-env="))
+		default:
+			remaining = append(remaining, args[i])
+		}
+	}
+
+	return remaining, keys
+}
+
+// wasiEnvArgs builds wasmtime --env arguments for the allowlisted host
+// environment variables. Variables unset on the host are skipped so the guest
+// never sees an empty placeholder.
+func wasiEnvArgs(keys []string) []string {
+	args := make([]string, 0, 2*len(keys))
+	for _, key := range uniqueStrings(keys) {
+		if value, ok := os.LookupEnv(key); ok {
+			args = append(args, "--env", key+"="+value)
+		}
+	}
+	return args
+}
+
 // resolvePathsInArgs resolves file-path arguments in a loom command to their
 // real on-disk paths and returns the resolved argument list together with the
 // directories that must be preopened (`--dir`) for wasmtime.
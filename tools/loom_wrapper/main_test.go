@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWasiEnvForwardsOnlyAllowlisted(t *testing.T) {
+	t.Setenv("LOOM_LEVEL", "3")
+	t.Setenv("LOOM_EMPTY", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "do-not-forward")
+	t.Setenv("GITHUB_TOKEN", "do-not-forward")
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantArgs  []string
+		wantEnv   []string
+		wantFlags []string
+	}{
+		{
+			name:     "nothing forwarded by default",
+			args:     []string{"optimize", "in.wasm", "-o", "out.wasm"},
+			wantArgs: []string{"optimize", "in.wasm", "-o", "out.wasm"},
+		},
+		{
+			name:      "separate and joined flags anywhere in args",
+			args:      []string{"--wasi-env", "LOOM_LEVEL", "optimize", "in.wasm", "--wasi-env=LOOM_EMPTY", "-o", "out.wasm"},
+			wantArgs:  []string{"optimize", "in.wasm", "-o", "out.wasm"},
+			wantEnv:   []string{"LOOM_LEVEL", "LOOM_EMPTY"},
+			wantFlags: []string{"--env", "LOOM_LEVEL=3", "--env", "LOOM_EMPTY="},
+		},
+		{
+			name:      "repeated and unset keys",
+			args:      []string{"--wasi-env=LOOM_LEVEL", "--wasi-env=LOOM_LEVEL", "--wasi-env=LOOM_UNSET", "optimize"},
+			wantArgs:  []string{"optimize"},
+			wantEnv:   []string{"LOOM_LEVEL", "LOOM_LEVEL", "LOOM_UNSET"},
+			wantFlags: []string{"--env", "LOOM_LEVEL=3"},
+		},
+		{
+			name:     "trailing flag without a key is passed through",
+			args:     []string{"optimize", "--wasi-env"},
+			wantArgs: []string{"optimize", "--wasi-env"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, env := extractWasiEnv(tt.args)
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("loom args = %q, want %q", args, tt.wantArgs)
+			}
+			if !reflect.DeepEqual(env, tt.wantEnv) {
+				t.Errorf("allowlist = %q, want %q", env, tt.wantEnv)
+			}
+
+			flags := wasiEnvArgs(env)
+			if len(flags) == 0 && len(tt.wantFlags) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(flags, tt.wantFlags) {
+				t.Errorf("wasmtime env flags = %q, want %q", flags, tt.wantFlags)
+			}
+		})
+	}
+}
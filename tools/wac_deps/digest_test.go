@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestComponentDigests checks the digests recorded while copying and while
+// hashing symlinked inputs against independently computed ones
+func TestComponentDigests(t *testing.T) {
+	sizes := map[string]int{
+		"empty":               0,
+		"header only":         8,
+		"spans copy buffers":  3*32*1024 + 7,
+		"exactly one buffer":  32 * 1024,
+		"larger than a block": 1 << 20,
+	}
+
+	for name, size := range sizes {
+		t.Run(name, func(t *testing.T) {
+			data := bytes.Repeat([]byte("\x00asm\x0d\x00\x01\x00component"), size/17+1)[:size]
+			sum := sha256.Sum256(data)
+			want := ComponentDigest{SHA256: hex.EncodeToString(sum[:]), Size: int64(size)}
+
+			dir := t.TempDir()
+			src := filepath.Join(dir, "input.wasm")
+			if err := os.WriteFile(src, data, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			copied, err := copyFile(src, filepath.Join(dir, "copy.wasm"))
+			if err != nil {
+				t.Fatalf("copyFile: %v", err)
+			}
+			if copied != want {
+				t.Errorf("copyFile digest = %+v, want %+v", copied, want)
+			}
+			if got, _ := os.ReadFile(filepath.Join(dir, "copy.wasm")); !bytes.Equal(got, data) {
+				t.Error("copy differs from the source")
+			}
+
+			hashed, err := digestFile(src)
+			if err != nil {
+				t.Fatalf("digestFile: %v", err)
+			}
+			if hashed != want {
+				t.Errorf("digestFile digest = %+v, want %+v", hashed, want)
+			}
+		})
+	}
+}
+
+func TestDigestFileFollowsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.wasm")
+	data := []byte("\x00asm\x0d\x00\x01\x00linked")
+	if err := os.WriteFile(target, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.wasm")
+	if err := os.Symlink("target.wasm", link); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := sha256.Sum256(data)
+	got, err := digestFile(link)
+	if err != nil {
+		t.Fatalf("digestFile: %v", err)
+	}
+	if got.SHA256 != hex.EncodeToString(sum[:]) || got.Size != int64(len(data)) {
+		t.Errorf("digestFile through a symlink = %+v, want the target's digest", got)
+	}
+}
+
+func TestExpectedDigests(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	expected := make(expectedDigests)
+
+	for _, value := range []string{"auth=" + digest, "storage=sha256:" + strings.ToUpper(digest)} {
+		if err := expected.Set(value); err != nil {
+			t.Errorf("Set(%q): %v", value, err)
+		}
+	}
+	for _, value := range []string{"auth", "=" + digest, "auth=abc", "auth=" + strings.Repeat("zz", 32)} {
+		if err := expected.Set(value); err == nil {
+			t.Errorf("Set(%q) accepted an invalid value", value)
+		}
+	}
+
+	if err := expected.verify("storage", digest); err != nil {
+		t.Errorf("verify with a sha256: prefixed, upper-case expectation: %v", err)
+	}
+	if err := expected.verify("logger", strings.Repeat("0", 64)); err != nil {
+		t.Errorf("verify without an expectation: %v", err)
+	}
+	if err := expected.verify("auth", strings.Repeat("0", 64)); err == nil || !strings.Contains(err.Error(), "checksum mismatch for component auth") {
+		t.Errorf("verify of a mismatch = %v, want a checksum mismatch", err)
+	}
+}
@@ -1,20 +1,70 @@
 package main
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmheader"
 )
 
+// digestsFile is the sidecar recording what was staged for each component
+const digestsFile = "component_digests.json"
+
+// ComponentDigest identifies the exact bytes staged for a component
+type ComponentDigest struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// expectedDigests collects repeatable --expected-sha=name=sha256 flags
+type expectedDigests map[string]string
+
+func (e expectedDigests) String() string {
+	return fmt.Sprint(map[string]string(e))
+}
+
+func (e expectedDigests) Set(value string) error {
+	name, digest, ok := strings.Cut(value, "=")
+	digest = strings.ToLower(strings.TrimPrefix(digest, "sha256:"))
+	if !ok || name == "" || len(digest) != 64 {
+		return fmt.Errorf("expected name=<64 hex digit sha256>, got %q", value)
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return fmt.Errorf("invalid sha256 for %s: %v", name, err)
+	}
+	e[name] = digest
+	return nil
+}
+
+// verify fails when the caller asserted a digest for name that differs from
+// the bytes actually read
+func (e expectedDigests) verify(name, actual string) error {
+	expected, ok := e[name]
+	if !ok || expected == actual {
+		return nil
+	}
+	return fmt.Errorf("checksum mismatch for component %s: expected %s, got %s", name, expected, actual)
+}
+
 func main() {
+	expected := make(expectedDigests)
+	flag.Var(expected, "expected-sha", "Assert a component's input digest before staging, as name=sha256 (repeatable)")
+	depends := make(dependencies)
+	flag.Var(depends, "depends", "Stage component a after component b, as a=b (repeatable)")
+
 	var (
 		outputDir   = flag.String("output-dir", "", "Output directory for WAC deps")
 		manifest    = flag.String("manifest", "", "Component manifest content")
 		profileInfo = flag.String("profile-info", "", "Profile info content")
 		useSymlinks = flag.Bool("use-symlinks", true, "Use symlinks instead of copying")
+		embedMeta   = flag.Bool("embed-metadata", false, "Stamp each component with a build-info custom section")
 	)
 	flag.Parse()
 
@@ -23,7 +73,8 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Parse component arguments
+	// Parse component arguments. Dependencies may also follow the
+	// components, where flag parsing has already stopped.
 	components := make(map[string]string)
 	for _, arg := range flag.Args() {
 		if strings.HasPrefix(arg, "--component=") {
@@ -32,6 +83,38 @@ func main() {
 				components[parts[0]] = parts[1]
 			}
 		}
+		if strings.HasPrefix(arg, "--depends=") {
+			if err := depends.Set(strings.TrimPrefix(arg, "--depends=")); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: invalid --depends: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	for name := range expected {
+		if _, ok := components[name]; !ok {
+			fmt.Fprintf(os.Stderr, "Error: --expected-sha given for unknown component %s\n", name)
+			os.Exit(1)
+		}
+	}
+
+	order, err := resolveOrder(components, depends)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving component order: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Reject inputs that are not WASM before anything is staged; wac can
+	// only compose components, so core modules are called out too
+	for _, name := range order {
+		kind, err := classifyWasmFile(components[name])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: component %s (%s): %v\n", name, components[name], err)
+			os.Exit(1)
+		}
+		if kind != wasmheader.Component {
+			fmt.Fprintf(os.Stderr, "Warning: %s is a core %s, not a component; wac will not compose it\n", name, kind)
+		}
 	}
 
 	// Create output directory
@@ -40,11 +123,45 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Create component files
-	for name, path := range components {
+	profiles := profilesFromManifest(*manifest)
+	digests := make(map[string]ComponentDigest)
+
+	// Create component files, dependencies first
+	for _, name := range order {
+		path := components[name]
 		destPath := filepath.Join(*outputDir, name+".wasm")
 
+		if *embedMeta {
+			stamped, err := embedBuildInfo(name, path, profiles[name], expected)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error embedding metadata for %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			// Stamped bytes differ from the input, so they are always written out
+			if stamped != nil {
+				if err := os.WriteFile(destPath, stamped, 0644); err != nil {
+					fmt.Fprintf(os.Stderr, "Error writing stamped component for %s: %v\n", name, err)
+					os.Exit(1)
+				}
+				sum := sha256.Sum256(stamped)
+				digests[name] = ComponentDigest{SHA256: hex.EncodeToString(sum[:]), Size: int64(len(stamped))}
+				continue
+			}
+		}
+
 		if *useSymlinks {
+			// Hash the input once, and only link it in if it is what the
+			// caller expected
+			digest, err := digestFile(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error hashing %s: %v\n", name, err)
+				os.Exit(1)
+			}
+			if err := expected.verify(name, digest.SHA256); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
 			// Create relative symlink
 			relPath, err := filepath.Rel(filepath.Dir(destPath), path)
 			if err != nil {
@@ -55,19 +172,42 @@ func main() {
 				fmt.Fprintf(os.Stderr, "Error creating symlink for %s: %v\n", name, err)
 				os.Exit(1)
 			}
+			digests[name] = digest
 		} else {
-			// Copy file
-			if err := copyFile(path, destPath); err != nil {
+			// Copy file, hashing it on the way through. A copy that turns
+			// out not to match is removed so it cannot feed the composition.
+			digest, err := copyFile(path, destPath)
+			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error copying file for %s: %v\n", name, err)
 				os.Exit(1)
 			}
+			if err := expected.verify(name, digest.SHA256); err != nil {
+				os.Remove(destPath)
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			digests[name] = digest
 		}
 	}
 
+	// Record component digests for provenance and cache checks
+	digestData, _ := json.MarshalIndent(digests, "", "  ")
+	if err := os.WriteFile(filepath.Join(*outputDir, digestsFile), append(digestData, '\n'), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing component digests: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Record the resolved order for wac and for inspection
+	orderData, _ := json.MarshalIndent(ComponentOrder{Order: order, Depends: depends}, "", "  ")
+	if err := os.WriteFile(filepath.Join(*outputDir, orderFile), append(orderData, '\n'), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing component order: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Create manifest file
 	if *manifest != "" {
 		manifestPath := filepath.Join(*outputDir, "components.toml")
-		if err := os.WriteFile(manifestPath, []byte(*manifest), 0644); err != nil {
+		if err := os.WriteFile(manifestPath, []byte(orderedManifest(*manifest, order)), 0644); err != nil {
 			fmt.Fprintf(os.Stderr, "Error writing manifest: %v\n", err)
 			os.Exit(1)
 		}
@@ -83,23 +223,81 @@ func main() {
 	}
 }
 
-func copyFile(src, dst string) error {
+// embedBuildInfo returns the component at path stamped with provenance, or
+// nil when it already carries a build-info section and can be staged as-is.
+// The input is checked against any expected digest before it is stamped.
+func embedBuildInfo(name, path, profile string, expected expectedDigests) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	if err := expected.verify(name, hex.EncodeToString(sum[:])); err != nil {
+		return nil, err
+	}
+
+	return stampBuildInfo(data, BuildInfo{
+		Component: name,
+		Source:    path,
+		Profile:   profile,
+		Timestamp: buildTimestamp(),
+	})
+}
+
+// classifyWasmFile reads just the header of the file at path
+func classifyWasmFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	kind, _, err := wasmheader.Classify(file)
+	return kind, err
+}
+
+// copyFile copies src to dst and returns the digest of the bytes written,
+// computed in the same pass
+func copyFile(src, dst string) (ComponentDigest, error) {
 	sourceFile, err := os.Open(src)
 	if err != nil {
-		return err
+		return ComponentDigest{}, err
 	}
 	defer sourceFile.Close()
 
 	destFile, err := os.Create(dst)
 	if err != nil {
-		return err
+		return ComponentDigest{}, err
 	}
 	defer destFile.Close()
 
-	_, err = io.Copy(destFile, sourceFile)
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(destFile, hasher), sourceFile)
+	if err != nil {
+		return ComponentDigest{}, err
+	}
+
+	if err := destFile.Sync(); err != nil {
+		return ComponentDigest{}, err
+	}
+
+	return ComponentDigest{SHA256: hex.EncodeToString(hasher.Sum(nil)), Size: size}, nil
+}
+
+// digestFile hashes a component that is staged by symlink
+func digestFile(path string) (ComponentDigest, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ComponentDigest{}, err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	size, err := io.Copy(hasher, file)
 	if err != nil {
-		return err
+		return ComponentDigest{}, err
 	}
 
-	return destFile.Sync()
+	return ComponentDigest{SHA256: hex.EncodeToString(hasher.Sum(nil)), Size: size}, nil
 }
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmbinary"
+	"github.com/pulseengine/rules_wasm_component/tools/wasmheader"
+)
+
+// buildInfoSection is the custom section name used for provenance metadata
+const buildInfoSection = "build-info"
+
+// BuildInfo is the provenance stamped into each bundled component
+type BuildInfo struct {
+	Component string `json:"component"`
+	Source    string `json:"source"`
+	Profile   string `json:"profile,omitempty"`
+	Timestamp string `json:"timestamp"`
+}
+
+// buildTimestamp honors SOURCE_DATE_EPOCH so stamped outputs stay reproducible
+func buildTimestamp() string {
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		if seconds, err := strconv.ParseInt(epoch, 10, 64); err == nil {
+			return time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+		}
+	}
+	return time.Now().UTC().Format(time.RFC3339)
+}
+
+// profilesFromManifest reads `profile = "..."` for each [components.<name>]
+// table of the manifest generated by wac_compose
+func profilesFromManifest(manifest string) map[string]string {
+	profiles := make(map[string]string)
+	current := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[components.") && strings.HasSuffix(line, "]") {
+			current = strings.TrimSuffix(strings.TrimPrefix(line, "[components."), "]")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && current != "" && strings.TrimSpace(key) == "profile" {
+			profiles[current] = strings.Trim(strings.TrimSpace(value), `"`)
+		}
+	}
+
+	return profiles
+}
+
+// hasCustomSection reports whether a module or component has a top-level
+// custom section with the given name
+func hasCustomSection(data []byte, name string) (bool, error) {
+	if _, _, err := wasmheader.Classify(bytes.NewReader(data)); err != nil {
+		return false, err
+	}
+
+	sections, err := wasmbinary.Sections(data)
+	if err != nil {
+		return false, err
+	}
+	for _, s := range sections {
+		if s.ID != wasmbinary.SectionCustom {
+			continue
+		}
+		if sectionName, _, ok := wasmbinary.CustomName(s.Body); ok && sectionName == name {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// stampBuildInfo returns the component bytes with a build-info section added,
+// or nil when the component already carries one
+func stampBuildInfo(data []byte, info BuildInfo) ([]byte, error) {
+	present, err := hasCustomSection(data, buildInfoSection)
+	if err != nil {
+		return nil, err
+	}
+	if present {
+		return nil, nil
+	}
+
+	payload, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(data)+len(buildInfoSection)+len(payload)+12)
+	out = append(out, data...)
+	return wasmbinary.AppendCustomSection(out, buildInfoSection, payload), nil
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmbinary"
+)
+
+// emptyComponent is the smallest valid component: the preamble and no sections
+var emptyComponent = []byte("\x00asm\x0d\x00\x01\x00")
+
+// customSectionPayload returns the payload of the first top-level custom
+// section called name, failing the test when there is none
+func customSectionPayload(t *testing.T, data []byte, name string) []byte {
+	t.Helper()
+	sections, err := wasmbinary.Sections(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range sections {
+		if sectionName, payload, ok := wasmbinary.CustomName(s.Body); s.ID == wasmbinary.SectionCustom && ok && sectionName == name {
+			return payload
+		}
+	}
+	t.Fatalf("no %s custom section", name)
+	return nil
+}
+
+func TestStampBuildInfo(t *testing.T) {
+	// A component that already has an unrelated custom section
+	original := wasmbinary.AppendCustomSection(append([]byte{}, emptyComponent...), "producers", []byte("existing"))
+	info := BuildInfo{Component: "auth", Source: "bazel-out/auth.wasm", Profile: "release", Timestamp: "2024-01-01T00:00:00Z"}
+
+	stamped, err := stampBuildInfo(original, info)
+	if err != nil {
+		t.Fatalf("stampBuildInfo: %v", err)
+	}
+	if !bytes.HasPrefix(stamped, original) {
+		t.Error("stamping changed the existing bytes instead of appending")
+	}
+	if got := customSectionPayload(t, stamped, "producers"); string(got) != "existing" {
+		t.Errorf("existing section payload = %q, want %q", got, "existing")
+	}
+
+	var got BuildInfo
+	if err := json.Unmarshal(customSectionPayload(t, stamped, buildInfoSection), &got); err != nil {
+		t.Fatalf("build-info payload: %v", err)
+	}
+	if got != info {
+		t.Errorf("build-info = %+v, want %+v", got, info)
+	}
+
+	// Stamping again is a no-op, so re-bundling does not stack sections
+	again, err := stampBuildInfo(stamped, info)
+	if err != nil || again != nil {
+		t.Errorf("second stampBuildInfo = %d bytes, %v; want nil, nil", len(again), err)
+	}
+}
+
+func TestStampBuildInfoRejectsNonWasm(t *testing.T) {
+	if _, err := stampBuildInfo([]byte("package example:demo;\n"), BuildInfo{}); err == nil {
+		t.Error("stampBuildInfo accepted a text file")
+	}
+	truncated := append(append([]byte(nil), emptyComponent...), 0, 0x10, 'x')
+	if _, err := stampBuildInfo(truncated, BuildInfo{}); err == nil || !strings.Contains(err.Error(), "overruns") {
+		t.Errorf("stampBuildInfo on a truncated section = %v, want an overrun error", err)
+	}
+}
+
+func TestProfilesFromManifest(t *testing.T) {
+	manifest := `[package]
+name = "bundle"
+
+[components.auth]
+path = "auth.wasm"
+profile = "release"
+
+[components.storage]
+path = "storage.wasm"
+
+[components.logger]
+profile = "debug"
+`
+	got := profilesFromManifest(manifest)
+	want := map[string]string{"auth": "release", "logger": "debug"}
+	if len(got) != len(want) || got["auth"] != want["auth"] || got["logger"] != want["logger"] {
+		t.Errorf("profilesFromManifest = %v, want %v", got, want)
+	}
+}
+
+func TestBuildTimestampHonorsSourceDateEpoch(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1700000000")
+	if got := buildTimestamp(); got != "2023-11-14T22:13:20Z" {
+		t.Errorf("buildTimestamp = %s, want 2023-11-14T22:13:20Z", got)
+	}
+}
+
+func TestEmbedBuildInfoChecksInputDigest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.wasm")
+	if err := os.WriteFile(path, emptyComponent, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(emptyComponent)
+	t.Setenv("SOURCE_DATE_EPOCH", "0")
+
+	stamped, err := embedBuildInfo("auth", path, "release", expectedDigests{"auth": hex.EncodeToString(sum[:])})
+	if err != nil {
+		t.Fatalf("embedBuildInfo with the right digest: %v", err)
+	}
+	var info BuildInfo
+	json.Unmarshal(customSectionPayload(t, stamped, buildInfoSection), &info)
+	if info.Component != "auth" || info.Source != path || info.Profile != "release" || info.Timestamp != "1970-01-01T00:00:00Z" {
+		t.Errorf("build-info = %+v", info)
+	}
+
+	wrong := strings.Repeat("0", 64)
+	if _, err := embedBuildInfo("auth", path, "release", expectedDigests{"auth": wrong}); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("embedBuildInfo with the wrong digest = %v, want a checksum mismatch", err)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// orderFile is the sidecar recording the resolved staging order
+const orderFile = "component_order.json"
+
+// ComponentOrder is the resolved order and the dependencies it satisfies
+type ComponentOrder struct {
+	Order   []string            `json:"order"`
+	Depends map[string][]string `json:"depends,omitempty"`
+}
+
+// dependencies collects repeatable --depends=a=b flags, meaning component a
+// consumes an export of component b, so b must come first
+type dependencies map[string][]string
+
+func (d dependencies) String() string {
+	return fmt.Sprint(map[string][]string(d))
+}
+
+func (d dependencies) Set(value string) error {
+	name, dependency, ok := strings.Cut(value, "=")
+	if !ok || name == "" || dependency == "" {
+		return fmt.Errorf("expected consumer=provider, got %q", value)
+	}
+	if name == dependency {
+		return fmt.Errorf("component %s cannot depend on itself", name)
+	}
+	for _, existing := range d[name] {
+		if existing == dependency {
+			return nil
+		}
+	}
+	d[name] = append(d[name], dependency)
+	return nil
+}
+
+// resolveOrder sorts components so that every component follows the ones it
+// depends on. Components with no ordering between them are sorted by name,
+// so the result is deterministic regardless of argument order.
+func resolveOrder(components map[string]string, depends dependencies) ([]string, error) {
+	for name, deps := range depends {
+		if _, ok := components[name]; !ok {
+			return nil, fmt.Errorf("--depends given for unknown component %s", name)
+		}
+		for _, dep := range deps {
+			if _, ok := components[dep]; !ok {
+				return nil, fmt.Errorf("component %s depends on unknown component %s", name, dep)
+			}
+		}
+	}
+
+	// Kahn's algorithm, always taking the smallest ready name
+	remaining := make(map[string]int, len(components))
+	dependents := make(map[string][]string)
+	for name := range components {
+		remaining[name] = len(depends[name])
+		for _, dep := range depends[name] {
+			dependents[dep] = append(dependents[dep], name)
+		}
+	}
+
+	var ready []string
+	for name, count := range remaining {
+		if count == 0 {
+			ready = append(ready, name)
+		}
+	}
+	sort.Strings(ready)
+
+	order := make([]string, 0, len(components))
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		order = append(order, name)
+
+		for _, dependent := range dependents[name] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+		sort.Strings(ready)
+	}
+
+	if len(order) < len(components) {
+		return nil, fmt.Errorf("dependency cycle: %s", strings.Join(findCycle(remaining, depends), " -> "))
+	}
+	return order, nil
+}
+
+// findCycle returns one cycle among the components left unordered, written
+// as a path that starts and ends with the same component
+func findCycle(remaining map[string]int, depends dependencies) []string {
+	var unresolved []string
+	for name, count := range remaining {
+		if count > 0 {
+			unresolved = append(unresolved, name)
+		}
+	}
+	sort.Strings(unresolved)
+
+	// Every unresolved component waits on another unresolved one, so
+	// following those edges from any of them must revisit a component
+	position := make(map[string]int)
+	var path []string
+	for name := unresolved[0]; ; {
+		if start, seen := position[name]; seen {
+			return append(path[start:], name)
+		}
+		position[name] = len(path)
+		path = append(path, name)
+
+		deps := append([]string(nil), depends[name]...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if remaining[dep] > 0 {
+				name = dep
+				break
+			}
+		}
+	}
+}
+
+// orderedManifest prefixes the manifest with the resolved order. The key has
+// to precede the first [components.<name>] table to stay top-level.
+func orderedManifest(manifest string, order []string) string {
+	quoted := make([]string, len(order))
+	for i, name := range order {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return fmt.Sprintf("# Resolved staging order (dependencies first)\norder = [%s]\n\n%s", strings.Join(quoted, ", "), manifest)
+}
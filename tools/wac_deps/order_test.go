@@ -0,0 +1,133 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveOrder(t *testing.T) {
+	components := map[string]string{
+		"frontend": "frontend.wasm",
+		"auth":     "auth.wasm",
+		"storage":  "storage.wasm",
+		"logger":   "logger.wasm",
+	}
+
+	tests := []struct {
+		name    string
+		depends []string // --depends values
+		want    []string
+	}{
+		{
+			name: "no dependencies sorts by name",
+			want: []string{"auth", "frontend", "logger", "storage"},
+		},
+		{
+			name:    "chain",
+			depends: []string{"frontend=auth", "auth=storage", "storage=logger"},
+			want:    []string{"logger", "storage", "auth", "frontend"},
+		},
+		{
+			name:    "diamond",
+			depends: []string{"frontend=auth", "frontend=storage", "auth=logger", "storage=logger"},
+			want:    []string{"logger", "auth", "storage", "frontend"},
+		},
+		{
+			name:    "unconstrained components keep name order",
+			depends: []string{"auth=storage"},
+			want:    []string{"frontend", "logger", "storage", "auth"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			depends := make(dependencies)
+			for _, value := range tt.depends {
+				if err := depends.Set(value); err != nil {
+					t.Fatalf("Set(%q): %v", value, err)
+				}
+			}
+
+			got, err := resolveOrder(components, depends)
+			if err != nil {
+				t.Fatalf("resolveOrder: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveOrderErrors(t *testing.T) {
+	components := map[string]string{"a": "a.wasm", "b": "b.wasm", "c": "c.wasm", "d": "d.wasm"}
+
+	tests := []struct {
+		name    string
+		depends dependencies
+		wantErr string
+	}{
+		{
+			name:    "cycle",
+			depends: dependencies{"a": {"b"}, "b": {"c"}, "c": {"a"}},
+			wantErr: "dependency cycle: a -> b -> c -> a",
+		},
+		{
+			name:    "cycle behind a resolvable prefix",
+			depends: dependencies{"b": {"a"}, "c": {"b", "d"}, "d": {"c"}},
+			wantErr: "dependency cycle: c -> d -> c",
+		},
+		{
+			name:    "unknown consumer",
+			depends: dependencies{"e": {"a"}},
+			wantErr: "--depends given for unknown component e",
+		},
+		{
+			name:    "unknown provider",
+			depends: dependencies{"a": {"e"}},
+			wantErr: "component a depends on unknown component e",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := resolveOrder(components, tt.depends)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("resolveOrder = %v, %v; want error %q", order, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDependenciesSet(t *testing.T) {
+	depends := make(dependencies)
+	for _, value := range []string{"a=b", "a=c", "a=b"} {
+		if err := depends.Set(value); err != nil {
+			t.Fatalf("Set(%q): %v", value, err)
+		}
+	}
+	if want := (dependencies{"a": {"b", "c"}}); !reflect.DeepEqual(depends, want) {
+		t.Errorf("dependencies = %v, want %v", depends, want)
+	}
+
+	for _, value := range []string{"a", "=b", "a=", "a=a"} {
+		if err := depends.Set(value); err == nil {
+			t.Errorf("Set(%q) succeeded, want an error", value)
+		}
+	}
+}
+
+func TestOrderedManifest(t *testing.T) {
+	manifest := "[components.auth]\npath = \"deps/auth.wasm\"\n"
+	got := orderedManifest(manifest, []string{"storage", "auth"})
+
+	if !strings.HasPrefix(got, "# Resolved staging order") {
+		t.Errorf("manifest does not start with the order comment:\n%s", got)
+	}
+	order := strings.Index(got, `order = ["storage", "auth"]`)
+	table := strings.Index(got, "[components.auth]")
+	if order < 0 || table < order {
+		t.Errorf("order key missing or not before the first table:\n%s", got)
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ComponentInterfaces is the import/export listing of a compiled component,
+// shaped like the imports/exports fields of the WasmComponentInfo provider
+type ComponentInterfaces struct {
+	Imports []string `json:"imports"`
+	Exports []string `json:"exports"`
+}
+
+// Connection wires one component's export into another component's import
+type Connection struct {
+	From   string `json:"from"`   // Component providing the export
+	Export string `json:"export"` // Interface exported by From
+	To     string `json:"to"`     // Component consuming the import
+	Import string `json:"import"` // Interface imported by To
+}
+
+// CompositionPlan mirrors a wac_compose target: the components mapping plus
+// the connections the composition wires up
+type CompositionPlan struct {
+	// Components maps each component name to its interface listing, relative
+	// to the plan file
+	Components  map[string]string `json:"components"`
+	Connections []Connection      `json:"connections"`
+	// Exports lists "component/interface" pairs re-exported by the composition
+	Exports []string `json:"exports"`
+	// Passthrough lists interface prefixes left as imports of the composed
+	// component (wac --import-dependencies). Defaults to ["wasi:"].
+	Passthrough []string `json:"passthrough"`
+}
+
+// Issue is a single problem found in the plan
+type Issue struct {
+	Component string `json:"component"`
+	Interface string `json:"interface"`
+	Message   string `json:"message"`
+}
+
+// PlanReport is the JSON result of checking a plan
+type PlanReport struct {
+	Valid              bool    `json:"valid"`
+	InvalidConnections []Issue `json:"invalid_connections"`
+	UnsatisfiedImports []Issue `json:"unsatisfied_imports"`
+	DanglingExports    []Issue `json:"dangling_exports"`
+}
+
+// Validates a wac composition plan before running wac: every wired import must
+// meet a compatible export and every other import must be a pass-through.
+// Dangling exports are reported but do not fail the check.
+func main() {
+	var (
+		planPath   = flag.String("plan", "", "Composition plan JSON")
+		components componentFlags
+	)
+	flag.Var(&components, "component", "Override a component listing as name=path (repeatable)")
+	flag.Parse()
+
+	if *planPath == "" {
+		fmt.Fprintf(os.Stderr, "Usage: %s --plan <plan.json> [--component name=listing.json ...]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	plan, err := readPlan(*planPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading plan: %v\n", err)
+		os.Exit(1)
+	}
+	for name, path := range components {
+		plan.Components[name] = path
+	}
+
+	listings := make(map[string]*ComponentInterfaces)
+	for name, path := range plan.Components {
+		listing, err := readComponentInterfaces(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading interfaces for %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		listings[name] = listing
+	}
+
+	report := checkPlan(plan, listings)
+
+	output, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(output))
+
+	if !report.Valid {
+		os.Exit(1)
+	}
+}
+
+// componentFlags collects repeated --component name=path flags
+type componentFlags map[string]string
+
+func (c *componentFlags) String() string {
+	return fmt.Sprintf("%v", map[string]string(*c))
+}
+
+func (c *componentFlags) Set(value string) error {
+	name, path, ok := strings.Cut(value, "=")
+	if !ok || name == "" || path == "" {
+		return fmt.Errorf("expected name=path, got %q", value)
+	}
+	if *c == nil {
+		*c = make(componentFlags)
+	}
+	(*c)[name] = path
+	return nil
+}
+
+func readPlan(path string) (*CompositionPlan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan CompositionPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, err
+	}
+
+	if plan.Components == nil {
+		plan.Components = make(map[string]string)
+	}
+	baseDir := filepath.Dir(path)
+	for name, listing := range plan.Components {
+		if !filepath.IsAbs(listing) {
+			plan.Components[name] = filepath.Join(baseDir, listing)
+		}
+	}
+	if plan.Passthrough == nil {
+		plan.Passthrough = []string{"wasi:"}
+	}
+
+	return &plan, nil
+}
+
+func readComponentInterfaces(path string) (*ComponentInterfaces, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var component ComponentInterfaces
+	if err := json.Unmarshal(data, &component); err != nil {
+		return nil, err
+	}
+
+	return &component, nil
+}
+
+// checkPlan validates each connection and then looks for imports nothing
+// satisfies and exports nothing consumes
+func checkPlan(plan *CompositionPlan, listings map[string]*ComponentInterfaces) *PlanReport {
+	report := &PlanReport{
+		InvalidConnections: []Issue{},
+		UnsatisfiedImports: []Issue{},
+		DanglingExports:    []Issue{},
+	}
+
+	wiredImports := make(map[string]bool)
+	usedExports := make(map[string]bool)
+
+	for _, conn := range plan.Connections {
+		from, fromOK := listings[conn.From]
+		to, toOK := listings[conn.To]
+
+		switch {
+		case !fromOK:
+			report.InvalidConnections = append(report.InvalidConnections,
+				Issue{conn.From, conn.Export, "component is not in the plan"})
+		case !toOK:
+			report.InvalidConnections = append(report.InvalidConnections,
+				Issue{conn.To, conn.Import, "component is not in the plan"})
+		case !contains(from.Exports, conn.Export):
+			report.InvalidConnections = append(report.InvalidConnections,
+				Issue{conn.From, conn.Export, "component does not export this interface"})
+		case !contains(to.Imports, conn.Import):
+			report.InvalidConnections = append(report.InvalidConnections,
+				Issue{conn.To, conn.Import, "component does not import this interface"})
+		case !compatibleInterfaces(conn.Export, conn.Import):
+			report.InvalidConnections = append(report.InvalidConnections,
+				Issue{conn.To, conn.Import, fmt.Sprintf("incompatible with %s export %s", conn.From, conn.Export)})
+		default:
+			wiredImports[conn.To+"/"+conn.Import] = true
+			usedExports[conn.From+"/"+conn.Export] = true
+		}
+	}
+
+	for _, export := range plan.Exports {
+		usedExports[export] = true
+	}
+
+	for _, name := range sortedNames(listings) {
+		listing := listings[name]
+
+		for _, imp := range listing.Imports {
+			if !wiredImports[name+"/"+imp] && !hasAnyPrefix(imp, plan.Passthrough) {
+				report.UnsatisfiedImports = append(report.UnsatisfiedImports,
+					Issue{name, imp, "import is not wired to any export"})
+			}
+		}
+
+		for _, exp := range listing.Exports {
+			if !usedExports[name+"/"+exp] {
+				report.DanglingExports = append(report.DanglingExports,
+					Issue{name, exp, "export is not consumed or re-exported"})
+			}
+		}
+	}
+
+	report.Valid = len(report.InvalidConnections) == 0 && len(report.UnsatisfiedImports) == 0
+	return report
+}
+
+// compatibleInterfaces reports whether an export can satisfy an import: the
+// same interface at a semver-compatible version (same major, or same minor
+// while major is 0). Unversioned names must match exactly.
+func compatibleInterfaces(export, imp string) bool {
+	exportName, exportVersion, _ := strings.Cut(export, "@")
+	importName, importVersion, _ := strings.Cut(imp, "@")
+	if exportName != importName {
+		return false
+	}
+	if exportVersion == importVersion {
+		return true
+	}
+	if exportVersion == "" || importVersion == "" {
+		return false
+	}
+
+	exportParts := strings.SplitN(exportVersion, ".", 3)
+	importParts := strings.SplitN(importVersion, ".", 3)
+	if exportParts[0] != importParts[0] {
+		return false
+	}
+	if exportParts[0] == "0" {
+		return len(exportParts) > 1 && len(importParts) > 1 && exportParts[1] == importParts[1]
+	}
+	return true
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedNames(listings map[string]*ComponentInterfaces) []string {
+	names := make([]string, 0, len(listings))
+	for name := range listings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
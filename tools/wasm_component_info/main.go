@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmbinary"
+	"github.com/pulseengine/rules_wasm_component/tools/wasmheader"
+)
+
+// ComponentInfo mirrors the WasmComponentInfo provider so rules and tests
+// can materialize the same data outside Starlark. Field names match the
+// provider; wit_info and the profile fields only exist at analysis time and
+// are not reproduced.
+type ComponentInfo struct {
+	WasmFile      string            `json:"wasm_file"`
+	ComponentType string            `json:"component_type"` // "component" or "module"
+	Imports       []string          `json:"imports"`
+	Exports       []string          `json:"exports"`
+	Metadata      map[string]string `json:"metadata"`
+}
+
+// Section ids used by the inspector
+const (
+	sectionCustom             = 0
+	moduleSectionImport       = 2
+	moduleSectionExport       = 7
+	componentSectionImport    = 10
+	componentSectionExport    = 11
+	componentSectionModule    = 1
+	componentSectionComponent = 4
+)
+
+// Prints WasmComponentInfo-shaped JSON for a .wasm file. For components the
+// imports and exports are the top-level interface names; for core modules
+// imports are "module/name" and exports are the export names. Metadata holds
+// the file size, digest, core module count and the producers section.
+//
+// `compose-report [--json] <dir>` instead summarizes a wac_deps bundle
+// directory before composition, and `compat-matrix [--json] <file.wasm>...`
+// shows which components of a set can satisfy each other's imports.
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "compose-report" {
+		runComposeReport(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "compat-matrix" {
+		runCompatMatrix(os.Args[2:])
+		return
+	}
+
+	if len(os.Args) != 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s <file.wasm>\n       %s compose-report [--json] <bundle-dir>\n       %s compat-matrix [--json] <file.wasm>...\n", os.Args[0], os.Args[0], os.Args[0])
+		os.Exit(1)
+	}
+
+	wasmPath := os.Args[1]
+	data, err := os.ReadFile(wasmPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading WASM file: %v\n", err)
+		os.Exit(1)
+	}
+
+	info, err := inspect(wasmPath, data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", wasmPath, err)
+		os.Exit(1)
+	}
+
+	output, _ := json.MarshalIndent(info, "", "  ")
+	fmt.Println(string(output))
+}
+
+func inspect(path string, data []byte) (*ComponentInfo, error) {
+	kind, _, err := wasmheader.Classify(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	info := &ComponentInfo{
+		WasmFile:      path,
+		ComponentType: kind,
+		Imports:       []string{},
+		Exports:       []string{},
+		Metadata: map[string]string{
+			"size":   strconv.Itoa(len(data)),
+			"sha256": hex.EncodeToString(sum[:]),
+		},
+	}
+
+	isComponent := kind == wasmheader.Component
+
+	// Only top-level sections: nested modules' and components' imports and
+	// exports are internal
+	modules := 0
+	err = wasmbinary.Walk(data, func(id byte, body []byte) error {
+		var names []string
+		var err error
+
+		switch {
+		case id == sectionCustom:
+			return readProducers(body, info.Metadata)
+		case isComponent && id == componentSectionModule:
+			modules++
+			return nil
+		case isComponent && id == componentSectionImport:
+			names, err = componentExternNames(body, false)
+			info.Imports = append(info.Imports, names...)
+		case isComponent && id == componentSectionExport:
+			names, err = componentExternNames(body, true)
+			info.Exports = append(info.Exports, names...)
+		case !isComponent && id == moduleSectionImport:
+			names, err = moduleImportNames(body)
+			info.Imports = append(info.Imports, names...)
+		case !isComponent && id == moduleSectionExport:
+			names, err = moduleExportNames(body)
+			info.Exports = append(info.Exports, names...)
+		}
+		if err != nil {
+			return fmt.Errorf("section %d: %w", id, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if isComponent {
+		info.Metadata["core_modules"] = strconv.Itoa(modules)
+	}
+	sort.Strings(info.Imports)
+	sort.Strings(info.Exports)
+	return info, nil
+}
+
+// readProducers flattens a producers custom section into metadata, e.g.
+// "processed-by": "TinyGo 0.33.0, wit-component 0.215.0". Other custom
+// sections are ignored.
+func readProducers(body []byte, metadata map[string]string) error {
+	r := wasmbinary.NewReader(body)
+	if r.Name() != "producers" || r.Err() != nil {
+		return nil
+	}
+
+	fields := r.ULEB()
+	for i := uint64(0); i < fields && r.Err() == nil; i++ {
+		field := r.Name()
+		count := r.ULEB()
+
+		var values []string
+		for j := uint64(0); j < count && r.Err() == nil; j++ {
+			value := r.Name()
+			if version := r.Name(); version != "" {
+				value += " " + version
+			}
+			values = append(values, value)
+		}
+		metadata[field] = strings.Join(values, ", ")
+	}
+
+	if r.Err() != nil {
+		return fmt.Errorf("producers section: %w", r.Err())
+	}
+	return nil
+}
+
+// moduleImportNames reads a core module import section as "module/name"
+func moduleImportNames(body []byte) ([]string, error) {
+	r := wasmbinary.NewReader(body)
+	count := r.ULEB()
+
+	var names []string
+	for i := uint64(0); i < count && r.Err() == nil; i++ {
+		module := r.Name()
+		names = append(names, module+"/"+r.Name())
+
+		switch r.Byte() {
+		case 0x00: // Function type index
+			r.ULEB()
+		case 0x01: // Table: reference type and limits
+			r.Byte()
+			r.Limits()
+		case 0x02: // Memory limits
+			r.Limits()
+		case 0x03: // Global: value type and mutability
+			r.Byte()
+			r.Byte()
+		case 0x04: // Tag: attribute and type index
+			r.Byte()
+			r.ULEB()
+		default:
+			r.Fail(errors.New("unknown import kind"))
+		}
+	}
+	return names, r.Err()
+}
+
+// moduleExportNames reads the names from a core module export section
+func moduleExportNames(body []byte) ([]string, error) {
+	r := wasmbinary.NewReader(body)
+	count := r.ULEB()
+
+	var names []string
+	for i := uint64(0); i < count && r.Err() == nil; i++ {
+		names = append(names, r.Name())
+		r.Byte() // Export kind
+		r.ULEB() // Index
+	}
+	return names, r.Err()
+}
+
+// componentExternNames reads the names from a component import or export
+// section. Imports are a name and an extern descriptor; exports are a name,
+// a sort and index, and an optional type ascription.
+func componentExternNames(body []byte, exports bool) ([]string, error) {
+	r := wasmbinary.NewReader(body)
+	count := r.ULEB()
+
+	var names []string
+	for i := uint64(0); i < count && r.Err() == nil; i++ {
+		r.Byte() // Name kind: 0x00 plain, 0x01 with version suffix (older encodings)
+		names = append(names, r.Name())
+
+		if !exports {
+			r.ExternDesc()
+			continue
+		}
+
+		if r.Byte() == 0x00 {
+			r.Byte() // Core sort
+		}
+		r.ULEB()
+		if r.Byte() == 0x01 {
+			r.ExternDesc()
+		}
+	}
+	return names, r.Err()
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// wasmName encodes a length-prefixed name. Fixture names stay under 128
+// bytes, so the length is a single LEB128 byte.
+func wasmName(s string) []byte {
+	return append([]byte{byte(len(s))}, s...)
+}
+
+// wasmSection wraps body as a top-level section with the given id
+func wasmSection(id byte, body ...[]byte) []byte {
+	var payload []byte
+	for _, part := range body {
+		payload = append(payload, part...)
+	}
+	return append([]byte{id, byte(len(payload))}, payload...)
+}
+
+// wasmFile joins a preamble and sections
+func wasmFile(preamble string, sections ...[]byte) []byte {
+	data := []byte(preamble)
+	for _, section := range sections {
+		data = append(data, section...)
+	}
+	return data
+}
+
+const (
+	componentPreamble = "\x00asm\x0d\x00\x01\x00"
+	modulePreamble    = "\x00asm\x01\x00\x00\x00"
+)
+
+// producersSection is what TinyGo and wit-component leave in a component
+var producersSection = wasmSection(sectionCustom,
+	wasmName("producers"),
+	[]byte{2},
+	wasmName("language"), []byte{1}, wasmName("Go"), wasmName("1.22"),
+	wasmName("processed-by"), []byte{2},
+	wasmName("wit-component"), wasmName("0.215.0"),
+	wasmName("TinyGo"), wasmName("0.33.0"),
+)
+
+// calculatorComponent imports one WASI interface, embeds a core module and
+// exports an interface instance and a function with a type ascription
+var calculatorComponent = wasmFile(componentPreamble,
+	wasmSection(componentSectionImport,
+		[]byte{1},
+		[]byte{0x00}, wasmName("wasi:cli/environment@0.2.0"), []byte{0x05, 0x00}, // Instance type 0
+	),
+	wasmSection(componentSectionModule, []byte(modulePreamble)),
+	wasmSection(componentSectionExport,
+		[]byte{2},
+		[]byte{0x00}, wasmName("run"), []byte{0x01, 0x00, 0x01, 0x01, 0x00}, // Func 0 ascribed func type 0
+		[]byte{0x00}, wasmName("example:calc/ops@1.0.0"), []byte{0x05, 0x00, 0x00}, // Instance 0, no ascription
+	),
+	producersSection,
+)
+
+// loggerModule is a core module importing a function and a memory with a
+// maximum, and exporting an entry point and its memory
+var loggerModule = wasmFile(modulePreamble,
+	wasmSection(moduleSectionImport,
+		[]byte{2},
+		wasmName("env"), wasmName("memory"), []byte{0x02, 0x01, 0x01, 0x02},
+		wasmName("env"), wasmName("log"), []byte{0x00, 0x00},
+	),
+	wasmSection(moduleSectionExport,
+		[]byte{2},
+		wasmName("_start"), []byte{0x00, 0x00},
+		wasmName("memory"), []byte{0x02, 0x00},
+	),
+)
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		data []byte
+		want ComponentInfo
+	}{
+		{
+			name: "component",
+			path: "bazel-out/calculator.wasm",
+			data: calculatorComponent,
+			want: ComponentInfo{
+				WasmFile:      "bazel-out/calculator.wasm",
+				ComponentType: "component",
+				Imports:       []string{"wasi:cli/environment@0.2.0"},
+				Exports:       []string{"example:calc/ops@1.0.0", "run"},
+				Metadata: map[string]string{
+					"size":         strconv.Itoa(len(calculatorComponent)),
+					"sha256":       sha256Hex(calculatorComponent),
+					"core_modules": "1",
+					"language":     "Go 1.22",
+					"processed-by": "wit-component 0.215.0, TinyGo 0.33.0",
+				},
+			},
+		},
+		{
+			name: "core module",
+			path: "logger.wasm",
+			data: loggerModule,
+			want: ComponentInfo{
+				WasmFile:      "logger.wasm",
+				ComponentType: "module",
+				Imports:       []string{"env/log", "env/memory"},
+				Exports:       []string{"_start", "memory"},
+				Metadata: map[string]string{
+					"size":   strconv.Itoa(len(loggerModule)),
+					"sha256": sha256Hex(loggerModule),
+				},
+			},
+		},
+		{
+			name: "empty component",
+			path: "empty.wasm",
+			data: []byte(componentPreamble),
+			want: ComponentInfo{
+				WasmFile:      "empty.wasm",
+				ComponentType: "component",
+				Imports:       []string{},
+				Exports:       []string{},
+				Metadata: map[string]string{
+					"size":         "8",
+					"sha256":       sha256Hex([]byte(componentPreamble)),
+					"core_modules": "0",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := inspect(tt.path, tt.data)
+			if err != nil {
+				t.Fatalf("inspect: %v", err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("inspect =\n%+v\nwant\n%+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+// TestInspectJSONMatchesProvider checks the output keys are the field names
+// of the WasmComponentInfo provider, and empty lists stay lists
+func TestInspectJSONMatchesProvider(t *testing.T) {
+	info, err := inspect("empty.wasm", []byte(componentPreamble))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	want := []string{"component_type", "exports", "imports", "metadata", "wasm_file"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("JSON keys = %v, want %v", keys, want)
+	}
+	for _, list := range []string{"imports", "exports"} {
+		if string(fields[list]) != "[]" {
+			t.Errorf("%s = %s, want []", list, fields[list])
+		}
+	}
+}
+
+func TestInspectErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{
+			name:    "not wasm",
+			data:    []byte("#!/bin/sh\necho hi\n"),
+			wantErr: "not a WASM binary",
+		},
+		{
+			name:    "section overruns file",
+			data:    append([]byte(modulePreamble), moduleSectionExport, 0x20, 0x01),
+			wantErr: "overruns file",
+		},
+		{
+			name:    "unknown import kind",
+			data:    wasmFile(modulePreamble, wasmSection(moduleSectionImport, []byte{1}, wasmName("env"), wasmName("x"), []byte{0x09})),
+			wantErr: "unknown import kind",
+		},
+		{
+			name:    "truncated export section",
+			data:    wasmFile(componentPreamble, wasmSection(componentSectionExport, []byte{1, 0x00}, wasmName("run"))),
+			wantErr: "unexpected end of section",
+		},
+		{
+			name:    "truncated producers",
+			data:    wasmFile(modulePreamble, wasmSection(sectionCustom, wasmName("producers"), []byte{1}, wasmName("language"), []byte{3})),
+			wantErr: "producers section",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := inspect("bad.wasm", tt.data)
+			if err == nil {
+				t.Fatal("inspect succeeded, want an error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
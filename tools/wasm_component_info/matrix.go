@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmheader"
+	"github.com/pulseengine/rules_wasm_component/tools/witsyntax"
+)
+
+// UnsatisfiedImport is an import no other component in the set can provide
+type UnsatisfiedImport struct {
+	Component string `json:"component"`
+	Import    string `json:"import"`
+	Reason    string `json:"reason"`
+}
+
+// CompatMatrix records, for every pair of components, which imports of the
+// consumer the provider's exports satisfy
+type CompatMatrix struct {
+	Components  []string                       `json:"components"`
+	Satisfies   map[string]map[string][]string `json:"satisfies"` // Consumer -> provider -> imports
+	Unsatisfied []UnsatisfiedImport            `json:"unsatisfied"`
+}
+
+// runCompatMatrix implements `compat-matrix [--json] <file.wasm>...`. Each
+// component is named after its file without the extension.
+func runCompatMatrix(args []string) {
+	fs := flag.NewFlagSet("compat-matrix", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the matrix as JSON instead of a table")
+	fs.Parse(args)
+
+	if fs.NArg() < 2 {
+		fmt.Fprintf(os.Stderr, "Usage: %s compat-matrix [--json] <file.wasm> <file.wasm>...\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	components := make(map[string]*ComponentInfo)
+	for _, path := range fs.Args() {
+		name := strings.TrimSuffix(filepath.Base(path), ".wasm")
+		if _, exists := components[name]; exists {
+			fmt.Fprintf(os.Stderr, "Error: two components named %s\n", name)
+			os.Exit(1)
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		info, err := inspect(path, data)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", path, err)
+			os.Exit(1)
+		}
+		if info.ComponentType != wasmheader.Component {
+			fmt.Fprintf(os.Stderr, "Error: %s is a core module, not a component\n", path)
+			os.Exit(1)
+		}
+		components[name] = info
+	}
+
+	matrix := buildCompatMatrix(components)
+
+	if *asJSON {
+		output, _ := json.MarshalIndent(matrix, "", "  ")
+		fmt.Println(string(output))
+		return
+	}
+	printCompatMatrix(matrix)
+}
+
+// buildCompatMatrix matches every import against the exports of the other
+// components. Interface names match when they name the same interface at
+// compatible versions; other names only match exactly.
+func buildCompatMatrix(components map[string]*ComponentInfo) *CompatMatrix {
+	matrix := &CompatMatrix{
+		Satisfies:   make(map[string]map[string][]string),
+		Unsatisfied: []UnsatisfiedImport{},
+	}
+	for name := range components {
+		matrix.Components = append(matrix.Components, name)
+	}
+	sort.Strings(matrix.Components)
+
+	for _, consumer := range matrix.Components {
+		matrix.Satisfies[consumer] = make(map[string][]string)
+
+		for _, imported := range components[consumer].Imports {
+			satisfied := false
+			var nearMiss []string
+			for _, provider := range matrix.Components {
+				if provider == consumer {
+					continue
+				}
+				for _, exported := range components[provider].Exports {
+					ok, sameInterface := satisfiesImport(imported, exported)
+					if ok {
+						matrix.Satisfies[consumer][provider] = append(matrix.Satisfies[consumer][provider], imported)
+						satisfied = true
+						break
+					}
+					if sameInterface {
+						nearMiss = append(nearMiss, fmt.Sprintf("%s exports incompatible %s", provider, exported))
+					}
+				}
+			}
+
+			if !satisfied {
+				reason := "not exported by any component"
+				if len(nearMiss) > 0 {
+					reason = strings.Join(nearMiss, "; ")
+				}
+				matrix.Unsatisfied = append(matrix.Unsatisfied, UnsatisfiedImport{Component: consumer, Import: imported, Reason: reason})
+			}
+		}
+	}
+
+	return matrix
+}
+
+// satisfiesImport reports whether an export can be plugged into an import,
+// and whether the two at least name the same interface. Versioned interfaces
+// are compatible when the export is a semver-compatible release no older
+// than the import.
+func satisfiesImport(imported, exported string) (ok, sameInterface bool) {
+	if imported == exported {
+		return true, true
+	}
+
+	importRef, importErr := witsyntax.ParsePackageRef(imported)
+	exportRef, exportErr := witsyntax.ParsePackageRef(exported)
+	if importErr != nil || exportErr != nil {
+		return false, false
+	}
+	if importRef.Namespace != exportRef.Namespace || importRef.Name != exportRef.Name {
+		return false, false
+	}
+	if importRef.Version == "" || exportRef.Version == "" {
+		return false, true
+	}
+
+	// Both versions were validated by ParsePackageRef
+	want, _ := witsyntax.ParseSemver(importRef.Version)
+	have, _ := witsyntax.ParseSemver(exportRef.Version)
+	return semverCompatible(want, have), true
+}
+
+// semverCompatible reports whether have can stand in for want: it shares the
+// major version and is not older. Before 1.0.0 the first non-zero component
+// acts as the major version, so 0.2.1 satisfies 0.2.0 but 0.3.0 does not.
+func semverCompatible(want, have witsyntax.Semver) bool {
+	major := 0
+	for major < 2 && want[major] == 0 {
+		major++
+	}
+	for i := 0; i <= major; i++ {
+		if have[i] != want[i] {
+			return false
+		}
+	}
+	for i := major + 1; i < 3; i++ {
+		if have[i] != want[i] {
+			return have[i] > want[i]
+		}
+	}
+	return true
+}
+
+func printCompatMatrix(matrix *CompatMatrix) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "CONSUMER \\ PROVIDER\t%s\tUNSATISFIED\n", strings.Join(matrix.Components, "\t"))
+
+	unsatisfied := make(map[string]int)
+	for _, u := range matrix.Unsatisfied {
+		unsatisfied[u.Component]++
+	}
+
+	for _, consumer := range matrix.Components {
+		cells := []string{consumer}
+		for _, provider := range matrix.Components {
+			switch n := len(matrix.Satisfies[consumer][provider]); {
+			case provider == consumer:
+				cells = append(cells, "-")
+			case n == 0:
+				cells = append(cells, ".")
+			default:
+				cells = append(cells, strconv.Itoa(n))
+			}
+		}
+		cells = append(cells, strconv.Itoa(unsatisfied[consumer]))
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+	w.Flush()
+
+	if len(matrix.Unsatisfied) == 0 {
+		fmt.Println("\nEvery import is satisfied within the set")
+		return
+	}
+	fmt.Printf("\n%d unsatisfiable import(s):\n", len(matrix.Unsatisfied))
+	for _, u := range matrix.Unsatisfied {
+		fmt.Printf("  %s: %s (%s)\n", u.Component, u.Import, u.Reason)
+	}
+}
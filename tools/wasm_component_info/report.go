@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmbinary"
+	"github.com/pulseengine/rules_wasm_component/tools/wasmheader"
+)
+
+// BundleEntry summarizes one component staged for composition
+type BundleEntry struct {
+	Name       string `json:"name"`
+	File       string `json:"file"`
+	Type       string `json:"type"` // "component" or "module"
+	Entrypoint string `json:"entrypoint"`
+	Imports    int    `json:"imports"`
+	Exports    int    `json:"exports"`
+	Size       int64  `json:"size"`
+}
+
+// CompositionReport is the dry-run summary of a wac bundle directory
+type CompositionReport struct {
+	Directory  string        `json:"directory"`
+	Components []BundleEntry `json:"components"`
+	TotalSize  int64         `json:"total_size"`
+}
+
+// Entrypoint kinds, matching wasm_entrypoint
+const (
+	kindReactor = "reactor"
+	kindCommand = "command"
+	kindBoth    = "both"
+	kindNone    = "none"
+)
+
+// runComposeReport implements `compose-report [--json] <dir>`. The directory
+// is laid out as wac_deps stages it: one <bundle-name>.wasm per component,
+// so the bundle name is the file name without its extension.
+func runComposeReport(args []string) {
+	fs := flag.NewFlagSet("compose-report", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the report as JSON instead of a table")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s compose-report [--json] <bundle-dir>\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	report, err := composeReport(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		output, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(output))
+		return
+	}
+	printComposeReport(report)
+}
+
+// composeReport inspects every .wasm file in dir, following the symlinks
+// wac_deps creates by default
+func composeReport(dir string) (*CompositionReport, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.wasm"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .wasm files in %s", dir)
+	}
+	sort.Strings(paths)
+
+	report := &CompositionReport{Directory: dir, Components: []BundleEntry{}}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+
+		info, err := inspect(path, data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		kind, err := entrypointKind(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+
+		report.Components = append(report.Components, BundleEntry{
+			Name:       strings.TrimSuffix(filepath.Base(path), ".wasm"),
+			File:       path,
+			Type:       info.ComponentType,
+			Entrypoint: kind,
+			Imports:    len(info.Imports),
+			Exports:    len(info.Exports),
+			Size:       int64(len(data)),
+		})
+		report.TotalSize += int64(len(data))
+	}
+
+	return report, nil
+}
+
+func printComposeReport(report *CompositionReport) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tTYPE\tENTRYPOINT\tIMPORTS\tEXPORTS\tSIZE")
+	for _, c := range report.Components {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\n", c.Name, c.Type, c.Entrypoint, c.Imports, c.Exports, c.Size)
+	}
+	w.Flush()
+	fmt.Printf("\n%d component(s), %d bytes total\n", len(report.Components), report.TotalSize)
+}
+
+// entrypointKind classifies a module or component the way wasm_entrypoint
+// does: command style exports _start (or wasi:cli/run for components),
+// reactor style exports _initialize
+func entrypointKind(data []byte) (string, error) {
+	var start, initialize, cliRun bool
+	if err := scanEntrypoints(data, &start, &initialize, &cliRun); err != nil {
+		return "", err
+	}
+
+	command := start || cliRun
+	switch {
+	case command && initialize:
+		return kindBoth, nil
+	case command:
+		return kindCommand, nil
+	case initialize:
+		return kindReactor, nil
+	default:
+		return kindNone, nil
+	}
+}
+
+// scanEntrypoints records entrypoint exports of a core module, or of every
+// core module nested in a component
+func scanEntrypoints(data []byte, start, initialize, cliRun *bool) error {
+	kind, _, err := wasmheader.Classify(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	isComponent := kind == wasmheader.Component
+
+	return wasmbinary.Walk(data, func(id byte, body []byte) error {
+		switch {
+		case isComponent && (id == componentSectionModule || id == componentSectionComponent):
+			return scanEntrypoints(body, start, initialize, cliRun)
+		case isComponent && id == componentSectionExport:
+			names, err := componentExternNames(body, true)
+			if err != nil {
+				return fmt.Errorf("component export section: %w", err)
+			}
+			for _, name := range names {
+				if name == "wasi:cli/run" || strings.HasPrefix(name, "wasi:cli/run@") {
+					*cliRun = true
+				}
+			}
+		case !isComponent && id == moduleSectionExport:
+			names, err := moduleExportNames(body)
+			if err != nil {
+				return fmt.Errorf("export section: %w", err)
+			}
+			for _, name := range names {
+				switch name {
+				case "_start":
+					*start = true
+				case "_initialize":
+					*initialize = true
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmbinary"
+)
+
+// EntrypointReport is the JSON emitted for a module or component
+type EntrypointReport struct {
+	File        string `json:"file"`
+	Component   bool   `json:"component"`
+	Start       bool   `json:"exports_start"`      // A core module exports _start
+	Initialize  bool   `json:"exports_initialize"` // A core module exports _initialize
+	CLIRun      bool   `json:"exports_cli_run"`    // The component exports wasi:cli/run
+	Kind        string `json:"kind"`
+	Expected    string `json:"expected,omitempty"`
+	Mismatch    bool   `json:"mismatch,omitempty"`
+	Explanation string `json:"explanation"`
+}
+
+// Entrypoint kinds, each with its own exit code so scripts can branch on the
+// classification without parsing the JSON. 1 is reserved for errors.
+const (
+	kindReactor = "reactor"
+	kindCommand = "command"
+	kindBoth    = "both"
+	kindNone    = "none"
+)
+
+var kindExitCodes = map[string]int{
+	kindReactor: 0,
+	kindCommand: 2,
+	kindBoth:    3,
+	kindNone:    4,
+}
+
+// exitMismatch is returned with --expect when the kind differs
+const exitMismatch = 5
+
+// Section ids used by the detector
+const (
+	moduleSectionExport       = 7
+	componentSectionModule    = 1
+	componentSectionComponent = 4
+	componentSectionExport    = 11
+)
+
+// Classifies a WASM module or component as command style (exports _start,
+// or wasi:cli/run for components) or reactor style (exports _initialize).
+// TinyGo builds either, and composing one where the other is expected fails
+// only at runtime.
+//
+// Exit codes: 0 reactor, 2 command, 3 both, 4 none, 1 error. With --expect
+// the exit code is 0 on a match and 5 on a mismatch.
+func main() {
+	expect := flag.String("expect", "", "Expected kind (reactor or command); exit 5 when the component differs")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [--expect reactor|command] <file.wasm>\n", os.Args[0])
+		os.Exit(1)
+	}
+	if *expect != "" && *expect != kindReactor && *expect != kindCommand {
+		fmt.Fprintf(os.Stderr, "Error: --expect must be reactor or command, got %q\n", *expect)
+		os.Exit(1)
+	}
+
+	wasmPath := flag.Arg(0)
+	data, err := os.ReadFile(wasmPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading WASM file: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := EntrypointReport{File: wasmPath}
+	if err := scanEntrypoints(data, &report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", wasmPath, err)
+		os.Exit(1)
+	}
+	classify(&report)
+
+	if *expect != "" {
+		report.Expected = *expect
+		report.Mismatch = report.Kind != *expect
+	}
+
+	output, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(output))
+
+	if *expect != "" {
+		if report.Mismatch {
+			fmt.Fprintf(os.Stderr, "Warning: %s is %s style, expected %s\n", wasmPath, report.Kind, *expect)
+			os.Exit(exitMismatch)
+		}
+		return
+	}
+	os.Exit(kindExitCodes[report.Kind])
+}
+
+// classify derives the kind from the exports found
+func classify(report *EntrypointReport) {
+	command := report.Start || report.CLIRun
+
+	switch {
+	case command && report.Initialize:
+		report.Kind = kindBoth
+		report.Explanation = "has a command entrypoint (_start or wasi:cli/run) and _initialize; hosts disagree on which to run"
+	case command:
+		report.Kind = kindCommand
+		report.Explanation = "runs once through _start or wasi:cli/run and exits; cannot serve calls to its exports afterwards"
+	case report.Initialize:
+		report.Kind = kindReactor
+		report.Explanation = "initialized once through _initialize, then serves calls to its exports"
+	default:
+		report.Kind = kindNone
+		report.Explanation = "exports neither _start nor _initialize; treated as a reactor with no initializer"
+	}
+}
+
+// scanEntrypoints records entrypoint exports of a core module, or of every
+// core module nested in a component
+func scanEntrypoints(data []byte, report *EntrypointReport) error {
+	if err := wasmbinary.CheckMagic(data); err != nil {
+		return err
+	}
+
+	isComponent := wasmbinary.IsComponent(data)
+	if isComponent {
+		report.Component = true
+	}
+
+	return wasmbinary.Walk(data, func(id byte, body []byte) error {
+		switch {
+		case isComponent && (id == componentSectionModule || id == componentSectionComponent):
+			if err := scanEntrypoints(body, report); err != nil {
+				return err
+			}
+		case isComponent && id == componentSectionExport:
+			names, err := componentExportNames(body)
+			if err != nil {
+				return fmt.Errorf("component export section: %w", err)
+			}
+			for _, name := range names {
+				if name == "wasi:cli/run" || strings.HasPrefix(name, "wasi:cli/run@") {
+					report.CLIRun = true
+				}
+			}
+		case !isComponent && id == moduleSectionExport:
+			names, err := moduleExportNames(body)
+			if err != nil {
+				return fmt.Errorf("export section: %w", err)
+			}
+			for _, name := range names {
+				switch name {
+				case "_start":
+					report.Start = true
+				case "_initialize":
+					report.Initialize = true
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// moduleExportNames reads the names from a core module export section
+func moduleExportNames(body []byte) ([]string, error) {
+	r := wasmbinary.NewReader(body)
+	count := r.ULEB()
+
+	var names []string
+	for i := uint64(0); i < count && r.Err() == nil; i++ {
+		names = append(names, r.Name())
+		r.Byte() // Export kind
+		r.ULEB() // Index
+	}
+	return names, r.Err()
+}
+
+// componentExportNames reads the names from a component export section.
+// Each entry is a name, a sort and index, and an optional type ascription.
+func componentExportNames(body []byte) ([]string, error) {
+	r := wasmbinary.NewReader(body)
+	count := r.ULEB()
+
+	var names []string
+	for i := uint64(0); i < count && r.Err() == nil; i++ {
+		r.Byte() // Name kind: 0x00 plain, 0x01 with version suffix (older encodings)
+		names = append(names, r.Name())
+
+		if r.Byte() == 0x00 {
+			r.Byte() // Core sort
+		}
+		r.ULEB()
+
+		if r.Byte() == 0x01 {
+			r.ExternDesc()
+		}
+	}
+	return names, r.Err()
+}
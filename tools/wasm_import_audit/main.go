@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmbinary"
+)
+
+// ImportUsage describes one interface the component imports
+type ImportUsage struct {
+	Interface string   `json:"interface"`
+	Functions []string `json:"functions"` // Functions the core modules import from it
+	Declared  bool     `json:"declared"`  // Listed by the world
+}
+
+// AuditReport is the JSON emitted for a component and its world
+type AuditReport struct {
+	Component string        `json:"component"`
+	World     string        `json:"world"`
+	Imports   []ImportUsage `json:"imports"`
+	// UnusedWorldImports are declared by the world but not imported by the
+	// component; they can be dropped from the world
+	UnusedWorldImports []string `json:"unused_world_imports"`
+	// TypeOnlyImports are imported but no function is called through them;
+	// they may only be needed for their types
+	TypeOnlyImports    []string `json:"type_only_imports"`
+	DeclaredImports    int      `json:"declared_imports"`
+	RemovableImports   int      `json:"removable_imports"`
+	EstimatedReduction float64  `json:"estimated_reduction_percent"` // Of the world's declared imports
+}
+
+// World is the set of interfaces a WIT world imports and exports
+type World struct {
+	Name    string
+	Package string
+	Imports []string
+	Exports []string
+}
+
+var (
+	packageRegex = regexp.MustCompile(`^package\s+([^;]+);`)
+	worldRegex   = regexp.MustCompile(`^world\s+([\w-]+)\s*\{`)
+	// Items are package-qualified (wasi:cli/environment@0.2.0) or local
+	// (name; or name: func...)
+	itemRegex = regexp.MustCompile(`^(import|export)\s+([\w-]+:[^;{\s]+|[\w-]+)\s*(:|;)`)
+)
+
+// Section ids used by the audit
+const (
+	moduleSectionImport       = 2
+	componentSectionModule    = 1
+	componentSectionComponent = 4
+	componentSectionImport    = 10
+)
+
+// Lists the interfaces a component imports, with the functions its core
+// modules call through each, and flags world imports the component never
+// uses so the WIT world (and the host surface) can be tightened. Prints the
+// JSON report on stdout and a short summary on stderr.
+func main() {
+	var (
+		witPath   = flag.String("wit", "", "WIT file defining the world")
+		worldName = flag.String("world", "", "World the component was built for (defaults to the only world in the file)")
+	)
+	flag.Parse()
+
+	if *witPath == "" || flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s --wit <world.wit> [--world <name>] <component.wasm>\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	wasmPath := flag.Arg(0)
+	data, err := os.ReadFile(wasmPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading WASM file: %v\n", err)
+		os.Exit(1)
+	}
+
+	imports, err := componentImports(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", wasmPath, err)
+		os.Exit(1)
+	}
+
+	world, err := parseWorld(*witPath, *worldName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing WIT world: %v\n", err)
+		os.Exit(1)
+	}
+
+	report := audit(wasmPath, world, imports)
+
+	output, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(output))
+	printSummary(report)
+}
+
+// audit compares the component's imports with its world
+func audit(path string, world *World, imports map[string][]string) *AuditReport {
+	report := &AuditReport{
+		Component:          path,
+		World:              world.Name,
+		Imports:            []ImportUsage{},
+		UnusedWorldImports: []string{},
+		TypeOnlyImports:    []string{},
+		DeclaredImports:    len(world.Imports),
+	}
+
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		usage := ImportUsage{Interface: name, Functions: imports[name]}
+		for _, declared := range world.Imports {
+			if sameInterface(declared, name) {
+				usage.Declared = true
+			}
+		}
+		if usage.Functions == nil {
+			usage.Functions = []string{}
+			report.TypeOnlyImports = append(report.TypeOnlyImports, name)
+		}
+		report.Imports = append(report.Imports, usage)
+	}
+
+	for _, declared := range world.Imports {
+		used := false
+		for _, name := range names {
+			if sameInterface(declared, name) {
+				used = true
+			}
+		}
+		if !used {
+			report.UnusedWorldImports = append(report.UnusedWorldImports, declared)
+		}
+	}
+	sort.Strings(report.UnusedWorldImports)
+
+	report.RemovableImports = len(report.UnusedWorldImports)
+	if report.DeclaredImports > 0 {
+		report.EstimatedReduction = float64(report.RemovableImports*1000/report.DeclaredImports) / 10
+	}
+	return report
+}
+
+// sameInterface matches a world import against a component import name. A
+// world import without a version matches any version of the interface.
+func sameInterface(declared, imported string) bool {
+	if declared == imported {
+		return true
+	}
+	return !strings.Contains(declared, "@") && strings.HasPrefix(imported, declared+"@")
+}
+
+func printSummary(report *AuditReport) {
+	functions := 0
+	for _, usage := range report.Imports {
+		functions += len(usage.Functions)
+	}
+	fmt.Fprintf(os.Stderr, "%d interfaces imported, calling %d functions; world %s declares %d imports\n",
+		len(report.Imports), functions, report.World, report.DeclaredImports)
+
+	for _, name := range report.UnusedWorldImports {
+		fmt.Fprintf(os.Stderr, "  unused: %s (declared by the world, never imported; consider removing it)\n", name)
+	}
+	for _, name := range report.TypeOnlyImports {
+		fmt.Fprintf(os.Stderr, "  types only: %s (no function is called through it)\n", name)
+	}
+
+	if report.RemovableImports == 0 {
+		fmt.Fprintln(os.Stderr, "No removable imports found")
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Estimated reduction: %d of %d world imports (%.1f%%)\n",
+		report.RemovableImports, report.DeclaredImports, report.EstimatedReduction)
+}
+
+// componentImports maps each top-level component import to the functions the
+// nested core modules import from it. Core modules name the interface as the
+// import module, so any function imported that way is attributed to it.
+func componentImports(data []byte) (map[string][]string, error) {
+	if err := wasmbinary.CheckMagic(data); err != nil {
+		return nil, err
+	}
+	if !wasmbinary.IsComponent(data) {
+		return nil, errors.New("core module, not a component")
+	}
+
+	imports := make(map[string][]string)
+	err := wasmbinary.Walk(data, func(id byte, body []byte) error {
+		if id != componentSectionImport {
+			return nil
+		}
+		names, err := componentImportNames(body)
+		for _, name := range names {
+			imports[name] = nil
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	coreImports := make(map[string]map[string]bool)
+	if err := collectCoreImports(data, coreImports); err != nil {
+		return nil, err
+	}
+
+	for name := range imports {
+		for function := range coreImports[name] {
+			imports[name] = append(imports[name], function)
+		}
+		sort.Strings(imports[name])
+	}
+	return imports, nil
+}
+
+// collectCoreImports gathers "module" -> functions from every core module
+// nested anywhere in the component
+func collectCoreImports(data []byte, into map[string]map[string]bool) error {
+	return wasmbinary.Walk(data, func(id byte, body []byte) error {
+		switch id {
+		case componentSectionComponent:
+			return collectCoreImports(body, into)
+		case componentSectionModule:
+			return wasmbinary.Walk(body, func(id byte, section []byte) error {
+				if id != moduleSectionImport {
+					return nil
+				}
+				return moduleImports(section, into)
+			})
+		}
+		return nil
+	})
+}
+
+// moduleImports records the function imports of a core module import section
+func moduleImports(body []byte, into map[string]map[string]bool) error {
+	r := wasmbinary.NewReader(body)
+	count := r.ULEB()
+
+	for i := uint64(0); i < count && r.Err() == nil; i++ {
+		module := r.Name()
+		field := r.Name()
+
+		switch r.Byte() {
+		case 0x00: // Function type index
+			r.ULEB()
+			if into[module] == nil {
+				into[module] = make(map[string]bool)
+			}
+			into[module][field] = true
+		case 0x01: // Table: reference type and limits
+			r.Byte()
+			r.Limits()
+		case 0x02: // Memory limits
+			r.Limits()
+		case 0x03: // Global: value type and mutability
+			r.Byte()
+			r.Byte()
+		case 0x04: // Tag: attribute and type index
+			r.Byte()
+			r.ULEB()
+		default:
+			r.Fail(errors.New("unknown import kind"))
+		}
+	}
+	return r.Err()
+}
+
+// componentImportNames reads the names from a component import section:
+// each entry is a name and an extern descriptor
+func componentImportNames(body []byte) ([]string, error) {
+	r := wasmbinary.NewReader(body)
+	count := r.ULEB()
+
+	var names []string
+	for i := uint64(0); i < count && r.Err() == nil; i++ {
+		r.Byte() // Name kind: 0x00 plain, 0x01 with version suffix (older encodings)
+		names = append(names, r.Name())
+		r.ExternDesc()
+	}
+	return names, r.Err()
+}
+
+// parseWorld extracts the imports and exports of a world from a WIT file.
+// Interfaces named without a package are qualified with the file's package.
+func parseWorld(witPath, worldName string) (*World, error) {
+	data, err := os.ReadFile(witPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkg string
+	var worlds []*World
+	var current *World
+	depth := 0
+
+	for _, rawLine := range strings.Split(string(data), "\n") {
+		line := strings.TrimSpace(stripLineComment(rawLine))
+		if line == "" {
+			continue
+		}
+
+		if matches := packageRegex.FindStringSubmatch(line); matches != nil && depth == 0 {
+			pkg = strings.TrimSpace(matches[1])
+		}
+
+		if matches := worldRegex.FindStringSubmatch(line); matches != nil && depth == 0 {
+			current = &World{Name: matches[1], Package: pkg}
+			worlds = append(worlds, current)
+		} else if current != nil && depth == 1 {
+			if matches := itemRegex.FindStringSubmatch(line); matches != nil {
+				name := qualifyInterface(matches[2], pkg)
+				if matches[1] == "import" {
+					current.Imports = append(current.Imports, name)
+				} else {
+					current.Exports = append(current.Exports, name)
+				}
+			}
+		}
+
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth == 0 {
+			current = nil
+		}
+	}
+
+	if len(worlds) == 0 {
+		return nil, fmt.Errorf("no world found in %s", witPath)
+	}
+
+	if worldName == "" {
+		if len(worlds) > 1 {
+			return nil, fmt.Errorf("%s defines %d worlds, use --world to pick one", witPath, len(worlds))
+		}
+		return worlds[0], nil
+	}
+
+	for _, world := range worlds {
+		if world.Name == worldName {
+			return world, nil
+		}
+	}
+
+	return nil, fmt.Errorf("world %s not found in %s", worldName, witPath)
+}
+
+// qualifyInterface turns a package-local interface name like "calculator" into
+// "example:calculator/calculator@1.0.0". Qualified names pass through as-is.
+func qualifyInterface(name, pkg string) string {
+	if strings.Contains(name, ":") || pkg == "" {
+		return name
+	}
+
+	pkgName, version, hasVersion := strings.Cut(pkg, "@")
+	if hasVersion {
+		return pkgName + "/" + name + "@" + version
+	}
+	return pkgName + "/" + name
+}
+
+func stripLineComment(line string) string {
+	if idx := strings.Index(line, "//"); idx >= 0 {
+		return line[:idx]
+	}
+	return line
+}
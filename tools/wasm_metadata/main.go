@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmbinary"
+)
+
+// defaultSection is the custom section build rules stamp metadata into
+const defaultSection = "rules-wasm-component-meta"
+
+// exitAbsent is returned by get when the section is not present
+const exitAbsent = 2
+
+// section is one top-level section of a module or component
+type section struct {
+	wasmbinary.Section
+	name    string // Custom sections only
+	payload []byte // Contents after the name, custom sections only
+}
+
+// Writes and reads a JSON blob in a named custom section, so build rules can
+// stamp metadata such as the source target, git SHA and build profile into a
+// module or component and retrieve it later.
+//
+//	set [--section NAME] [--json JSON] <input.wasm> <output.wasm> [json-file|-]
+//	get [--section NAME] <input.wasm>
+//
+// set replaces any existing section of that name and leaves every other
+// section byte-for-byte intact. get prints the JSON, exiting 2 when the
+// section is absent and 1 on errors.
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "set":
+		runSet(os.Args[2:])
+	case "get":
+		runGet(os.Args[2:])
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s set [--section NAME] [--json JSON] <input.wasm> <output.wasm> [json-file|-]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s get [--section NAME] <input.wasm>\n", os.Args[0])
+	os.Exit(1)
+}
+
+func runSet(args []string) {
+	flags := flag.NewFlagSet("set", flag.ExitOnError)
+	sectionName := flags.String("section", defaultSection, "Custom section to write")
+	inline := flags.String("json", "", "Metadata JSON (instead of a file argument)")
+	flags.Parse(args)
+
+	if flags.NArg() < 2 || flags.NArg() > 3 || (*inline != "") == (flags.NArg() == 3) {
+		usage()
+	}
+	inputPath, outputPath := flags.Arg(0), flags.Arg(1)
+
+	payload := []byte(*inline)
+	if *inline == "" {
+		var err error
+		if payload, err = readPayload(flags.Arg(2)); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading metadata: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Store compact JSON so the section does not depend on input formatting
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, payload); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: metadata is not valid JSON: %v\n", err)
+		os.Exit(1)
+	}
+
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading WASM file: %v\n", err)
+		os.Exit(1)
+	}
+
+	stamped, err := setCustomSection(data, *sectionName, compact.Bytes())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", inputPath, err)
+		os.Exit(1)
+	}
+
+	if err := writeFileAtomic(outputPath, stamped); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", outputPath, err)
+		os.Exit(1)
+	}
+}
+
+func runGet(args []string) {
+	flags := flag.NewFlagSet("get", flag.ExitOnError)
+	sectionName := flags.String("section", defaultSection, "Custom section to read")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		usage()
+	}
+	inputPath := flags.Arg(0)
+
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading WASM file: %v\n", err)
+		os.Exit(1)
+	}
+
+	sections, err := parseSections(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", inputPath, err)
+		os.Exit(1)
+	}
+
+	payload, found := customSection(sections, *sectionName)
+	if !found {
+		fmt.Fprintf(os.Stderr, "No %s section in %s\n", *sectionName, inputPath)
+		os.Exit(exitAbsent)
+	}
+
+	var pretty bytes.Buffer
+	if err := json.Indent(&pretty, payload, "", "  "); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s section is not valid JSON: %v\n", *sectionName, err)
+		os.Exit(1)
+	}
+	fmt.Println(pretty.String())
+}
+
+func readPayload(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// parseSections splits a module or component into its top-level sections
+func parseSections(data []byte) ([]section, error) {
+	if err := wasmbinary.CheckMagic(data); err != nil {
+		return nil, err
+	}
+
+	raw, err := wasmbinary.Sections(data)
+	if err != nil {
+		return nil, err
+	}
+
+	sections := make([]section, len(raw))
+	for i, s := range raw {
+		sections[i].Section = s
+		if s.ID != wasmbinary.SectionCustom {
+			continue
+		}
+		name, payload, ok := wasmbinary.CustomName(s.Body)
+		if !ok {
+			return nil, fmt.Errorf("custom section at offset %d has a malformed name", s.Start)
+		}
+		sections[i].name, sections[i].payload = name, payload
+	}
+
+	return sections, nil
+}
+
+// customSection returns the payload of the custom section called name. The
+// last one wins, matching how set replaces earlier ones.
+func customSection(sections []section, name string) ([]byte, bool) {
+	var payload []byte
+	found := false
+	for _, s := range sections {
+		if s.ID == wasmbinary.SectionCustom && s.name == name {
+			payload, found = s.payload, true
+		}
+	}
+	return payload, found
+}
+
+// setCustomSection drops any top-level custom sections called name and
+// appends one holding payload. Everything else is copied unchanged, and
+// custom sections may appear anywhere, so the result stays valid.
+func setCustomSection(data []byte, name string, payload []byte) ([]byte, error) {
+	sections, err := parseSections(data)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(data)+len(name)+len(payload)+12)
+	out = append(out, data[:8]...)
+	for _, s := range sections {
+		if s.ID == wasmbinary.SectionCustom && s.name == name {
+			continue
+		}
+		out = append(out, data[s.Start:s.End]...)
+	}
+
+	return wasmbinary.AppendCustomSection(out, name, payload), nil
+}
+
+// writeFileAtomic writes through a temp file so the input can also be the
+// output
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".wasm_metadata-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmbinary"
+)
+
+// rawSection encodes a section with a size that may need several LEB128 bytes
+func rawSection(id byte, body []byte) []byte {
+	out := wasmbinary.AppendULEB128([]byte{id}, uint64(len(body)))
+	return append(out, body...)
+}
+
+// customSectionBytes encodes a custom section called name
+func customSectionBytes(name string, payload []byte) []byte {
+	return wasmbinary.AppendCustomSection(nil, name, payload)
+}
+
+// componentFixture is a component laid out the way wit-component emits one:
+// an embedded core module, type, import, canon and export sections, and a
+// producers section, with a section large enough for a two-byte size
+func componentFixture() []byte {
+	coreModule := []byte("\x00asm\x01\x00\x00\x00")
+	data := []byte("\x00asm\x0d\x00\x01\x00")
+	data = append(data, rawSection(1, coreModule)...)
+	data = append(data, rawSection(7, bytes.Repeat([]byte{0x40}, 200))...)
+	data = append(data, customSectionBytes("component-name", []byte("\x00\x05calc"))...)
+	data = append(data, rawSection(10, []byte("\x01\x00\x1awasi:cli/environment@0.2.0\x05\x00"))...)
+	data = append(data, rawSection(8, []byte("\x01\x00\x00\x00\x00\x00"))...)
+	data = append(data, rawSection(11, []byte("\x01\x00\x03run\x01\x00\x00"))...)
+	data = append(data, customSectionBytes("producers", []byte("\x01\x08language\x01\x02Go\x041.22"))...)
+	return data
+}
+
+// withoutSection reassembles data minus the custom sections called name
+func withoutSection(t *testing.T, data []byte, name string) []byte {
+	t.Helper()
+	sections, err := parseSections(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := append([]byte(nil), data[:8]...)
+	for _, s := range sections {
+		if s.ID != wasmbinary.SectionCustom || s.name != name {
+			out = append(out, data[s.Start:s.End]...)
+		}
+	}
+	return out
+}
+
+// TestSetGetRoundTrip stamps metadata into the component fixture and checks
+// it reads back, while everything else stays byte-identical
+func TestSetGetRoundTrip(t *testing.T) {
+	original := componentFixture()
+	metadata := []byte(`{"target":"//examples/calc:component","git_sha":"0123abcd","profile":"release"}`)
+
+	stamped, err := setCustomSection(original, defaultSection, metadata)
+	if err != nil {
+		t.Fatalf("setCustomSection: %v", err)
+	}
+	if !bytes.HasPrefix(stamped, original) {
+		t.Error("stamping changed the existing sections instead of appending")
+	}
+
+	sections, err := parseSections(stamped)
+	if err != nil {
+		t.Fatalf("parsing the stamped component: %v", err)
+	}
+	got, found := customSection(sections, defaultSection)
+	if !found || !bytes.Equal(got, metadata) {
+		t.Errorf("get = %q (found %v), want %q", got, found, metadata)
+	}
+	if stripped := withoutSection(t, stamped, defaultSection); !bytes.Equal(stripped, original) {
+		t.Error("component minus the metadata section differs from the original")
+	}
+
+	// Setting again replaces the section rather than adding a second one
+	restamped, err := setCustomSection(stamped, defaultSection, []byte(`{"profile":"debug"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections, err = parseSections(restamped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, s := range sections {
+		if s.ID == wasmbinary.SectionCustom && s.name == defaultSection {
+			count++
+		}
+	}
+	if got, _ := customSection(sections, defaultSection); count != 1 || string(got) != `{"profile":"debug"}` {
+		t.Errorf("after a second set: %d sections holding %q, want one with the new metadata", count, got)
+	}
+	if stripped := withoutSection(t, restamped, defaultSection); !bytes.Equal(stripped, original) {
+		t.Error("second set changed the other sections")
+	}
+}
+
+func TestSetCustomSectionLargePayload(t *testing.T) {
+	metadata := []byte(`{"notes":"` + strings.Repeat("x", 20000) + `"}`)
+	stamped, err := setCustomSection(componentFixture(), "large", metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections, err := parseSections(stamped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, found := customSection(sections, "large"); !found || !bytes.Equal(got, metadata) {
+		t.Errorf("large payload did not round-trip (found %v, %d bytes)", found, len(got))
+	}
+}
+
+func TestCustomSectionAbsent(t *testing.T) {
+	sections, err := parseSections(componentFixture())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := customSection(sections, defaultSection); found {
+		t.Error("found metadata in an unstamped component")
+	}
+	// Only custom sections match, not other sections or other names
+	if _, found := customSection(sections, "component-name"); !found {
+		t.Error("existing custom section not found")
+	}
+}
+
+func TestParseSectionsErrors(t *testing.T) {
+	valid := componentFixture()
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "not wasm", data: []byte("#!/bin/sh\n"), wantErr: "bad magic"},
+		{name: "too short", data: []byte("\x00asm"), wantErr: "bad magic"},
+		{name: "truncated", data: valid[:len(valid)-3], wantErr: "overruns file"},
+		{name: "truncated size", data: append([]byte("\x00asm\x0d\x00\x01\x00\x01"), 0x80), wantErr: "truncated LEB128"},
+		{name: "custom name overruns", data: append([]byte("\x00asm\x0d\x00\x01\x00"), 0x00, 0x02, 0x09, 'a'), wantErr: "malformed name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseSections(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want one mentioning %q", err, tt.wantErr)
+			}
+			if _, err := setCustomSection(tt.data, defaultSection, []byte("{}")); err == nil {
+				t.Error("setCustomSection accepted malformed input")
+			}
+		})
+	}
+}
+
+// TestWriteFileAtomicInPlace covers set writing its output over its input
+func TestWriteFileAtomicInPlace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calc.wasm")
+	original := componentFixture()
+	if err := os.WriteFile(path, original, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stamped, err := setCustomSection(data, defaultSection, []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, stamped); err != nil {
+		t.Fatalf("writeFileAtomic: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, stamped) {
+		t.Error("file does not hold the stamped component")
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%d files in the directory, want no temp file left behind", len(entries))
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/wasmbinary"
+)
+
+// SectionSizes breaks a WASM file's bytes down by content kind. Code and data
+// are summed over every core module, including those nested in a component.
+type SectionSizes struct {
+	Code   int64 `json:"code"`
+	Data   int64 `json:"data"`
+	Custom int64 `json:"custom"`
+	Other  int64 `json:"other"` // Headers, types, imports, exports, component glue
+}
+
+// SizeReport is the JSON emitted for CI
+type SizeReport struct {
+	File          string       `json:"file"`
+	Size          int64        `json:"size"`
+	Sections      SectionSizes `json:"sections"`
+	Budget        int64        `json:"budget,omitempty"`
+	OverBudget    bool         `json:"over_budget"`
+	BaselineSize  int64        `json:"baseline_size,omitempty"`
+	GrowthPercent float64      `json:"growth_percent,omitempty"`
+	Regression    bool         `json:"regression"`
+	Passed        bool         `json:"passed"`
+}
+
+// Section ids used in the breakdown
+const (
+	sectionCustom          = 0
+	moduleSectionCode      = 10
+	moduleSectionData      = 11
+	componentSectionModule = 1
+	componentSectionNested = 4
+)
+
+// Size budget checker for WASM modules and components.
+//
+// Reports the file size with a code/data/custom breakdown and fails when the
+// file exceeds --budget or grows more than --threshold percent over the size
+// recorded in a --baseline report from an earlier run.
+func main() {
+	var (
+		budget    = flag.String("budget", "", "Maximum size, in bytes or human-readable (e.g. 512KiB, 2MB)")
+		baseline  = flag.String("baseline", "", "JSON report from a previous run to compare against")
+		threshold = flag.Float64("threshold", 5, "Allowed growth over the baseline, in percent")
+	)
+	flag.Parse()
+
+	if flag.NArg() != 1 || (*budget == "" && *baseline == "") {
+		fmt.Fprintf(os.Stderr, "Usage: %s [--budget <size>] [--baseline <report.json> [--threshold <percent>]] <file.wasm>\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	wasmPath := flag.Arg(0)
+	data, err := os.ReadFile(wasmPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading WASM file: %v\n", err)
+		os.Exit(1)
+	}
+
+	sections, err := measureSections(data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", wasmPath, err)
+		os.Exit(1)
+	}
+
+	report := SizeReport{
+		File:     wasmPath,
+		Size:     int64(len(data)),
+		Sections: sections,
+		Passed:   true,
+	}
+
+	if *budget != "" {
+		limit, err := parseSize(*budget)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --budget: %v\n", err)
+			os.Exit(1)
+		}
+		report.Budget = limit
+		report.OverBudget = report.Size > limit
+	}
+
+	if *baseline != "" {
+		baselineSize, err := readBaselineSize(*baseline)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading baseline: %v\n", err)
+			os.Exit(1)
+		}
+		report.BaselineSize = baselineSize
+		report.GrowthPercent = float64(report.Size-baselineSize) / float64(baselineSize) * 100
+		report.Regression = report.GrowthPercent > *threshold
+	}
+
+	report.Passed = !report.OverBudget && !report.Regression
+
+	output, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(output))
+
+	if !report.Passed {
+		os.Exit(1)
+	}
+}
+
+// measureSections walks a core module or component and attributes each byte
+// to a section kind. Core modules nested in a component are walked as well.
+func measureSections(data []byte) (SectionSizes, error) {
+	var sizes SectionSizes
+	if err := walkSections(data, &sizes); err != nil {
+		return sizes, err
+	}
+	return sizes, nil
+}
+
+func walkSections(data []byte, sizes *SectionSizes) error {
+	if err := wasmbinary.CheckMagic(data); err != nil {
+		return err
+	}
+
+	isComponent := wasmbinary.IsComponent(data)
+	sizes.Other += wasmbinary.HeaderSize
+
+	sections, err := wasmbinary.Sections(data)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range sections {
+		header, total := int64(s.HeaderLen()), int64(s.End-s.Start)
+
+		switch {
+		case s.ID == sectionCustom:
+			sizes.Custom += total
+		case isComponent && (s.ID == componentSectionModule || s.ID == componentSectionNested):
+			sizes.Other += header
+			if err := walkSections(s.Body, sizes); err != nil {
+				return err
+			}
+		case !isComponent && s.ID == moduleSectionCode:
+			sizes.Code += total
+		case !isComponent && s.ID == moduleSectionData:
+			sizes.Data += total
+		default:
+			sizes.Other += total
+		}
+	}
+
+	return nil
+}
+
+// sizeUnits maps suffixes to multipliers. SI units are powers of 1000 and
+// IEC units powers of 1024.
+var sizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"KIB", 1 << 10},
+	{"MIB", 1 << 20},
+	{"GIB", 1 << 30},
+	{"KB", 1000},
+	{"MB", 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"B", 1},
+}
+
+// parseSize accepts a byte count such as "524288" or "1.5MB" or "512KiB"
+func parseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("cannot parse size %q", s)
+	}
+
+	return int64(number * float64(multiplier)), nil
+}
+
+// readBaselineSize reads the size recorded in a previous report
+func readBaselineSize(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	var previous SizeReport
+	if err := json.Unmarshal(data, &previous); err != nil {
+		return 0, err
+	}
+	if previous.Size <= 0 {
+		return 0, fmt.Errorf("%s does not record a size", path)
+	}
+
+	return previous.Size, nil
+}
@@ -0,0 +1,253 @@
+// Package wasmbinary decodes the parts of the WebAssembly binary format the
+// tools share: LEB128 integers, the section framing of core modules and
+// components, custom section names, and the primitive encodings found in
+// import and export sections.
+package wasmbinary
+
+import (
+	"errors"
+	"fmt"
+)
+
+// HeaderSize is the length of the preamble before the first section
+const HeaderSize = 8
+
+// SectionCustom is the id of custom sections, in modules and components alike
+const SectionCustom = 0
+
+// CheckMagic reports whether data starts with a WASM preamble. Use
+// wasmheader.Classify to also check the version and layer.
+func CheckMagic(data []byte) error {
+	if len(data) < HeaderSize || string(data[:4]) != "\x00asm" {
+		return errors.New("not a WASM binary (bad magic)")
+	}
+	return nil
+}
+
+// IsComponent reports whether a preamble belongs to a component. Version 1
+// is a core module; the component layer sets the high bytes.
+func IsComponent(data []byte) bool {
+	return data[6] != 0 || data[7] != 0
+}
+
+// Section is one top-level section of a module or component
+type Section struct {
+	ID    byte
+	Start int    // Offset of the id byte
+	End   int    // Offset just past the body
+	Body  []byte // Contents after the id and size
+}
+
+// HeaderLen is the size of the id byte and the LEB128 length
+func (s Section) HeaderLen() int {
+	return s.End - s.Start - len(s.Body)
+}
+
+// Sections splits a module or component into its top-level sections. The
+// preamble is not checked; see CheckMagic.
+func Sections(data []byte) ([]Section, error) {
+	var sections []Section
+	err := walk(data, func(s Section) error {
+		sections = append(sections, s)
+		return nil
+	})
+	return sections, err
+}
+
+// Walk calls fn with the id and body of each top-level section, stopping at
+// the first error
+func Walk(data []byte, fn func(id byte, body []byte) error) error {
+	return walk(data, func(s Section) error {
+		return fn(s.ID, s.Body)
+	})
+}
+
+func walk(data []byte, fn func(Section) error) error {
+	offset := HeaderSize
+	for offset < len(data) {
+		id := data[offset]
+		size, n, err := ReadULEB128(data[offset+1:])
+		if err != nil {
+			return fmt.Errorf("section at offset %d: %w", offset, err)
+		}
+
+		start := offset + 1 + n
+		end := start + int(size)
+		if end > len(data) || end < start {
+			return fmt.Errorf("section %d at offset %d overruns file", id, offset)
+		}
+
+		if err := fn(Section{ID: id, Start: offset, End: end, Body: data[start:end]}); err != nil {
+			return err
+		}
+		offset = end
+	}
+	return nil
+}
+
+// CustomName splits a custom section body into its name and payload. ok is
+// false when the name is malformed.
+func CustomName(body []byte) (name string, payload []byte, ok bool) {
+	length, n, err := ReadULEB128(body)
+	if err != nil || uint64(len(body)-n) < length {
+		return "", nil, false
+	}
+	end := n + int(length)
+	return string(body[n:end]), body[end:], true
+}
+
+// AppendCustomSection appends a custom section holding name and payload.
+// Custom sections may appear anywhere, so appending keeps modules and
+// components valid.
+func AppendCustomSection(buf []byte, name string, payload []byte) []byte {
+	body := AppendULEB128(nil, uint64(len(name)))
+	body = append(body, name...)
+	body = append(body, payload...)
+
+	buf = append(buf, SectionCustom)
+	buf = AppendULEB128(buf, uint64(len(body)))
+	return append(buf, body...)
+}
+
+// ReadULEB128 decodes an unsigned LEB128 value of at most 64 bits,
+// returning it and the number of bytes read
+func ReadULEB128(data []byte) (uint64, int, error) {
+	var result uint64
+	var shift uint
+	for i, b := range data {
+		if i >= 10 {
+			break
+		}
+		result |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return result, i + 1, nil
+		}
+		shift += 7
+	}
+	return 0, 0, errors.New("truncated LEB128")
+}
+
+// AppendULEB128 appends the shortest LEB128 encoding of value
+func AppendULEB128(buf []byte, value uint64) []byte {
+	for {
+		b := byte(value & 0x7f)
+		value >>= 7
+		if value != 0 {
+			buf = append(buf, b|0x80)
+			continue
+		}
+		return append(buf, b)
+	}
+}
+
+// Reader decodes the primitive encodings inside a section, remembering the
+// first error so callers can check once at the end. Reads after an error
+// return zero values.
+type Reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// NewReader returns a Reader over a section body
+func NewReader(data []byte) *Reader {
+	return &Reader{data: data}
+}
+
+// Err returns the first error encountered
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Fail records err unless an earlier error is already recorded, for
+// encodings the caller decodes itself
+func (r *Reader) Fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+// Byte reads one byte
+func (r *Reader) Byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.data) {
+		r.err = errors.New("unexpected end of section")
+		return 0
+	}
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+// ULEB reads an unsigned LEB128 value
+func (r *Reader) ULEB() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	value, n, err := ReadULEB128(r.data[r.pos:])
+	if err != nil {
+		r.err = err
+		return 0
+	}
+	r.pos += n
+	return value
+}
+
+// Name reads a length-prefixed UTF-8 name
+func (r *Reader) Name() string {
+	length := r.ULEB()
+	if r.err != nil {
+		return ""
+	}
+	if uint64(len(r.data)-r.pos) < length {
+		r.err = errors.New("name overruns section")
+		return ""
+	}
+	name := string(r.data[r.pos : r.pos+int(length)])
+	r.pos += int(length)
+	return name
+}
+
+// ExternDesc skips a component extern descriptor. Value types are a single
+// primitive byte or a type index, which is all an export ascription holds.
+func (r *Reader) ExternDesc() {
+	switch r.Byte() {
+	case 0x00: // Core module
+		r.Byte()
+		r.ULEB()
+	case 0x02: // Value
+		if r.Byte() == 0x00 {
+			r.ULEB()
+		} else {
+			r.ValType()
+		}
+	case 0x03: // Type
+		if r.Byte() == 0x00 {
+			r.ULEB()
+		}
+	default: // Func, component or instance type index
+		r.ULEB()
+	}
+}
+
+// ValType skips a component value type: a primitive byte or a type index
+func (r *Reader) ValType() {
+	if r.err == nil && r.pos < len(r.data) && r.data[r.pos] >= 0x73 {
+		r.Byte() // Primitive
+		return
+	}
+	r.ULEB() // Type index
+}
+
+// Limits skips core table or memory limits: a flags byte, the minimum and,
+// when bit 0 is set, the maximum
+func (r *Reader) Limits() {
+	if r.Byte()&0x01 != 0 {
+		r.ULEB()
+		r.ULEB()
+		return
+	}
+	r.ULEB()
+}
@@ -0,0 +1,226 @@
+package wasmbinary
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// emptyComponent is the smallest valid component: the preamble and no sections
+var emptyComponent = []byte("\x00asm\x0d\x00\x01\x00")
+
+// rawSection encodes a section with a size that may need several LEB128 bytes
+func rawSection(id byte, body []byte) []byte {
+	return append(AppendULEB128([]byte{id}, uint64(len(body))), body...)
+}
+
+func TestULEB128RoundTrip(t *testing.T) {
+	for _, value := range []uint64{0, 1, 127, 128, 300, 1 << 21, 1<<63 + 5, ^uint64(0)} {
+		encoded := AppendULEB128(nil, value)
+		got, n, err := ReadULEB128(encoded)
+		if err != nil || got != value || n != len(encoded) {
+			t.Errorf("round trip of %d = %d, %d, %v (encoded as %d bytes)", value, got, n, err, len(encoded))
+		}
+	}
+
+	// Trailing bytes after the value are left for the caller
+	if got, n, err := ReadULEB128([]byte{0xe5, 0x8e, 0x26, 0xff}); got != 624485 || n != 3 || err != nil {
+		t.Errorf("ReadULEB128 = %d, %d, %v, want 624485, 3", got, n, err)
+	}
+
+	for _, data := range [][]byte{nil, {0x80, 0x80}, bytes.Repeat([]byte{0xff}, 11)} {
+		if _, _, err := ReadULEB128(data); err == nil || !strings.Contains(err.Error(), "truncated LEB128") {
+			t.Errorf("ReadULEB128(% x) error = %v, want truncated LEB128", data, err)
+		}
+	}
+}
+
+func TestCheckMagic(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		wantErr   bool
+		component bool
+	}{
+		{name: "core module", data: []byte("\x00asm\x01\x00\x00\x00")},
+		{name: "component", data: emptyComponent, component: true},
+		{name: "text file", data: []byte("package example:demo;\n"), wantErr: true},
+		{name: "truncated header", data: []byte("\x00asm\x01"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckMagic(tt.data)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "bad magic") {
+					t.Fatalf("CheckMagic() error = %v, want bad magic", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckMagic() error = %v", err)
+			}
+			if IsComponent(tt.data) != tt.component {
+				t.Errorf("IsComponent() = %v, want %v", !tt.component, tt.component)
+			}
+		})
+	}
+}
+
+func TestSections(t *testing.T) {
+	large := bytes.Repeat([]byte{0x40}, 200) // Needs a two-byte size
+	data := append([]byte{}, emptyComponent...)
+	data = append(data, rawSection(1, []byte("\x00asm\x01\x00\x00\x00"))...)
+	data = append(data, rawSection(7, large)...)
+	data = AppendCustomSection(data, "producers", []byte("payload"))
+
+	sections, err := Sections(data)
+	if err != nil {
+		t.Fatalf("Sections: %v", err)
+	}
+	if len(sections) != 3 {
+		t.Fatalf("got %d sections, want 3", len(sections))
+	}
+
+	want := []struct {
+		id     byte
+		start  int
+		header int
+	}{{1, 8, 2}, {7, 18, 3}, {SectionCustom, 221, 2}}
+	for i, s := range sections {
+		if s.ID != want[i].id || s.Start != want[i].start || s.HeaderLen() != want[i].header {
+			t.Errorf("section %d = id %d at %d with a %d-byte header, want id %d at %d with %d", i, s.ID, s.Start, s.HeaderLen(), want[i].id, want[i].start, want[i].header)
+		}
+		if !bytes.Equal(data[s.End-len(s.Body):s.End], s.Body) {
+			t.Errorf("section %d body is not the bytes before End", i)
+		}
+	}
+	if sections[2].End != len(data) {
+		t.Errorf("last section ends at %d, want %d", sections[2].End, len(data))
+	}
+
+	name, payload, ok := CustomName(sections[2].Body)
+	if !ok || name != "producers" || string(payload) != "payload" {
+		t.Errorf("CustomName = %q, %q, %v, want producers and its payload", name, payload, ok)
+	}
+
+	var ids []byte
+	if err := Walk(data, func(id byte, body []byte) error {
+		ids = append(ids, id)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if !reflect.DeepEqual(ids, []byte{1, 7, SectionCustom}) {
+		t.Errorf("Walk visited %v", ids)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	if err := Walk(data, func(byte, []byte) error { calls++; return stop }); err != stop || calls != 1 {
+		t.Errorf("Walk returned %v after %d calls, want the callback's error after 1", err, calls)
+	}
+}
+
+func TestSectionsMalformed(t *testing.T) {
+	valid := append(append([]byte{}, emptyComponent...), rawSection(7, []byte("abcdef"))...)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "truncated body", data: valid[:len(valid)-3], wantErr: "section 7 at offset 8 overruns file"},
+		{name: "truncated size", data: append(append([]byte{}, emptyComponent...), 0x01, 0x80), wantErr: "section at offset 8: truncated LEB128"},
+		{name: "size wraps", data: append(append([]byte{}, emptyComponent...), AppendULEB128([]byte{0x01}, ^uint64(0))...), wantErr: "overruns file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Sections(tt.data); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Sections() error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCustomNameMalformed(t *testing.T) {
+	for _, body := range [][]byte{nil, {0x80}, {0x09, 'a'}} {
+		if _, _, ok := CustomName(body); ok {
+			t.Errorf("CustomName(% x) accepted a malformed name", body)
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	// A component export with a func type ascription, followed by a core
+	// module descriptor, a value descriptor and memory limits
+	data := "\x01\x00\x03run" + "\x01\x00\x01" + "\x01\x00" + "\x00\x11\x03" + "\x02\x01\x7f" + "\x01\x02\x10"
+	r := NewReader([]byte(data))
+	if r.ULEB() != 1 || r.Byte() != 0x00 || r.Name() != "run" {
+		t.Fatal("failed to read the export count, name kind and name")
+	}
+	r.Byte() // Sort
+	r.ULEB() // Index
+	if r.Byte() != 0x01 {
+		t.Fatal("missing type ascription")
+	}
+	r.ExternDesc() // Func type index 0
+	r.ExternDesc() // Core module type 3
+	r.ExternDesc() // Value of primitive type
+	r.Limits()     // Flags 0x01 with a minimum and maximum
+	if err := r.Err(); err != nil {
+		t.Fatalf("Err() = %v", err)
+	}
+
+	// Reads past the end record the first error and return zero values
+	if r.Byte() != 0 || r.Name() != "" {
+		t.Error("reads past the end returned data")
+	}
+	if err := r.Err(); err == nil || err.Error() != "unexpected end of section" {
+		t.Errorf("Err() = %v, want unexpected end of section", err)
+	}
+	r.Fail(errors.New("later"))
+	if r.Err().Error() != "unexpected end of section" {
+		t.Error("Fail replaced the first error")
+	}
+
+	r = NewReader([]byte("\x05ab"))
+	if r.Name() != "" || r.Err() == nil || r.Err().Error() != "name overruns section" {
+		t.Errorf("Name() error = %v, want name overruns section", r.Err())
+	}
+
+	r = NewReader([]byte{0x80})
+	if r.ULEB() != 0 || r.Err() == nil || !strings.Contains(r.Err().Error(), "truncated LEB128") {
+		t.Errorf("ULEB() error = %v, want truncated LEB128", r.Err())
+	}
+
+	r = NewReader(nil)
+	r.Fail(errors.New("unknown import kind"))
+	if r.Err() == nil || r.Err().Error() != "unknown import kind" {
+		t.Errorf("Err() = %v, want the recorded failure", r.Err())
+	}
+}
+
+func TestReaderValType(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		read int
+	}{
+		{name: "primitive", data: []byte{0x7f, 0x00}, read: 1},
+		{name: "type index", data: []byte{0x05, 0x00}, read: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(tt.data)
+			r.ValType()
+			if r.Err() != nil || r.pos != tt.read {
+				t.Errorf("ValType() read %d bytes with error %v, want %d", r.pos, r.Err(), tt.read)
+			}
+		})
+	}
+}
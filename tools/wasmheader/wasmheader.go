@@ -0,0 +1,43 @@
+// Package wasmheader tells WebAssembly core modules and components apart by
+// their 8-byte preamble, without parsing any sections.
+package wasmheader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Kinds returned by Classify
+const (
+	Module    = "module"
+	Component = "component"
+)
+
+// Classify reads the 8-byte preamble of a WASM binary: the \0asm magic, a
+// 16-bit version and a 16-bit layer, both little-endian. Layer 0 with
+// version 1 is a core module; layer 1 is a component, whose version is the
+// component encoding revision (0x0d at the time of writing).
+func Classify(r io.Reader) (kind string, version uint32, err error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return "", 0, errors.New("not a WASM binary (too short)")
+		}
+		return "", 0, err
+	}
+	if string(header[:4]) != "\x00asm" {
+		return "", 0, errors.New("not a WASM binary (bad magic)")
+	}
+
+	version = uint32(binary.LittleEndian.Uint16(header[4:6]))
+	switch layer := binary.LittleEndian.Uint16(header[6:8]); {
+	case layer == 0 && version == 1:
+		return Module, version, nil
+	case layer == 1:
+		return Component, version, nil
+	default:
+		return "", 0, fmt.Errorf("unsupported WASM version %d, layer %d", version, layer)
+	}
+}
@@ -0,0 +1,53 @@
+package wasmheader
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// adapterPath is a real core module checked into the repository
+const adapterPath = "../../wasm/adapters/wasi_snapshot_preview1.reactor.wasm"
+
+func TestClassify(t *testing.T) {
+	adapter, err := os.ReadFile(adapterPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		data        []byte
+		wantKind    string
+		wantVersion uint32
+		wantErr     string
+	}{
+		{name: "core module", data: adapter, wantKind: Module, wantVersion: 1},
+		// The smallest valid component: the preamble and no sections
+		{name: "component", data: []byte("\x00asm\x0d\x00\x01\x00"), wantKind: Component, wantVersion: 0x0d},
+		{name: "text file", data: []byte("package example:demo;\n"), wantErr: "bad magic"},
+		{name: "empty file", data: nil, wantErr: "too short"},
+		{name: "truncated header", data: []byte("\x00asm\x01"), wantErr: "too short"},
+		{name: "unknown layer", data: []byte("\x00asm\x01\x00\x02\x00"), wantErr: "unsupported"},
+		{name: "module version 2", data: []byte("\x00asm\x02\x00\x00\x00"), wantErr: "unsupported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, version, err := Classify(bytes.NewReader(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Classify() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Classify() error = %v", err)
+			}
+			if kind != tt.wantKind || version != tt.wantVersion {
+				t.Errorf("Classify() = %s %d, want %s %d", kind, version, tt.wantKind, tt.wantVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+)
+
+// BatchJob is a single sign or verify operation in a batch config
+type BatchJob struct {
+	Operation string `json:"operation"` // "sign" or "verify"
+	Input     string `json:"input"`
+	Output    string `json:"output,omitempty"` // sign only
+	Key       string `json:"key"`              // Secret key for sign, public key for verify
+	Detached  bool   `json:"detached,omitempty"`
+	Signature string `json:"signature,omitempty"` // Detached signature file
+}
+
+// BatchConfig lists the jobs run by `batch <config.json>`
+type BatchConfig struct {
+	Jobs []BatchJob `json:"jobs"`
+}
+
+// BatchJobResult is the outcome of one batch job
+type BatchJobResult struct {
+	VerificationResult
+	Output string `json:"output_file,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchReport collects the results of every job in a batch
+type BatchReport struct {
+	Jobs   []BatchJobResult `json:"jobs"`
+	Passed int              `json:"passed"`
+	Failed int              `json:"failed"`
+}
+
+// args builds the wsc command line for the job
+func (j BatchJob) args() (string, []string, error) {
+	if j.Input == "" || j.Key == "" {
+		return "", nil, fmt.Errorf("job requires input and key")
+	}
+	if j.Detached && j.Signature == "" {
+		return "", nil, fmt.Errorf("detached job requires signature")
+	}
+
+	switch j.Operation {
+	case "sign":
+		if j.Output == "" {
+			return "", nil, fmt.Errorf("sign job requires output")
+		}
+		args := []string{"-i", j.Input, "-o", j.Output, "-k", j.Key}
+		if j.Detached {
+			args = append(args, "-S", j.Signature)
+		}
+		return "sign", args, nil
+
+	case "verify":
+		args := []string{"-i", j.Input, "-K", j.Key}
+		if j.Detached {
+			args = append(args, "-S", j.Signature)
+		}
+		return "verify", args, nil
+
+	default:
+		return "", nil, fmt.Errorf("unknown operation %q", j.Operation)
+	}
+}
+
+// runBatch executes every job in the config and returns the process exit
+// code. wsc handles one operation per process, so each job still gets its own
+// wasmtime run, but paths are resolved once and every run shares one set of
+// directory mappings.
+func runBatch(configPath, wasmtimeBinary, wasmsign2Wasm string, wasiEnv []string, resultJSON string) int {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		log.Fatalf("Failed to read batch config %s: %v", configPath, err)
+	}
+
+	var config BatchConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		log.Fatalf("Failed to parse batch config %s: %v", configPath, err)
+	}
+
+	type preparedJob struct {
+		command string
+		args    []string
+		err     error
+	}
+
+	// Resolve every job's paths up front and collect one set of mappings
+	prepared := make([]preparedJob, len(config.Jobs))
+	var allDirs []string
+	for i, job := range config.Jobs {
+		command, args, err := job.args()
+		if err == nil {
+			var dirs []string
+			args, dirs, err = resolvePathsInArgs(command, args)
+			allDirs = append(allDirs, dirs...)
+		}
+		prepared[i] = preparedJob{command, args, err}
+	}
+	dirs := uniqueStrings(allDirs)
+
+	report := BatchReport{Jobs: make([]BatchJobResult, 0, len(config.Jobs))}
+	for i, job := range prepared {
+		result := BatchJobResult{Output: config.Jobs[i].Output}
+
+		if job.err != nil {
+			result.VerificationResult = VerificationResult{
+				Command:   config.Jobs[i].Operation,
+				InputFile: config.Jobs[i].Input,
+				Status:    "failed",
+				ExitCode:  -1,
+			}
+			result.Error = job.err.Error()
+		} else {
+			cmd := wasmtimeCommand(wasmtimeBinary, wasmsign2Wasm, dirs, wasiEnv, job.command, job.args)
+			cmd.Stdout = os.Stderr // Keep stdout free for the report
+			cmd.Stderr = os.Stderr
+
+			exitCode := 0
+			if err := cmd.Run(); err != nil {
+				exitErr, ok := err.(*exec.ExitError)
+				if !ok {
+					log.Fatalf("Failed to execute wasmtime: %v", err)
+				}
+				exitCode = exitErr.ExitCode()
+			}
+			result.VerificationResult = newVerificationResult(job.command, job.args, exitCode)
+		}
+
+		if result.Status == "passed" {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Jobs = append(report.Jobs, result)
+	}
+
+	output, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		log.Fatalf("Failed to encode batch report: %v", err)
+	}
+	if resultJSON != "" {
+		if err := os.WriteFile(resultJSON, append(output, '\n'), 0644); err != nil {
+			log.Fatalf("Failed to write result JSON: %v", err)
+		}
+	} else {
+		fmt.Println(string(output))
+	}
+
+	if report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// CapturedRun is written next to the --capture-output log so a failed
+// component run can be inspected after its live output has scrolled away
+type CapturedRun struct {
+	Command  string `json:"command"`
+	ExitCode int    `json:"exit_code"`
+	Status   string `json:"status"` // "passed" or "failed"
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	LogFile  string `json:"log_file"`
+}
+
+// outputCapture tees a child's stdout and stderr into one interleaved log
+// file and per-stream buffers while they are still forwarded as usual
+type outputCapture struct {
+	mu     sync.Mutex
+	path   string
+	log    *os.File
+	stdout bytes.Buffer
+	stderr bytes.Buffer
+}
+
+func newOutputCapture(path string) (*outputCapture, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &outputCapture{path: path, log: file}, nil
+}
+
+// captureWriter records one stream into the shared log and its own buffer
+type captureWriter struct {
+	capture *outputCapture
+	buf     *bytes.Buffer
+}
+
+func (w captureWriter) Write(p []byte) (int, error) {
+	w.capture.mu.Lock()
+	defer w.capture.mu.Unlock()
+
+	w.buf.Write(p)
+	return w.capture.log.Write(p)
+}
+
+// stdoutTo and stderrTo wrap the writers a stream would normally go to
+func (c *outputCapture) stdoutTo(w io.Writer) io.Writer {
+	return io.MultiWriter(w, captureWriter{c, &c.stdout})
+}
+
+func (c *outputCapture) stderrTo(w io.Writer) io.Writer {
+	return io.MultiWriter(w, captureWriter{c, &c.stderr})
+}
+
+// finish closes the log and writes the CapturedRun to <log>.json
+func (c *outputCapture) finish(command string, exitCode int) error {
+	if err := c.log.Close(); err != nil {
+		return err
+	}
+
+	status := "passed"
+	if exitCode != 0 {
+		status = "failed"
+	}
+
+	data, err := json.MarshalIndent(CapturedRun{
+		Command:  command,
+		ExitCode: exitCode,
+		Status:   status,
+		Stdout:   c.stdout.String(),
+		Stderr:   c.stderr.String(),
+		LogFile:  c.path,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path+".json", append(data, '\n'), 0644)
+}
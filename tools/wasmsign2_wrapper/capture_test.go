@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestMain lets the test binary stand in for wasmtime: with
+// CAPTURE_TEST_CHILD set it writes to both streams and exits with code 3
+func TestMain(m *testing.M) {
+	if os.Getenv("CAPTURE_TEST_CHILD") == "1" {
+		fmt.Fprint(os.Stdout, "signing component\n")
+		fmt.Fprint(os.Stderr, "error: key not found\n")
+		fmt.Fprint(os.Stdout, "done\n")
+		os.Exit(3)
+	}
+	os.Exit(m.Run())
+}
+
+func readCapturedRun(t *testing.T, path string) CapturedRun {
+	t.Helper()
+	data, err := os.ReadFile(path + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var run CapturedRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		t.Fatalf("parsing %s.json: %v", path, err)
+	}
+	return run
+}
+
+// TestOutputCaptureFailedRun runs a child that fails and checks its output
+// is still forwarded, logged interleaved, and split by stream in the JSON
+func TestOutputCaptureFailedRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wsc.log")
+	capture, err := newOutputCapture(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var forwardedOut, forwardedErr bytes.Buffer
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "CAPTURE_TEST_CHILD=1")
+	cmd.Stdout = capture.stdoutTo(&forwardedOut)
+	cmd.Stderr = capture.stderrTo(&forwardedErr)
+
+	exitCode := 0
+	var exitErr *exec.ExitError
+	if err := cmd.Run(); errors.As(err, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != 3 {
+		t.Fatalf("child exit code = %d, want 3", exitCode)
+	}
+
+	if err := capture.finish("verify", exitCode); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+
+	if got, want := forwardedOut.String(), "signing component\ndone\n"; got != want {
+		t.Errorf("forwarded stdout = %q, want %q", got, want)
+	}
+	if got, want := forwardedErr.String(), "error: key not found\n"; got != want {
+		t.Errorf("forwarded stderr = %q, want %q", got, want)
+	}
+
+	logged, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != forwardedOut.Len()+forwardedErr.Len() {
+		t.Errorf("log has %d bytes, want both streams' %d", len(logged), forwardedOut.Len()+forwardedErr.Len())
+	}
+
+	want := CapturedRun{
+		Command:  "verify",
+		ExitCode: 3,
+		Status:   "failed",
+		Stdout:   "signing component\ndone\n",
+		Stderr:   "error: key not found\n",
+		LogFile:  path,
+	}
+	if got := readCapturedRun(t, path); got != want {
+		t.Errorf("captured run = %+v, want %+v", got, want)
+	}
+}
+
+// TestOutputCaptureInterleaves checks the log keeps the order writes
+// arrived in across both streams
+func TestOutputCaptureInterleaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wsc.log")
+	capture, err := newOutputCapture(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var discard bytes.Buffer
+	stdout, stderr := capture.stdoutTo(&discard), capture.stderrTo(&discard)
+	fmt.Fprint(stdout, "one\n")
+	fmt.Fprint(stderr, "two\n")
+	fmt.Fprint(stdout, "three\n")
+
+	if err := capture.finish("sign", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	logged, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(logged) != "one\ntwo\nthree\n" {
+		t.Errorf("log = %q, want the writes in order", logged)
+	}
+	run := readCapturedRun(t, path)
+	if run.Status != "passed" || run.ExitCode != 0 || run.Stdout != "one\nthree\n" || run.Stderr != "two\n" {
+		t.Errorf("captured run = %+v, want a passed run with split streams", run)
+	}
+}
+
+func TestNewOutputCaptureMissingDirectory(t *testing.T) {
+	if _, err := newOutputCapture(filepath.Join(t.TempDir(), "missing", "wsc.log")); err == nil {
+		t.Error("newOutputCapture succeeded in a missing directory")
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
@@ -29,6 +30,16 @@ func main() {
 	//                                  inheriting this process's stdout. Used
 	//                                  by show-chain to produce a Bazel output
 	//                                  artifact.
+	//   --bazel-result-json=PATH       Write a JSON VerificationResult (input,
+	//                                  key, status, split regex) to PATH,
+	//                                  on failure as well as success.
+	//   --wasi-env KEY, --wasi-env=KEY Forward host environment variable KEY
+	//                                  into the guest. Repeatable; no host
+	//                                  variables are forwarded by default.
+	//   --capture-output PATH,         Also tee wsc's stdout and stderr into
+	//   --capture-output=PATH          PATH, still forwarding them, and write
+	//                                  the exit code and both streams to
+	//                                  PATH.json for inspecting failed runs.
 	//
 	// wasmtime and the wasm component are passed by the calling rule (and staged
 	// as action inputs) rather than located via runfiles: a hardcoded runfiles
@@ -37,19 +48,35 @@ func main() {
 	// `rules_wasm_component+` prefix), breaking downstream signing (issue #501,
 	// same fix as #490/#497). wasmtime opens both files natively.
 	var markerFile string
+	var resultJSON string
 	var stageSource string
 	var captureStdout string
+	var captureOutput string
 	var wasmtimeBinary string
 	var wasmsign2Wasm string
+	var wasiEnv []string
 	filteredArgs := make([]string, 0, len(os.Args))
-	for i, arg := range os.Args {
+	for i := 0; i < len(os.Args); i++ {
+		arg := os.Args[i]
 		switch {
+		case arg == "--wasi-env" && i+1 < len(os.Args):
+			i++
+			wasiEnv = append(wasiEnv, os.Args[i])
+		case strings.HasPrefix(arg, "--wasi-env="):
+			wasiEnv = append(wasiEnv, strings.TrimPrefix(arg, "--wasi-env="))
+		case arg == "--capture-output" && i+1 < len(os.Args):
+			i++
+			captureOutput = os.Args[i]
+		case strings.HasPrefix(arg, "--capture-output="):
+			captureOutput = strings.TrimPrefix(arg, "--capture-output=")
 		case strings.HasPrefix(arg, "--bazel-wasmtime="):
 			wasmtimeBinary = strings.TrimPrefix(arg, "--bazel-wasmtime=")
 		case strings.HasPrefix(arg, "--bazel-wasm-component="):
 			wasmsign2Wasm = strings.TrimPrefix(arg, "--bazel-wasm-component=")
 		case strings.HasPrefix(arg, "--bazel-marker-file="):
 			markerFile = strings.TrimPrefix(arg, "--bazel-marker-file=")
+		case strings.HasPrefix(arg, "--bazel-result-json="):
+			resultJSON = strings.TrimPrefix(arg, "--bazel-result-json=")
 		case strings.HasPrefix(arg, "--bazel-stage-source="):
 			stageSource = strings.TrimPrefix(arg, "--bazel-stage-source=")
 		case strings.HasPrefix(arg, "--bazel-capture-stdout="):
@@ -74,10 +101,21 @@ func main() {
 		log.Fatalf("wasmsign2.wasm not found at %s: %v", wasmsign2Wasm, err)
 	}
 
+	if len(filteredArgs) == 0 {
+		log.Fatal("Usage: wasmsign2_wrapper <command> [args...]")
+	}
+
 	// Parse command
 	command := filteredArgs[0]
 	cmdArgs := filteredArgs[1:]
 
+	if command == "batch" {
+		if len(cmdArgs) != 1 {
+			log.Fatal("Usage: wasmsign2_wrapper batch <config.json>")
+		}
+		os.Exit(runBatch(cmdArgs[0], wasmtimeBinary, wasmsign2Wasm, wasiEnv, resultJSON))
+	}
+
 	// Resolve all file paths in arguments to real paths
 	resolvedArgs, dirs, err := resolvePathsInArgs(command, cmdArgs)
 	if err != nil {
@@ -100,25 +138,8 @@ func main() {
 		}
 	}
 
-	// Build wasmtime command with directory mappings
-	wasmtimeArgs := []string{
-		"run",
-		"-S", "cli",
-		"-S", "http",
-	}
-
-	// Add unique directory mappings
-	uniqueDirs := uniqueStrings(dirs)
-	for _, dir := range uniqueDirs {
-		wasmtimeArgs = append(wasmtimeArgs, "--dir", dir)
-	}
-
-	// Add wasmsign2.wasm and command with resolved arguments
-	wasmtimeArgs = append(wasmtimeArgs, wasmsign2Wasm, command)
-	wasmtimeArgs = append(wasmtimeArgs, resolvedArgs...)
-
 	// Execute wasmtime
-	cmd := exec.Command(wasmtimeBinary, wasmtimeArgs...)
+	cmd := wasmtimeCommand(wasmtimeBinary, wasmsign2Wasm, uniqueStrings(dirs), wasiEnv, command, resolvedArgs)
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
@@ -133,11 +154,40 @@ func main() {
 		cmd.Stdout = os.Stdout
 	}
 
+	var capture *outputCapture
+	if captureOutput != "" {
+		capture, err = newOutputCapture(captureOutput)
+		if err != nil {
+			log.Fatalf("Failed to create output capture file %s: %v", captureOutput, err)
+		}
+		cmd.Stdout = capture.stdoutTo(cmd.Stdout)
+		cmd.Stderr = capture.stderrTo(cmd.Stderr)
+	}
+
+	exitCode := 0
 	if err := cmd.Run(); err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			os.Exit(exitErr.ExitCode())
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			log.Fatalf("Failed to execute wasmtime: %v", err)
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	if capture != nil {
+		if err := capture.finish(command, exitCode); err != nil {
+			log.Fatalf("Failed to write captured output: %v", err)
+		}
+	}
+
+	// Record the outcome before propagating a failure exit code
+	if resultJSON != "" {
+		if err := writeResultJSON(resultJSON, newVerificationResult(command, resolvedArgs, exitCode)); err != nil {
+			log.Fatalf("Failed to write result JSON: %v", err)
 		}
-		log.Fatalf("Failed to execute wasmtime: %v", err)
+	}
+
+	if exitCode != 0 {
+		os.Exit(exitCode)
 	}
 
 	// If marker file was requested, create it on success
@@ -148,6 +198,75 @@ func main() {
 	}
 }
 
+// wasmtimeCommand builds the wasmtime invocation running one wsc command with
+// the given directory mappings
+func wasmtimeCommand(wasmtimeBinary, wasmsign2Wasm string, dirs, wasiEnv []string, command string, resolvedArgs []string) *exec.Cmd {
+	wasmtimeArgs := []string{
+		"run",
+		"-S", "cli",
+		"-S", "http",
+	}
+
+	// Add directory mappings
+	for _, dir := range dirs {
+		wasmtimeArgs = append(wasmtimeArgs, "--dir", dir)
+	}
+
+	// Forward only allowlisted host environment variables into the guest
+	wasmtimeArgs = append(wasmtimeArgs, wasiEnvArgs(wasiEnv)...)
+
+	// Add wasmsign2.wasm and command with resolved arguments
+	wasmtimeArgs = append(wasmtimeArgs, wasmsign2Wasm, command)
+	wasmtimeArgs = append(wasmtimeArgs, resolvedArgs...)
+
+	return exec.Command(wasmtimeBinary, wasmtimeArgs...)
+}
+
+// VerificationResult is the machine-readable outcome of a wsc invocation
+type VerificationResult struct {
+	Command       string `json:"command"`
+	InputFile     string `json:"input_file"`
+	PublicKey     string `json:"public_key,omitempty"`
+	GitHubAccount string `json:"github_account,omitempty"`
+	SignatureFile string `json:"signature_file,omitempty"`
+	SplitRegex    string `json:"split_regex,omitempty"`
+	Status        string `json:"status"` // "passed" or "failed"
+	ExitCode      int    `json:"exit_code"`
+}
+
+// newVerificationResult describes a finished wsc run from its resolved arguments
+func newVerificationResult(command string, args []string, exitCode int) VerificationResult {
+	inputFile := findFlagValue(args, "--input", "-i")
+	if inputFile == "" {
+		inputFile = findFlagValue(args, "--input-file", "")
+	}
+
+	status := "passed"
+	if exitCode != 0 {
+		status = "failed"
+	}
+
+	return VerificationResult{
+		Command:       command,
+		InputFile:     inputFile,
+		PublicKey:     findFlagValue(args, "--public-key", "-K"),
+		GitHubAccount: findFlagValue(args, "--from-github", "-G"),
+		SignatureFile: findFlagValue(args, "--signature", "-S"),
+		SplitRegex:    findFlagValue(args, "--split", "-s"),
+		Status:        status,
+		ExitCode:      exitCode,
+	}
+}
+
+// writeResultJSON writes result as indented JSON to path
+func writeResultJSON(path string, result VerificationResult) error {
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
 // resolvePathsInArgs resolves file paths in command arguments
 // Returns resolved arguments and list of directories to map
 func resolvePathsInArgs(command string, args []string) ([]string, []string, error) {
@@ -259,6 +378,19 @@ func findFlagValue(args []string, longFlag, shortFlag string) string {
 	return ""
 }
 
+// wasiEnvArgs builds wasmtime --env arguments for the allowlisted host
+// environment variables. Variables unset on the host are skipped so the guest
+// never sees an empty placeholder.
+func wasiEnvArgs(keys []string) []string {
+	args := make([]string, 0, 2*len(keys))
+	for _, key := range uniqueStrings(keys) {
+		if value, ok := os.LookupEnv(key); ok {
+			args = append(args, "--env", key+"="+value)
+		}
+	}
+	return args
+}
+
 // uniqueStrings returns unique strings from a slice
 func uniqueStrings(strs []string) []string {
 	seen := make(map[string]bool)
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWasiEnvArgsForwardsOnlyAllowlisted(t *testing.T) {
+	t.Setenv("WASMSIGN2_KEY_ID", "release")
+	t.Setenv("WASMSIGN2_EMPTY", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "do-not-forward")
+	t.Setenv("GITHUB_TOKEN", "do-not-forward")
+
+	tests := []struct {
+		name string
+		keys []string
+		want []string
+	}{
+		{name: "nothing by default", keys: nil, want: []string{}},
+		{name: "one key", keys: []string{"WASMSIGN2_KEY_ID"}, want: []string{"--env", "WASMSIGN2_KEY_ID=release"}},
+		{name: "empty value is still set", keys: []string{"WASMSIGN2_EMPTY"}, want: []string{"--env", "WASMSIGN2_EMPTY="}},
+		{name: "unset key is skipped", keys: []string{"WASMSIGN2_UNSET"}, want: []string{}},
+		{
+			name: "duplicates forwarded once",
+			keys: []string{"WASMSIGN2_KEY_ID", "WASMSIGN2_EMPTY", "WASMSIGN2_KEY_ID"},
+			want: []string{"--env", "WASMSIGN2_KEY_ID=release", "--env", "WASMSIGN2_EMPTY="},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wasiEnvArgs(tt.keys); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wasiEnvArgs(%q) = %q, want %q", tt.keys, got, tt.want)
+			}
+		})
+	}
+}
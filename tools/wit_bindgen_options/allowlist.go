@@ -0,0 +1,66 @@
+package main
+
+// OptionSpec describes one wit-bindgen flag
+type OptionSpec struct {
+	Value      bool   `json:"value,omitempty"`      // Takes an argument, as --flag=v or --flag v
+	Repeatable bool   `json:"repeatable,omitempty"` // May be given more than once
+	Managed    string `json:"managed,omitempty"`    // Attribute that already sets it, or "rule" if always set
+}
+
+// defaultAllowlist holds the flags accepted by each wit_bindgen language,
+// keyed by the long flag name. Rust and C are the `wit-bindgen <language>`
+// subcommands; Go is `wit-bindgen-go generate`. Update this table when the
+// pinned generators gain or drop flags, or pass --allowlist to override it.
+var defaultAllowlist = map[string]map[string]OptionSpec{
+	"rust": {
+		"--world":                               {Value: true, Managed: "wit"},
+		"--out-dir":                             {Value: true, Managed: "rule"},
+		"--check":                               {},
+		"--format":                              {Managed: "format_code"},
+		"--with":                                {Value: true, Repeatable: true, Managed: "with_mappings"},
+		"--generate-all":                        {Managed: "generate_all"},
+		"--ownership":                           {Value: true, Managed: "ownership"},
+		"--additional-derive-attributes":        {Value: true, Repeatable: true, Managed: "additional_derives"},
+		"--async":                               {Value: true, Repeatable: true, Managed: "async_interfaces"},
+		"--runtime-path":                        {Value: true, Managed: "generation_mode"},
+		"--pub-export-macro":                    {Managed: "generation_mode"},
+		"--std-feature":                         {},
+		"--raw-strings":                         {},
+		"--skip":                                {Value: true, Repeatable: true},
+		"--stubs":                               {},
+		"--export-prefix":                       {Value: true},
+		"--bitflags-path":                       {Value: true},
+		"--additional-derive-ignore":            {Value: true, Repeatable: true},
+		"--type-section-suffix":                 {Value: true},
+		"--disable-run-ctors-once-workaround":   {},
+		"--default-bindings-module":             {Value: true},
+		"--export-macro-name":                   {Value: true},
+		"--generate-unused-types":               {},
+		"--disable-custom-section-link-helpers": {},
+		"--merge-structurally-equal-types":      {},
+		"--enable-method-chaining":              {},
+	},
+	"c": {
+		"--world":               {Value: true, Managed: "wit"},
+		"--out-dir":             {Value: true, Managed: "rule"},
+		"--check":               {},
+		"--with":                {Value: true, Repeatable: true, Managed: "with_mappings"},
+		"--generate-all":        {Managed: "generate_all"},
+		"--async":               {Value: true, Repeatable: true, Managed: "async_interfaces"},
+		"--no-helpers":          {},
+		"--string-encoding":     {Value: true},
+		"--no-sig-flattening":   {},
+		"--no-object-file":      {},
+		"--rename":              {Value: true, Repeatable: true},
+		"--rename-world":        {Value: true},
+		"--type-section-suffix": {Value: true},
+		"--autodrop-borrows":    {Value: true},
+	},
+	"go": {
+		"--world":        {Value: true, Managed: "wit"},
+		"--out":          {Value: true, Managed: "rule"},
+		"--package-root": {Value: true},
+		"--versioned":    {},
+		"--cm":           {Value: true},
+	},
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// InvalidOption is one problem found in the requested options
+type InvalidOption struct {
+	Option string `json:"option"`
+	Reason string `json:"reason"`
+}
+
+// ValidationResult is the JSON report for a set of wit_bindgen options
+type ValidationResult struct {
+	Language string          `json:"language"`
+	Options  []string        `json:"options"`
+	Valid    bool            `json:"valid"`
+	Invalid  []InvalidOption `json:"invalid"`
+}
+
+// Checks the freeform `options` of a wit_bindgen target against the flags
+// its generator accepts, so a typo is reported by name instead of as an
+// opaque generator failure. Options follow the flags, after `--`:
+//
+//	wit_bindgen_options --language=rust -- --stubs --skip=foo
+func main() {
+	var (
+		language      = flag.String("language", "", "wit_bindgen language (rust, c or go)")
+		allowlistPath = flag.String("allowlist", "", "JSON allowlist replacing the built-in one, keyed by language then flag")
+	)
+	flag.Parse()
+
+	if *language == "" {
+		fmt.Fprintf(os.Stderr, "Usage: %s --language <rust|c|go> [--allowlist <file.json>] -- <options...>\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	allowlist := defaultAllowlist
+	if *allowlistPath != "" {
+		var err error
+		allowlist, err = loadAllowlist(*allowlistPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading allowlist: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	specs, ok := allowlist[*language]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: no allowlist for language %q (have %s)\n", *language, strings.Join(languages(allowlist), ", "))
+		os.Exit(1)
+	}
+
+	result := validateOptions(*language, flag.Args(), specs)
+
+	output, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(output))
+
+	if !result.Valid {
+		os.Exit(1)
+	}
+}
+
+func loadAllowlist(path string) (map[string]map[string]OptionSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var allowlist map[string]map[string]OptionSpec
+	if err := json.Unmarshal(data, &allowlist); err != nil {
+		return nil, err
+	}
+	return allowlist, nil
+}
+
+func languages(allowlist map[string]map[string]OptionSpec) []string {
+	var names []string
+	for name := range allowlist {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateOptions reports unknown flags, flags the rule already sets from an
+// attribute, repeats of single-use flags and flags missing their value.
+// Bare arguments are only accepted as the value of the preceding flag.
+func validateOptions(language string, options []string, specs map[string]OptionSpec) ValidationResult {
+	result := ValidationResult{
+		Language: language,
+		Options:  options,
+		Invalid:  []InvalidOption{},
+	}
+	if result.Options == nil {
+		result.Options = []string{}
+	}
+
+	invalid := func(option, reason string) {
+		result.Invalid = append(result.Invalid, InvalidOption{Option: option, Reason: reason})
+	}
+
+	seen := make(map[string]bool)
+	for i := 0; i < len(options); i++ {
+		option := options[i]
+		if !strings.HasPrefix(option, "--") {
+			invalid(option, "not a flag; positional arguments are supplied by the rule")
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(option, "=")
+		spec, ok := specs[name]
+		if !ok {
+			reason := "unknown flag for " + language
+			if suggestion := closestFlag(name, specs); suggestion != "" {
+				reason += "; did you mean " + suggestion + "?"
+			}
+			invalid(option, reason)
+			continue
+		}
+
+		// Consume a separate value so it is not mistaken for a positional
+		if spec.Value && !hasValue {
+			if i+1 >= len(options) || strings.HasPrefix(options[i+1], "--") {
+				invalid(option, "requires a value")
+				continue
+			}
+			i++
+		}
+		if !spec.Value && hasValue {
+			invalid(option, "does not take a value")
+			continue
+		}
+
+		if spec.Managed == "rule" {
+			invalid(option, "conflicts with the wit_bindgen rule, which always sets it")
+			continue
+		}
+		if spec.Managed != "" {
+			invalid(option, fmt.Sprintf("conflicts with the %s attribute, which already sets it", spec.Managed))
+			continue
+		}
+		if seen[name] && !spec.Repeatable {
+			invalid(option, "given more than once")
+			continue
+		}
+		seen[name] = true
+	}
+
+	result.Valid = len(result.Invalid) == 0
+	return result
+}
+
+// closestFlag suggests a known flag within two edits of name, if any
+func closestFlag(name string, specs map[string]OptionSpec) string {
+	best, bestDistance := "", 3
+	for _, candidate := range sortedFlags(specs) {
+		if d := editDistance(name, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+func sortedFlags(specs map[string]OptionSpec) []string {
+	var flags []string
+	for flag := range specs {
+		flags = append(flags, flag)
+	}
+	sort.Strings(flags)
+	return flags
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
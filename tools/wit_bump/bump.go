@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/witsyntax"
+)
+
+// Bump kinds, named after the flags that select them
+const (
+	bumpMajor = "major"
+	bumpMinor = "minor"
+	bumpPatch = "patch"
+)
+
+// bumpVersion computes the next version of the given kind. Pre-release and
+// build metadata are dropped, so 1.2.0-rc.1 bumps to a plain release.
+func bumpVersion(version, kind string) (string, error) {
+	v, err := witsyntax.ParseSemver(version)
+	if err != nil {
+		return "", err
+	}
+
+	switch kind {
+	case bumpMajor:
+		v = witsyntax.Semver{v[0] + 1, 0, 0}
+	case bumpMinor:
+		v = witsyntax.Semver{v[0], v[1] + 1, 0}
+	case bumpPatch:
+		v = witsyntax.Semver{v[0], v[1], v[2] + 1}
+	default:
+		return "", fmt.Errorf("unknown bump kind %q", kind)
+	}
+
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2]), nil
+}
+
+// declaredPackage returns the top-level package declaration of src, if the
+// file has one. Nested `package a:b { ... }` blocks declare other packages
+// and are not considered.
+func declaredPackage(src string) (witsyntax.PackageRef, bool, error) {
+	tokens, err := witsyntax.Tokenize(src)
+	if err != nil {
+		return witsyntax.PackageRef{}, false, err
+	}
+
+	var code []witsyntax.Token
+	for _, tok := range tokens {
+		if tok.Kind != witsyntax.TokComment {
+			code = append(code, tok)
+		}
+	}
+
+	if len(code) < 4 || code[0].Text != "package" || !code[2].Is(":") {
+		return witsyntax.PackageRef{}, false, nil
+	}
+
+	ref := witsyntax.PackageRef{Namespace: code[1].Text, Name: code[3].Text}
+	end := 4
+	if len(code) >= 6 && code[4].Is("@") && code[5].Kind == witsyntax.TokVersion {
+		ref.Version = code[5].Text
+		end = 6
+	}
+	if end < len(code) && code[end].Is("{") {
+		return witsyntax.PackageRef{}, false, nil
+	}
+	return ref, true, nil
+}
+
+// findPackage picks the package to bump from the declarations found in the
+// tree. When the tree declares several packages, for example a deps/
+// directory next to the main package, name selects one of them. Every
+// declaration of the chosen package must agree on its version.
+func findPackage(declared []witsyntax.PackageRef, name string) (witsyntax.PackageRef, error) {
+	var found []witsyntax.PackageRef
+	seen := make(map[string]bool)
+	for _, ref := range declared {
+		if name != "" && ref.Namespace+":"+ref.Name != name {
+			continue
+		}
+		if key := ref.String(); !seen[key] {
+			seen[key] = true
+			found = append(found, ref)
+		}
+	}
+
+	switch {
+	case len(found) == 1:
+		return found[0], nil
+	case len(found) == 0 && name != "":
+		return witsyntax.PackageRef{}, fmt.Errorf("no package declaration for %s", name)
+	case len(found) == 0:
+		return witsyntax.PackageRef{}, fmt.Errorf("no package declaration found")
+	}
+
+	names := make([]string, len(found))
+	for i, ref := range found {
+		names[i] = ref.String()
+	}
+	sort.Strings(names)
+	if name != "" {
+		return witsyntax.PackageRef{}, fmt.Errorf("package %s is declared with different versions (%s)", name, strings.Join(names, ", "))
+	}
+	return witsyntax.PackageRef{}, fmt.Errorf("several packages declared (%s); choose one with --package", strings.Join(names, ", "))
+}
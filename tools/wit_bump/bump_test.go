@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pulseengine/rules_wasm_component/tools/witsyntax"
+)
+
+// fixtureTemplates is a tree where acme:shapes is split over two files and
+// referenced from a deps/ package. {v} marks every reference a bump must
+// rewrite; everything else, including the comment and the 1.0.0 reference,
+// must come through untouched.
+var fixtureTemplates = map[string]string{
+	"shapes/types.wit": `package acme:shapes@{v};
+
+interface types {
+    record point { x: s32, y: s32 }
+}
+`,
+	"shapes/world.wit": `package acme:shapes@{v};
+
+// Documented as acme:shapes@1.2.3; comments are left alone
+world canvas {
+    import wasi:io/streams@0.2.0;
+    export acme:shapes/types@{v};
+    include acme:shapes/base@{v};
+}
+
+world base {}
+`,
+	"deps/render/render.wit": `package acme:render@0.1.0;
+
+interface draw {
+    use acme:shapes/types@{v}.{point};
+}
+
+world renderer {
+    import acme:shapes/types@{v};
+}
+`,
+	"legacy/old.wit": `package acme:legacy;
+
+world old {
+    import acme:shapes/types@1.0.0;
+}
+`,
+}
+
+func renderFixture(version string) map[string]string {
+	files := make(map[string]string)
+	for name, tmpl := range fixtureTemplates {
+		files[name] = strings.ReplaceAll(tmpl, "{v}", version)
+	}
+	return files
+}
+
+// writeTree creates files under a temp directory and returns it
+func writeTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// checkTree fails for every file under dir that differs from want
+func checkTree(t *testing.T, dir string, want map[string]string) {
+	t.Helper()
+	for name, content := range want {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("%s =\n%s\nwant:\n%s", name, got, content)
+		}
+	}
+}
+
+func TestBumpTree(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       string
+		setVersion string
+		want       string
+	}{
+		{name: "major", kind: bumpMajor, want: "2.0.0"},
+		{name: "minor", kind: bumpMinor, want: "1.3.0"},
+		{name: "patch", kind: bumpPatch, want: "1.2.4"},
+		{name: "set-version", kind: "set-version", setVersion: "3.0.0-rc.1", want: "3.0.0-rc.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTree(t, renderFixture("1.2.3"))
+
+			var out bytes.Buffer
+			if err := bumpTree(dir, "acme:shapes", tt.kind, tt.setVersion, false, &out); err != nil {
+				t.Fatalf("bumpTree: %v", err)
+			}
+
+			checkTree(t, dir, renderFixture(tt.want))
+			if info, _ := os.Stat(filepath.Join(dir, "shapes/types.wit")); info.Mode().Perm() != 0640 {
+				t.Errorf("mode = %v, want 0640 kept", info.Mode().Perm())
+			}
+
+			wantOut := "acme:shapes@1.2.3 -> acme:shapes@" + tt.want + "\n" +
+				filepath.Join(dir, "deps/render/render.wit") + ": 2 replacements\n" +
+				filepath.Join(dir, "shapes/types.wit") + ": 1 replacement\n" +
+				filepath.Join(dir, "shapes/world.wit") + ": 3 replacements\n" +
+				"Updated 6 references in 3 files\n"
+			if out.String() != wantOut {
+				t.Errorf("output =\n%s\nwant:\n%s", out.String(), wantOut)
+			}
+		})
+	}
+}
+
+func TestBumpTreeDryRun(t *testing.T) {
+	fixture := renderFixture("1.2.3")
+	dir := writeTree(t, fixture)
+
+	var out bytes.Buffer
+	if err := bumpTree(dir, "acme:shapes", bumpMinor, "", true, &out); err != nil {
+		t.Fatalf("bumpTree: %v", err)
+	}
+
+	checkTree(t, dir, fixture)
+	for _, line := range []string{
+		"  line 4: acme:shapes@1.2.3 -> acme:shapes@1.3.0\n",
+		"  line 7: acme:shapes@1.2.3 -> acme:shapes@1.3.0\n",
+		"Would update 6 references in 3 files\n",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output missing %q:\n%s", line, out.String())
+		}
+	}
+}
+
+func TestBumpTreeAlreadyAtVersion(t *testing.T) {
+	fixture := renderFixture("1.2.3")
+	dir := writeTree(t, fixture)
+
+	var out bytes.Buffer
+	if err := bumpTree(dir, "acme:shapes", "set-version", "1.2.3", false, &out); err != nil {
+		t.Fatalf("bumpTree: %v", err)
+	}
+	if want := "acme:shapes@1.2.3 is already at 1.2.3\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	checkTree(t, dir, fixture)
+}
+
+func TestBumpTreeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		pkg     string
+		kind    string
+		wantErr string
+	}{
+		{
+			name:    "several packages without --package",
+			files:   renderFixture("1.2.3"),
+			kind:    bumpPatch,
+			wantErr: "several packages declared (acme:legacy, acme:render@0.1.0, acme:shapes@1.2.3); choose one with --package",
+		},
+		{
+			name:    "unknown package",
+			files:   renderFixture("1.2.3"),
+			pkg:     "acme:missing",
+			kind:    bumpPatch,
+			wantErr: "no package declaration for acme:missing",
+		},
+		{
+			name:    "unversioned package",
+			files:   renderFixture("1.2.3"),
+			pkg:     "acme:legacy",
+			kind:    bumpMajor,
+			wantErr: "package acme:legacy has no version to bump; use --set-version",
+		},
+		{
+			name: "files disagree on the version",
+			files: map[string]string{
+				"a.wit": "package acme:shapes@1.2.3;\n",
+				"b.wit": "package acme:shapes@1.3.0;\n",
+			},
+			pkg:     "acme:shapes",
+			kind:    bumpPatch,
+			wantErr: "package acme:shapes is declared with different versions (acme:shapes@1.2.3, acme:shapes@1.3.0)",
+		},
+		{
+			name:    "no package declaration",
+			files:   map[string]string{"a.wit": "interface empty {}\n"},
+			kind:    bumpPatch,
+			wantErr: "no package declaration found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := writeTree(t, tt.files)
+			err := bumpTree(dir, tt.pkg, tt.kind, "", false, &bytes.Buffer{})
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("bumpTree = %v, want %q", err, tt.wantErr)
+			}
+			checkTree(t, dir, tt.files)
+		})
+	}
+}
+
+// TestBumpTreeLeavesTreeOnParseError checks that one unreadable file stops
+// the bump before any file is written
+func TestBumpTreeLeavesTreeOnParseError(t *testing.T) {
+	fixture := renderFixture("1.2.3")
+	dir := writeTree(t, fixture)
+	if err := os.WriteFile(filepath.Join(dir, "zz_broken.wit"), []byte("world broken {\n    import acme:shapes/types@1.2.3;\n/* never closed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := bumpTree(dir, "acme:shapes", bumpPatch, "", false, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "zz_broken.wit") {
+		t.Fatalf("bumpTree = %v, want an error naming the broken file", err)
+	}
+	checkTree(t, dir, fixture)
+}
+
+func TestBumpVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		kind    string
+		want    string
+		wantErr bool
+	}{
+		{version: "1.2.3", kind: bumpMajor, want: "2.0.0"},
+		{version: "1.2.3", kind: bumpMinor, want: "1.3.0"},
+		{version: "1.2.3", kind: bumpPatch, want: "1.2.4"},
+		{version: "0.2.0", kind: bumpMinor, want: "0.3.0"},
+		{version: "1.2.0-rc.1", kind: bumpPatch, want: "1.2.1"},
+		{version: "1.2.3+build.7", kind: bumpMajor, want: "2.0.0"},
+		{version: "1.2", kind: bumpPatch, wantErr: true},
+		{version: "1.2.3", kind: "huge", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+"/"+tt.kind, func(t *testing.T) {
+			got, err := bumpVersion(tt.version, tt.kind)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("bumpVersion = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("bumpVersion = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeclaredPackage(t *testing.T) {
+	tests := []struct {
+		name   string
+		src    string
+		want   witsyntax.PackageRef
+		wantOK bool
+	}{
+		{name: "versioned", src: "package acme:shapes@1.2.3;\n", want: witsyntax.PackageRef{Namespace: "acme", Name: "shapes", Version: "1.2.3"}, wantOK: true},
+		{name: "unversioned", src: "package acme:shapes;\n", want: witsyntax.PackageRef{Namespace: "acme", Name: "shapes"}, wantOK: true},
+		{name: "after a comment", src: "// header\n/* block */ package acme:shapes@0.1.0;\n", want: witsyntax.PackageRef{Namespace: "acme", Name: "shapes", Version: "0.1.0"}, wantOK: true},
+		{name: "nested package block", src: "package acme:inner@1.0.0 {\n    interface i {}\n}\n"},
+		{name: "no package", src: "interface i {}\n"},
+		{name: "empty", src: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := declaredPackage(tt.src)
+			if err != nil {
+				t.Fatalf("declaredPackage: %v", err)
+			}
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("declaredPackage = %+v, %v, want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/witsyntax"
+)
+
+// Bumps the version of a WIT package across a directory tree: the package
+// declaration and every use, import, export and include of that version are
+// rewritten to the new one. Each changed file is reported with its
+// replacement count; --dry-run shows the changes without writing them.
+func main() {
+	var (
+		dir        = flag.String("dir", ".", "Directory tree of .wit files to rewrite")
+		major      = flag.Bool("major", false, "Bump the major version: 1.2.3 -> 2.0.0")
+		minor      = flag.Bool("minor", false, "Bump the minor version: 1.2.3 -> 1.3.0")
+		patch      = flag.Bool("patch", false, "Bump the patch version: 1.2.3 -> 1.2.4")
+		setVersion = flag.String("set-version", "", "Set an explicit version instead of bumping")
+		pkg        = flag.String("package", "", "Package to bump (namespace:name) when the tree declares several")
+		dryRun     = flag.Bool("dry-run", false, "Show the replacements without writing files")
+	)
+	flag.Parse()
+
+	var kinds []string
+	for kind, selected := range map[string]bool{bumpMajor: *major, bumpMinor: *minor, bumpPatch: *patch, "set-version": *setVersion != ""} {
+		if selected {
+			kinds = append(kinds, kind)
+		}
+	}
+	if len(kinds) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s (--major | --minor | --patch | --set-version <x.y.z>) [--dir <dir>] [--package <ns:name>] [--dry-run]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	if *setVersion != "" {
+		if _, err := witsyntax.ParseSemver(*setVersion); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --set-version: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if err := bumpTree(*dir, *pkg, kinds[0], *setVersion, *dryRun, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// bumpTree moves the package declared under dir, or pkg when the tree
+// declares several, to its next version of the given kind, or to setVersion
+// for the "set-version" kind. Each changed file and its replacement count
+// is reported to out. Everything is rewritten in memory first, so a parse
+// error in one file leaves the whole tree untouched.
+func bumpTree(dir, pkg, kind, setVersion string, dryRun bool, out io.Writer) error {
+	files, err := findWitFiles(dir)
+	if err != nil {
+		return fmt.Errorf("scanning %s: %w", dir, err)
+	}
+
+	sources := make(map[string]string)
+	var declared []witsyntax.PackageRef
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", file, err)
+		}
+		sources[file] = string(data)
+
+		ref, ok, err := declaredPackage(string(data))
+		if err != nil {
+			return fmt.Errorf("in %s: %w", file, err)
+		}
+		if ok {
+			declared = append(declared, ref)
+		}
+	}
+
+	current, err := findPackage(declared, pkg)
+	if err != nil {
+		return err
+	}
+
+	next := witsyntax.PackageRef{Namespace: current.Namespace, Name: current.Name, Version: setVersion}
+	if kind != "set-version" {
+		if current.Version == "" {
+			return fmt.Errorf("package %s has no version to bump; use --set-version", current)
+		}
+		next.Version, err = bumpVersion(current.Version, kind)
+		if err != nil {
+			return fmt.Errorf("package %s: %w", current, err)
+		}
+	}
+	if next == current {
+		fmt.Fprintf(out, "%s is already at %s\n", current, next.Version)
+		return nil
+	}
+	fmt.Fprintf(out, "%s -> %s\n", current, next)
+
+	rewritten := make(map[string]string)
+	counts := make(map[string][]witsyntax.Replacement)
+	for _, file := range files {
+		output, replacements, err := witsyntax.RenameRefs(sources[file], current, next)
+		if err != nil {
+			return fmt.Errorf("in %s: %w", file, err)
+		}
+		if len(replacements) > 0 {
+			rewritten[file] = output
+			counts[file] = replacements
+		}
+	}
+
+	total := 0
+	for _, file := range files {
+		replacements, changed := counts[file]
+		if !changed {
+			continue
+		}
+		total += len(replacements)
+
+		fmt.Fprintf(out, "%s: %d %s\n", file, len(replacements), plural(len(replacements), "replacement"))
+		if dryRun {
+			for _, r := range replacements {
+				fmt.Fprintf(out, "  line %d: %s -> %s\n", r.Line, r.Old, r.New)
+			}
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, []byte(rewritten[file]), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("writing %s: %w", file, err)
+		}
+	}
+
+	verb := "Updated"
+	if dryRun {
+		verb = "Would update"
+	}
+	fmt.Fprintf(out, "%s %d %s in %d %s\n", verb, total, plural(total, "reference"), len(counts), plural(len(counts), "file"))
+	return nil
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// findWitFiles lists the .wit files under dir in a stable order
+func findWitFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".wit") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
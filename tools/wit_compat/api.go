@@ -0,0 +1,337 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/witsyntax"
+)
+
+// APISummary is the public interface of a WIT package: every interface and
+// world member, keyed by where it lives, with its declaration normalized so
+// formatting and comments do not count as changes
+type APISummary struct {
+	Package string            `json:"package"`
+	Version string            `json:"version,omitempty"`
+	Hash    string            `json:"hash"`
+	Items   map[string]string `json:"items"`
+}
+
+// isMajorBump reports whether current may break compatibility with baseline
+// under semver. Before 1.0.0 the first non-zero component acts as the major
+// version, so 0.2.x -> 0.3.0 and 0.0.1 -> 0.0.2 are breaking bumps.
+func isMajorBump(baseline, current witsyntax.Semver) bool {
+	for i := 0; i < 3; i++ {
+		if current[i] != baseline[i] {
+			return current[i] > baseline[i]
+		}
+		if baseline[i] != 0 {
+			return false
+		}
+	}
+	return false
+}
+
+// summarizeWIT extracts the public interface of a WIT package from the
+// contents of its files. Items gated with @unstable are not part of the
+// stable interface and are left out.
+func summarizeWIT(sources map[string]string) (*APISummary, error) {
+	summary := &APISummary{Items: make(map[string]string)}
+
+	files := make([]string, 0, len(sources))
+	for file := range sources {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		all, err := witsyntax.Tokenize(sources[file])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		var tokens []witsyntax.Token
+		for _, tok := range all {
+			if tok.Kind != witsyntax.TokComment {
+				tokens = append(tokens, tok)
+			}
+		}
+
+		s := &summarizer{tokens: tokens, items: summary.Items}
+		ref, err := s.summarizeFile()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+
+		if ref == nil {
+			continue
+		}
+		if summary.Package != "" && ref.String() != summary.Package+versionSuffix(summary.Version) {
+			return nil, fmt.Errorf("%s: package %s does not match %s", file, ref, summary.Package+versionSuffix(summary.Version))
+		}
+		summary.Package = ref.Namespace + ":" + ref.Name
+		summary.Version = ref.Version
+	}
+
+	if summary.Package == "" {
+		return nil, fmt.Errorf("no package declaration found")
+	}
+
+	summary.Hash = hashItems(summary.Items)
+	return summary, nil
+}
+
+func versionSuffix(version string) string {
+	if version == "" {
+		return ""
+	}
+	return "@" + version
+}
+
+// hashItems fingerprints the summary so an unchanged interface is
+// recognized without diffing
+func hashItems(items map[string]string) string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(h, "%s\x00%s\n", key, items[key])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type summarizer struct {
+	tokens []witsyntax.Token
+	pos    int
+	items  map[string]string
+}
+
+func (s *summarizer) summarizeFile() (*witsyntax.PackageRef, error) {
+	var ref *witsyntax.PackageRef
+
+	for !s.done() {
+		unstable := s.skipGates()
+		tok := s.next()
+
+		switch tok.Text {
+		case "package":
+			start := s.pos
+			s.skipStatement()
+			end := s.pos - 1 // The terminating ";"
+			switch {
+			case end < start || (end == start && s.tokens[end].Is(";")):
+				return nil, fmt.Errorf("line %d: package declaration has no name", tok.Line)
+			case s.tokens[end].Is("}"):
+				return nil, fmt.Errorf("line %d: nested package blocks are not supported", tok.Line)
+			case !s.tokens[end].Is(";"):
+				return nil, fmt.Errorf("line %d: package declaration is missing its \";\"", tok.Line)
+			}
+			var text strings.Builder
+			for _, t := range s.tokens[start:end] {
+				text.WriteString(t.Text)
+			}
+			parsed, err := witsyntax.ParsePackageRef(text.String())
+			if err != nil {
+				return nil, err
+			}
+			ref = &parsed
+
+		case "interface", "world":
+			name := s.next().Text
+			if err := s.expect("{"); err != nil {
+				return nil, err
+			}
+			s.summarizeBlock(tok.Text+" "+name, unstable)
+
+		default:
+			// Top-level use statements only alias names for this file
+			s.pos--
+			start := s.pos
+			s.skipStatement()
+			if s.pos == start {
+				s.next() // Stray "}"
+			}
+		}
+	}
+
+	return ref, nil
+}
+
+// summarizeBlock records each member of an interface, world or resource
+// body, up to and including its closing "}". The block itself is recorded
+// too, so removing an empty interface is still noticed.
+func (s *summarizer) summarizeBlock(scope string, unstable bool) {
+	if !unstable {
+		s.items[scope] = ""
+	}
+
+	for !s.done() && !s.peek().Is("}") {
+		memberUnstable := s.skipGates() || unstable
+		start := s.pos
+		tok := s.next()
+
+		var key string
+		switch tok.Text {
+		case "record", "variant", "enum", "flags", "type":
+			key = tok.Text + " " + s.peek().Text
+		case "use", "include":
+			key = ""
+		case "import", "export":
+			key = tok.Text + " " + s.worldItemName()
+		case "resource":
+			name := s.next().Text
+			if s.peek().Is("{") {
+				s.next()
+				s.summarizeBlock(scope+"/resource "+name, memberUnstable)
+				continue
+			}
+			key = "resource " + name
+		case "constructor":
+			key = "constructor"
+		default:
+			key = "func " + tok.Text
+		}
+
+		s.pos = start
+		s.skipStatement()
+		if memberUnstable {
+			continue
+		}
+
+		text := s.join(start, s.pos)
+		if key == "" {
+			// Uses and includes are keyed by their full text: there is
+			// nothing to compare them with but their presence
+			key = text
+		}
+		s.items[scope+"/"+key] = text
+	}
+
+	s.next()
+}
+
+// worldItemName reads the name of an import or export: the part before the
+// ":" for named items, or the whole interface path otherwise
+func (s *summarizer) worldItemName() string {
+	if s.peekAt(1).Is(":") && !s.peekAt(3).Is("/") {
+		return s.peek().Text
+	}
+
+	start := s.pos
+	end := start
+	for end < len(s.tokens) && !s.tokens[end].Is(";") && !s.tokens[end].Is("{") {
+		end++
+	}
+
+	var path strings.Builder
+	for _, tok := range s.tokens[start:end] {
+		path.WriteString(tok.Text)
+	}
+	return path.String()
+}
+
+// join renders tokens[start:end] with single spaces, which is enough to
+// compare declarations independently of how they were formatted
+func (s *summarizer) join(start, end int) string {
+	texts := make([]string, 0, end-start)
+	for _, tok := range s.tokens[start:end] {
+		texts = append(texts, tok.Text)
+	}
+	return strings.Join(texts, " ")
+}
+
+// skipGates skips feature gates such as @since(version = 0.2.0), reporting
+// whether one of them was @unstable
+func (s *summarizer) skipGates() bool {
+	unstable := false
+	for s.peek().Is("@") {
+		s.next()
+		if s.next().Text == "unstable" {
+			unstable = true
+		}
+		if s.peek().Is("(") {
+			for !s.done() && !s.next().Is(")") {
+			}
+		}
+	}
+	return unstable
+}
+
+// skipStatement advances past the current statement: up to and including a
+// ";" outside any block, or the "}" closing a block it opened. Use lists like
+// streams.{a, b} are not blocks. A "}" closing the enclosing block is left
+// for the caller.
+func (s *summarizer) skipStatement() {
+	var braces []bool // true for block braces, false for use lists
+	blocks := 0
+
+	for !s.done() {
+		tok := s.next()
+		switch {
+		case tok.Is("{"):
+			isBlock := s.pos < 2 || !s.tokens[s.pos-2].Is(".")
+			braces = append(braces, isBlock)
+			if isBlock {
+				blocks++
+			}
+
+		case tok.Is("}"):
+			if len(braces) == 0 {
+				s.pos--
+				return
+			}
+			isBlock := braces[len(braces)-1]
+			braces = braces[:len(braces)-1]
+			if isBlock {
+				blocks--
+				if blocks == 0 {
+					return
+				}
+			}
+
+		case tok.Is(";") && blocks == 0:
+			return
+		}
+	}
+}
+
+func (s *summarizer) done() bool {
+	return s.pos >= len(s.tokens)
+}
+
+func (s *summarizer) peek() witsyntax.Token {
+	return s.peekAt(0)
+}
+
+func (s *summarizer) peekAt(offset int) witsyntax.Token {
+	if s.pos+offset >= len(s.tokens) {
+		return witsyntax.Token{}
+	}
+	return s.tokens[s.pos+offset]
+}
+
+func (s *summarizer) next() witsyntax.Token {
+	tok := s.peek()
+	if !s.done() {
+		s.pos++
+	}
+	return tok
+}
+
+func (s *summarizer) expect(punct string) error {
+	tok := s.next()
+	if !tok.Is(punct) {
+		if tok.Text == "" {
+			return fmt.Errorf("unexpected end of file, expected %q", punct)
+		}
+		return fmt.Errorf("line %d: expected %q, found %q", tok.Line, punct, tok.Text)
+	}
+	return nil
+}
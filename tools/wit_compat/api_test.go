@@ -0,0 +1,41 @@
+package main
+
+import "testing"
+
+func TestSummarizeWITPackageDeclaration(t *testing.T) {
+	tests := []struct {
+		name        string
+		src         string
+		wantPackage string
+		wantVersion string
+		wantErr     string
+	}{
+		{name: "versioned", src: "package acme:api@1.2.0;\n\ninterface types {}\n", wantPackage: "acme:api", wantVersion: "1.2.0"},
+		{name: "unversioned", src: "package acme:api;\n", wantPackage: "acme:api"},
+		{name: "keyword only", src: "package", wantErr: "api.wit: line 1: package declaration has no name"},
+		{name: "no name", src: "// header\npackage;\n", wantErr: "api.wit: line 2: package declaration has no name"},
+		{name: "stray brace", src: "package }", wantErr: "api.wit: line 1: package declaration has no name"},
+		{name: "unterminated", src: "package acme:api", wantErr: `api.wit: line 1: package declaration is missing its ";"`},
+		{name: "nested block", src: "package acme:api {\n}\n", wantErr: "api.wit: line 1: nested package blocks are not supported"},
+		{name: "bad reference", src: "package acme;\n", wantErr: "api.wit: package acme: expected namespace:name"},
+		{name: "missing", src: "interface types {}\n", wantErr: "no package declaration found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := summarizeWIT(map[string]string{"api.wit": tt.src})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("summarizeWIT() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("summarizeWIT() error = %v", err)
+			}
+			if summary.Package != tt.wantPackage || summary.Version != tt.wantVersion {
+				t.Errorf("package = %s@%s, want %s@%s", summary.Package, summary.Version, tt.wantPackage, tt.wantVersion)
+			}
+		})
+	}
+}
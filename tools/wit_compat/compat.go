@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/pulseengine/rules_wasm_component/tools/witsyntax"
+)
+
+// CompatReport classifies how the current interface differs from a baseline
+type CompatReport struct {
+	Package         string   `json:"package"`
+	BaselineVersion string   `json:"baseline_version,omitempty"`
+	CurrentVersion  string   `json:"current_version,omitempty"`
+	Unchanged       bool     `json:"unchanged"`
+	Added           []string `json:"added"`   // New items, compatible with existing users
+	Removed         []string `json:"removed"` // Breaking
+	Changed         []string `json:"changed"` // Breaking
+	MajorBump       bool     `json:"major_bump"`
+	Passed          bool     `json:"passed"`
+	Reason          string   `json:"reason,omitempty"`
+}
+
+// Breaking reports whether any removed or changed item was found
+func (r *CompatReport) Breaking() bool {
+	return len(r.Removed) > 0 || len(r.Changed) > 0
+}
+
+// compareAPI diffs the current interface against the baseline. Adding
+// items is compatible; removing an item or changing its declaration (a
+// function signature, record field or enum case) breaks existing users and
+// needs a major version bump of the package.
+func compareAPI(baseline, current *APISummary) (*CompatReport, error) {
+	report := &CompatReport{
+		Package:         current.Package,
+		BaselineVersion: baseline.Version,
+		CurrentVersion:  current.Version,
+		Added:           []string{},
+		Removed:         []string{},
+		Changed:         []string{},
+	}
+
+	if baseline.Package != current.Package {
+		return nil, fmt.Errorf("baseline is for package %s, not %s", baseline.Package, current.Package)
+	}
+
+	if baseline.Hash == current.Hash {
+		report.Unchanged = true
+		report.Passed = true
+		return report, nil
+	}
+
+	for key, text := range current.Items {
+		old, exists := baseline.Items[key]
+		switch {
+		case !exists:
+			report.Added = append(report.Added, key)
+		case old != text:
+			report.Changed = append(report.Changed, key)
+		}
+	}
+	for key := range baseline.Items {
+		if _, exists := current.Items[key]; !exists {
+			report.Removed = append(report.Removed, key)
+		}
+	}
+	sort.Strings(report.Added)
+	sort.Strings(report.Removed)
+	sort.Strings(report.Changed)
+
+	if baseline.Version != "" && current.Version != "" {
+		base, err := witsyntax.ParseSemver(baseline.Version)
+		if err != nil {
+			return nil, fmt.Errorf("baseline: %w", err)
+		}
+		cur, err := witsyntax.ParseSemver(current.Version)
+		if err != nil {
+			return nil, err
+		}
+		report.MajorBump = isMajorBump(base, cur)
+	}
+
+	switch {
+	case !report.Breaking():
+		report.Passed = true
+	case report.MajorBump:
+		report.Passed = true
+		report.Reason = "breaking changes covered by the major version bump"
+	case current.Version == "":
+		report.Reason = "breaking changes in an unversioned package"
+	default:
+		report.Reason = fmt.Sprintf("breaking changes require a major version bump from %s", baseline.Version)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Gates WIT interface changes on semantic versioning. --write-baseline
+// records a summary of a WIT package's public interface; --baseline compares
+// the current interface against that record and fails when items were
+// removed or changed without a major version bump in the package name.
+func main() {
+	var (
+		witPath       = flag.String("wit", "", "WIT file, or directory of .wit files forming one package")
+		writeBaseline = flag.String("write-baseline", "", "Write the interface summary to this file")
+		baselinePath  = flag.String("baseline", "", "Compare against the interface summary in this file")
+	)
+	flag.Parse()
+
+	if *witPath == "" || (*writeBaseline == "") == (*baselinePath == "") {
+		fmt.Fprintf(os.Stderr, "Usage: %s --wit <file-or-dir> (--write-baseline <out.json> | --baseline <baseline.json>)\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	sources, err := readWITSources(*witPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading WIT: %v\n", err)
+		os.Exit(1)
+	}
+
+	current, err := summarizeWIT(sources)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing WIT: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *writeBaseline != "" {
+		if err := writeBaselineFile(*writeBaseline, current); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing baseline: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote baseline for %s (%d items) to %s\n", current.Package+versionSuffix(current.Version), len(current.Items), *writeBaseline)
+		return
+	}
+
+	baseline, err := readBaseline(*baselinePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading baseline: %v\n", err)
+		os.Exit(1)
+	}
+
+	report, err := compareAPI(baseline, current)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	output, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(output))
+
+	if !report.Passed {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", report.Package, report.Reason)
+		os.Exit(1)
+	}
+}
+
+// readWITSources loads a single WIT file, or the .wit files directly inside
+// a directory. Subdirectories such as deps/ hold other packages and are not
+// read.
+func readWITSources(path string) (map[string]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = filepath.Glob(filepath.Join(path, "*.wit"))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no .wit files in %s", path)
+		}
+	}
+
+	sources := make(map[string]string, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		sources[file] = string(data)
+	}
+
+	return sources, nil
+}
+
+// writeBaselineFile stores the summary with WIT's "->" and "<...>" left
+// unescaped, so baselines stay readable in code review
+func writeBaselineFile(path string, summary *APISummary) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(summary); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+func readBaseline(path string) (*APISummary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var baseline APISummary
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, err
+	}
+	if baseline.Package == "" || baseline.Items == nil {
+		return nil, fmt.Errorf("%s is not a wit_compat baseline", path)
+	}
+	if !strings.Contains(baseline.Package, ":") {
+		return nil, fmt.Errorf("invalid package %q in baseline", baseline.Package)
+	}
+
+	return &baseline, nil
+}
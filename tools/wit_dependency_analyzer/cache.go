@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// cacheEntry records what a single WIT or BUILD file yielded when last parsed
+type cacheEntry struct {
+	ModTime  int64        `json:"mod_time"`
+	Size     int64        `json:"size"`
+	Packages []WitPackage `json:"packages"`
+	// MissingPackage records a WIT file with content but no package
+	// declaration, so the warning survives cache hits
+	MissingPackage bool `json:"missing_package,omitempty"`
+}
+
+// scanCacheVersion changes whenever cacheEntry gains information older
+// caches lack, so those are discarded rather than trusted
+const scanCacheVersion = 2
+
+// ScanCache remembers the packages discovered in each workspace file so that
+// re-scans only parse files whose mtime or size changed
+type ScanCache struct {
+	Version      int                   `json:"version"`
+	WorkspaceDir string                `json:"workspace_dir"`
+	Files        map[string]cacheEntry `json:"files"`
+
+	path   string
+	seen   map[string]bool
+	reused int
+	parsed int
+}
+
+// loadScanCache reads the cache at path, starting empty when the file is
+// missing, unreadable or was written for a different workspace
+func loadScanCache(path, workspaceDir string) *ScanCache {
+	cache := &ScanCache{
+		Version:      scanCacheVersion,
+		WorkspaceDir: workspaceDir,
+		Files:        make(map[string]cacheEntry),
+		path:         path,
+		seen:         make(map[string]bool),
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+
+	var stored ScanCache
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != scanCacheVersion || stored.WorkspaceDir != workspaceDir {
+		tracef("scan cache %s is stale or unreadable, starting fresh", path)
+		return cache
+	}
+	if stored.Files != nil {
+		cache.Files = stored.Files
+	}
+
+	return cache
+}
+
+// packagesFor returns the packages found in filePath, reusing the cached
+// result when the file is unchanged and calling parse otherwise. It also
+// reports whether parse failed with errNoPackageDeclaration.
+func (c *ScanCache) packagesFor(filePath string, info os.FileInfo, parse func() ([]WitPackage, error)) ([]WitPackage, bool) {
+	if c == nil {
+		packages, err := parse()
+		return packages, errors.Is(err, errNoPackageDeclaration)
+	}
+
+	c.seen[filePath] = true
+	if entry, ok := c.Files[filePath]; ok && entry.ModTime == info.ModTime().UnixNano() && entry.Size == info.Size() {
+		tracef("  unchanged, reusing cached result")
+		c.reused++
+		return entry.Packages, entry.MissingPackage
+	}
+
+	c.parsed++
+	packages, err := parse()
+	if err != nil {
+		// Remember unparseable files too so they are not retried until they change
+		packages = nil
+	}
+	missingPackage := errors.Is(err, errNoPackageDeclaration)
+	c.Files[filePath] = cacheEntry{
+		ModTime:        info.ModTime().UnixNano(),
+		Size:           info.Size(),
+		Packages:       packages,
+		MissingPackage: missingPackage,
+	}
+
+	return packages, missingPackage
+}
+
+// save writes the cache back, dropping entries for files that were not seen
+// during this scan
+func (c *ScanCache) save() error {
+	if c == nil {
+		return nil
+	}
+
+	for filePath := range c.Files {
+		if !c.seen[filePath] {
+			delete(c.Files, filePath)
+		}
+	}
+
+	tracef("scan cache: %d files reused, %d parsed", c.reused, c.parsed)
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return err
+	}
+
+	tmpPath := c.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.path)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// copyFixture copies the named fixture workspace into a temp dir, so its
+// files can be modified and cached without touching the source tree
+func copyFixture(t *testing.T, name string) string {
+	t.Helper()
+	dst := t.TempDir()
+	src := filepath.Join("fixtures", name)
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if info.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), data, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
+
+func TestScanCacheSecondRunParsesNothing(t *testing.T) {
+	workspace := copyFixture(t, "multi_file")
+	cachePath := filepath.Join(t.TempDir(), "scan-cache.json")
+
+	first := loadScanCache(cachePath, workspace)
+	want, _, err := findAvailableWitPackages(workspace, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.parsed == 0 || first.reused != 0 {
+		t.Fatalf("first run: parsed %d, reused %d; want every file parsed", first.parsed, first.reused)
+	}
+
+	second := loadScanCache(cachePath, workspace)
+	got, _, err := findAvailableWitPackages(workspace, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.parsed != 0 || second.reused != first.parsed {
+		t.Errorf("second run: parsed %d, reused %d; want 0 parsed, %d reused", second.parsed, second.reused, first.parsed)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("cached packages = %+v, want %+v", got, want)
+	}
+
+	// Touching one file re-parses just that file
+	var changed string
+	filepath.Walk(workspace, func(path string, info os.FileInfo, err error) error {
+		if changed == "" && err == nil && filepath.Ext(path) == ".wit" {
+			changed = path
+		}
+		return nil
+	})
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(changed, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	third := loadScanCache(cachePath, workspace)
+	if _, _, err := findAvailableWitPackages(workspace, third); err != nil {
+		t.Fatal(err)
+	}
+	if third.parsed != 1 {
+		t.Errorf("after touching %s: parsed %d, want 1", filepath.Base(changed), third.parsed)
+	}
+}
+
+func TestScanCacheIgnoresOtherWorkspace(t *testing.T) {
+	workspace := copyFixture(t, "multi_file")
+	cachePath := filepath.Join(t.TempDir(), "scan-cache.json")
+
+	if _, _, err := findAvailableWitPackages(workspace, loadScanCache(cachePath, workspace)); err != nil {
+		t.Fatal(err)
+	}
+
+	other := copyFixture(t, "multi_file")
+	cache := loadScanCache(cachePath, other)
+	if _, _, err := findAvailableWitPackages(other, cache); err != nil {
+		t.Fatal(err)
+	}
+	if cache.reused != 0 {
+		t.Errorf("reused %d entries cached for another workspace", cache.reused)
+	}
+}
+
+// TestScanCacheKeepsMissingPackageWarning checks a package-less WIT file is
+// still reported when its result comes from the cache
+func TestScanCacheKeepsMissingPackageWarning(t *testing.T) {
+	workspace := copyFixture(t, "missing_package")
+	cachePath := filepath.Join(t.TempDir(), "scan-cache.json")
+	want := []string{"malformed package: missing_package.wit has content but no package declaration"}
+
+	first := loadScanCache(cachePath, workspace)
+	_, warnings, err := findAvailableWitPackages(workspace, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Fatalf("first run warnings = %q, want %q", warnings, want)
+	}
+
+	second := loadScanCache(cachePath, workspace)
+	packages, warnings, err := findAvailableWitPackages(workspace, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.parsed != 0 || second.reused != 1 {
+		t.Fatalf("second run: parsed %d, reused %d; want the file served from the cache", second.parsed, second.reused)
+	}
+	if len(packages) != 0 {
+		t.Errorf("packages = %+v, want none", packages)
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("cached run warnings = %q, want %q", warnings, want)
+	}
+}
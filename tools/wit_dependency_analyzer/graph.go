@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GraphNode is a WIT package in the dependency graph
+type GraphNode struct {
+	ID       string   `json:"id"`
+	Files    []string `json:"files,omitempty"`
+	External bool     `json:"external"` // Referenced but not defined in the workspace
+}
+
+// GraphEdge is a `use` relationship from one package to another
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Cycle bool   `json:"cycle"`
+}
+
+// DependencyGraph is the package-level `use` graph of a workspace
+type DependencyGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// runGraph implements `graph [--json] <workspace-dir>`
+func runGraph(args []string) error {
+	flags := flag.NewFlagSet("graph", flag.ContinueOnError)
+	jsonOutput := flags.Bool("json", false, "Emit JSON node/edge lists instead of Graphviz DOT")
+	flags.BoolVar(&verbose, "verbose", false, "Trace files examined to stderr")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("usage: graph [--json] [--verbose] <workspace-dir>")
+	}
+
+	graph, err := buildDependencyGraph(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if *jsonOutput {
+		output, err := json.MarshalIndent(graph, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	fmt.Print(renderDOT(graph))
+	return nil
+}
+
+// buildDependencyGraph scans the workspace for WIT packages and connects each
+// package to the packages its files `use`
+func buildDependencyGraph(workspaceDir string) (*DependencyGraph, error) {
+	packages, warnings, err := findAvailableWitPackages(workspaceDir, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Stdout carries the graph, so warnings go to stderr
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
+	files := make(map[string][]string)
+	edgeSet := make(map[string]map[string]bool)
+	for _, pkg := range packages {
+		// BUILD-file entries describe targets, not WIT sources
+		if pkg.Target != "" || pkg.PackageName == "" {
+			continue
+		}
+		files[pkg.PackageName] = append(files[pkg.PackageName], pkg.FilePath)
+
+		used, err := findMissingPackages(filepath.Join(workspaceDir, pkg.FilePath))
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", pkg.FilePath, err)
+		}
+		for _, dep := range used {
+			if edgeSet[pkg.PackageName] == nil {
+				edgeSet[pkg.PackageName] = make(map[string]bool)
+			}
+			edgeSet[pkg.PackageName][dep] = true
+		}
+	}
+
+	adjacency := make(map[string][]string)
+	nodeSet := make(map[string]bool)
+	for name := range files {
+		nodeSet[name] = true
+	}
+	for from, targets := range edgeSet {
+		for to := range targets {
+			adjacency[from] = append(adjacency[from], to)
+			nodeSet[to] = true
+		}
+		sort.Strings(adjacency[from])
+	}
+
+	graph := &DependencyGraph{}
+	for _, name := range sortedKeys(nodeSet) {
+		nodeFiles := files[name]
+		sort.Strings(nodeFiles)
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID:       name,
+			Files:    nodeFiles,
+			External: len(nodeFiles) == 0,
+		})
+	}
+
+	cycleEdges := findCycleEdges(adjacency)
+	for _, from := range sortedKeys(nodeSet) {
+		for _, to := range adjacency[from] {
+			graph.Edges = append(graph.Edges, GraphEdge{
+				From:  from,
+				To:    to,
+				Cycle: cycleEdges[from+"->"+to],
+			})
+		}
+	}
+
+	return graph, nil
+}
+
+// findCycleEdges returns the set of "from->to" edges that lie on a cycle,
+// i.e. edges whose endpoints share a strongly connected component
+func findCycleEdges(adjacency map[string][]string) map[string]bool {
+	index := 0
+	indices := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	component := make(map[string]int)
+	var stack []string
+	components := 0
+
+	var strongConnect func(node string)
+	strongConnect = func(node string) {
+		indices[node] = index
+		lowlink[node] = index
+		index++
+		stack = append(stack, node)
+		onStack[node] = true
+
+		for _, next := range adjacency[node] {
+			if _, visited := indices[next]; !visited {
+				strongConnect(next)
+				lowlink[node] = min(lowlink[node], lowlink[next])
+			} else if onStack[next] {
+				lowlink[node] = min(lowlink[node], indices[next])
+			}
+		}
+
+		if lowlink[node] == indices[node] {
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				component[top] = components
+				if top == node {
+					break
+				}
+			}
+			components++
+		}
+	}
+
+	nodes := make([]string, 0, len(adjacency))
+	for node := range adjacency {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	for _, node := range nodes {
+		if _, visited := indices[node]; !visited {
+			strongConnect(node)
+		}
+	}
+
+	cycles := make(map[string]bool)
+	for from, targets := range adjacency {
+		for _, to := range targets {
+			if from == to || component[from] == component[to] {
+				cycles[from+"->"+to] = true
+			}
+		}
+	}
+
+	return cycles
+}
+
+// renderDOT renders the graph as Graphviz DOT, drawing cycle edges in red and
+// packages defined outside the workspace with dashed outlines
+func renderDOT(graph *DependencyGraph) string {
+	var b strings.Builder
+	b.WriteString("digraph wit_dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+
+	for _, node := range graph.Nodes {
+		if node.External {
+			fmt.Fprintf(&b, "  %q [style=dashed];\n", node.ID)
+		} else {
+			fmt.Fprintf(&b, "  %q;\n", node.ID)
+		}
+	}
+
+	for _, edge := range graph.Edges {
+		if edge.Cycle {
+			fmt.Fprintf(&b, "  %q -> %q [color=red];\n", edge.From, edge.To)
+		} else {
+			fmt.Fprintf(&b, "  %q -> %q;\n", edge.From, edge.To)
+		}
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -17,6 +19,7 @@ type Config struct {
 	WorkspaceDir    string   `json:"workspace_dir"`
 	WitFile         string   `json:"wit_file"`
 	MissingPackages []string `json:"missing_packages"`
+	Verbose         bool     `json:"verbose,omitempty"` // Trace the workspace scan to stderr
 }
 
 type WitPackage struct {
@@ -31,22 +34,64 @@ type AnalysisResult struct {
 	AvailablePackages []WitPackage `json:"available_packages"`
 	SuggestedDeps     []string     `json:"suggested_deps"`
 	ErrorMessage      string       `json:"error_message,omitempty"`
+	// Warnings flags WIT files with content but no package declaration,
+	// in directories where no other file declares the package either
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+var (
+	// errEmptyWitFile marks WIT files holding only whitespace and comments,
+	// which are skipped without a warning
+	errEmptyWitFile = errors.New("empty WIT file")
+	// errNoPackageDeclaration marks WIT files with content but no package
+	errNoPackageDeclaration = errors.New("no package declaration found")
+)
+
+// verbose enables tracing of the workspace scan to stderr. Stdout stays pure JSON.
+var verbose bool
+
+func tracef(format string, args ...interface{}) {
+	if verbose {
+		fmt.Fprintf(os.Stderr, "[trace] "+format+"\n", args...)
+	}
 }
 
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <config.json>\n", os.Args[0])
+	if len(os.Args) >= 2 && os.Args[1] == "graph" {
+		if err := runGraph(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error building dependency graph: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	flag.BoolVar(&verbose, "verbose", false, "Trace files examined and packages matched to stderr")
+	noCache := flag.Bool("no-cache", false, "Re-parse every workspace file instead of using the scan cache")
+	cacheFile := flag.String("cache-file", "", "Reuse parse results for unchanged files through the scan cache at this path")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [--verbose] [--no-cache | --cache-file <path>] <config.json>\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s graph [--json] [--verbose] <workspace-dir>\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	configPath := os.Args[1]
+	configPath := flag.Arg(0)
 	config, err := readConfig(configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
 		os.Exit(1)
 	}
+	verbose = verbose || config.Verbose
 
-	result, err := analyzeWitDependencies(config)
+	// The cache is opt-in: Bazel actions must not write outside their
+	// sandbox, and a shared cache would make their results depend on it
+	cachePath := *cacheFile
+	if *noCache {
+		cachePath = ""
+	}
+
+	result, err := analyzeWitDependencies(config, cachePath)
 	if err != nil {
 		result = &AnalysisResult{
 			ErrorMessage: fmt.Sprintf("Analysis failed: %v", err),
@@ -72,7 +117,7 @@ func readConfig(path string) (*Config, error) {
 	return &config, nil
 }
 
-func analyzeWitDependencies(config *Config) (*AnalysisResult, error) {
+func analyzeWitDependencies(config *Config, cachePath string) (*AnalysisResult, error) {
 	result := &AnalysisResult{}
 
 	// Parse the WIT file to find use statements
@@ -84,11 +129,17 @@ func analyzeWitDependencies(config *Config) (*AnalysisResult, error) {
 
 	// If we have missing packages, search the workspace
 	if len(missingPackages) > 0 {
-		availablePackages, err := findAvailableWitPackages(config.WorkspaceDir)
+		var cache *ScanCache
+		if cachePath != "" {
+			cache = loadScanCache(cachePath, config.WorkspaceDir)
+		}
+
+		availablePackages, warnings, err := findAvailableWitPackages(config.WorkspaceDir, cache)
 		if err != nil {
 			return nil, fmt.Errorf("searching workspace: %w", err)
 		}
 		result.AvailablePackages = availablePackages
+		result.Warnings = warnings
 
 		// Generate suggestions
 		result.SuggestedDeps = generateSuggestions(missingPackages, availablePackages)
@@ -105,7 +156,7 @@ func findMissingPackages(witFilePath string) ([]string, error) {
 	defer file.Close()
 
 	var missingPackages []string
-	useRegex := regexp.MustCompile(`use\s+([^/]+)/([^@]+)@([^;]+);`)
+	useRegex := regexp.MustCompile(`use\s+([^/\s]+)/([^@\s]+)@([^;\s{]+?)(?:\.\{[^}]*\})?\s*;`)
 
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
@@ -119,8 +170,14 @@ func findMissingPackages(witFilePath string) ([]string, error) {
 	return missingPackages, scanner.Err()
 }
 
-func findAvailableWitPackages(workspaceDir string) ([]WitPackage, error) {
+// findAvailableWitPackages walks the workspace for WIT packages and
+// wit_library targets. A non-nil cache skips files unchanged since last scan.
+// The returned warnings name WIT files that look like a forgotten package
+// line; files sharing a directory with a package declaration are exempt,
+// since only one file of a multi-file package needs to declare it.
+func findAvailableWitPackages(workspaceDir string, cache *ScanCache) ([]WitPackage, []string, error) {
 	var packages []WitPackage
+	var malformed []string
 
 	err := filepath.Walk(workspaceDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
@@ -129,24 +186,68 @@ func findAvailableWitPackages(workspaceDir string) ([]WitPackage, error) {
 
 		// Look for .wit files
 		if strings.HasSuffix(path, ".wit") {
-			pkg, err := parseWitPackage(path, workspaceDir)
-			if err == nil && pkg != nil {
-				packages = append(packages, *pkg)
+			tracef("examining WIT file %s", path)
+			witPackages, missingPackage := cache.packagesFor(path, info, func() ([]WitPackage, error) {
+				pkg, err := parseWitPackage(path, workspaceDir)
+				if err != nil {
+					tracef("  skipped: %v", err)
+					return nil, err
+				}
+				tracef("  discovered package %s", pkg.PackageName)
+				return []WitPackage{*pkg}, nil
+			})
+			packages = append(packages, witPackages...)
+			if missingPackage {
+				relPath, _ := filepath.Rel(workspaceDir, path)
+				malformed = append(malformed, relPath)
 			}
 		}
 
 		// Look for BUILD.bazel files to find wit_library targets
 		if info.Name() == "BUILD.bazel" || info.Name() == "BUILD" {
-			buildPackages, err := parseBuildFile(path, workspaceDir)
-			if err == nil {
-				packages = append(packages, buildPackages...)
-			}
+			tracef("examining BUILD file %s", path)
+			buildPackages, _ := cache.packagesFor(path, info, func() ([]WitPackage, error) {
+				buildPackages, err := parseBuildFile(path, workspaceDir)
+				if err != nil {
+					tracef("  skipped: %v", err)
+					return nil, err
+				}
+				for _, pkg := range buildPackages {
+					tracef("  discovered wit_library %s (package %q)", pkg.Target, pkg.PackageName)
+				}
+				return buildPackages, nil
+			})
+			packages = append(packages, buildPackages...)
 		}
 
 		return nil
 	})
 
-	return packages, err
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := cache.save(); err != nil {
+		tracef("failed to write scan cache: %v", err)
+	}
+
+	declared := make(map[string]bool)
+	for _, pkg := range packages {
+		if pkg.Target == "" {
+			declared[filepath.Dir(pkg.FilePath)] = true
+		}
+	}
+
+	var warnings []string
+	for _, relPath := range malformed {
+		if declared[filepath.Dir(relPath)] {
+			tracef("%s has no package declaration but shares a directory with one", relPath)
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("malformed package: %s has content but no package declaration", relPath))
+	}
+
+	return packages, warnings, nil
 }
 
 func parseWitPackage(filePath, workspaceDir string) (*WitPackage, error) {
@@ -161,22 +262,37 @@ func parseWitPackage(filePath, workspaceDir string) (*WitPackage, error) {
 
 	var packageName string
 	var interfaces []string
+	hasContent := false
+	inBlockComment := false
 
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 
-		if matches := packageRegex.FindStringSubmatch(line); matches != nil {
+		var code string
+		code, inBlockComment = stripWitComments(line, inBlockComment)
+		if strings.TrimSpace(code) != "" {
+			hasContent = true
+		}
+
+		// Match on code only, so commented-out declarations do not count
+		if matches := packageRegex.FindStringSubmatch(code); matches != nil {
 			packageName = strings.TrimSpace(matches[1])
 		}
 
-		if matches := interfaceRegex.FindStringSubmatch(line); matches != nil {
+		if matches := interfaceRegex.FindStringSubmatch(code); matches != nil {
 			interfaces = append(interfaces, strings.TrimSpace(matches[1]))
 		}
 	}
 
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if packageName == "" && !hasContent {
+		return nil, errEmptyWitFile
+	}
 	if packageName == "" {
-		return nil, fmt.Errorf("no package declaration found")
+		return nil, errNoPackageDeclaration
 	}
 
 	relPath, _ := filepath.Rel(workspaceDir, filePath)
@@ -188,6 +304,39 @@ func parseWitPackage(filePath, workspaceDir string) (*WitPackage, error) {
 	}, nil
 }
 
+// stripWitComments removes line and block comments from one line of WIT,
+// carrying whether a block comment is still open into the next line
+func stripWitComments(line string, inBlockComment bool) (string, bool) {
+	var code strings.Builder
+	for line != "" {
+		if inBlockComment {
+			end := strings.Index(line, "*/")
+			if end < 0 {
+				return code.String(), true
+			}
+			line = line[end+2:]
+			inBlockComment = false
+			continue
+		}
+
+		lineComment := strings.Index(line, "//")
+		blockComment := strings.Index(line, "/*")
+		switch {
+		case lineComment >= 0 && (blockComment < 0 || lineComment < blockComment):
+			code.WriteString(line[:lineComment])
+			return code.String(), false
+		case blockComment >= 0:
+			code.WriteString(line[:blockComment])
+			line = line[blockComment+2:]
+			inBlockComment = true
+		default:
+			code.WriteString(line)
+			line = ""
+		}
+	}
+	return code.String(), inBlockComment
+}
+
 func parseBuildFile(buildPath, workspaceDir string) ([]WitPackage, error) {
 	file, err := os.Open(buildPath)
 	if err != nil {
@@ -238,14 +387,31 @@ func generateSuggestions(missingPackages []string, availablePackages []WitPackag
 	var suggestions []string
 
 	for _, missing := range missingPackages {
+		matched := false
 		for _, available := range availablePackages {
-			if available.PackageName == missing && available.Target != "" {
-				suggestions = append(suggestions, fmt.Sprintf(
-					"Add to deps: \"%s\",  # Provides package %s",
-					available.Target,
-					missing,
-				))
+			if available.PackageName != missing {
+				source := available.FilePath
+				if available.Target != "" {
+					source = available.Target
+				}
+				tracef("match %s against %q (%s): different package", missing, available.PackageName, source)
+				continue
+			}
+			if available.Target == "" {
+				tracef("match %s against %s: package found but not a wit_library target", missing, available.FilePath)
+				continue
 			}
+
+			tracef("match %s against %s: suggesting %s", missing, available.FilePath, available.Target)
+			matched = true
+			suggestions = append(suggestions, fmt.Sprintf(
+				"Add to deps: \"%s\",  # Provides package %s",
+				available.Target,
+				missing,
+			))
+		}
+		if !matched {
+			tracef("no wit_library target provides %s", missing)
 		}
 	}
 
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestFindAvailableWitPackagesFixtures scans each fixture workspace, with and
+// without a scan cache. Files that are empty or only hold comments declare
+// nothing and are not malformed; a file with content but no package line is
+// reported unless another file in its directory declares the package.
+func TestFindAvailableWitPackagesFixtures(t *testing.T) {
+	tests := []struct {
+		fixture      string
+		wantPackages []string
+		wantWarnings []string
+	}{
+		{fixture: "empty"},
+		{fixture: "comment_only"},
+		{
+			fixture:      "missing_package",
+			wantWarnings: []string{"malformed package: missing_package.wit has content but no package declaration"},
+		},
+		{fixture: "multi_file", wantPackages: []string{"example:calc@0.1.0"}},
+	}
+
+	for _, tt := range tests {
+		for _, cached := range []bool{false, true} {
+			name := tt.fixture
+			if cached {
+				name += "/cached"
+			}
+			t.Run(name, func(t *testing.T) {
+				workspace := copyFixture(t, tt.fixture)
+				var cache *ScanCache
+				if cached {
+					cache = loadScanCache(filepath.Join(t.TempDir(), "scan-cache.json"), workspace)
+				}
+
+				packages, warnings, err := findAvailableWitPackages(workspace, cache)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				var names []string
+				for _, pkg := range packages {
+					names = append(names, pkg.PackageName)
+				}
+				if !reflect.DeepEqual(names, tt.wantPackages) {
+					t.Errorf("packages = %q, want %q", names, tt.wantPackages)
+				}
+				if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+					t.Errorf("warnings = %q, want %q", warnings, tt.wantWarnings)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DepEntry is a single dependency declared in deps.toml
+type DepEntry struct {
+	Name   string
+	Path   string
+	URL    string
+	SHA256 string
+	SHA512 string
+	Line   int
+}
+
+// Problem describes a validation failure for a deps.toml entry
+type Problem struct {
+	Line    int
+	Name    string
+	Message string
+}
+
+// entryAttributes are the keys that belong to an entry rather than naming a new one
+var entryAttributes = map[string]bool{
+	"path":   true,
+	"url":    true,
+	"sha256": true,
+	"sha512": true,
+}
+
+// Parser and validator for the deps.toml files written by wit_structure.
+//
+// Understands the layout emitted by wit_library (a quoted package name on its
+// own line followed by attribute lines) as well as the wit-deps forms
+// `name = "url"` and `name = { path = "..." }`.
+func main() {
+	var (
+		check  = flag.Bool("check", false, "Validate deps.toml and exit non-zero on problems")
+		format = flag.Bool("format", false, "Rewrite deps.toml sorted and canonicalized")
+	)
+	flag.Parse()
+
+	if flag.NArg() != 1 || (*check && *format) {
+		fmt.Fprintf(os.Stderr, "Usage: %s [--check | --format] <deps.toml>\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	depsTomlPath := flag.Arg(0)
+	entries, problems, err := parseDepsToml(depsTomlPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading deps.toml: %v\n", err)
+		os.Exit(1)
+	}
+
+	problems = append(problems, validateEntries(entries, filepath.Dir(depsTomlPath))...)
+	sort.SliceStable(problems, func(i, j int) bool {
+		return problems[i].Line < problems[j].Line
+	})
+
+	switch {
+	case *check:
+		if len(problems) > 0 {
+			printProblems(depsTomlPath, problems)
+			os.Exit(1)
+		}
+		fmt.Printf("%s: %d entries OK\n", depsTomlPath, len(entries))
+
+	case *format:
+		// Refuse to pick a winner between duplicate keys on the caller's behalf
+		if hasDuplicates(problems) {
+			printProblems(depsTomlPath, problems)
+			fmt.Fprintf(os.Stderr, "Error: refusing to format %s with duplicate keys\n", depsTomlPath)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(depsTomlPath, []byte(canonicalize(entries)), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing deps.toml: %v\n", err)
+			os.Exit(1)
+		}
+
+	default:
+		printProblems(depsTomlPath, problems)
+		fmt.Print(canonicalize(entries))
+	}
+}
+
+// parseDepsToml reads deps.toml entries, reporting duplicate and malformed keys
+func parseDepsToml(path string) ([]DepEntry, []Problem, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	var entries []DepEntry
+	var problems []Problem
+	seen := make(map[string]int)
+	var current *DepEntry
+
+	addEntry := func(name string, line int) *DepEntry {
+		if firstLine, exists := seen[name]; exists {
+			problems = append(problems, Problem{
+				Line:    line,
+				Name:    name,
+				Message: fmt.Sprintf("duplicate key (first defined on line %d)", firstLine),
+			})
+		} else {
+			seen[name] = line
+		}
+		entries = append(entries, DepEntry{Name: name, Line: line})
+		return &entries[len(entries)-1]
+	}
+
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+
+		// Table headers: [deps] opens the dependency section, [deps.name] or
+		// [name] opens a single entry
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			header := strings.TrimSpace(line[1 : len(line)-1])
+			if header == "deps" {
+				current = nil
+				continue
+			}
+			current = addEntry(unquote(strings.TrimPrefix(header, "deps.")), lineNum)
+			continue
+		}
+
+		key, value, hasValue := strings.Cut(line, "=")
+		key = unquote(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		// A lone key line starts an entry whose attributes follow
+		if !hasValue {
+			current = addEntry(key, lineNum)
+			continue
+		}
+
+		if current != nil && entryAttributes[key] {
+			setAttribute(current, key, unquote(value))
+			continue
+		}
+
+		// Inline entry: name = "url-or-path" or name = { path = "..." }
+		current = nil
+		entry := addEntry(key, lineNum)
+		if strings.HasPrefix(value, "{") && strings.HasSuffix(value, "}") {
+			for _, field := range strings.Split(value[1:len(value)-1], ",") {
+				fieldKey, fieldValue, ok := strings.Cut(field, "=")
+				fieldKey = strings.TrimSpace(fieldKey)
+				if !ok || !entryAttributes[fieldKey] {
+					problems = append(problems, Problem{
+						Line:    lineNum,
+						Name:    key,
+						Message: fmt.Sprintf("unsupported inline field %q", strings.TrimSpace(field)),
+					})
+					continue
+				}
+				setAttribute(entry, fieldKey, unquote(strings.TrimSpace(fieldValue)))
+			}
+		} else if v := unquote(value); isURL(v) {
+			entry.URL = v
+		} else {
+			entry.Path = v
+		}
+	}
+
+	return entries, problems, scanner.Err()
+}
+
+// validateEntries checks that every entry points at an existing path or a resolvable URL
+func validateEntries(entries []DepEntry, baseDir string) []Problem {
+	var problems []Problem
+
+	for _, entry := range entries {
+		switch {
+		case entry.Path == "" && entry.URL == "":
+			problems = append(problems, Problem{entry.Line, entry.Name, "entry has neither path nor url"})
+
+		case entry.Path != "" && entry.URL != "":
+			problems = append(problems, Problem{entry.Line, entry.Name, "entry sets both path and url"})
+
+		case entry.Path != "":
+			resolved := entry.Path
+			if !filepath.IsAbs(resolved) {
+				resolved = filepath.Join(baseDir, resolved)
+			}
+			if _, err := os.Stat(resolved); err != nil {
+				problems = append(problems, Problem{entry.Line, entry.Name, fmt.Sprintf("dangling path %s", entry.Path)})
+			}
+
+		default:
+			if !isURL(entry.URL) {
+				problems = append(problems, Problem{entry.Line, entry.Name, fmt.Sprintf("unresolvable url %s", entry.URL)})
+			}
+		}
+	}
+
+	return problems
+}
+
+// canonicalize renders entries sorted by name in the layout wit_library emits
+func canonicalize(entries []DepEntry) string {
+	sorted := make([]DepEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	var b strings.Builder
+	b.WriteString("[deps]\n")
+	for _, entry := range sorted {
+		fmt.Fprintf(&b, "%q\n", entry.Name)
+		if entry.Path != "" {
+			fmt.Fprintf(&b, "path = %q\n", entry.Path)
+		}
+		if entry.URL != "" {
+			fmt.Fprintf(&b, "url = %q\n", entry.URL)
+		}
+		if entry.SHA256 != "" {
+			fmt.Fprintf(&b, "sha256 = %q\n", entry.SHA256)
+		}
+		if entry.SHA512 != "" {
+			fmt.Fprintf(&b, "sha512 = %q\n", entry.SHA512)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func setAttribute(entry *DepEntry, key, value string) {
+	switch key {
+	case "path":
+		entry.Path = value
+	case "url":
+		entry.URL = value
+	case "sha256":
+		entry.SHA256 = strings.ToLower(value)
+	case "sha512":
+		entry.SHA512 = strings.ToLower(value)
+	}
+}
+
+func hasDuplicates(problems []Problem) bool {
+	for _, problem := range problems {
+		if strings.HasPrefix(problem.Message, "duplicate key") {
+			return true
+		}
+	}
+	return false
+}
+
+func printProblems(path string, problems []Problem) {
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "%s:%d: %s: %s\n", path, problem.Line, problem.Name, problem.Message)
+	}
+}
+
+// stripComment removes a trailing # comment that is not inside a quoted string
+func stripComment(line string) string {
+	inQuotes := false
+	for i, r := range line {
+		switch r {
+		case '"':
+			inQuotes = !inQuotes
+		case '#':
+			if !inQuotes {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
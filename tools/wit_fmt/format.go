@@ -0,0 +1,405 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/witsyntax"
+)
+
+const indentUnit = "    "
+
+// item is one declaration: a statement ending in ";", a list entry ending in
+// ",", or a header followed by a braced block of nested items
+type item struct {
+	comments    []witsyntax.Token // Own-line comments directly above the item
+	header      []witsyntax.Token
+	block       *block
+	trailing    *witsyntax.Token // Comment on the same line after the item
+	blankBefore bool
+	blankHeader bool // Blank line between the comments and the header
+}
+
+type block struct {
+	items       []*item
+	openComment *witsyntax.Token  // Comment on the same line as the opening brace
+	closing     []witsyntax.Token // Comments after the last item
+}
+
+// formatWIT re-emits src in canonical form: four-space indentation, one item
+// per line, normalized spacing, at most one blank line between items and
+// each run of adjacent use statements sorted. Comments are kept with the
+// item that follows them.
+func formatWIT(src string) (string, error) {
+	tokens, err := witsyntax.Tokenize(src)
+	if err != nil {
+		return "", err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseBlock(false)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	writeItems(&b, root, 0)
+	return b.String(), nil
+}
+
+type parser struct {
+	tokens []witsyntax.Token
+	pos    int
+}
+
+func (p *parser) peek() (witsyntax.Token, bool) {
+	if p.pos >= len(p.tokens) {
+		return witsyntax.Token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// parseBlock reads items up to the closing brace (left unconsumed) or, at the
+// top level, to the end of the file
+func (p *parser) parseBlock(nested bool) (*block, error) {
+	blk := &block{}
+	var pending []witsyntax.Token
+
+	for {
+		tok, ok := p.peek()
+		if !ok {
+			if nested {
+				return nil, fmt.Errorf("unexpected end of file, missing }")
+			}
+			blk.closing = pending
+			return blk, nil
+		}
+
+		if tok.Is("}") {
+			if !nested {
+				return nil, fmt.Errorf("line %d: unmatched }", tok.Line)
+			}
+			blk.closing = pending
+			return blk, nil
+		}
+
+		if tok.Kind == witsyntax.TokComment {
+			p.pos++
+			if !tok.NewlineBefore && len(pending) == 0 && len(blk.items) > 0 && blk.items[len(blk.items)-1].trailing == nil {
+				blk.items[len(blk.items)-1].trailing = &tok
+			} else {
+				pending = append(pending, tok)
+			}
+			continue
+		}
+
+		it := &item{comments: pending, blankBefore: tok.BlankBefore}
+		if len(pending) > 0 {
+			it.blankBefore = pending[0].BlankBefore
+			it.blankHeader = tok.BlankBefore
+		}
+		pending = nil
+
+		if err := p.parseItem(it); err != nil {
+			return nil, err
+		}
+		blk.items = append(blk.items, it)
+	}
+}
+
+// parseItem reads the item's header up to its terminator or braced block
+func (p *parser) parseItem(it *item) error {
+	depth := 0       // Nesting of () and <>
+	inlineDepth := 0 // Nesting of use-list braces like .{a, b}
+
+	for {
+		tok, ok := p.peek()
+		if !ok {
+			return nil
+		}
+
+		switch {
+		case tok.Is("}") && inlineDepth == 0:
+			return nil // Last entry of a list without a trailing comma
+
+		case tok.Is("(") || tok.Is("<"):
+			depth++
+		case tok.Is(")") || tok.Is(">"):
+			depth--
+		case tok.Is("}"):
+			inlineDepth--
+
+		case tok.Is("{"):
+			if len(it.header) > 0 && it.header[len(it.header)-1].Is(".") {
+				inlineDepth++
+				break
+			}
+
+			p.pos++
+			it.block = &block{}
+			if next, ok := p.peek(); ok && next.Kind == witsyntax.TokComment && !next.NewlineBefore {
+				it.block.openComment = &next
+				p.pos++
+			}
+			children, err := p.parseBlock(true)
+			if err != nil {
+				return err
+			}
+			children.openComment = it.block.openComment
+			it.block = children
+			p.pos++ // The closing brace
+			return nil
+		}
+
+		it.header = append(it.header, tok)
+		p.pos++
+
+		if depth == 0 && inlineDepth == 0 && (tok.Is(";") || tok.Is(",")) {
+			return nil
+		}
+	}
+}
+
+func writeItems(b *strings.Builder, blk *block, level int) {
+	sortUseRuns(blk.items)
+
+	indent := strings.Repeat(indentUnit, level)
+	headers := make([]string, len(blk.items))
+	for i, it := range blk.items {
+		headers[i] = renderHeader(it.header, level)
+	}
+	widths := trailingCommentWidths(blk.items, headers)
+
+	for i, it := range blk.items {
+		if i > 0 && it.blankBefore {
+			b.WriteString("\n")
+		}
+		writeComments(b, it.comments, indent)
+		if it.blankHeader {
+			b.WriteString("\n")
+		}
+
+		b.WriteString(indent + headers[i])
+
+		if it.block != nil {
+			writeBlock(b, it.block, level)
+		}
+		if it.trailing != nil {
+			b.WriteString(strings.Repeat(" ", widths[i]-len(headers[i])+1) + it.trailing.Text)
+		}
+		b.WriteString("\n")
+	}
+
+	if len(blk.closing) > 0 && blk.closing[0].BlankBefore && len(blk.items) > 0 {
+		b.WriteString("\n")
+	}
+	writeComments(b, blk.closing, indent)
+}
+
+// writeComments writes own-line comments, keeping blank lines between them
+func writeComments(b *strings.Builder, comments []witsyntax.Token, indent string) {
+	for i, c := range comments {
+		if i > 0 && c.BlankBefore {
+			b.WriteString("\n")
+		}
+		b.WriteString(indent + c.Text + "\n")
+	}
+}
+
+// trailingCommentWidths aligns trailing comments on adjacent single-line
+// items, returning for each item the header width its comment is padded to
+func trailingCommentWidths(items []*item, headers []string) []int {
+	widths := make([]int, len(items))
+	aligned := func(i int) bool {
+		it := items[i]
+		return it.trailing != nil && it.block == nil && !strings.Contains(headers[i], "\n")
+	}
+
+	for start := 0; start < len(items); {
+		if !aligned(start) {
+			widths[start] = len(headers[start])
+			start++
+			continue
+		}
+
+		end := start + 1
+		for end < len(items) && aligned(end) && !items[end].blankBefore && len(items[end].comments) == 0 {
+			end++
+		}
+
+		width := 0
+		for i := start; i < end; i++ {
+			if len(headers[i]) > width {
+				width = len(headers[i])
+			}
+		}
+		for i := start; i < end; i++ {
+			widths[i] = width
+		}
+
+		start = end
+	}
+
+	return widths
+}
+
+func writeBlock(b *strings.Builder, blk *block, level int) {
+	if len(blk.items) == 0 && len(blk.closing) == 0 && blk.openComment == nil {
+		b.WriteString(" {}")
+		return
+	}
+
+	b.WriteString(" {")
+	if blk.openComment != nil {
+		b.WriteString(" " + blk.openComment.Text)
+	}
+	b.WriteString("\n")
+	writeItems(b, blk, level+1)
+	b.WriteString(strings.Repeat(indentUnit, level) + "}")
+}
+
+// renderHeader joins header tokens with canonical spacing. Feature gates such
+// as @since(version = 0.2.0) go on their own line above the declaration, and
+// parameter lists that were wrapped in the source get one parameter per line.
+func renderHeader(header []witsyntax.Token, level int) string {
+	var b strings.Builder
+	indent := strings.Repeat(indentUnit, level)
+
+	isPackage := len(header) > 0 && header[0].Kind == witsyntax.TokIdent && header[0].Text == "package"
+
+	// Line breaks are deferred until the next token so that two requests in a
+	// row produce one break at the later indentation
+	lineStart := true
+	pendingIndent := ""
+	newline := func(extra string) {
+		lineStart = true
+		pendingIndent = indent + extra
+	}
+
+	depth := 0
+	expanded := false // Inside a wrapped parameter list
+	inGate := len(header) > 0 && header[0].Is("@")
+
+	for i, tok := range header {
+		if expanded && depth == 1 && tok.Is(")") {
+			newline("")
+		}
+
+		if lineStart {
+			if i > 0 {
+				b.WriteString("\n" + pendingIndent)
+			}
+			lineStart = false
+		} else if spaceBetween(header, i, isPackage) {
+			b.WriteString(" ")
+		}
+		b.WriteString(tok.Text)
+
+		switch {
+		case tok.Is("<"):
+			depth++
+		case tok.Is(">"):
+			depth--
+		case tok.Is("("):
+			depth++
+			if depth == 1 && !inGate && wrappedList(header, i) {
+				expanded = true
+				newline(indentUnit)
+			}
+		case tok.Is(")"):
+			depth--
+			if depth == 0 {
+				expanded = false
+				if inGate {
+					newline("")
+					inGate = i+1 < len(header) && header[i+1].Is("@")
+				}
+			}
+		case tok.Is(",") && expanded && depth == 1:
+			newline(indentUnit)
+		case tok.IsLineComment():
+			newline(indentUnit)
+		}
+	}
+
+	return b.String()
+}
+
+// wrappedList reports whether the parenthesized list opening at header[open]
+// spans several lines in the source
+func wrappedList(header []witsyntax.Token, open int) bool {
+	depth := 0
+	for _, tok := range header[open:] {
+		switch {
+		case tok.Is("(") || tok.Is("<"):
+			depth++
+		case tok.Is(")") || tok.Is(">"):
+			depth--
+			if depth == 0 {
+				return false
+			}
+		}
+		if tok.NewlineBefore && depth > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func spaceBetween(header []witsyntax.Token, i int, isPackage bool) bool {
+	prev, cur := header[i-1], header[i]
+
+	switch {
+	case cur.Is(",") || cur.Is(";") || cur.Is(")") || cur.Is(">") || cur.Is(".") || cur.Is(":"):
+		return false
+	case cur.Is("}"): // Only inline use lists reach the header
+		return false
+	case cur.Is("/") || prev.Is("/") || cur.Is("@") || prev.Is("@"):
+		return false
+	case prev.Is(".") || prev.Is("(") || prev.Is("<") || prev.Is("{"):
+		return false
+	case cur.Is("<"):
+		return false
+	case cur.Is("("):
+		return prev.Kind != witsyntax.TokIdent
+	case prev.Is(":"):
+		// Package names like wasi:io keep the colon tight; everywhere else a
+		// colon separates a name from its type
+		if isPackage {
+			return false
+		}
+		return !(i+1 < len(header) && (header[i+1].Is("/") || header[i+1].Is("@")))
+	}
+	return true
+}
+
+// sortUseRuns sorts each run of consecutive use statements that is not
+// broken up by a blank line. Comments above a statement move with it.
+func sortUseRuns(items []*item) {
+	for start := 0; start < len(items); {
+		if !isUse(items[start]) {
+			start++
+			continue
+		}
+
+		end := start + 1
+		for end < len(items) && isUse(items[end]) && !items[end].blankBefore {
+			end++
+		}
+
+		run := items[start:end]
+		blank := run[0].blankBefore
+		run[0].blankBefore = false
+		sort.SliceStable(run, func(a, b int) bool {
+			return renderHeader(run[a].header, 0) < renderHeader(run[b].header, 0)
+		})
+		run[0].blankBefore = blank
+
+		start = end
+	}
+}
+
+func isUse(it *item) bool {
+	return it.block == nil && len(it.header) > 0 && it.header[0].Kind == witsyntax.TokIdent && it.header[0].Text == "use"
+}
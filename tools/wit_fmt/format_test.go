@@ -0,0 +1,142 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormatWIT(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string // Canonical form, which must format to itself
+	}{
+		{
+			name:  "spacing and indentation",
+			input: "package   example:demo@1.0.0;\n\ninterface   api{\n  add: func(a:s32,b:s32)->s32;\n}\n",
+			want:  "package example:demo@1.0.0;\n\ninterface api {\n    add: func(a: s32, b: s32) -> s32;\n}\n",
+		},
+		{
+			name:  "blank lines collapse",
+			input: "package example:demo;\n\n\n\ninterface api {\n\n\n    f: func();\n\n\n    g: func();\n}\n",
+			want:  "package example:demo;\n\ninterface api {\n    f: func();\n\n    g: func();\n}\n",
+		},
+		{
+			name:  "use statements sorted",
+			input: "interface api {\n    use wasi:io/streams.{output-stream};\n    use example:types/common.{id};\n}\n",
+			want:  "interface api {\n    use example:types/common.{id};\n    use wasi:io/streams.{output-stream};\n}\n",
+		},
+		{
+			name:  "one field per line",
+			input: "interface api {\n    record point{x:u32,y:u32,}\n    variant shape { circle(u32), square(u32) }\n}\n",
+			want: "interface api {\n    record point {\n        x: u32,\n        y: u32,\n    }\n" +
+				"    variant shape {\n        circle(u32),\n        square(u32)\n    }\n}\n",
+		},
+		{
+			name:  "comments stay with their item",
+			input: "interface api {\n// The record\n  record point { x: u32 }\n  add: func(); // sum\n}\n",
+			want:  "interface api {\n    // The record\n    record point {\n        x: u32\n    }\n    add: func(); // sum\n}\n",
+		},
+		{
+			name:  "world",
+			input: "world   demo   {\n  export api;\n      import wasi:cli/environment@0.2.0;\n}\n",
+			want:  "world demo {\n    export api;\n    import wasi:cli/environment@0.2.0;\n}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatWIT(tt.input)
+			if err != nil {
+				t.Fatalf("formatWIT: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("formatWIT:\n%s\nwant:\n%s", got, tt.want)
+			}
+
+			again, err := formatWIT(tt.want)
+			if err != nil {
+				t.Fatalf("formatWIT of canonical form: %v", err)
+			}
+			if again != tt.want {
+				t.Errorf("canonical form is not stable, reformatted to:\n%s", again)
+			}
+		})
+	}
+}
+
+func TestFormatWITRejectsUnbalancedBraces(t *testing.T) {
+	if _, err := formatWIT("interface api {\n"); err == nil {
+		t.Error("formatWIT accepted an unclosed block")
+	}
+}
+
+// TestFormatWITIdempotentOnRepositoryFiles formats every WIT file in the
+// repository twice and checks the second pass changes nothing
+func TestFormatWITIdempotentOnRepositoryFiles(t *testing.T) {
+	root := filepath.Join("..", "..")
+	checked := 0
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (strings.HasPrefix(d.Name(), "bazel-") || d.Name() == ".git") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || filepath.Ext(path) != ".wit" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		once, err := formatWIT(string(data))
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			return nil
+		}
+		twice, err := formatWIT(once)
+		if err != nil || twice != once {
+			t.Errorf("%s: formatting is not idempotent (err %v)", path, err)
+		}
+		checked++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checked == 0 {
+		t.Fatal("found no WIT files")
+	}
+}
+
+func TestFormatFileCheckMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.wit")
+	messy := "interface   api{\n  f: func();\n}\n"
+	if err := os.WriteFile(path, []byte(messy), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := formatFile(path, true)
+	if err != nil || !changed {
+		t.Fatalf("--check on a messy file = %v, %v; want true, nil", changed, err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != messy {
+		t.Error("--check rewrote the file")
+	}
+
+	if changed, err := formatFile(path, false); err != nil || !changed {
+		t.Fatalf("formatting a messy file = %v, %v; want true, nil", changed, err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Errorf("mode after rewrite = %v, want 0640", info.Mode().Perm())
+	}
+
+	if changed, err := formatFile(path, true); err != nil || changed {
+		t.Errorf("--check after formatting = %v, %v; want false, nil", changed, err)
+	}
+}
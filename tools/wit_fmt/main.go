@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Rewrites WIT files in a canonical form so formatting differences do not
+// show up as diffs or invalidate caches. With --check, reports files that
+// are not canonical and exits non-zero instead of rewriting them.
+func main() {
+	check := flag.Bool("check", false, "Report files that are not canonically formatted instead of rewriting them")
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [--check] <file.wit>...\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	failed := false
+	for _, path := range flag.Args() {
+		changed, err := formatFile(path, *check)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error formatting %s: %v\n", path, err)
+			failed = true
+			continue
+		}
+
+		if changed && *check {
+			fmt.Println(path)
+			failed = true
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// formatFile formats path, rewriting it unless checkOnly is set, and reports
+// whether the file was not already canonical
+func formatFile(path string, checkOnly bool) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	formatted, err := formatWIT(string(data))
+	if err != nil {
+		return false, err
+	}
+
+	if formatted == string(data) {
+		return false, nil
+	}
+	if checkOnly {
+		return true, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return true, err
+	}
+	return true, os.WriteFile(path, []byte(formatted), info.Mode().Perm())
+}
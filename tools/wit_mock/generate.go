@@ -0,0 +1,302 @@
+package main
+
+import (
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cmImport is the Component Model support package used by wit-bindgen-go
+const cmImport = "go.bytecodealliance.org/cm"
+
+var primitiveTypes = map[string]string{
+	"bool":    "bool",
+	"s8":      "int8",
+	"s16":     "int16",
+	"s32":     "int32",
+	"s64":     "int64",
+	"u8":      "uint8",
+	"u16":     "uint16",
+	"u32":     "uint32",
+	"u64":     "uint64",
+	"f32":     "float32",
+	"f64":     "float64",
+	"float32": "float32",
+	"float64": "float64",
+	"char":    "rune",
+	"string":  "string",
+}
+
+// Canonical ABI sizes on wasm32, used to pick the shape of a cm.Result
+var primitiveSizes = map[string]int{
+	"bool": 1, "s8": 1, "u8": 1,
+	"s16": 2, "u16": 2,
+	"s32": 4, "u32": 4, "f32": 4, "float32": 4, "char": 4,
+	"s64": 8, "u64": 8, "f64": 8, "float64": 8,
+	"string": 8, "list": 8,
+}
+
+// Words wit-bindgen-go spells as Go initialisms
+var initialisms = map[string]string{
+	"api": "API", "http": "HTTP", "id": "ID", "io": "IO", "ip": "IP",
+	"json": "JSON", "tcp": "TCP", "udp": "UDP", "uri": "URI", "url": "URL",
+}
+
+// generator emits a Go file that assigns a stub to every export of a world,
+// using the bindings wit-bindgen-go generates under module
+type generator struct {
+	pkg       *witPackage
+	module    string
+	responses map[string]string
+
+	imports map[string]string // Import path to alias
+	used    map[string]bool   // Response keys that matched an export
+}
+
+func (g *generator) generate(world *witWorld, source string) ([]byte, error) {
+	g.imports = make(map[string]string)
+	g.used = make(map[string]bool)
+
+	var body strings.Builder
+	for _, export := range world.Exports {
+		iface, ok := g.pkg.Interfaces[export]
+		if !ok {
+			// Exports of other packages have no function listing in this file
+			fmt.Fprintf(&body, "\t// %s is defined outside %s and is not mocked\n", export, source)
+			continue
+		}
+		alias := g.importInterface(g.pkg.Namespace, g.pkg.Name, iface.Name)
+		for _, fn := range iface.Funcs {
+			g.writeStub(&body, alias, iface, fn, iface.Name+"/"+fn.Name)
+		}
+	}
+
+	if len(world.Funcs) > 0 {
+		alias := g.importInterface(g.pkg.Namespace, g.pkg.Name, world.Name)
+		for _, fn := range world.Funcs {
+			g.writeStub(&body, alias, nil, fn, world.Name+"/"+fn.Name)
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "// Code generated by wit_mock from %s. DO NOT EDIT.\n\n", source)
+	out.WriteString("// Mock implementation of the " + world.Name + " world: every export returns\n")
+	out.WriteString("// its canned response, or the zero value when there is none.\n")
+	out.WriteString("package main\n\n")
+
+	out.WriteString("import (\n\t\"encoding/json\"\n\n")
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if path == cmImport {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		} else {
+			fmt.Fprintf(&out, "\t%s %q\n", g.imports[path], path)
+		}
+	}
+	out.WriteString(")\n\n")
+
+	out.WriteString("// mockResponses holds canned results as JSON, keyed by \"interface/function\"\n")
+	out.WriteString("var mockResponses = map[string]string{\n")
+	for _, key := range sortedKeys(g.responses) {
+		if g.used[key] {
+			fmt.Fprintf(&out, "\t%q: %s,\n", key, strconv.Quote(g.responses[key]))
+		}
+	}
+	out.WriteString("}\n\n")
+
+	out.WriteString("// mockResponse decodes the canned response for key into out, leaving it at\n")
+	out.WriteString("// the zero value when there is none\n")
+	out.WriteString("func mockResponse(key string, out any) {\n")
+	out.WriteString("\tif data, ok := mockResponses[key]; ok {\n\t\tjson.Unmarshal([]byte(data), out)\n\t}\n}\n\n")
+
+	out.WriteString("func init() {\n")
+	out.WriteString(body.String())
+	out.WriteString("}\n\n")
+	out.WriteString("func main() {}\n")
+
+	formatted, err := format.Source([]byte(out.String()))
+	if err != nil {
+		return nil, fmt.Errorf("generated code does not parse: %w", err)
+	}
+	return formatted, nil
+}
+
+// unusedResponses lists canned responses that matched no export
+func (g *generator) unusedResponses() []string {
+	var unused []string
+	for _, key := range sortedKeys(g.responses) {
+		if !g.used[key] {
+			unused = append(unused, key)
+		}
+	}
+	return unused
+}
+
+func (g *generator) writeStub(b *strings.Builder, alias string, iface *witInterface, fn witFunc, key string) {
+	params := make([]string, len(fn.Params))
+	for i, param := range fn.Params {
+		params[i] = goParamName(param.Name) + " " + g.goType(param.Type, iface)
+	}
+
+	fmt.Fprintf(b, "\t%s.Exports.%s = func(%s)", alias, goName(fn.Name), strings.Join(params, ", "))
+	if fn.Result == nil {
+		b.WriteString(" {}\n")
+		return
+	}
+
+	if _, ok := g.responses[key]; ok {
+		g.used[key] = true
+	}
+	fmt.Fprintf(b, " (mockResult %s) {\n", g.goType(fn.Result, iface))
+	fmt.Fprintf(b, "\t\tmockResponse(%q, &mockResult)\n\t\treturn\n\t}\n", key)
+}
+
+// goType maps a WIT type to the Go type wit-bindgen-go generates for it
+func (g *generator) goType(t *witType, iface *witInterface) string {
+	if goType, ok := primitiveTypes[t.Name]; ok {
+		return goType
+	}
+
+	args := make([]string, len(t.Args))
+	for i, arg := range t.Args {
+		args[i] = g.goType(arg, iface)
+	}
+
+	switch t.Name {
+	case "_":
+		return "struct{}"
+	case "list", "option":
+		g.imports[cmImport] = "cm"
+		return fmt.Sprintf("cm.%s[%s]", goName(t.Name), args[0])
+	case "tuple":
+		g.imports[cmImport] = "cm"
+		if len(args) == 2 {
+			return "cm.Tuple[" + strings.Join(args, ", ") + "]"
+		}
+		return fmt.Sprintf("cm.Tuple%d[%s]", len(args), strings.Join(args, ", "))
+	case "own", "borrow":
+		return args[0]
+	case "result":
+		g.imports[cmImport] = "cm"
+		switch len(args) {
+		case 0:
+			return "cm.BoolResult"
+		case 1:
+			return fmt.Sprintf("cm.Result[%s, %s, struct{}]", args[0], args[0])
+		}
+		if t.Args[0].Name == "_" {
+			return fmt.Sprintf("cm.Result[%s, struct{}, %s]", args[1], args[1])
+		}
+		// The shape is whichever payload is larger; assume the ok type when
+		// a size is unknown
+		shape := args[0]
+		okSize, okKnown := abiSize(t.Args[0])
+		errSize, errKnown := abiSize(t.Args[1])
+		if okKnown && errKnown && errSize > okSize {
+			shape = args[1]
+		}
+		return fmt.Sprintf("cm.Result[%s, %s, %s]", shape, args[0], args[1])
+	}
+
+	// A named type: local to the interface, or brought in by a use
+	name := t.Name
+	namespace, pkgName, ifaceName := g.pkg.Namespace, g.pkg.Name, ""
+	if iface != nil {
+		ifaceName = iface.Name
+		if use, ok := iface.Uses[t.Name]; ok {
+			name = use.Name
+			namespace, pkgName, ifaceName = splitInterfacePath(use.From, g.pkg)
+		}
+	}
+	if ifaceName == "" {
+		return goName(name)
+	}
+	return g.importInterface(namespace, pkgName, ifaceName) + "." + goName(name)
+}
+
+// importInterface returns the alias for the bindings package of an interface
+func (g *generator) importInterface(namespace, pkgName, ifaceName string) string {
+	path := strings.Join([]string{g.module, namespace, pkgName, ifaceName}, "/")
+	if alias, ok := g.imports[path]; ok {
+		return alias
+	}
+
+	taken := map[string]bool{"json": true, "cm": true}
+	for _, existing := range g.imports {
+		taken[existing] = true
+	}
+
+	alias := strings.ReplaceAll(ifaceName, "-", "")
+	if taken[alias] {
+		alias = strings.ReplaceAll(namespace+pkgName+ifaceName, "-", "")
+	}
+	for i := 2; taken[alias]; i++ {
+		alias = fmt.Sprintf("%s%d", strings.ReplaceAll(ifaceName, "-", ""), i)
+	}
+
+	g.imports[path] = alias
+	return alias
+}
+
+// splitInterfacePath resolves a use path like "types" or "wasi:io/streams@0.2.0"
+func splitInterfacePath(path string, pkg *witPackage) (namespace, pkgName, ifaceName string) {
+	path, _, _ = strings.Cut(path, "@")
+	qualified, iface, ok := strings.Cut(path, "/")
+	if !ok {
+		return pkg.Namespace, pkg.Name, path
+	}
+	namespace, pkgName, _ = strings.Cut(qualified, ":")
+	return namespace, pkgName, iface
+}
+
+func abiSize(t *witType) (int, bool) {
+	size, ok := primitiveSizes[t.Name]
+	return size, ok
+}
+
+// goName converts a kebab-case WIT name to an exported Go identifier
+func goName(name string) string {
+	name = strings.TrimPrefix(name, "%")
+	var b strings.Builder
+	for _, word := range strings.Split(name, "-") {
+		if word == "" {
+			continue
+		}
+		if initialism, ok := initialisms[word]; ok {
+			b.WriteString(initialism)
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
+
+// goParamName converts a WIT parameter name to a Go identifier that cannot
+// clash with keywords or the named result
+func goParamName(name string) string {
+	first, rest, _ := strings.Cut(strings.TrimPrefix(name, "%"), "-")
+	param := strings.ToLower(first) + goName(rest)
+	switch param {
+	case "break", "case", "chan", "const", "continue", "default", "defer", "else",
+		"fallthrough", "for", "func", "go", "goto", "if", "import", "interface",
+		"map", "package", "range", "return", "select", "struct", "switch", "type",
+		"var", "mockResult":
+		return param + "_"
+	}
+	return param
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
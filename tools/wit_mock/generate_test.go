@@ -0,0 +1,222 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files in testdata")
+
+// bindingsModule is the module the fake wit-bindgen-go bindings live under
+const bindingsModule = "example.com/calc/gen"
+
+// generateMock runs the generator over testdata/calc.wit the way main does
+func generateMock(t *testing.T, responses map[string]string) (*generator, []byte) {
+	t.Helper()
+	data, err := os.ReadFile("testdata/calc.wit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err := parseWIT(string(data))
+	if err != nil {
+		t.Fatalf("parseWIT: %v", err)
+	}
+	world, err := selectWorld(pkg, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gen := &generator{pkg: pkg, module: bindingsModule, responses: responses}
+	source, err := gen.generate(world, "calc.wit")
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	return gen, source
+}
+
+func TestGenerateGolden(t *testing.T) {
+	_, source := generateMock(t, map[string]string{"calculator/add": "42", "calc/version": `"1.2.3"`})
+
+	golden := filepath.Join("testdata", "calc_mock.go.golden")
+	if *update {
+		if err := os.WriteFile(golden, source, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if string(source) != string(want) {
+		t.Errorf("generated mock differs from %s (run with -update to accept):\n%s", golden, source)
+	}
+}
+
+func TestGenerateResponses(t *testing.T) {
+	responses := map[string]string{
+		"calculator/add":     "42",
+		"calculator/history": `["1 + 2"]`,
+		"calculator/reset":   "null", // No result to return it from
+		"calculator/divide":  "0",    // No such function
+	}
+	gen, source := generateMock(t, responses)
+
+	for _, want := range []string{
+		`"calculator/add":     "42",`,
+		`"calculator/history": "[\"1 + 2\"]",`,
+		`mockResponse("calculator/add", &mockResult)`,
+		`mockResponse("calc/version", &mockResult)`,
+	} {
+		if !strings.Contains(string(source), want) {
+			t.Errorf("generated mock does not contain %s", want)
+		}
+	}
+	if strings.Contains(string(source), `"calculator/reset":`) {
+		t.Error("a response for a function without a result was embedded")
+	}
+
+	if got, want := gen.unusedResponses(), []string{"calculator/divide", "calculator/reset"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unusedResponses = %v, want %v", got, want)
+	}
+}
+
+func TestReadResponses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "responses.json")
+	if err := os.WriteFile(path, []byte(`{
+  "calculator/add": 42,
+  "calculator/history": [ "1 + 2",  "3 * 4" ]
+}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	responses, err := readResponses(path)
+	if err != nil {
+		t.Fatalf("readResponses: %v", err)
+	}
+	want := map[string]string{"calculator/add": "42", "calculator/history": `["1 + 2","3 * 4"]`}
+	if !reflect.DeepEqual(responses, want) {
+		t.Errorf("readResponses = %v, want %v", responses, want)
+	}
+
+	if responses, err := readResponses(""); err != nil || len(responses) != 0 {
+		t.Errorf("readResponses without a file = %v, %v, want no responses", responses, err)
+	}
+}
+
+// fakeBindings stand in for the wit-bindgen-go output and the cm package
+// for testdata/calc.wit, declaring just what the mock refers to
+var fakeBindings = map[string]string{
+	"go.mod": `module example.com/calc
+
+go 1.21
+
+require go.bytecodealliance.org/cm v0.0.0
+
+replace go.bytecodealliance.org/cm => ./cm
+`,
+	"cm/go.mod": "module go.bytecodealliance.org/cm\n\ngo 1.21\n",
+	"cm/cm.go": `package cm
+
+type List[T any] struct{ Items []T }
+
+type Result[Shape, OK, Err any] struct {
+	OK  *OK
+	Err *Err
+}
+`,
+	"gen/example/calc/types/types.go": `package types
+
+type Pair struct {
+	Left  int32
+	Right int32
+}
+`,
+	"gen/example/calc/calculator/calculator.go": `package calculator
+
+import (
+	"example.com/calc/gen/example/calc/types"
+	"go.bytecodealliance.org/cm"
+)
+
+type Op uint8
+
+var Exports struct {
+	Add     func(a int32, b int32) int32
+	Apply   func(operation Op, operands types.Pair) cm.Result[string, int32, string]
+	History func() cm.List[string]
+	Reset   func()
+}
+`,
+	"gen/example/calc/calc/calc.go": `package calc
+
+var Exports struct {
+	Version func() string
+}
+`,
+	// Calls the stubs the way the component's exports would
+	"mock/mock_test.go": `package main
+
+import (
+	"testing"
+
+	"example.com/calc/gen/example/calc/calc"
+	"example.com/calc/gen/example/calc/calculator"
+	"example.com/calc/gen/example/calc/types"
+)
+
+func TestCannedResponses(t *testing.T) {
+	if got := calculator.Exports.Add(1, 2); got != 42 {
+		t.Errorf("Add = %d, want the canned 42", got)
+	}
+	if got := calc.Exports.Version(); got != "1.2.3" {
+		t.Errorf("Version = %q, want the canned 1.2.3", got)
+	}
+	if got := calculator.Exports.Apply(0, types.Pair{}); got.OK != nil || got.Err != nil {
+		t.Errorf("Apply = %+v, want the zero value", got)
+	}
+	calculator.Exports.Reset()
+}
+`,
+}
+
+// TestGeneratedMockCompiles builds the mock against fake bindings and runs
+// its stubs, so the golden file is known to be valid Go that returns the
+// canned responses
+func TestGeneratedMockCompiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a Go module")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go tool on PATH")
+	}
+
+	_, source := generateMock(t, map[string]string{"calculator/add": "42", "calc/version": `"1.2.3"`})
+
+	dir := t.TempDir()
+	files := map[string]string{"mock/calc_mock.go": string(source)}
+	for path, content := range fakeBindings {
+		files[path] = content
+	}
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cmd := exec.Command(goTool, "test", "./mock")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go test on the generated mock failed: %v\n%s", err, output)
+	}
+}
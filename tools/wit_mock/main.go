@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Generates a Go mock for a WIT world: every exported function gets a stub
+// that returns a canned response from --responses, or the zero value. The
+// stub builds against wit-bindgen-go bindings into a stand-in component for
+// compositions whose real dependency is not ready yet.
+func main() {
+	var (
+		witPath       = flag.String("wit", "", "WIT file defining the world")
+		worldName     = flag.String("world", "", "World to mock (defaults to the only world in the file)")
+		goModule      = flag.String("go-module", "", "Go module path the wit-bindgen-go bindings are generated under")
+		responsesPath = flag.String("responses", "", "JSON object of canned results keyed by \"interface/function\"")
+		outDir        = flag.String("out-dir", "", "Directory to write the generated Go source to")
+	)
+	flag.Parse()
+
+	if *witPath == "" || *goModule == "" || *outDir == "" {
+		fmt.Fprintf(os.Stderr, "Usage: %s --wit <world.wit> --go-module <module> --out-dir <dir> [--world <name>] [--responses <responses.json>]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	data, err := os.ReadFile(*witPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading WIT file: %v\n", err)
+		os.Exit(1)
+	}
+
+	pkg, err := parseWIT(string(data))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing %s: %v\n", *witPath, err)
+		os.Exit(1)
+	}
+
+	world, err := selectWorld(pkg, *worldName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	responses, err := readResponses(*responsesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading responses: %v\n", err)
+		os.Exit(1)
+	}
+
+	gen := &generator{pkg: pkg, module: *goModule, responses: responses}
+	source, err := gen.generate(world, filepath.Base(*witPath))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating mock: %v\n", err)
+		os.Exit(1)
+	}
+
+	for _, key := range gen.unusedResponses() {
+		fmt.Fprintf(os.Stderr, "Warning: response %q does not match any exported function\n", key)
+	}
+
+	if err := os.MkdirAll(*outDir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Error creating output directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	outPath := filepath.Join(*outDir, strings.ReplaceAll(world.Name, "-", "_")+"_mock.go")
+	if err := os.WriteFile(outPath, source, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %v\n", outPath, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Generated %s\n", outPath)
+}
+
+func selectWorld(pkg *witPackage, name string) (*witWorld, error) {
+	if len(pkg.Worlds) == 0 {
+		return nil, fmt.Errorf("no world found")
+	}
+
+	if name == "" {
+		if len(pkg.Worlds) > 1 {
+			return nil, fmt.Errorf("%d worlds defined, use --world to pick one", len(pkg.Worlds))
+		}
+		return pkg.Worlds[0], nil
+	}
+
+	for _, world := range pkg.Worlds {
+		if world.Name == name {
+			return world, nil
+		}
+	}
+	return nil, fmt.Errorf("world %s not found", name)
+}
+
+// readResponses loads canned results, keeping each value as compact JSON
+func readResponses(path string) (map[string]string, error) {
+	responses := make(map[string]string)
+	if path == "" {
+		return responses, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	for key, value := range raw {
+		compact, _ := json.Marshal(value)
+		responses[key] = string(compact)
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/witsyntax"
+)
+
+// witPackage is the subset of a WIT file needed to mock a world's exports
+type witPackage struct {
+	Namespace  string
+	Name       string
+	Interfaces map[string]*witInterface
+	Worlds     []*witWorld
+}
+
+type witInterface struct {
+	Name  string
+	Funcs []witFunc
+	// Types maps each locally declared type to its kind (record, enum, ...)
+	Types map[string]string
+	// Uses maps names brought in by use statements to where they come from
+	Uses map[string]witUse
+}
+
+// witUse is a type imported by a use statement
+type witUse struct {
+	From string // Interface path, local ("types") or qualified ("wasi:io/streams")
+	Name string // Name in that interface, which differs from the local one after "as"
+}
+
+type witWorld struct {
+	Name    string
+	Exports []string  // Exported interfaces, local or qualified
+	Funcs   []witFunc // Functions exported directly by the world
+}
+
+type witFunc struct {
+	Name   string
+	Params []witParam
+	Result *witType // nil for functions without a result
+}
+
+type witParam struct {
+	Name string
+	Type *witType
+}
+
+// witType is a type reference such as u32, calculation-result or
+// result<list<u8>, string>. The name "_" stands for an omitted result type.
+type witType struct {
+	Name string
+	Args []*witType
+}
+
+type witParser struct {
+	tokens []witsyntax.Token
+	pos    int
+}
+
+// parseWIT reads the package, interface functions and world exports of a WIT
+// file. Anything else, such as imports or type bodies, is skipped.
+func parseWIT(src string) (*witPackage, error) {
+	all, err := witsyntax.Tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []witsyntax.Token
+	for _, tok := range all {
+		if tok.Kind != witsyntax.TokComment {
+			tokens = append(tokens, tok)
+		}
+	}
+
+	p := &witParser{tokens: tokens}
+	pkg := &witPackage{Interfaces: make(map[string]*witInterface)}
+
+	for !p.done() {
+		p.skipGates()
+		switch p.next().Text {
+		case "package":
+			pkg.Namespace = p.next().Text
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			pkg.Name = p.next().Text
+			p.skipStatement()
+
+		case "interface":
+			iface, err := p.parseInterface()
+			if err != nil {
+				return nil, err
+			}
+			pkg.Interfaces[iface.Name] = iface
+
+		case "world":
+			world, err := p.parseWorld()
+			if err != nil {
+				return nil, err
+			}
+			pkg.Worlds = append(pkg.Worlds, world)
+
+		default:
+			p.pos--
+			start := p.pos
+			p.skipStatement()
+			if p.pos == start {
+				p.next() // Stray "}"
+			}
+		}
+	}
+
+	return pkg, nil
+}
+
+func (p *witParser) parseInterface() (*witInterface, error) {
+	iface := &witInterface{
+		Name:  p.next().Text,
+		Types: make(map[string]string),
+		Uses:  make(map[string]witUse),
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	for !p.done() && !p.peek().Is("}") {
+		p.skipGates()
+		tok := p.next()
+
+		switch tok.Text {
+		case "use":
+			p.parseUse(iface.Uses)
+
+		case "record", "variant", "enum", "flags", "resource", "type":
+			iface.Types[p.next().Text] = tok.Text
+			p.pos--
+			p.skipStatement()
+
+		default:
+			if !p.peek().Is(":") {
+				p.pos--
+				p.skipStatement()
+				continue
+			}
+			p.next()
+			fn, err := p.parseFunc(tok.Text)
+			if err != nil {
+				return nil, fmt.Errorf("interface %s: %w", iface.Name, err)
+			}
+			iface.Funcs = append(iface.Funcs, fn)
+		}
+	}
+
+	return iface, p.expect("}")
+}
+
+func (p *witParser) parseWorld() (*witWorld, error) {
+	world := &witWorld{Name: p.next().Text}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	for !p.done() && !p.peek().Is("}") {
+		p.skipGates()
+		if p.peek().Text != "export" {
+			p.skipStatement()
+			continue
+		}
+		p.next()
+
+		// export name: func(...) exports a function from the world itself
+		if p.peekAt(1).Is(":") && (p.peekAt(2).Text == "func" || p.peekAt(2).Text == "async") {
+			name := p.next().Text
+			p.next()
+			fn, err := p.parseFunc(name)
+			if err != nil {
+				return nil, fmt.Errorf("world %s: %w", world.Name, err)
+			}
+			world.Funcs = append(world.Funcs, fn)
+			continue
+		}
+
+		start := p.pos
+		p.skipStatement()
+		var path strings.Builder
+		for _, tok := range p.tokens[start : p.pos-1] {
+			path.WriteString(tok.Text)
+		}
+		// Inline interfaces (export name: interface { ... }) have no bindings
+		// package of their own to assign to
+		if !strings.Contains(path.String(), "{") {
+			world.Exports = append(world.Exports, path.String())
+		}
+	}
+
+	return world, p.expect("}")
+}
+
+// parseUse records the names a use statement brings into scope
+func (p *witParser) parseUse(uses map[string]witUse) {
+	var path strings.Builder
+	for !p.done() && !p.peek().Is(";") && !(p.peek().Is(".") && p.peekAt(1).Is("{")) {
+		path.WriteString(p.next().Text)
+	}
+	if p.peek().Is(".") {
+		p.next()
+		p.next()
+		for !p.done() && !p.peek().Is("}") {
+			name := p.next().Text
+			alias := name
+			if p.peek().Text == "as" {
+				p.next()
+				alias = p.next().Text
+			}
+			uses[alias] = witUse{From: path.String(), Name: name}
+			if p.peek().Is(",") {
+				p.next()
+			}
+		}
+		p.next()
+	}
+	p.skipStatement()
+}
+
+// parseFunc reads "[async] func(params) [-> type];" after "name:"
+func (p *witParser) parseFunc(name string) (witFunc, error) {
+	fn := witFunc{Name: name}
+	if p.peek().Text == "async" {
+		p.next()
+	}
+	if tok := p.next(); tok.Text != "func" {
+		return fn, fmt.Errorf("line %d: expected func after %s:", tok.Line, name)
+	}
+	if err := p.expect("("); err != nil {
+		return fn, err
+	}
+
+	for !p.done() && !p.peek().Is(")") {
+		paramName := p.next().Text
+		if err := p.expect(":"); err != nil {
+			return fn, err
+		}
+		fn.Params = append(fn.Params, witParam{Name: paramName, Type: p.parseType()})
+		if p.peek().Is(",") {
+			p.next()
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return fn, err
+	}
+
+	if p.peek().Is("->") {
+		p.next()
+		fn.Result = p.parseType()
+	}
+
+	return fn, p.expect(";")
+}
+
+func (p *witParser) parseType() *witType {
+	t := &witType{Name: p.next().Text}
+	if !p.peek().Is("<") {
+		return t
+	}
+
+	p.next()
+	for !p.done() && !p.peek().Is(">") {
+		if p.peek().Kind == witsyntax.TokVersion {
+			p.next() // Fixed-size list length
+		} else {
+			t.Args = append(t.Args, p.parseType())
+		}
+		if p.peek().Is(",") {
+			p.next()
+		}
+	}
+	p.next()
+	return t
+}
+
+func (p *witParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *witParser) peek() witsyntax.Token {
+	return p.peekAt(0)
+}
+
+func (p *witParser) peekAt(offset int) witsyntax.Token {
+	if p.pos+offset >= len(p.tokens) {
+		return witsyntax.Token{}
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *witParser) next() witsyntax.Token {
+	tok := p.peek()
+	if !p.done() {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *witParser) expect(punct string) error {
+	tok := p.next()
+	if !tok.Is(punct) {
+		if tok.Text == "" {
+			return fmt.Errorf("unexpected end of file, expected %q", punct)
+		}
+		return fmt.Errorf("line %d: expected %q, found %q", tok.Line, punct, tok.Text)
+	}
+	return nil
+}
+
+// skipGates skips feature gates such as @since(version = 0.2.0)
+func (p *witParser) skipGates() {
+	for p.peek().Is("@") {
+		p.next()
+		p.next()
+		if p.peek().Is("(") {
+			for !p.done() && !p.next().Is(")") {
+			}
+		}
+	}
+}
+
+// skipStatement advances past the current statement: up to and including a
+// ";" outside any block, or the "}" closing a block it opened. Use lists like
+// streams.{a, b} are not blocks. A "}" closing the enclosing block is left
+// for the caller.
+func (p *witParser) skipStatement() {
+	var braces []bool // true for block braces, false for use lists
+	blocks := 0
+
+	for !p.done() {
+		tok := p.next()
+		switch {
+		case tok.Is("{"):
+			isBlock := p.pos < 2 || !p.tokens[p.pos-2].Is(".")
+			braces = append(braces, isBlock)
+			if isBlock {
+				blocks++
+			}
+
+		case tok.Is("}"):
+			if len(braces) == 0 {
+				p.pos--
+				return
+			}
+			isBlock := braces[len(braces)-1]
+			braces = braces[:len(braces)-1]
+			if isBlock {
+				blocks--
+				if blocks == 0 {
+					return
+				}
+			}
+
+		case tok.Is(";") && blocks == 0:
+			return
+		}
+	}
+}
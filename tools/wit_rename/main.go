@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/witsyntax"
+)
+
+// Renames a WIT package across a directory tree: every package, use,
+// import, export and include that references the old package is rewritten
+// to the new one. Each changed file is reported with its replacement count;
+// --dry-run shows the changes without writing them.
+func main() {
+	var (
+		dir    = flag.String("dir", ".", "Directory tree of .wit files to rewrite")
+		from   = flag.String("from", "", "Package to rename, e.g. example:old or example:old@1.0.0")
+		to     = flag.String("to", "", "New package reference, e.g. example:new or example:new@2.0.0")
+		dryRun = flag.Bool("dry-run", false, "Show the replacements without writing files")
+	)
+	flag.Parse()
+
+	if *from == "" || *to == "" {
+		fmt.Fprintf(os.Stderr, "Usage: %s --from <ns:name[@version]> --to <ns:name[@version]> [--dir <dir>] [--dry-run]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	fromRef, err := witsyntax.ParsePackageRef(*from)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --from: %v\n", err)
+		os.Exit(1)
+	}
+	toRef, err := witsyntax.ParsePackageRef(*to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --to: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := renameTree(*dir, fromRef, toRef, *dryRun, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// renameTree rewrites references to from as to in every .wit file under dir,
+// reporting each changed file and its replacement count to out. Everything
+// is rewritten in memory first, so a parse error in one file leaves the
+// whole tree untouched.
+func renameTree(dir string, from, to witsyntax.PackageRef, dryRun bool, out io.Writer) error {
+	files, err := findWitFiles(dir)
+	if err != nil {
+		return fmt.Errorf("scanning %s: %w", dir, err)
+	}
+
+	rewritten := make(map[string]string)
+	counts := make(map[string][]witsyntax.Replacement)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", file, err)
+		}
+
+		output, replacements, err := witsyntax.RenameRefs(string(data), from, to)
+		if err != nil {
+			return fmt.Errorf("in %s: %w", file, err)
+		}
+		if len(replacements) > 0 {
+			rewritten[file] = output
+			counts[file] = replacements
+		}
+	}
+
+	total := 0
+	for _, file := range files {
+		replacements, changed := counts[file]
+		if !changed {
+			continue
+		}
+		total += len(replacements)
+
+		fmt.Fprintf(out, "%s: %d %s\n", file, len(replacements), plural(len(replacements), "replacement"))
+		if dryRun {
+			for _, r := range replacements {
+				fmt.Fprintf(out, "  line %d: %s -> %s\n", r.Line, r.Old, r.New)
+			}
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(file, []byte(rewritten[file]), info.Mode().Perm()); err != nil {
+			return fmt.Errorf("writing %s: %w", file, err)
+		}
+	}
+
+	verb := "Renamed"
+	if dryRun {
+		verb = "Would rename"
+	}
+	fmt.Fprintf(out, "%s %d %s in %d %s\n", verb, total, plural(total, "reference"), len(counts), plural(len(counts), "file"))
+	return nil
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+// findWitFiles lists the .wit files under dir in a stable order
+func findWitFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".wit") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
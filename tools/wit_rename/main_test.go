@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pulseengine/rules_wasm_component/tools/witsyntax"
+)
+
+// fixture is a small multi-package tree; files that do not reference
+// acme:shapes must come through untouched
+var fixture = map[string]string{
+	"shapes/shapes.wit": `package acme:shapes@1.0.0;
+
+interface types {
+    record point { x: s32, y: s32 }
+}
+`,
+	"app/world.wit": `package acme:app;
+
+// acme:shapes in a comment is left alone
+world app {
+    import acme:shapes/types@1.0.0;
+    export acme:shapes/types@1.0.0;
+}
+`,
+	"app/deps/render.wit": `package acme:render;
+
+interface draw {
+    use acme:shapes/types@1.0.0.{point};
+}
+`,
+	"unrelated.wit": `package other:thing;
+
+world thing {
+    import wasi:io/streams@0.2.0;
+}
+`,
+	"notes.txt": "acme:shapes@1.0.0 outside a .wit file\n",
+}
+
+// writeFixture creates fixture under a temp directory and returns it
+func writeFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range fixture {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func mustParse(t *testing.T, ref string) witsyntax.PackageRef {
+	t.Helper()
+	parsed, err := witsyntax.ParsePackageRef(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestRenameTree(t *testing.T) {
+	dir := writeFixture(t)
+
+	var out bytes.Buffer
+	if err := renameTree(dir, mustParse(t, "acme:shapes"), mustParse(t, "acme:geometry"), false, &out); err != nil {
+		t.Fatalf("renameTree: %v", err)
+	}
+
+	want := map[string]string{
+		"shapes/shapes.wit":   strings.Replace(fixture["shapes/shapes.wit"], "acme:shapes@", "acme:geometry@", 1),
+		"app/world.wit":       strings.ReplaceAll(fixture["app/world.wit"], "acme:shapes/", "acme:geometry/"),
+		"app/deps/render.wit": strings.Replace(fixture["app/deps/render.wit"], "acme:shapes/", "acme:geometry/", 1),
+		"unrelated.wit":       fixture["unrelated.wit"],
+		"notes.txt":           fixture["notes.txt"],
+	}
+	for name, content := range want {
+		path := filepath.Join(dir, name)
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Errorf("%s =\n%s\nwant:\n%s", name, got, content)
+		}
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+			t.Errorf("%s mode = %v, want 0640 kept", name, info.Mode().Perm())
+		}
+	}
+
+	wantOut := filepath.Join(dir, "app/deps/render.wit") + ": 1 replacement\n" +
+		filepath.Join(dir, "app/world.wit") + ": 2 replacements\n" +
+		filepath.Join(dir, "shapes/shapes.wit") + ": 1 replacement\n" +
+		"Renamed 4 references in 3 files\n"
+	if out.String() != wantOut {
+		t.Errorf("output =\n%s\nwant:\n%s", out.String(), wantOut)
+	}
+}
+
+func TestRenameTreeDryRun(t *testing.T) {
+	dir := writeFixture(t)
+
+	var out bytes.Buffer
+	if err := renameTree(dir, mustParse(t, "acme:shapes@1.0.0"), mustParse(t, "acme:shapes@2.0.0"), true, &out); err != nil {
+		t.Fatalf("renameTree: %v", err)
+	}
+
+	for name, content := range fixture {
+		if got, _ := os.ReadFile(filepath.Join(dir, name)); string(got) != content {
+			t.Errorf("--dry-run rewrote %s", name)
+		}
+	}
+	for _, line := range []string{
+		"  line 5: acme:shapes@1.0.0 -> acme:shapes@2.0.0\n",
+		"  line 1: acme:shapes@1.0.0 -> acme:shapes@2.0.0\n",
+		"Would rename 4 references in 3 files\n",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("output missing %q:\n%s", line, out.String())
+		}
+	}
+}
+
+// TestRenameTreeLeavesTreeOnParseError checks that one unreadable file
+// stops the rename before any file is written
+func TestRenameTreeLeavesTreeOnParseError(t *testing.T) {
+	dir := writeFixture(t)
+	broken := filepath.Join(dir, "zz_broken.wit")
+	if err := os.WriteFile(broken, []byte("package acme:broken;\n/* never closed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err := renameTree(dir, mustParse(t, "acme:shapes"), mustParse(t, "acme:geometry"), false, &out)
+	if err == nil || !strings.Contains(err.Error(), "zz_broken.wit") {
+		t.Fatalf("renameTree = %v, want an error naming the broken file", err)
+	}
+	for name, content := range fixture {
+		if got, _ := os.ReadFile(filepath.Join(dir, name)); string(got) != content {
+			t.Errorf("%s was rewritten despite the parse error", name)
+		}
+	}
+}
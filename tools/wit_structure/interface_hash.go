@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// fileHash is the normalized hash of one WIT file, identified by its path
+// relative to the hashed directory
+type fileHash struct {
+	relPath string
+	digest  string
+}
+
+// interfaceHash fingerprints every .wit file under dir, including deps/.
+// Files are hashed concurrently by a pool of at most workers goroutines, then
+// combined in sorted path order, so the result never depends on scheduling.
+func interfaceHash(dir string, workers int) (string, error) {
+	paths, err := collectWitFiles(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	hashes := make([]fileHash, len(paths))
+	errs := make([]error, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				hashes[i], errs[i] = hashWitFile(dir, paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return combineFileHashes(hashes), nil
+}
+
+// collectWitFiles lists the .wit files under dir
+func collectWitFiles(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".wit") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// hashWitFile hashes a file's normalized content together with its relative
+// path, so moving a file between dependency directories changes the hash
+func hashWitFile(dir, path string) (fileHash, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fileHash{}, err
+	}
+
+	relPath, err := filepath.Rel(dir, path)
+	if err != nil {
+		return fileHash{}, err
+	}
+	relPath = filepath.ToSlash(relPath)
+
+	sum := sha256.Sum256(normalizeWit(data))
+	return fileHash{relPath: relPath, digest: hex.EncodeToString(sum[:])}, nil
+}
+
+// normalizeWit removes differences that do not affect the interface: line
+// endings, trailing whitespace and blank lines
+func normalizeWit(data []byte) []byte {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	var out bytes.Buffer
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimRight(line, " \t\r")
+		if len(line) == 0 {
+			continue
+		}
+		out.Write(line)
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+// combineFileHashes folds per-file hashes into one digest in sorted path order
+func combineFileHashes(hashes []fileHash) string {
+	sorted := append([]fileHash(nil), hashes...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].relPath < sorted[j].relPath })
+
+	h := sha256.New()
+	for _, fh := range sorted {
+		fmt.Fprintf(h, "%s  %s\n", fh.digest, fh.relPath)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeWitTree creates packages×files WIT files under dir, laid out as
+// dependencies are, and returns dir
+func writeWitTree(tb testing.TB, dir string, packages, files int) string {
+	tb.Helper()
+	for p := 0; p < packages; p++ {
+		pkgDir := filepath.Join(dir, "deps", fmt.Sprintf("pkg%03d", p))
+		if err := os.MkdirAll(pkgDir, 0755); err != nil {
+			tb.Fatal(err)
+		}
+		for f := 0; f < files; f++ {
+			var b strings.Builder
+			fmt.Fprintf(&b, "package example:pkg%03d;\n\ninterface iface%d {\n", p, f)
+			for fn := 0; fn < 50; fn++ {
+				fmt.Fprintf(&b, "    func%d: func(input: list<u8>) -> result<string, u32>;\n", fn)
+			}
+			b.WriteString("}\n")
+			path := filepath.Join(pkgDir, fmt.Sprintf("iface%d.wit", f))
+			if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+	return dir
+}
+
+// serialInterfaceHash is the straightforward one-file-at-a-time computation
+// the parallel hash must agree with
+func serialInterfaceHash(dir string) (string, error) {
+	paths, err := collectWitFiles(dir)
+	if err != nil {
+		return "", err
+	}
+	hashes := make([]fileHash, 0, len(paths))
+	for _, path := range paths {
+		fh, err := hashWitFile(dir, path)
+		if err != nil {
+			return "", err
+		}
+		hashes = append(hashes, fh)
+	}
+	return combineFileHashes(hashes), nil
+}
+
+func TestInterfaceHashMatchesSerial(t *testing.T) {
+	dir := writeWitTree(t, t.TempDir(), 12, 5)
+
+	want, err := serialInterfaceHash(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, workers := range []int{0, 1, 2, 7, 64} {
+		got, err := interfaceHash(dir, workers)
+		if err != nil {
+			t.Fatalf("workers=%d: %v", workers, err)
+		}
+		if got != want {
+			t.Errorf("workers=%d: hash %s, serial hash %s", workers, got, want)
+		}
+	}
+}
+
+func TestInterfaceHashNormalization(t *testing.T) {
+	original := writeWitTree(t, t.TempDir(), 2, 2)
+	base, err := interfaceHash(original, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(original, "deps", "pkg000", "iface0.wit")
+	data, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewrite := func(t *testing.T, content string) string {
+		t.Helper()
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.WriteFile(target, data, 0644) })
+		hash, err := interfaceHash(original, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+
+	t.Run("formatting only", func(t *testing.T) {
+		crlf := strings.ReplaceAll(string(data), "\n", "  \r\n\r\n")
+		if got := rewrite(t, crlf); got != base {
+			t.Errorf("hash changed from %s to %s for whitespace-only edits", base, got)
+		}
+	})
+	t.Run("interface change", func(t *testing.T) {
+		changed := strings.Replace(string(data), "func0", "renamed", 1)
+		if got := rewrite(t, changed); got == base {
+			t.Error("hash unchanged after renaming a function")
+		}
+	})
+}
+
+func BenchmarkInterfaceHash(b *testing.B) {
+	dir := writeWitTree(b, b.TempDir(), 50, 20)
+
+	for _, workers := range []int{1, 0} {
+		name := fmt.Sprintf("workers=%d", workers)
+		if workers == 0 {
+			name = "workers=cpus"
+		}
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := interfaceHash(dir, workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
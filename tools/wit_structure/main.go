@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -21,22 +22,30 @@ type Config struct {
 	SourceFiles     []string     `json:"source_files"`
 	Dependencies    []Dependency `json:"dependencies"`
 	DepsTomlContent string       `json:"deps_toml_content"`
+	// InterfaceHashFile, when set, receives a hash of every assembled WIT
+	// file so consumers can detect interface changes without diffing trees
+	InterfaceHashFile string `json:"interface_hash_file,omitempty"`
+	// HashWorkers bounds how many files are hashed at once (default: CPUs)
+	HashWorkers int `json:"hash_workers,omitempty"`
 }
 
 func main() {
-	if len(os.Args) != 2 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <config.json>\n", os.Args[0])
+	clean := flag.Bool("clean", false, "Remove files in the output directory that this run did not write")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: %s [--clean] <config.json>\n", os.Args[0])
 		os.Exit(1)
 	}
 
-	configPath := os.Args[1]
+	configPath := flag.Arg(0)
 	config, err := readConfig(configPath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error reading config: %v\n", err)
 		os.Exit(1)
 	}
 
-	if err := createWitStructure(config); err != nil {
+	if err := createWitStructure(config, *clean); err != nil {
 		fmt.Fprintf(os.Stderr, "Error creating WIT structure: %v\n", err)
 		os.Exit(1)
 	}
@@ -56,15 +65,22 @@ func readConfig(path string) (*Config, error) {
 	return &config, nil
 }
 
-func createWitStructure(config *Config) error {
+// createWitStructure assembles the output directory. With clean set, files
+// left over from an earlier run over the same directory (say, a source or
+// dependency since dropped from the config) are removed afterwards.
+func createWitStructure(config *Config, clean bool) error {
 	// Create output directory
 	if err := os.MkdirAll(config.OutputDir, 0755); err != nil {
 		return fmt.Errorf("creating output directory: %w", err)
 	}
 
+	// Every path this run writes, so --clean knows what to keep
+	written := make(map[string]bool)
+
 	// Copy source files
 	for _, srcPath := range config.SourceFiles {
 		dstPath := filepath.Join(config.OutputDir, filepath.Base(srcPath))
+		written[dstPath] = true
 		if err := copyFile(srcPath, dstPath); err != nil {
 			return fmt.Errorf("copying source file %s: %w", srcPath, err)
 		}
@@ -85,6 +101,7 @@ func createWitStructure(config *Config) error {
 
 			for _, witFile := range dep.WitFiles {
 				dstPath := filepath.Join(depDir, filepath.Base(witFile))
+				written[dstPath] = true
 				if err := copyFile(witFile, dstPath); err != nil {
 					return fmt.Errorf("copying dependency file %s: %w", witFile, err)
 				}
@@ -95,7 +112,7 @@ func createWitStructure(config *Config) error {
 				depDepsDir := filepath.Join(dep.OutputDir, "deps")
 				if _, err := os.Stat(depDepsDir); err == nil {
 					// Copy all subdirectories from the dependency's deps/ to our deps/
-					if err := copyDirRecursive(depDepsDir, depsDir); err != nil {
+					if err := copyDirRecursive(depDepsDir, depsDir, written); err != nil {
 						return fmt.Errorf("copying transitive deps from %s: %w", depDepsDir, err)
 					}
 				}
@@ -106,11 +123,33 @@ func createWitStructure(config *Config) error {
 	// Write deps.toml if needed
 	if config.DepsTomlContent != "" {
 		depsTomlPath := filepath.Join(config.OutputDir, "deps.toml")
+		written[depsTomlPath] = true
 		if err := ioutil.WriteFile(depsTomlPath, []byte(config.DepsTomlContent), 0644); err != nil {
 			return fmt.Errorf("writing deps.toml: %w", err)
 		}
 	}
 
+	// The hash file may live inside the output directory
+	if config.InterfaceHashFile != "" {
+		written[filepath.Clean(config.InterfaceHashFile)] = true
+	}
+
+	if clean {
+		if err := removeStaleFiles(config.OutputDir, written); err != nil {
+			return fmt.Errorf("cleaning output directory: %w", err)
+		}
+	}
+
+	if config.InterfaceHashFile != "" {
+		hash, err := interfaceHash(config.OutputDir, config.HashWorkers)
+		if err != nil {
+			return fmt.Errorf("hashing interface: %w", err)
+		}
+		if err := ioutil.WriteFile(config.InterfaceHashFile, []byte(hash+"\n"), 0644); err != nil {
+			return fmt.Errorf("writing interface hash: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -132,8 +171,9 @@ func copyFile(src, dst string) error {
 }
 
 // copyDirRecursive copies all subdirectories and files from src to dst
-// It merges content, so if a directory already exists in dst, it adds files to it
-func copyDirRecursive(src, dst string) error {
+// It merges content, so if a directory already exists in dst, it adds files to it.
+// Each file written is recorded in written.
+func copyDirRecursive(src, dst string, written map[string]bool) error {
 	entries, err := ioutil.ReadDir(src)
 	if err != nil {
 		return err
@@ -149,11 +189,12 @@ func copyDirRecursive(src, dst string) error {
 				return err
 			}
 			// Recursively copy directory contents
-			if err := copyDirRecursive(srcPath, dstPath); err != nil {
+			if err := copyDirRecursive(srcPath, dstPath, written); err != nil {
 				return err
 			}
 		} else {
 			// Copy file
+			written[dstPath] = true
 			if err := copyFile(srcPath, dstPath); err != nil {
 				return err
 			}
@@ -162,3 +203,42 @@ func copyDirRecursive(src, dst string) error {
 
 	return nil
 }
+
+// removeStaleFiles deletes files under dir that are not in keep, then any
+// directories left empty by that
+func removeStaleFiles(dir string, keep map[string]bool) error {
+	var dirs []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir {
+				dirs = append(dirs, path)
+			}
+			return nil
+		}
+		if !keep[filepath.Clean(path)] {
+			return os.Remove(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Deepest first, so parents empty out before they are checked
+	for i := len(dirs) - 1; i >= 0; i-- {
+		entries, err := ioutil.ReadDir(dirs[i])
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			if err := os.Remove(dirs[i]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// writeFiles creates each file under dir with its name as content
+func writeFiles(t *testing.T, dir string, names ...string) []string {
+	t.Helper()
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(paths[i]), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(paths[i], []byte(name+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+// listFiles returns the files under dir, relative and slash-separated
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			rel, _ := filepath.Rel(dir, path)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	return files
+}
+
+func TestCreateWitStructureClean(t *testing.T) {
+	src := t.TempDir()
+	sources := writeFiles(t, src, "world.wit", "types.wit")
+	cliFiles := writeFiles(t, src, "cli/command.wit")
+	ioFiles := writeFiles(t, src, "io/streams.wit")
+
+	// A dependency whose own output carries a transitive deps/ tree
+	ioOutput := t.TempDir()
+	writeFiles(t, ioOutput, "deps/poll/poll.wit")
+
+	tests := []struct {
+		name  string
+		clean bool
+		want  []string
+	}{
+		{
+			name:  "clean removes stale files",
+			clean: true,
+			want: []string{
+				"deps.toml",
+				"deps/io/streams.wit",
+				"deps/poll/poll.wit",
+				"interface.sha256",
+				"world.wit",
+			},
+		},
+		{
+			name:  "without clean stale files remain",
+			clean: false,
+			want: []string{
+				"deps.toml",
+				"deps/cli/command.wit",
+				"deps/io/streams.wit",
+				"deps/poll/poll.wit",
+				"interface.sha256",
+				"types.wit",
+				"world.wit",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := t.TempDir()
+			config := &Config{
+				OutputDir:   out,
+				SourceFiles: sources,
+				Dependencies: []Dependency{
+					{SimpleName: "cli", WitFiles: cliFiles},
+					{SimpleName: "io", WitFiles: ioFiles, OutputDir: ioOutput},
+				},
+				DepsTomlContent:   "[deps]\n",
+				InterfaceHashFile: filepath.Join(out, "interface.sha256"),
+			}
+			if err := createWitStructure(config, tt.clean); err != nil {
+				t.Fatalf("first run: %v", err)
+			}
+
+			// Drop a source and a dependency, as an incremental build would
+			config.SourceFiles = sources[:1]
+			config.Dependencies = config.Dependencies[1:]
+			if err := createWitStructure(config, tt.clean); err != nil {
+				t.Fatalf("second run: %v", err)
+			}
+
+			got := listFiles(t, out)
+			if len(got) != len(tt.want) {
+				t.Fatalf("output files = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("output files = %v, want %v", got, tt.want)
+				}
+			}
+
+			_, err := os.Stat(filepath.Join(out, "deps", "cli"))
+			if tt.clean && !os.IsNotExist(err) {
+				t.Errorf("emptied deps/cli directory not removed (stat error %v)", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ComponentInterfaces is the import/export listing of a compiled component,
+// shaped like the imports/exports fields of the WasmComponentInfo provider
+type ComponentInterfaces struct {
+	Imports []string `json:"imports"`
+	Exports []string `json:"exports"`
+}
+
+// World is the set of interfaces a WIT world imports and exports
+type World struct {
+	Name    string
+	Package string
+	Imports []string
+	Exports []string
+}
+
+// CheckResult reports how a component differs from its declared world
+type CheckResult struct {
+	World            string   `json:"world"`
+	Satisfied        bool     `json:"satisfied"`
+	MissingExports   []string `json:"missing_exports"`    // Declared by the world, not exported
+	ExtraExports     []string `json:"extra_exports"`      // Exported, not declared by the world
+	UnusedImports    []string `json:"unused_imports"`     // Declared by the world, not imported
+	UndeclaredImport []string `json:"undeclared_imports"` // Imported, not declared by the world
+}
+
+// Checks that a compiled component satisfies the WIT world it was built for:
+// every world export is implemented and every declared import is used.
+func main() {
+	var (
+		interfacesPath = flag.String("interfaces", "", "JSON file with the component's imports and exports")
+		witPath        = flag.String("wit", "", "WIT file defining the world")
+		worldName      = flag.String("world", "", "World to check (defaults to the only world in the file)")
+	)
+	flag.Parse()
+
+	if *interfacesPath == "" || *witPath == "" {
+		fmt.Fprintf(os.Stderr, "Usage: %s --interfaces <component.json> --wit <world.wit> [--world <name>]\n", os.Args[0])
+		os.Exit(1)
+	}
+
+	component, err := readComponentInterfaces(*interfacesPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading component interfaces: %v\n", err)
+		os.Exit(1)
+	}
+
+	world, err := parseWorld(*witPath, *worldName)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing WIT world: %v\n", err)
+		os.Exit(1)
+	}
+
+	result := checkWorld(world, component)
+
+	output, _ := json.MarshalIndent(result, "", "  ")
+	fmt.Println(string(output))
+
+	if !result.Satisfied {
+		os.Exit(1)
+	}
+}
+
+func readComponentInterfaces(path string) (*ComponentInterfaces, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var component ComponentInterfaces
+	if err := json.Unmarshal(data, &component); err != nil {
+		return nil, err
+	}
+
+	return &component, nil
+}
+
+// parseWorld extracts the imports and exports of a world from a WIT file.
+// Interfaces named without a package are qualified with the file's package.
+func parseWorld(witPath, worldName string) (*World, error) {
+	data, err := ioutil.ReadFile(witPath)
+	if err != nil {
+		return nil, err
+	}
+
+	worlds, err := parseWorlds(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", witPath, err)
+	}
+
+	if len(worlds) == 0 {
+		return nil, fmt.Errorf("no world found in %s", witPath)
+	}
+
+	if worldName == "" {
+		if len(worlds) > 1 {
+			return nil, fmt.Errorf("%s defines %d worlds, use --world to pick one", witPath, len(worlds))
+		}
+		return worlds[0], nil
+	}
+
+	for _, world := range worlds {
+		if world.Name == worldName {
+			return world, nil
+		}
+	}
+
+	return nil, fmt.Errorf("world %s not found in %s", worldName, witPath)
+}
+
+// qualifyInterface turns a package-local interface name like "calculator" into
+// "example:calculator/calculator@1.0.0". Qualified names pass through as-is.
+func qualifyInterface(name, pkg string) string {
+	if strings.Contains(name, ":") || pkg == "" {
+		return name
+	}
+
+	pkgName, version, hasVersion := strings.Cut(pkg, "@")
+	if hasVersion {
+		return pkgName + "/" + name + "@" + version
+	}
+	return pkgName + "/" + name
+}
+
+// checkWorld compares the world's declarations with the component's listing
+func checkWorld(world *World, component *ComponentInterfaces) *CheckResult {
+	result := &CheckResult{
+		World:            world.Name,
+		MissingExports:   difference(world.Exports, component.Exports),
+		ExtraExports:     difference(component.Exports, world.Exports),
+		UnusedImports:    difference(world.Imports, component.Imports),
+		UndeclaredImport: difference(component.Imports, world.Imports),
+	}
+
+	result.Satisfied = len(result.MissingExports) == 0 &&
+		len(result.ExtraExports) == 0 &&
+		len(result.UnusedImports) == 0 &&
+		len(result.UndeclaredImport) == 0
+
+	return result
+}
+
+// difference returns the sorted entries of a that are not in b
+func difference(a, b []string) []string {
+	inB := make(map[string]bool, len(b))
+	for _, s := range b {
+		inB[s] = true
+	}
+
+	result := []string{}
+	seen := make(map[string]bool)
+	for _, s := range a {
+		if !inB[s] && !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
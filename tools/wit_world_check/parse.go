@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pulseengine/rules_wasm_component/tools/witsyntax"
+)
+
+type worldParser struct {
+	tokens []witsyntax.Token
+	pos    int
+}
+
+// parseWorlds reads the imports and exports of every world in a WIT file.
+// Worlds inside a nested `package a:b { ... }` block belong to that package;
+// everything other than worlds and package declarations is skipped.
+func parseWorlds(src string) ([]*World, error) {
+	all, err := witsyntax.Tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens []witsyntax.Token
+	for _, tok := range all {
+		if tok.Kind != witsyntax.TokComment {
+			tokens = append(tokens, tok)
+		}
+	}
+
+	p := &worldParser{tokens: tokens}
+	worlds, err := p.parseItems("", true)
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("line %d: unexpected %q", p.peek().Line, p.peek().Text)
+	}
+	return worlds, nil
+}
+
+// parseItems reads the items of a file or nested package block, up to the
+// "}" closing the block
+func (p *worldParser) parseItems(pkg string, topLevel bool) ([]*World, error) {
+	var worlds []*World
+
+	for !p.done() && !p.peek().Is("}") {
+		p.skipGates()
+		tok := p.next()
+
+		switch tok.Text {
+		case "package":
+			name := p.path()
+			if name == "" {
+				return nil, fmt.Errorf("line %d: package declaration has no name", tok.Line)
+			}
+			if !p.peek().Is("{") {
+				pkg = name
+				if err := p.expect(";"); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if !topLevel {
+				return nil, fmt.Errorf("line %d: package %s is nested in another package", tok.Line, name)
+			}
+			p.next()
+			nested, err := p.parseItems(name, false)
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("}"); err != nil {
+				return nil, err
+			}
+			worlds = append(worlds, nested...)
+
+		case "world":
+			world, err := p.parseWorld(pkg)
+			if err != nil {
+				return nil, err
+			}
+			worlds = append(worlds, world)
+
+		default:
+			p.pos--
+			start := p.pos
+			p.skipStatement()
+			if p.pos == start {
+				p.next() // Stray "}"
+			}
+		}
+	}
+
+	return worlds, nil
+}
+
+// parseWorld reads a world's imports and exports. Uses, types and includes
+// are skipped.
+func (p *worldParser) parseWorld(pkg string) (*World, error) {
+	world := &World{Name: p.next().Text, Package: pkg}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	for !p.done() && !p.peek().Is("}") {
+		p.skipGates()
+		kind := p.peek().Text
+		if kind != "import" && kind != "export" {
+			p.skipStatement()
+			continue
+		}
+		p.next()
+
+		name := p.itemName(pkg)
+		p.skipStatement()
+		if kind == "import" {
+			world.Imports = append(world.Imports, name)
+		} else {
+			world.Exports = append(world.Exports, name)
+		}
+	}
+
+	return world, p.expect("}")
+}
+
+// itemName reads the name a component gives an import or export. Named
+// items (name: func(...), name: interface { ... }) keep their plain name;
+// interface paths are qualified with the enclosing package.
+func (p *worldParser) itemName(pkg string) string {
+	if p.peekAt(1).Is(":") && !p.peekAt(3).Is("/") {
+		return strings.TrimPrefix(p.peek().Text, "%")
+	}
+
+	start := p.pos
+	name := p.path()
+	p.pos = start
+	return qualifyInterface(name, pkg)
+}
+
+// path joins the tokens of a package or interface path such as
+// wasi:cli/environment@0.2.0, stopping before ";" or "{"
+func (p *worldParser) path() string {
+	var path strings.Builder
+	for !p.done() && !p.peek().Is(";") && !p.peek().Is("{") && !p.peek().Is("}") {
+		path.WriteString(strings.TrimPrefix(p.next().Text, "%"))
+	}
+	return path.String()
+}
+
+func (p *worldParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *worldParser) peek() witsyntax.Token {
+	return p.peekAt(0)
+}
+
+func (p *worldParser) peekAt(offset int) witsyntax.Token {
+	if p.pos+offset >= len(p.tokens) {
+		return witsyntax.Token{}
+	}
+	return p.tokens[p.pos+offset]
+}
+
+func (p *worldParser) next() witsyntax.Token {
+	tok := p.peek()
+	if !p.done() {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *worldParser) expect(punct string) error {
+	tok := p.next()
+	if !tok.Is(punct) {
+		if tok.Text == "" {
+			return fmt.Errorf("unexpected end of file, expected %q", punct)
+		}
+		return fmt.Errorf("line %d: expected %q, found %q", tok.Line, punct, tok.Text)
+	}
+	return nil
+}
+
+// skipGates skips feature gates such as @since(version = 0.2.0)
+func (p *worldParser) skipGates() {
+	for p.peek().Is("@") {
+		p.next()
+		p.next()
+		if p.peek().Is("(") {
+			for !p.done() && !p.next().Is(")") {
+			}
+		}
+	}
+}
+
+// skipStatement advances past the current statement: up to and including a
+// ";" outside any block, or the "}" closing a block it opened. Use lists like
+// streams.{a, b} are not blocks. A "}" closing the enclosing block is left
+// for the caller.
+func (p *worldParser) skipStatement() {
+	var braces []bool // true for block braces, false for use lists
+	blocks := 0
+
+	for !p.done() {
+		tok := p.next()
+		switch {
+		case tok.Is("{"):
+			isBlock := p.pos < 2 || !p.tokens[p.pos-2].Is(".")
+			braces = append(braces, isBlock)
+			if isBlock {
+				blocks++
+			}
+
+		case tok.Is("}"):
+			if len(braces) == 0 {
+				p.pos--
+				return
+			}
+			isBlock := braces[len(braces)-1]
+			braces = braces[:len(braces)-1]
+			if isBlock {
+				blocks--
+				if blocks == 0 {
+					return
+				}
+			}
+
+		case tok.Is(";") && blocks == 0:
+			return
+		}
+	}
+}
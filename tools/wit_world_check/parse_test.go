@@ -0,0 +1,205 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWorlds(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []*World
+	}{
+		{
+			name: "local and qualified interfaces",
+			src: `package example:calc@1.0.0;
+
+world calculator {
+    import wasi:cli/environment@0.2.0;
+    import logging;
+    export calculator;
+}
+`,
+			want: []*World{{
+				Name:    "calculator",
+				Package: "example:calc@1.0.0",
+				Imports: []string{"wasi:cli/environment@0.2.0", "example:calc/logging@1.0.0"},
+				Exports: []string{"example:calc/calculator@1.0.0"},
+			}},
+		},
+		{
+			name: "headers and items split across lines",
+			src: `package example:calc
+    @1.0.0;
+
+world
+    calculator
+{
+    import wasi:cli/
+        environment@0.2.0
+        ;
+    export
+        calculator;
+}
+`,
+			want: []*World{{
+				Name:    "calculator",
+				Package: "example:calc@1.0.0",
+				Imports: []string{"wasi:cli/environment@0.2.0"},
+				Exports: []string{"example:calc/calculator@1.0.0"},
+			}},
+		},
+		{
+			name: "braces and items in comments",
+			src: `package example:calc@1.0.0;
+
+// world commented { export nothing; }
+world calculator { // {
+    /* import hidden;
+       } */
+    export calculator; // export other;
+}
+`,
+			want: []*World{{
+				Name:    "calculator",
+				Package: "example:calc@1.0.0",
+				Exports: []string{"example:calc/calculator@1.0.0"},
+			}},
+		},
+		{
+			name: "inline interfaces, functions, types and gates",
+			src: `package example:calc@1.0.0;
+
+interface types {
+    record pair { a: u32, b: u32 }
+}
+
+world calculator {
+    use types.{pair};
+    type alias = u32;
+    import host: interface {
+        record config { verbose: bool }
+        get-config: func() -> config;
+    }
+    @since(version = 1.0.0)
+    import log: func(msg: string);
+    export run: func() -> result<_, string>;
+    export calculator;
+    include other;
+}
+`,
+			want: []*World{{
+				Name:    "calculator",
+				Package: "example:calc@1.0.0",
+				Imports: []string{"host", "log"},
+				Exports: []string{"run", "example:calc/calculator@1.0.0"},
+			}},
+		},
+		{
+			name: "nested package blocks",
+			src: `package example:app@2.0.0;
+
+world app {
+    import example:deps/store@0.1.0;
+    export handler;
+}
+
+package example:deps@0.1.0 {
+    interface store {
+        get: func(key: string) -> option<string>;
+    }
+
+    world deps {
+        export store;
+    }
+}
+`,
+			want: []*World{
+				{
+					Name:    "app",
+					Package: "example:app@2.0.0",
+					Imports: []string{"example:deps/store@0.1.0"},
+					Exports: []string{"example:app/handler@2.0.0"},
+				},
+				{
+					Name:    "deps",
+					Package: "example:deps@0.1.0",
+					Exports: []string{"example:deps/store@0.1.0"},
+				},
+			},
+		},
+		{
+			name: "no package",
+			src:  "world plain {\n    import %interface;\n}\n",
+			want: []*World{{Name: "plain", Imports: []string{"interface"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseWorlds(tt.src)
+			if err != nil {
+				t.Fatalf("parseWorlds: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseWorlds =\n%s\nwant\n%s", describeWorlds(got), describeWorlds(tt.want))
+			}
+		})
+	}
+}
+
+func TestParseWorldsErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		wantErr string
+	}{
+		{name: "unterminated world", src: "world calculator {\n    export calculator;\n", wantErr: "unexpected end of file"},
+		{name: "unterminated comment", src: "world calculator { /* }", wantErr: "unterminated block comment"},
+		{name: "missing brace", src: "world calculator export calculator;", wantErr: `expected "{"`},
+		{name: "stray brace", src: "world calculator {}\n}\n", wantErr: `line 2: unexpected "}"`},
+		{name: "doubly nested package", src: "package a:b { package c:d { } }", wantErr: "nested in another package"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseWorlds(tt.src); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("parseWorlds error = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseWorldSelection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "worlds.wit")
+	src := "package example:calc@1.0.0;\n\nworld a { export x; }\nworld b { export y; }\n"
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	world, err := parseWorld(path, "b")
+	if err != nil {
+		t.Fatalf("parseWorld: %v", err)
+	}
+	if world.Name != "b" || !reflect.DeepEqual(world.Exports, []string{"example:calc/y@1.0.0"}) {
+		t.Errorf("parseWorld picked %+v", world)
+	}
+
+	for worldName, wantErr := range map[string]string{"": "defines 2 worlds", "c": "world c not found"} {
+		if _, err := parseWorld(path, worldName); err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("parseWorld(%q) error = %v, want one containing %q", worldName, err, wantErr)
+		}
+	}
+}
+
+func describeWorlds(worlds []*World) string {
+	var lines []string
+	for _, w := range worlds {
+		lines = append(lines, "  "+strings.Join([]string{w.Name, w.Package, strings.Join(w.Imports, ","), strings.Join(w.Exports, ",")}, " | "))
+	}
+	return strings.Join(lines, "\n")
+}
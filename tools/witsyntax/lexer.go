@@ -0,0 +1,135 @@
+// Package witsyntax tokenizes WIT source and parses and rewrites package
+// references, for the tools that read WIT without a full parser.
+package witsyntax
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TokenKind classifies a Token
+type TokenKind int
+
+const (
+	TokIdent   TokenKind = iota // Identifiers and keywords, including %-escaped names
+	TokVersion                  // Semver and other digit-led words like 0.2.0-rc.1
+	TokPunct                    // Single punctuation characters and "->"
+	TokComment                  // Line, doc and block comments, kept verbatim
+)
+
+// Token is one lexical element of a WIT file along with the whitespace that
+// preceded it, which formatters use to keep comments and blank lines
+type Token struct {
+	Kind          TokenKind
+	Text          string
+	Line          int
+	Offset        int  // Byte offset of the token in the source
+	NewlineBefore bool // Starts a new line in the source
+	BlankBefore   bool // Preceded by at least one blank line
+}
+
+// Is reports whether t is the punctuation text
+func (t Token) Is(text string) bool {
+	return t.Kind == TokPunct && t.Text == text
+}
+
+// IsLineComment reports whether t is a // comment
+func (t Token) IsLineComment() bool {
+	return t.Kind == TokComment && strings.HasPrefix(t.Text, "//")
+}
+
+// Tokenize splits WIT source into tokens
+func Tokenize(src string) ([]Token, error) {
+	var tokens []Token
+	line := 1
+	newlines := 1 // The first token starts a line but has no blank line before it
+
+	for i := 0; i < len(src); {
+		c := src[i]
+
+		switch {
+		case c == '\n':
+			line++
+			newlines++
+			i++
+			continue
+
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+			continue
+		}
+
+		start := i
+		kind := TokPunct
+
+		switch {
+		case strings.HasPrefix(src[i:], "//"):
+			kind = TokComment
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+
+		case strings.HasPrefix(src[i:], "/*"):
+			kind = TokComment
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated block comment", line)
+			}
+			i += end + 4
+
+		case strings.HasPrefix(src[i:], "->"):
+			i += 2
+
+		case isIdentStart(c):
+			kind = TokIdent
+			i++
+			for i < len(src) && isIdentChar(src[i]) && !strings.HasPrefix(src[i:], "->") {
+				i++
+			}
+
+		case c >= '0' && c <= '9':
+			kind = TokVersion
+			// A dot only continues the version when more of it follows, so
+			// streams@0.2.0.{a} still splits before the use list
+			for i < len(src) && isVersionChar(src[i]) && !(src[i] == '.' && (i+1 == len(src) || !isIdentChar(src[i+1]))) {
+				i++
+			}
+
+		case strings.IndexByte("{}()<>,;:=/@.*", c) >= 0:
+			i++
+
+		default:
+			return nil, fmt.Errorf("line %d: unexpected character %q", line, c)
+		}
+
+		text := src[start:i]
+		if kind == TokComment {
+			text = strings.TrimRight(text, " \t\r")
+		}
+
+		tokens = append(tokens, Token{
+			Kind:          kind,
+			Text:          text,
+			Line:          line,
+			Offset:        start,
+			NewlineBefore: newlines > 0,
+			BlankBefore:   newlines > 1,
+		})
+		line += strings.Count(text, "\n")
+		newlines = 0
+	}
+
+	return tokens, nil
+}
+
+func isIdentStart(c byte) bool {
+	return c == '%' || c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c == '-' || (c >= '0' && c <= '9')
+}
+
+func isVersionChar(c byte) bool {
+	return isIdentChar(c) || c == '.' || c == '+'
+}
@@ -0,0 +1,58 @@
+package witsyntax
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	src := "package wasi:io@0.2.0-rc.1;\n\n// Streams\nuse wasi:io/streams@0.2.0.{input-stream};\nfunc() -> %result;\n"
+	tokens, err := Tokenize(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, tok := range tokens {
+		got = append(got, tok.Text)
+		if src[tok.Offset:tok.Offset+len(tok.Text)] != tok.Text {
+			t.Errorf("token %q at offset %d does not match the source", tok.Text, tok.Offset)
+		}
+	}
+	want := []string{
+		"package", "wasi", ":", "io", "@", "0.2.0-rc.1", ";",
+		"// Streams",
+		"use", "wasi", ":", "io", "/", "streams", "@", "0.2.0", ".", "{", "input-stream", "}", ";",
+		"func", "(", ")", "->", "%result", ";",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("tokens = %q, want %q", got, want)
+	}
+
+	comment := tokens[7]
+	if !comment.IsLineComment() || comment.Line != 3 || !comment.NewlineBefore || !comment.BlankBefore {
+		t.Errorf("comment token = %+v, want a line comment on line 3 after a blank line", comment)
+	}
+	if version := tokens[5]; version.Kind != TokVersion {
+		t.Errorf("%q kind = %d, want TokVersion", version.Text, version.Kind)
+	}
+	if !tokens[24].Is("->") || tokens[25].Kind != TokIdent {
+		t.Errorf("want -> punctuation followed by an identifier, got %+v %+v", tokens[24], tokens[25])
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{src: "package a:b;\n/* open", want: "line 2: unterminated block comment"},
+		{src: "interface x {\n  f: func() -> $;\n}", want: "line 2: unexpected character '$'"},
+	}
+
+	for _, tt := range tests {
+		if _, err := Tokenize(tt.src); err == nil || err.Error() != tt.want {
+			t.Errorf("Tokenize(%q) error = %v, want %q", tt.src, err, tt.want)
+		}
+	}
+}
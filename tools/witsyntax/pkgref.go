@@ -0,0 +1,71 @@
+package witsyntax
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PackageRef is a parsed "namespace:name@version" package reference
+type PackageRef struct {
+	Namespace string
+	Name      string
+	Version   string
+}
+
+func (r PackageRef) String() string {
+	ref := r.Namespace + ":" + r.Name
+	if r.Version != "" {
+		ref += "@" + r.Version
+	}
+	return ref
+}
+
+// ParsePackageRef reads a package reference such as wasi:io@0.2.0
+func ParsePackageRef(ref string) (PackageRef, error) {
+	var parsed PackageRef
+
+	name := ref
+	if at := strings.Index(ref, "@"); at >= 0 {
+		name, parsed.Version = ref[:at], ref[at+1:]
+		if _, err := ParseSemver(parsed.Version); err != nil {
+			return parsed, fmt.Errorf("package %s: %w", ref, err)
+		}
+	}
+
+	colon := strings.Index(name, ":")
+	if colon <= 0 || colon == len(name)-1 {
+		return parsed, fmt.Errorf("package %s: expected namespace:name", ref)
+	}
+	parsed.Namespace, parsed.Name = name[:colon], name[colon+1:]
+
+	return parsed, nil
+}
+
+// Semver holds the numeric part of a version; pre-release and build
+// metadata do not affect compatibility decisions
+type Semver [3]int
+
+// ParseSemver reads major.minor.patch, ignoring pre-release and build suffixes
+func ParseSemver(version string) (Semver, error) {
+	var v Semver
+
+	core := version
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid version %q: expected major.minor.patch", version)
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid version %q", version)
+		}
+		v[i] = n
+	}
+
+	return v, nil
+}
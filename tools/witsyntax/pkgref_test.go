@@ -0,0 +1,63 @@
+package witsyntax
+
+import "testing"
+
+func TestParsePackageRef(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    PackageRef
+		wantErr bool
+	}{
+		{ref: "wasi:io", want: PackageRef{Namespace: "wasi", Name: "io"}},
+		{ref: "wasi:io@0.2.0", want: PackageRef{Namespace: "wasi", Name: "io", Version: "0.2.0"}},
+		{ref: "my-org:api@1.0.0-rc.1+build", want: PackageRef{Namespace: "my-org", Name: "api", Version: "1.0.0-rc.1+build"}},
+		{ref: "io", wantErr: true},
+		{ref: ":io", wantErr: true},
+		{ref: "wasi:", wantErr: true},
+		{ref: "wasi:io@0.2", wantErr: true},
+		{ref: "wasi:io@latest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePackageRef(tt.ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePackageRef(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePackageRef(%q) = %+v, want %+v", tt.ref, got, tt.want)
+		}
+		if got.String() != tt.ref {
+			t.Errorf("ParsePackageRef(%q).String() = %q", tt.ref, got.String())
+		}
+	}
+}
+
+func TestParseSemver(t *testing.T) {
+	tests := []struct {
+		version string
+		want    Semver
+		wantErr bool
+	}{
+		{version: "0.2.0", want: Semver{0, 2, 0}},
+		{version: "1.10.3-rc.1", want: Semver{1, 10, 3}},
+		{version: "2.0.0+build.5", want: Semver{2, 0, 0}},
+		{version: "1.0", wantErr: true},
+		{version: "1.0.x", wantErr: true},
+		{version: "1.-1.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSemver(tt.version)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSemver(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseSemver(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
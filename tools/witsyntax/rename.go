@@ -0,0 +1,114 @@
+package witsyntax
+
+import "strings"
+
+// Replacement is one rewritten package reference
+type Replacement struct {
+	Line int
+	Old  string
+	New  string
+}
+
+// refKeywords introduce statements whose path may name a package
+var refKeywords = map[string]bool{
+	"package": true,
+	"use":     true,
+	"import":  true,
+	"export":  true,
+	"include": true,
+}
+
+// RenameRefs rewrites references to package from as package to in src.
+// Comments and everything else are left byte-for-byte as they were.
+//
+// A versioned from only matches that exact version. An unversioned from
+// matches every version, which is kept unless to names a version itself.
+func RenameRefs(src string, from, to PackageRef) (string, []Replacement, error) {
+	tokens, err := Tokenize(src)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var code []Token
+	for _, tok := range tokens {
+		if tok.Kind != TokComment {
+			code = append(code, tok)
+		}
+	}
+
+	var out strings.Builder
+	var replacements []Replacement
+	copied := 0
+
+	for i := 0; i+2 < len(code); i++ {
+		if !startsRef(code, i) || code[i].Text != from.Namespace || !code[i+1].Is(":") || code[i+2].Text != from.Name {
+			continue
+		}
+
+		// The version follows the package, or the interface path in uses
+		// and imports: wasi:io/streams@0.2.0
+		end := i + 3
+		for end+1 < len(code) && code[end].Is("/") && code[end+1].Kind == TokIdent {
+			end += 2
+		}
+		var version *Token
+		if end+1 < len(code) && code[end].Is("@") && code[end+1].Kind == TokVersion {
+			version = &code[end+1]
+		}
+
+		current := ""
+		if version != nil {
+			current = version.Text
+		}
+		if from.Version != "" && current != from.Version {
+			continue
+		}
+
+		oldRef := PackageRef{from.Namespace, from.Name, current}.String()
+		newVersion := current
+		if to.Version != "" {
+			newVersion = to.Version
+		}
+		newRef := PackageRef{to.Namespace, to.Name, newVersion}.String()
+		if oldRef == newRef {
+			continue
+		}
+
+		// Namespace, ":" and name are rewritten as one span; the version
+		// is rewritten in place after any interface path, or added there
+		// when the reference had none
+		out.WriteString(src[copied:code[i].Offset])
+		out.WriteString(to.Namespace + ":" + to.Name)
+		copied = code[i+2].Offset + len(code[i+2].Text)
+		switch {
+		case version != nil && newVersion != current:
+			out.WriteString(src[copied:version.Offset])
+			out.WriteString(newVersion)
+			copied = version.Offset + len(version.Text)
+		case version == nil && newVersion != "":
+			last := code[end-1]
+			out.WriteString(src[copied : last.Offset+len(last.Text)])
+			out.WriteString("@" + newVersion)
+			copied = last.Offset + len(last.Text)
+		}
+
+		replacements = append(replacements, Replacement{Line: code[i].Line, Old: oldRef, New: newRef})
+		i += 2
+	}
+
+	out.WriteString(src[copied:])
+	return out.String(), replacements, nil
+}
+
+// startsRef reports whether the token at i begins a package path: directly
+// after package, use, import, export or include, or after the name of a
+// named import or export (import streams: wasi:io/streams;)
+func startsRef(code []Token, i int) bool {
+	if i == 0 {
+		return false
+	}
+	if refKeywords[code[i-1].Text] {
+		return true
+	}
+	return i >= 3 && code[i-1].Is(":") && (code[i-3].Text == "import" || code[i-3].Text == "export")
+}
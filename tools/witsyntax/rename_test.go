@@ -0,0 +1,110 @@
+package witsyntax
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenameRefs(t *testing.T) {
+	src := `package acme:app@1.0.0;
+
+// wasi:io@0.2.0 in a comment stays as it is
+interface handler {
+  use wasi:io/streams@0.2.0.{input-stream};
+}
+
+world app {
+  import wasi:io/error@0.2.0;
+  import errors: wasi:io/error@0.1.0;
+  export wasi:io/poll;
+}
+`
+
+	tests := []struct {
+		name     string
+		from, to PackageRef
+		want     string
+		replaced []Replacement
+	}{
+		{
+			name: "versioned from matches only that version",
+			from: PackageRef{"wasi", "io", "0.2.0"},
+			to:   PackageRef{"wasi", "io", "0.2.1"},
+			want: `package acme:app@1.0.0;
+
+// wasi:io@0.2.0 in a comment stays as it is
+interface handler {
+  use wasi:io/streams@0.2.1.{input-stream};
+}
+
+world app {
+  import wasi:io/error@0.2.1;
+  import errors: wasi:io/error@0.1.0;
+  export wasi:io/poll;
+}
+`,
+			replaced: []Replacement{
+				{Line: 5, Old: "wasi:io@0.2.0", New: "wasi:io@0.2.1"},
+				{Line: 9, Old: "wasi:io@0.2.0", New: "wasi:io@0.2.1"},
+			},
+		},
+		{
+			name: "unversioned from keeps each version",
+			from: PackageRef{"wasi", "io", ""},
+			to:   PackageRef{"acme", "io", ""},
+			want: `package acme:app@1.0.0;
+
+// wasi:io@0.2.0 in a comment stays as it is
+interface handler {
+  use acme:io/streams@0.2.0.{input-stream};
+}
+
+world app {
+  import acme:io/error@0.2.0;
+  import errors: acme:io/error@0.1.0;
+  export acme:io/poll;
+}
+`,
+			replaced: []Replacement{
+				{Line: 5, Old: "wasi:io@0.2.0", New: "acme:io@0.2.0"},
+				{Line: 9, Old: "wasi:io@0.2.0", New: "acme:io@0.2.0"},
+				{Line: 10, Old: "wasi:io@0.1.0", New: "acme:io@0.1.0"},
+				{Line: 11, Old: "wasi:io", New: "acme:io"},
+			},
+		},
+		{
+			name: "versioned to replaces the version",
+			from: PackageRef{"acme", "app", ""},
+			to:   PackageRef{"acme", "app", "2.0.0"},
+			want: `package acme:app@2.0.0;
+
+// wasi:io@0.2.0 in a comment stays as it is
+interface handler {
+  use wasi:io/streams@0.2.0.{input-stream};
+}
+
+world app {
+  import wasi:io/error@0.2.0;
+  import errors: wasi:io/error@0.1.0;
+  export wasi:io/poll;
+}
+`,
+			replaced: []Replacement{{Line: 1, Old: "acme:app@1.0.0", New: "acme:app@2.0.0"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, replaced, err := RenameRefs(src, tt.from, tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("RenameRefs() =\n%s\nwant\n%s", got, tt.want)
+			}
+			if !reflect.DeepEqual(replaced, tt.replaced) {
+				t.Errorf("replacements = %+v, want %+v", replaced, tt.replaced)
+			}
+		})
+	}
+}
@@ -300,8 +300,9 @@ def _wasm_verify_impl(ctx):
         # When using raw public key file, user must specify format explicitly
         openssh_format = ctx.attr.openssh_format
 
-    # Declare output verification marker
+    # Declare output verification marker and machine-readable result
     verification_marker = ctx.actions.declare_file(ctx.label.name + "_verified.txt")
+    verification_json = ctx.actions.declare_file(ctx.label.name + "_verification.json")
 
     # Build command arguments
     args = ctx.actions.args()
@@ -339,12 +340,13 @@ def _wasm_verify_impl(ctx):
     # Run verification via Go wrapper (no shell scripts!)
     # The wrapper will create the marker file on success
     args.add("--bazel-marker-file=" + verification_marker.path)
+    args.add("--bazel-result-json=" + verification_json.path)
 
     ctx.actions.run(
         executable = wasmsign2_wrapper,
         arguments = [args],
         inputs = inputs,
-        outputs = [verification_marker],
+        outputs = [verification_marker, verification_json],
         mnemonic = "WasmVerify",
         progress_message = "Verifying WASM signature %s" % ctx.label,
     )
@@ -366,6 +368,7 @@ def _wasm_verify_impl(ctx):
     return [
         verification_info,
         DefaultInfo(files = depset([verification_marker])),
+        OutputGroupInfo(verification_json = depset([verification_json])),
     ]
 
 wasm_verify = rule(